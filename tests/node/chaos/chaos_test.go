@@ -0,0 +1,33 @@
+package node_chaos
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	node_utils "github.com/op-rs/kona/node/utils"
+)
+
+const (
+	chaosRounds = 10
+	chaosSeed   = 1337
+)
+
+// Ensure that the network reconverges to a single unsafe head after a random sequence of restarts and disconnections.
+func TestChaosRestart(gt *testing.T) {
+	t := devtest.SerialT(gt)
+
+	out := node_utils.NewMixedOpKona(t)
+
+	nodes := out.SortedCLNodes()
+	t.Gate().Greater(len(nodes), 1, "expected at least two nodes")
+
+	sequencerKey := out.L2CLSequencerNodes()[0].Escape().ID().Key()
+	sequencer := slices.IndexFunc(nodes, func(node dsl.L2CLNode) bool { return node.Escape().ID().Key() == sequencerKey })
+	t.Require().GreaterOrEqual(sequencer, 0, "sequencer %s is not among the nodes", sequencerKey)
+
+	// The chain must keep making progress through the chaos, and every node stopped along the way must come back.
+	actions := node_utils.ChaosRestart(t, nodes, chaosRounds, chaosSeed, node_utils.ChaosProgressCheck(nodes, sequencer))
+	node_utils.AssertStoppedNodesRecovered(t, nodes, actions)
+}
@@ -0,0 +1,23 @@
+package node_chaos
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/compat"
+	"github.com/ethereum-optimism/optimism/op-devstack/presets"
+	node_utils "github.com/op-rs/kona/node/utils"
+)
+
+// TestMain creates the test-setups against the shared backend
+func TestMain(m *testing.M) {
+	// Like the restart tests, the chaos tests only support kona nodes because of the req-resp sync incompatibility
+	// of the op nodes. They only run against sysgo, where nodes can be stopped and restarted in-process.
+	config := node_utils.L2NodeConfig{
+		KonaSequencerNodesWithGeth: 1,
+		KonaNodesWithGeth:          2,
+	}
+
+	fmt.Printf("Running chaos e2e tests with Config: %d\n", config)
+	presets.DoMain(m, node_utils.WithMixedOpKona(config), presets.WithCompatibleTypes(compat.SysGo), node_utils.WithRPCStats())
+}
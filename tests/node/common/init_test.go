@@ -1,6 +1,7 @@
 package node
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -8,10 +9,15 @@ import (
 	node_utils "github.com/op-rs/kona/node/utils"
 )
 
-// TestMain creates the test-setups against the shared backend
+// TestMain creates the test-setups against the shared backend, or against the external network described at
+// node_utils.ExternalNetworkEnvVar if set.
 func TestMain(m *testing.M) {
+	if err := node_utils.UseExternalNetwork(context.Background()); err != nil {
+		panic(fmt.Sprintf("invalid %s: %v", node_utils.ExternalNetworkEnvVar, err))
+	}
+
 	config := node_utils.ParseL2NodeConfigFromEnv()
 
 	fmt.Printf("Running e2e tests with Config: %d\n", config)
-	presets.DoMain(m, node_utils.WithMixedOpKona(config))
+	presets.DoMain(m, node_utils.WithMixedOpKona(config), node_utils.WithRPCStats())
 }
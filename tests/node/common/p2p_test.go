@@ -59,8 +59,9 @@ func TestP2PMinimal(gt *testing.T) {
 	t := devtest.ParallelT(gt)
 
 	out := node_utils.NewMixedOpKona(t)
+	node_utils.RequireMixedImpl(t, out, 1, 1)
 
-	nodes := out.L2CLNodes()
+	nodes := out.SortedCLNodes()
 	firstNode := nodes[0]
 	secondNode := nodes[1]
 
@@ -0,0 +1,24 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	node_utils "github.com/op-rs/kona/node/utils"
+)
+
+// rpcFuzzRandomCases is the number of random fuzz cases sent to each node, on top of the fixed ones.
+const rpcFuzzRandomCases = 200
+
+// Check that the kona nodes reject malformed RPC params with JSON-RPC errors, and keep syncing afterwards.
+func TestRPCFuzz(gt *testing.T) {
+	t := devtest.ParallelT(gt)
+
+	out := node_utils.NewMixedOpKona(t)
+
+	for _, node := range out.L2CLKonaNodes() {
+		t.Run(node.Escape().ID().Key(), func(tt devtest.T) {
+			node_utils.AssertRPCFuzz(tt, node, rpcFuzzRandomCases)
+		})
+	}
+}
@@ -13,12 +13,20 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// p2pMethods are the p2p RPC methods exercised by the p2p tests.
+var p2pMethods = []string{"opp2p_self", "opp2p_peers", "opp2p_peerStats", "opp2p_blockPeer", "opp2p_unblockPeer"}
+
 // Check that the node p2p RPC endpoints are working.
 func TestP2PPeers(gt *testing.T) {
 	t := devtest.SerialT(gt)
 
 	out := node_utils.NewMixedOpKona(t)
 
+	// Report any missing p2p method up front rather than deep inside the checks below.
+	for _, node := range out.L2CLNodes() {
+		node_utils.AssertRPCMethodsAvailable(t, node, p2pMethods)
+	}
+
 	p2pPeersAndPeerStats(t, out)
 
 	p2pSelfAndPeers(t, out)
@@ -95,11 +103,13 @@ func p2pPeersAndPeerStats(t devtest.T, out *node_utils.MixedOpKonaPreset) {
 			clRPC := node_utils.GetNodeRPCEndpoint(node)
 			clName := node.Escape().ID().Key()
 
+			// Both are fetched in the same batch, so that the peers don't change in between.
 			peers := &apis.PeerDump{}
-			require.NoError(t, node_utils.SendRPCRequest(clRPC, "opp2p_peers", peers, true), "failed to send RPC request to node %s: %s", clName)
-
 			peerStats := &apis.PeerStats{}
-			require.NoError(t, node_utils.SendRPCRequest(clRPC, "opp2p_peerStats", peerStats), "failed to send RPC request to node %s: %s", clName)
+			require.NoError(t, node_utils.SendRPCBatch(clRPC,
+				node_utils.NewRPCCall("opp2p_peers", peers, true),
+				node_utils.NewRPCCall("opp2p_peerStats", peerStats),
+			), "failed to send RPC batch to node %s", clName)
 
 			require.Equal(t, peers.TotalConnected, peerStats.Connected, "totalConnected mismatch node %s", clName)
 			require.Equal(t, len(peers.Peers), int(peers.TotalConnected), "peer count mismatch node %s", clName)
@@ -111,11 +121,11 @@ func p2pPeersAndPeerStats(t devtest.T, out *node_utils.MixedOpKonaPreset) {
 func p2pBanPeer(t devtest.T, out *node_utils.MixedOpKonaPreset) {
 	nodes := out.L2CLNodes()
 	for _, node := range nodes {
-		clRPC := node_utils.GetNodeRPCEndpoint(&node)
+		client := node_utils.NewKonaNodeClient(t, &node)
 		clName := node.Escape().ID().Key()
 
-		peers := &apis.PeerDump{}
-		require.NoError(t, node_utils.SendRPCRequest(clRPC, "opp2p_peers", peers, true), "failed to send RPC request to node %s: %s", clName)
+		peers, err := client.Peers(t.Ctx(), true)
+		require.NoError(t, err, "failed to get the peers of node %s", clName)
 
 		connectedPeers := peers.TotalConnected
 
@@ -129,11 +139,11 @@ func p2pBanPeer(t devtest.T, out *node_utils.MixedOpKonaPreset) {
 
 		require.NotEmpty(t, peerToBan, "no connected peer found")
 
-		require.NoError(t, node_utils.SendRPCRequest[any](clRPC, "opp2p_blockPeer", nil, peerToBan), "failed to send RPC request to node %s: %s", clName)
+		require.NoError(t, client.BlockPeer(t.Ctx(), peerToBan), "failed to block peer on node %s", clName)
 
 		// Check that the peer is banned.
-		peersAfterBan := &apis.PeerDump{}
-		require.NoError(t, node_utils.SendRPCRequest(clRPC, "opp2p_peers", peersAfterBan, true), "failed to send RPC request to node %s: %s", clName)
+		peersAfterBan, err := client.Peers(t.Ctx(), true)
+		require.NoError(t, err, "failed to get the peers of node %s", clName)
 
 		require.Equal(t, connectedPeers, peersAfterBan.TotalConnected, "totalConnected mismatch node %s", clName)
 
@@ -149,11 +159,11 @@ func p2pBanPeer(t devtest.T, out *node_utils.MixedOpKonaPreset) {
 		require.True(t, contains, "peer %s not banned", peerToBan)
 
 		// Try to unban the peer.
-		require.NoError(t, node_utils.SendRPCRequest[any](clRPC, "opp2p_unblockPeer", nil, peerToBan), "failed to send RPC request to node %s: %s", clName)
+		require.NoError(t, client.UnblockPeer(t.Ctx(), peerToBan), "failed to unblock peer on node %s", clName)
 
 		// Check that the peer is unbanned.
-		peersAfterUnban := &apis.PeerDump{}
-		require.NoError(t, node_utils.SendRPCRequest(clRPC, "opp2p_peers", peersAfterUnban, true), "failed to send RPC request to node %s: %s", clName)
+		peersAfterUnban, err := client.Peers(t.Ctx(), true)
+		require.NoError(t, err, "failed to get the peers of node %s", clName)
 
 		require.Equal(t, connectedPeers, peersAfterUnban.TotalConnected, "totalConnected mismatch node %s", clName)
 		require.NotContains(t, peersAfterUnban.BannedPeers, peerToBan, "peer %s is banned", peerToBan)
@@ -161,11 +171,8 @@ func p2pBanPeer(t devtest.T, out *node_utils.MixedOpKonaPreset) {
 }
 
 func rollupConfig(t devtest.T, node *dsl.L2CLNode) *rollup.Config {
-	clRPC := node_utils.GetNodeRPCEndpoint(node)
-	clName := node.Escape().ID().Key()
-
-	rollupConfig := &rollup.Config{}
-	require.NoError(t, node_utils.SendRPCRequest(clRPC, "optimism_rollupConfig", rollupConfig), "failed to send RPC request to node %s: %s", clName)
+	rollupConfig, err := node_utils.NewKonaNodeClient(t, node).RollupConfig(t.Ctx())
+	require.NoError(t, err, "failed to get the rollup config of node %s", node.Escape().ID().Key())
 
 	return rollupConfig
 }
@@ -2,6 +2,7 @@ package node
 
 import (
 	"testing"
+	"time"
 
 	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
 	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
@@ -9,11 +10,15 @@ import (
 	node_utils "github.com/op-rs/kona/node/utils"
 )
 
+// syncTestDeadline bounds the duration of the sync tests, so that a stalled node fails the test with its heads.
+const syncTestDeadline = 10 * time.Minute
+
 // Check that all the nodes in the network are synced to the local safe block and can catch up to the sequencer node.
 func TestL2SafeSync(gt *testing.T) {
 	t := devtest.ParallelT(gt)
 
 	out := node_utils.NewMixedOpKona(t)
+	t = node_utils.WithDeadline(t, syncTestDeadline, node_utils.HeadsDiagnostic(out.L2CLNodes()))
 
 	sequencer := out.L2CLSequencerNodes()[0]
 	nodes := out.L2CLValidatorNodes()
@@ -33,6 +38,7 @@ func TestL2UnsafeSync(gt *testing.T) {
 	t := devtest.ParallelT(gt)
 
 	out := node_utils.NewMixedOpKona(t)
+	t = node_utils.WithDeadline(t, syncTestDeadline, node_utils.HeadsDiagnostic(out.L2CLNodes()))
 
 	nodes := out.L2CLNodes()
 
@@ -7,7 +7,6 @@ import (
 
 	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
 	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
-	"github.com/ethereum-optimism/optimism/op-service/eth"
 	"github.com/ethereum-optimism/optimism/op-supervisor/supervisor/types"
 	node_utils "github.com/op-rs/kona/node/utils"
 	"github.com/stretchr/testify/require"
@@ -36,41 +35,16 @@ func TestSyncUnsafeBecomesSafe(gt *testing.T) {
 	}
 	dsl.CheckAll(t, advancedFns...)
 
-	var wg sync.WaitGroup
+	// Every unsafe head received in the first seconds must become safe by the time the safe heads stop being collected.
+	consolidationFns := make([]dsl.CheckFunc, 0, len(nodes))
 	for _, node := range nodes {
-		wg.Add(1)
-		go func(node *dsl.L2CLNode) {
-			defer wg.Done()
-
-			unsafeBlocks := node_utils.GetKonaWs(t, node, "unsafe_head", time.After(SECS_WAIT_FOR_UNSAFE_HEAD*time.Second))
-
-			safeBlocks := node_utils.GetKonaWs(t, node, "safe_head", time.After(SECS_WAIT_FOR_SAFE_HEAD*time.Second))
-
-			require.GreaterOrEqual(t, len(unsafeBlocks), 1, "we didn't receive enough unsafe gossip blocks!")
-			require.GreaterOrEqual(t, len(safeBlocks), 1, "we didn't receive enough safe gossip blocks!")
-
-			safeBlockMap := make(map[uint64]eth.L2BlockRef)
-			// Create a map of safe blocks with block number as the key
-			for _, safeBlock := range safeBlocks {
-				safeBlockMap[safeBlock.Number] = safeBlock
-			}
-
-			cond := false
-
-			// Iterate over unsafe blocks and find matching safe blocks
-			for _, unsafeBlock := range unsafeBlocks {
-				if safeBlock, exists := safeBlockMap[unsafeBlock.Number]; exists {
-					require.Equal(t, unsafeBlock, safeBlock, "unsafe block %d doesn't match safe block %d", unsafeBlock.Number, safeBlock.Number)
-					cond = true
-				}
-			}
-
-			require.True(t, cond, "No matching safe block found for unsafe block")
-
-			t.Log("✓ unsafe and safe head blocks match between all nodes")
-		}(&node)
+		unsafeBlocks := node_utils.GetKonaWsAsync(t, &node, "unsafe_head", time.After(SECS_WAIT_FOR_UNSAFE_HEAD*time.Second))
+		safeBlocks := node_utils.GetKonaWsAsync(t, &node, "safe_head", time.After(SECS_WAIT_FOR_SAFE_HEAD*time.Second))
+		consolidationFns = append(consolidationFns, node_utils.ExpectConsolidation(unsafeBlocks, safeBlocks, SECS_WAIT_FOR_SAFE_HEAD*time.Second))
 	}
-	wg.Wait()
+	dsl.CheckAll(t, consolidationFns...)
+
+	t.Log("✓ unsafe and safe head blocks match between all nodes")
 }
 
 // System tests that ensure that the kona-nodes are syncing the unsafe chain.
@@ -0,0 +1,50 @@
+package node_conformance
+
+import (
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/ethereum-optimism/optimism/op-supervisor/supervisor/types"
+	node_utils "github.com/op-rs/kona/node/utils"
+)
+
+// conformanceBlock is the L2 block that must be safe on every node before the responses are compared.
+const conformanceBlock = 10
+
+// Check that every kona node answers the read-only optimism, opp2p and admin methods like an op-node of the same role.
+func TestRPCConformance(gt *testing.T) {
+	t := devtest.ParallelT(gt)
+
+	out := node_utils.NewMixedOpKona(t)
+	node_utils.RequireMixedImpl(t, out, 1, 1)
+
+	checkFuns := make([]dsl.CheckFunc, 0, len(out.L2CLNodes()))
+	for _, node := range out.L2CLNodes() {
+		checkFuns = append(checkFuns, node.ReachedFn(types.LocalSafe, conformanceBlock, 60))
+	}
+	dsl.CheckAll(t, checkFuns...)
+
+	pairs := [][2][]dsl.L2CLNode{
+		{out.L2CLKonaSequencerNodes, out.L2CLOpSequencerNodes},
+		{out.L2CLKonaValidatorNodes, out.L2CLOpValidatorNodes},
+	}
+
+	for _, pair := range pairs {
+		konaNodes, opNodes := pair[0], pair[1]
+		if len(opNodes) == 0 {
+			continue
+		}
+		opNode := opNodes[0]
+
+		status, err := node_utils.NewKonaNodeClient(t, &opNode).SyncStatus(t.Ctx())
+		t.Require().NoError(err, "failed to get the sync status of %s", opNode.Escape().ID().Key())
+		cases := node_utils.DefaultRPCConformanceCases(conformanceBlock, status.CurrentL1.Number)
+
+		for _, konaNode := range konaNodes {
+			t.Run(konaNode.Escape().ID().Key(), func(tt devtest.T) {
+				node_utils.AssertRPCConformance(tt, konaNode, opNode, cases)
+			})
+		}
+	}
+}
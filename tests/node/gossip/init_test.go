@@ -0,0 +1,23 @@
+package node_gossip
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/compat"
+	"github.com/ethereum-optimism/optimism/op-devstack/presets"
+	node_utils "github.com/op-rs/kona/node/utils"
+)
+
+// TestMain creates the test-setups against the shared backend
+func TestMain(m *testing.M) {
+	// The gossip tests need a line topology of three kona nodes: a sequencer and two validators.
+	// They only run against sysgo because they rely on blocking peers to control the topology.
+	config := node_utils.L2NodeConfig{
+		KonaSequencerNodesWithGeth: 1,
+		KonaNodesWithGeth:          2,
+	}
+
+	fmt.Printf("Running gossip e2e tests with Config: %d\n", config)
+	presets.DoMain(m, node_utils.WithMixedOpKona(config), presets.WithCompatibleTypes(compat.SysGo), node_utils.WithRPCStats())
+}
@@ -0,0 +1,22 @@
+package node_gossip
+
+import (
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	node_utils "github.com/op-rs/kona/node/utils"
+)
+
+// Ensure that kona validators forward the blocks they receive through gossip to their own peers.
+func TestNodeReGossips(gt *testing.T) {
+	t := devtest.SerialT(gt)
+
+	out := node_utils.NewMixedOpKona(t)
+
+	sequencers := out.L2CLSequencerNodes()
+	validators := out.L2CLValidatorNodes()
+	t.Gate().Greater(len(sequencers), 0, "expected at least one sequencer node")
+	t.Gate().Greater(len(validators), 1, "expected at least two validator nodes")
+
+	node_utils.AssertNodeReGossips(t, sequencers[0], validators[0], validators[1])
+}
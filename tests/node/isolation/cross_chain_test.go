@@ -0,0 +1,18 @@
+package node_isolation
+
+import (
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/presets"
+	node_utils "github.com/op-rs/kona/node/utils"
+)
+
+// TestCrossChainPeeringRejected checks that the nodes of two different chains don't gossip blocks to each other once
+// connected.
+func TestCrossChainPeeringRejected(gt *testing.T) {
+	t := devtest.SerialT(gt)
+	sys := presets.NewSimpleInterop(t)
+
+	node_utils.AssertCrossChainPeeringRejected(t, *sys.L2CLA, *sys.L2CLB)
+}
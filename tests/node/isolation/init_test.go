@@ -0,0 +1,15 @@
+package node_isolation
+
+import (
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/compat"
+	"github.com/ethereum-optimism/optimism/op-devstack/presets"
+)
+
+// TestMain creates the test-setups against the shared backend
+func TestMain(m *testing.M) {
+	// The isolation tests need two L2 chains whose nodes can reach each other.
+	// They only run against sysgo, where the nodes of both chains share the same network.
+	presets.DoMain(m, presets.WithSimpleInterop(), presets.WithCompatibleTypes(compat.SysGo))
+}
@@ -0,0 +1,18 @@
+package node_l1halt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	node_utils "github.com/op-rs/kona/node/utils"
+)
+
+// Ensure that the safe heads of all the nodes stall while L1 is stopped and advance again once it resumes.
+func TestL1StopHaltsSafe(gt *testing.T) {
+	t := devtest.SerialT(gt)
+
+	out := node_utils.NewMixedOpKona(t)
+
+	node_utils.AssertL1StopHaltsSafe(t, out, time.Minute)
+}
@@ -0,0 +1,19 @@
+package node_l1reorg
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/compat"
+	"github.com/ethereum-optimism/optimism/op-devstack/presets"
+	node_utils "github.com/op-rs/kona/node/utils"
+)
+
+// TestMain creates the test-setups against the shared backend
+func TestMain(m *testing.M) {
+	config := node_utils.ParseL2NodeConfigFromEnv()
+
+	// The L1 reorg tests drive L1 through kurtosis, so they only run against kurtosis devnets.
+	fmt.Printf("Running L1 reorg e2e tests with Config: %d\n", config)
+	presets.DoMain(m, node_utils.WithMixedOpKona(config), presets.WithCompatibleTypes(compat.Kurtosis), node_utils.WithRPCStats())
+}
@@ -0,0 +1,19 @@
+package node_l1reorg
+
+import (
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	node_utils "github.com/op-rs/kona/node/utils"
+	"github.com/op-rs/kona/supervisor/utils"
+)
+
+// Ensure that the nodes stabilize and the cross-safe heads advance again after a series of quick L1 reorgs.
+func TestReorgStorm(gt *testing.T) {
+	t := devtest.SerialT(gt)
+
+	out := node_utils.NewMixedOpKona(t)
+	trm := utils.NewTestReorgManager(t)
+
+	node_utils.ReorgStorm(t, trm, 5, 3, out.L2CLNodes()...)
+}
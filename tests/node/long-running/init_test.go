@@ -3,8 +3,10 @@ package node
 import (
 	"flag"
 	"testing"
+	"time"
 
 	"github.com/ethereum-optimism/optimism/op-devstack/presets"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
 	node_utils "github.com/op-rs/kona/node/utils"
 )
 
@@ -13,8 +15,16 @@ var (
 	percentageNewAccounts = flag.Int("percentage-new-accounts", 20, "percentage of new accounts to produce transactions for")
 	fundAmount            = flag.Int("fund-amount", 10, "eth amount to fund each new account with")
 	initNumAccounts       = flag.Int("init-num-accounts", 10, "initial number of accounts to fund")
+	emptyBlocksWindow     = flag.Int("empty-blocks-window", 100, "number of blocks over which empty blocks are counted")
+	maxFinalizationLag    = flag.Uint64("max-finalization-lag", 1000, "maximum number of blocks the finalized head may lag behind the unsafe head")
+	finalizationLagWindow = flag.Duration("finalization-lag-window", 2*time.Minute, "duration over which the finalization lag is sampled")
 )
 
+// numPrefundedEOAs accounts are funded with prefundedAmount at genesis.
+const numPrefundedEOAs = 10
+
+var prefundedAmount = eth.Ether(100)
+
 // TestMain creates the test-setups against the shared backend
 func TestMain(m *testing.M) {
 	flag.Parse()
@@ -28,5 +38,5 @@ func TestMain(m *testing.M) {
 		OpNodesWithReth:            1,
 		KonaNodesWithGeth:          1,
 		KonaNodesWithReth:          1,
-	}))
+	}), node_utils.WithPrefundedEOAs(numPrefundedEOAs, prefundedAmount), node_utils.WithRPCStats())
 }
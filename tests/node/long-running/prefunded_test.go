@@ -0,0 +1,26 @@
+package node
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	node_utils "github.com/op-rs/kona/node/utils"
+)
+
+// Check that the accounts prefunded in the deployer intent hold the expected balance at genesis.
+func TestPrefundedEOAs(gt *testing.T) {
+	t := devtest.ParallelT(gt)
+
+	out := node_utils.NewMixedOpKona(t)
+
+	t.Require().Len(out.PrefundedEOAs(), numPrefundedEOAs, "unexpected number of prefunded EOAs")
+
+	for _, el := range out.L2ELNodes() {
+		for _, eoa := range out.PrefundedEOAs() {
+			balance, err := el.Escape().EthClient().BalanceAt(t.Ctx(), eoa.Address(), big.NewInt(0))
+			t.Require().NoError(err, "failed to get genesis balance of %s on %s", eoa.Address(), el.Escape().ID())
+			t.Require().Equal(prefundedAmount.ToBig(), balance, "unexpected genesis balance of %s on %s", eoa.Address(), el.Escape().ID())
+		}
+	}
+}
@@ -160,6 +160,8 @@ func (tr *TxReceiver) Start(wg *sync.WaitGroup) {
 // Produces transactions in a loop. Ensures that...
 // - transactions get included
 // - transactions get gossiped
+// - the sequencer doesn't produce empty blocks while transactions are flowing
+// - finalization doesn't fall behind while transactions are flowing
 func TestTxProducer(gt *testing.T) {
 	t := devtest.SerialT(gt)
 
@@ -176,6 +178,17 @@ func TestTxProducer(gt *testing.T) {
 		txReceiver.Start(&wg)
 	}
 
+	// While the transactions are flowing, ensure that the sequencer keeps including them.
+	sequencerEL := out.L2ELSequencerNodes()[0]
+	sequencerCL := out.L2CLSequencerNodes()[0]
+	for t.Ctx().Err() == nil {
+		from := sequencerEL.BlockRefByLabel(eth.Unsafe).Number + 1
+		to := from + uint64(*emptyBlocksWindow) - 1
+		sequencerEL.WaitForBlockNumber(to)
+		node_utils.AssertNoEmptyBlocksUnderLoad(t, sequencerEL, from, to)
+		node_utils.AssertFinalizationLagBounded(t, sequencerCL, *maxFinalizationLag, *finalizationLagWindow)
+	}
+
 	wg.Wait()
 
 	t.Logf("producer and receiver threads finished")
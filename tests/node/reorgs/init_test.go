@@ -14,5 +14,5 @@ func TestMain(m *testing.M) {
 
 	fmt.Printf("Running e2e reorg tests with Config: %d\n", l2Config)
 
-	presets.DoMain(m, node_utils.WithMixedWithTestSequencer(l2Config))
+	presets.DoMain(m, node_utils.WithMixedWithTestSequencer(l2Config), node_utils.WithRPCStats())
 }
@@ -20,6 +20,7 @@ func TestL2Reorg(gt *testing.T) {
 	t := devtest.SerialT(gt)
 
 	out := node_utils.NewMixedOpKonaWithTestSequencer(t)
+	t = node_utils.WithDeadline(t, node_utils.ReorgTestDeadline, node_utils.HeadsDiagnostic(out.L2CLNodes()))
 	sequencerCL := out.L2CLSequencerNodes()[0]
 	sequencerEL := out.L2ELSequencerNodes()[0]
 
@@ -79,7 +80,7 @@ func TestL2Reorg(gt *testing.T) {
 		l1OriginHash := l1Origin.Hash()
 
 		// Reorg the L2 Chain to the unsafe head
-		controlAPI := out.TestSequencer.Escape().ControlAPI(out.L2CLNodes()[0].ChainID())
+		controlAPI := out.TestSequencer.Escape().ControlAPI(out.SortedCLNodes()[0].ChainID())
 		t.Require().NoError(controlAPI.New(t.Ctx(), seqtypes.BuildOpts{
 			Parent:   unsafeHead.ParentHash,
 			L1Origin: &l1OriginHash,
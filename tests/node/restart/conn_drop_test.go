@@ -3,6 +3,7 @@ package node_restart
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
 	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
@@ -50,10 +51,9 @@ func TestConnDropSync(gt *testing.T) {
 
 		currentUnsafeHead := node.ChainSyncStatus(node.ChainID(), types.LocalUnsafe)
 
-		endSignal := make(chan struct{})
-
-		safeHeads := node_utils.GetKonaWsAsync(t, &node, "safe_head", endSignal)
-		unsafeHeads := node_utils.GetKonaWsAsync(t, &node, "unsafe_head", endSignal)
+		subscriber := node_utils.NewWsSubscriber(t, &node)
+		safeHeads := subscriber.SubscribeKona("safe_head")
+		unsafeHeads := subscriber.SubscribeKona("unsafe_head")
 
 		// Ensures that....
 		// - the node's safe head is advancing and eventually catches up with the unsafe head
@@ -62,18 +62,18 @@ func TestConnDropSync(gt *testing.T) {
 		outer_loop:
 			for {
 				select {
-				case safeHead := <-safeHeads:
+				case safeHead := <-safeHeads.C:
 					t.Logf("node %s safe head is advancing", clName)
 					if safeHead.Number >= currentUnsafeHead.Number {
 						t.Logf("node %s safe head caught up with unsafe head", clName)
 						break outer_loop
 					}
-				case unsafeHead := <-unsafeHeads:
+				case unsafeHead := <-unsafeHeads.C:
 					return fmt.Errorf("node %s unsafe head is advancing: %d", clName, unsafeHead.Number)
 				}
 			}
 
-			endSignal <- struct{}{}
+			subscriber.Close()
 
 			return nil
 		}
@@ -117,4 +117,9 @@ func TestConnDropSync(gt *testing.T) {
 	}
 
 	dsl.CheckAll(t, postReconnectCheckFuns...)
+
+	// Check that the nodes consolidate the unsafe blocks they receive again.
+	for _, node := range nodes {
+		node_utils.AssertCrossUnsafeConsolidates(t, node, sequencer, 2*time.Minute)
+	}
 }
@@ -0,0 +1,30 @@
+package node_restart
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/compat"
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/presets"
+	node_utils "github.com/op-rs/kona/node/utils"
+)
+
+// Ensure that kona-nodes and their ELs recover when both are restarted at the same time.
+func TestELAndCLRestart(gt *testing.T) {
+	t := devtest.SerialT(gt)
+	// The ELs can only be stopped and restarted in-process.
+	t.Gate().Equal(compat.SysGo, presets.Orchestrator().Type(), "combined restarts are only supported on sysgo")
+
+	out := node_utils.NewMixedOpKona(t)
+
+	nodes := out.L2CLValidatorNodes()
+	t.Gate().Greater(len(nodes), 0, "expected at least one validator node")
+
+	for _, node := range nodes {
+		el, ok := out.PairedEL(node)
+		t.Require().True(ok, "no EL paired with %s", node.Escape().ID().Key())
+
+		node_utils.AssertRecoversAfterELAndCLRestart(t, node, el, 2*time.Minute)
+	}
+}
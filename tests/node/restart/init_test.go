@@ -17,5 +17,5 @@ func TestMain(m *testing.M) {
 	}
 
 	fmt.Printf("Running restart e2e tests with Config: %d\n", config)
-	presets.DoMain(m, node_utils.WithMixedOpKona(config))
+	presets.DoMain(m, node_utils.WithMixedOpKona(config), node_utils.WithRPCStats())
 }
@@ -0,0 +1,140 @@
+package node_utils
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/ethereum-optimism/optimism/op-devstack/shim"
+	"github.com/ethereum-optimism/optimism/op-devstack/stack"
+	"github.com/ethereum-optimism/optimism/op-devstack/sysgo"
+)
+
+// addedNodeCount numbers the nodes spawned by addValidator. It only ever increases, so that an added node never reuses
+// the name of another one, even after nodes are removed.
+var addedNodeCount atomic.Uint64
+
+// addedNodes holds the keys of the nodes spawned by addValidator. They belong to the test that added them, so the
+// presets of the other tests, which hydrate the same orchestrator, leave them out.
+var addedNodes = struct {
+	sync.Mutex
+	keys map[string]bool
+}{keys: make(map[string]bool)}
+
+func markAddedNode(key string) {
+	addedNodes.Lock()
+	defer addedNodes.Unlock()
+	addedNodes.keys[key] = true
+}
+
+func isAddedNode(key string) bool {
+	addedNodes.Lock()
+	defer addedNodes.Unlock()
+	return addedNodes.keys[key]
+}
+
+// withoutAddedNodes returns `nodes` without the nodes spawned by addValidator.
+func withoutAddedNodes[I interface {
+	comparable
+	Key() string
+}, E stack.Identifiable[I]](nodes []E) []E {
+	return slices.DeleteFunc(slices.Clone(nodes), func(node E) bool { return isAddedNode(node.ID().Key()) })
+}
+
+// AddKonaValidator spawns a new kona-node validator driving a fresh EL of kind `elKind`, connects both of them to all
+// the nodes of the chain and returns them. The new node starts syncing from genesis, which makes it a late-joining node
+// without having to provision it idle from the start. The node is stopped when the test ends. This only works on
+// in-process sysgo systems.
+func (m *MixedOpKonaPreset) AddKonaValidator(t devtest.T, elKind L2ELKind) (dsl.L2CLNode, dsl.L2ELNode) {
+	cl, el := m.addValidator(t, KonaNode, elKind)
+	m.L2CLKonaValidatorNodes = append(m.L2CLKonaValidatorNodes, cl)
+	m.L2ELKonaValidatorNodes = append(m.L2ELKonaValidatorNodes, el)
+	return cl, el
+}
+
+// AddOpValidator spawns a new op-node validator driving a fresh EL of kind `elKind`, connects both of them to all the
+// nodes of the chain and returns them. The node is stopped when the test ends. This only works on in-process sysgo
+// systems.
+func (m *MixedOpKonaPreset) AddOpValidator(t devtest.T, elKind L2ELKind) (dsl.L2CLNode, dsl.L2ELNode) {
+	cl, el := m.addValidator(t, OpNode, elKind)
+	m.L2CLOpValidatorNodes = append(m.L2CLOpValidatorNodes, cl)
+	m.L2ELOpValidatorNodes = append(m.L2ELOpValidatorNodes, el)
+	return cl, el
+}
+
+func (m *MixedOpKonaPreset) addValidator(t devtest.T, clKind L2NodeKind, elKind L2ELKind) (dsl.L2CLNode, dsl.L2ELNode) {
+	orch, ok := m.orch.(*sysgo.Orchestrator)
+	t.Require().True(ok, "nodes can only be added to in-process sysgo systems")
+
+	l2Net := m.L2Chain.Escape()
+	node := addedNode(clKind, elKind, addedNodeCount.Add(1))
+	clID, elID := node.nodeIDs(l2Net.ChainID())
+	markAddedNode(clID.Key())
+	markAddedNode(elID.Key())
+
+	existingCLs := make([]stack.L2CLNodeID, 0, len(m.L2CLNodes()))
+	for _, cl := range m.L2CLNodes() {
+		existingCLs = append(existingCLs, cl.Escape().ID())
+	}
+	existingELs := make([]stack.L2ELNodeID, 0, len(m.L2ELNodes()))
+	for _, el := range m.L2ELNodes() {
+		existingELs = append(existingELs, el.Escape().ID())
+	}
+
+	opt := addedNodeOption(node, clID, elID, m.L1CL.Escape().ID(), m.L1EL.Escape().ID(), existingCLs, existingELs)
+	opt.BeforeDeploy(orch)
+	opt.Deploy(orch)
+	opt.AfterDeploy(orch)
+	opt.Finally(orch)
+	t.Cleanup(func() {
+		orch.ControlPlane().L2CLNodeState(clID, stack.Stop)
+		orch.ControlPlane().L2ELNodeState(elID, stack.Stop)
+		t.Logf("stopped added node %s", clID.Key())
+	})
+
+	// The preset was hydrated before the node existed, so the node is looked up on a freshly hydrated system.
+	system := shim.NewSystem(t)
+	orch.Hydrate(system)
+	hydrated := system.L2Network(l2Net.ID())
+
+	t.Logf("added %s validator %s", clKind, clID.Key())
+	cl := dsl.NewL2CLNode(hydrated.L2CLNode(clID), orch.ControlPlane())
+	el := dsl.NewL2ELNode(hydrated.L2ELNode(elID), orch.ControlPlane())
+	return *cl, *el
+}
+
+// addedNode describes the `index`-th validator added at runtime. The index keeps the name unique, and the name doesn't
+// contain any L2NodeKind.
+func addedNode(clKind L2NodeKind, elKind L2ELKind, index uint64) TopologyNode {
+	return TopologyNode{
+		Name: fmt.Sprintf("added-%d", index),
+		CL:   clKind,
+		EL:   elKind,
+		Role: Validator,
+	}
+}
+
+// addedNodeOption spawns the added node, with the same defaults as the preset, and connects it to the existing nodes.
+func addedNodeOption(node TopologyNode, clID stack.L2CLNodeID, elID stack.L2ELNodeID, l1CL stack.L1CLNodeID, l1EL stack.L1ELNodeID, existingCLs []stack.L2CLNodeID, existingELs []stack.L2ELNodeID) stack.CombinedOption[*sysgo.Orchestrator] {
+	opt := stack.Combine[*sysgo.Orchestrator]()
+
+	opt.Add(WithL2EL(node.EL, elID))
+
+	if node.CL == KonaNode {
+		opt.Add(sysgo.WithKonaNode(clID, l1CL, l1EL, elID, L2CLSyncMode(node.syncMode())))
+	} else {
+		opt.Add(sysgo.WithOpNode(clID, l1CL, l1EL, elID))
+	}
+
+	for _, cl := range existingCLs {
+		opt.Add(sysgo.WithL2CLP2PConnection(cl, clID))
+	}
+	for _, el := range existingELs {
+		opt.Add(sysgo.WithL2ELP2PConnection(el, elID))
+	}
+
+	return opt
+}
@@ -0,0 +1,37 @@
+package node_utils
+
+import (
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/stack"
+	"github.com/ethereum-optimism/optimism/op-node/rollup/sync"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddedNode(gt *testing.T) {
+	t := devtest.SerialT(gt)
+	l2ID := eth.ChainIDFromUInt64(DefaultL2ID)
+
+	cl, el := addedNode(KonaNode, RethEL, 3).nodeIDs(l2ID)
+	require.Equal(t, "cl-reth-kona-validator-added-3", cl.Key())
+	require.Equal(t, "el-reth-kona-validator-added-3", el.Key())
+
+	nodes := []*fakeStackL2CLNode{{t: t, id: cl}}
+	require.Len(t, L2NodeMatcher[stack.L2CLNodeID, *fakeStackL2CLNode](string(KonaNode), string(Validator)).Match(nodes), 1)
+	require.Empty(t, L2NodeMatcher[stack.L2CLNodeID, *fakeStackL2CLNode](string(Sequencer)).Match(nodes))
+
+	next, _ := addedNode(KonaNode, RethEL, 4).nodeIDs(l2ID)
+	require.NotEqual(t, cl, next, "each added node gets its own ID")
+
+	require.Equal(t, sync.ELSync, addedNode(KonaNode, GethEL, 0).syncMode())
+	require.Equal(t, sync.CLSync, addedNode(OpNode, GethEL, 0).syncMode())
+
+	first, second := addedNodeCount.Add(1), addedNodeCount.Add(1)
+	require.Greater(t, second, first, "the index of added nodes only increases")
+
+	markAddedNode(cl.Key())
+	kept := &fakeStackL2CLNode{t: t, id: stack.NewL2CLNodeID("cl-reth-kona-validator-0", l2ID)}
+	require.Equal(t, []*fakeStackL2CLNode{kept}, withoutAddedNodes[stack.L2CLNodeID, *fakeStackL2CLNode]([]*fakeStackL2CLNode{nodes[0], kept}))
+}
@@ -0,0 +1,92 @@
+package node_utils
+
+import (
+	"strings"
+
+	"github.com/ethereum-optimism/optimism/op-batcher/batcher"
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/ethereum-optimism/optimism/op-devstack/stack"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+)
+
+// standbyBatcherPrefix prefixes the ID key of the standby batchers, which is how they are told apart from the active
+// ones once the system is hydrated.
+const standbyBatcherPrefix = "standby-"
+
+// BatcherSet is the set of batchers of a chain, split by their current role. All the batchers of a chain post with the
+// same key, so failover tests should keep a single active batcher at a time.
+type BatcherSet struct {
+	Active  []*dsl.L2Batcher
+	Standby []*dsl.L2Batcher
+}
+
+// newBatcherSet splits `batchers` into active and standby batchers by their ID.
+func newBatcherSet(batchers []*dsl.L2Batcher) *BatcherSet {
+	set := &BatcherSet{}
+	for _, b := range batchers {
+		if isStandbyBatcher(b.Escape().ID()) {
+			set.Standby = append(set.Standby, b)
+		} else {
+			set.Active = append(set.Active, b)
+		}
+	}
+	return set
+}
+
+// All returns the active batchers, then the standby ones.
+func (s *BatcherSet) All() []*dsl.L2Batcher {
+	return append(append([]*dsl.L2Batcher{}, s.Active...), s.Standby...)
+}
+
+// ByName returns the batcher named `name` in the topology, and whether it was found.
+func (s *BatcherSet) ByName(name string) (*dsl.L2Batcher, bool) {
+	for _, b := range s.All() {
+		if strings.TrimPrefix(b.Escape().ID().Key(), standbyBatcherPrefix) == name {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+// Failover stops the active batcher `from` and starts the standby batcher `to` in its place, swapping their roles.
+func (s *BatcherSet) Failover(t devtest.T, from, to *dsl.L2Batcher) {
+	i := indexOfBatcher(s.Active, from)
+	t.Require().GreaterOrEqual(i, 0, "batcher %s is not active", from.Escape().ID())
+	j := indexOfBatcher(s.Standby, to)
+	t.Require().GreaterOrEqual(j, 0, "batcher %s is not on standby", to.Escape().ID())
+
+	from.Stop()
+	to.Start()
+
+	s.Active[i], s.Standby[j] = to, from
+	t.Logf("failed over from batcher %s to batcher %s", from.Escape().ID(), to.Escape().ID())
+}
+
+func indexOfBatcher(batchers []*dsl.L2Batcher, b *dsl.L2Batcher) int {
+	for i, other := range batchers {
+		if other.Escape().ID() == b.Escape().ID() {
+			return i
+		}
+	}
+	return -1
+}
+
+// batcherID returns the ID of the batcher named `name` in the topology, marking standby batchers with a prefix.
+func batcherID(name string, standby bool, l2ID eth.ChainID) stack.L2BatcherID {
+	if standby {
+		name = standbyBatcherPrefix + name
+	}
+	return stack.NewL2BatcherID(name, l2ID)
+}
+
+func isStandbyBatcher(id stack.L2BatcherID) bool {
+	return strings.HasPrefix(id.Key(), standbyBatcherPrefix)
+}
+
+// withStandbyBatchersStopped starts the standby batchers stopped, leaving them to be started on failover.
+func withStandbyBatchersStopped(id stack.L2BatcherID, cfg *batcher.CLIConfig) {
+	if isStandbyBatcher(id) {
+		cfg.Stopped = true
+	}
+}
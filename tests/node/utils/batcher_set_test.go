@@ -0,0 +1,30 @@
+package node_utils
+
+import (
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-batcher/batcher"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStandbyBatchers(t *testing.T) {
+	l2ID := eth.ChainIDFromUInt64(DefaultL2ID)
+	active := batcherID("main", false, l2ID)
+	standby := batcherID("backup", true, l2ID)
+
+	t.Run("IDs", func(t *testing.T) {
+		require.Equal(t, "main", active.Key())
+		require.Equal(t, "standby-backup", standby.Key())
+		require.False(t, isStandbyBatcher(active))
+		require.True(t, isStandbyBatcher(standby))
+	})
+
+	t.Run("standby batchers start stopped", func(t *testing.T) {
+		var activeCfg, standbyCfg batcher.CLIConfig
+		withStandbyBatchersStopped(active, &activeCfg)
+		withStandbyBatchersStopped(standby, &standbyCfg)
+		require.False(t, activeCfg.Stopped)
+		require.True(t, standbyCfg.Stopped)
+	})
+}
@@ -0,0 +1,110 @@
+package node_utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"slices"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/op-rs/kona/supervisor/utils"
+)
+
+// AssertBlobPayloadAccepted builds a block with the test block builder and checks that it carries at least one blob
+// commitment, that the EL accepted it through `engine_newPayload`, and that the versioned hashes of the blobs bundle
+// match the blob hashes of the transactions in the payload.
+// The blob transactions must already be in the EL's mempool when this is called.
+func AssertBlobPayloadAccepted(t devtest.T, builder *utils.TestBlockBuilder, ctx context.Context) {
+	envelope, err := builder.BuildBlock(ctx, nil)
+	t.Require().NoError(err, "the newly built payload was not accepted by the EL")
+
+	t.Require().NoError(checkBlobVersionedHashes(envelope))
+}
+
+// checkBlobVersionedHashes checks that the payload carries blobs and that the versioned hashes derived from the
+// blobs bundle commitments match the blob hashes referenced by the payload's transactions.
+func checkBlobVersionedHashes(envelope *engine.ExecutionPayloadEnvelope) error {
+	if envelope.BlobsBundle == nil || len(envelope.BlobsBundle.Commitments) == 0 {
+		return fmt.Errorf("payload does not carry any blob commitment")
+	}
+
+	versionedHashes, err := utils.VersionedHashes(envelope.BlobsBundle)
+	if err != nil {
+		return err
+	}
+
+	txBlobHashes := make([]common.Hash, 0)
+	for i, rawTx := range envelope.ExecutionPayload.Transactions {
+		var tx types.Transaction
+		if err := tx.UnmarshalBinary(rawTx); err != nil {
+			return fmt.Errorf("failed to decode transaction %d: %w", i, err)
+		}
+		txBlobHashes = append(txBlobHashes, tx.BlobHashes()...)
+	}
+
+	if len(versionedHashes) != len(txBlobHashes) {
+		return fmt.Errorf("blob hashes length mismatch: bundle has %d, transactions reference %d", len(versionedHashes), len(txBlobHashes))
+	}
+
+	for i := range versionedHashes {
+		if versionedHashes[i] != txBlobHashes[i] {
+			return fmt.Errorf("blob hash mismatch at index %d: bundle %s, transaction %s", i, versionedHashes[i], txBlobHashes[i])
+		}
+	}
+
+	return nil
+}
+
+// AssertBlobTxBlock publishes a blob transaction carrying `data` to the EL with `key`, builds a block including it and
+// checks that the blobs bundle of the payload carries the blobs, commitments and proofs of the transaction. This lets
+// tests exercise blob batches rather than calldata only.
+func AssertBlobTxBlock(t devtest.T, builder *utils.TestBlockBuilder, ctx context.Context, key *ecdsa.PrivateKey, to common.Address, data []byte) *engine.ExecutionPayloadEnvelope {
+	tx, err := builder.PublishBlobTx(ctx, key, to, data)
+	t.Require().NoError(err, "failed to publish the blob transaction")
+
+	envelope, err := builder.BuildBlock(ctx, nil)
+	t.Require().NoError(err, "failed to build a block with the blob transaction")
+
+	t.Require().NoError(checkBlobsBundle(envelope, tx))
+	return envelope
+}
+
+// checkBlobsBundle checks that the payload includes the blob transaction, that its blobs bundle is consistent with the
+// payload transactions, and that it carries the blobs, commitments and proofs of the transaction sidecar.
+func checkBlobsBundle(envelope *engine.ExecutionPayloadEnvelope, tx *types.Transaction) error {
+	sidecar := tx.BlobTxSidecar()
+	if sidecar == nil {
+		return fmt.Errorf("transaction %s carries no blob sidecar", tx.Hash())
+	}
+
+	if err := checkBlobVersionedHashes(envelope); err != nil {
+		return err
+	}
+	versionedHashes, err := utils.VersionedHashes(envelope.BlobsBundle)
+	if err != nil {
+		return err
+	}
+
+	bundle := envelope.BlobsBundle
+	if len(bundle.Blobs) != len(versionedHashes) || len(bundle.Proofs) != len(versionedHashes) {
+		return fmt.Errorf("blobs bundle has %d commitments, %d blobs and %d proofs", len(versionedHashes), len(bundle.Blobs), len(bundle.Proofs))
+	}
+
+	for i, hash := range tx.BlobHashes() {
+		index := slices.Index(versionedHashes, hash)
+		if index < 0 {
+			return fmt.Errorf("blob %d of transaction %s is not in the blobs bundle", i, tx.Hash())
+		}
+		if !bytes.Equal(bundle.Blobs[index], sidecar.Blobs[i][:]) {
+			return fmt.Errorf("blob %d of transaction %s differs from the bundled one", i, tx.Hash())
+		}
+		if !bytes.Equal(bundle.Proofs[index], sidecar.Proofs[i][:]) {
+			return fmt.Errorf("proof of blob %d of transaction %s differs from the bundled one", i, tx.Hash())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package node_utils
+
+import (
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+	"github.com/op-rs/kona/supervisor/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBlobEnvelope returns the payload envelope a fake EL would serve for a block containing a single blob
+// transaction referencing txBlobHashes, with bundleCommitments as the blobs bundle commitments.
+func fakeBlobEnvelope(t *testing.T, bundleCommitments [][48]byte, txBlobHashes []common.Hash) *engine.ExecutionPayloadEnvelope {
+	tx := types.NewTx(&types.BlobTx{
+		ChainID:    uint256.NewInt(900),
+		GasTipCap:  uint256.NewInt(1),
+		GasFeeCap:  uint256.NewInt(1),
+		Gas:        21_000,
+		BlobFeeCap: uint256.NewInt(1),
+		BlobHashes: txBlobHashes,
+	})
+	rawTx, err := tx.MarshalBinary()
+	require.NoError(t, err)
+
+	commitments := make([]hexutil.Bytes, len(bundleCommitments))
+	for i, commitment := range bundleCommitments {
+		commitments[i] = commitment[:]
+	}
+
+	return &engine.ExecutionPayloadEnvelope{
+		ExecutionPayload: &engine.ExecutableData{Transactions: [][]byte{rawTx}},
+		BlobsBundle:      &engine.BlobsBundleV1{Commitments: commitments},
+	}
+}
+
+func TestBlobVersionedHashes(t *testing.T) {
+	commitments := [][48]byte{{0x01}, {0x02}}
+	hashes := []common.Hash{eth.KZGToVersionedHash(commitments[0]), eth.KZGToVersionedHash(commitments[1])}
+
+	t.Run("matching hashes", func(t *testing.T) {
+		require.NoError(t, checkBlobVersionedHashes(fakeBlobEnvelope(t, commitments, hashes)))
+	})
+
+	t.Run("mismatching hashes", func(t *testing.T) {
+		require.Error(t, checkBlobVersionedHashes(fakeBlobEnvelope(t, commitments, []common.Hash{hashes[1], hashes[0]})))
+	})
+
+	t.Run("no blobs", func(t *testing.T) {
+		require.Error(t, checkBlobVersionedHashes(fakeBlobEnvelope(t, nil, hashes)))
+	})
+}
+
+func TestBlobsBundle(t *testing.T) {
+	sidecar, err := utils.NewBlobTxSidecar([]byte("batch"))
+	require.NoError(t, err)
+	tx := types.NewTx(&types.BlobTx{
+		ChainID:    uint256.NewInt(900),
+		Gas:        21_000,
+		BlobFeeCap: uint256.NewInt(1),
+		BlobHashes: sidecar.BlobHashes(),
+		Sidecar:    sidecar,
+	})
+
+	envelope := func(blob []byte) *engine.ExecutionPayloadEnvelope {
+		rawTx, err := tx.WithoutBlobTxSidecar().MarshalBinary()
+		require.NoError(t, err)
+		return &engine.ExecutionPayloadEnvelope{
+			ExecutionPayload: &engine.ExecutableData{Transactions: [][]byte{rawTx}},
+			BlobsBundle: &engine.BlobsBundleV1{
+				Commitments: []hexutil.Bytes{sidecar.Commitments[0][:]},
+				Proofs:      []hexutil.Bytes{sidecar.Proofs[0][:]},
+				Blobs:       []hexutil.Bytes{blob},
+			},
+		}
+	}
+
+	t.Run("matching bundle", func(t *testing.T) {
+		require.NoError(t, checkBlobsBundle(envelope(sidecar.Blobs[0][:]), tx))
+	})
+
+	t.Run("different blob", func(t *testing.T) {
+		require.ErrorContains(t, checkBlobsBundle(envelope(make([]byte, len(sidecar.Blobs[0]))), tx), "differs from the bundled one")
+	})
+
+	t.Run("no sidecar", func(t *testing.T) {
+		require.ErrorContains(t, checkBlobsBundle(envelope(sidecar.Blobs[0][:]), tx.WithoutBlobTxSidecar()), "carries no blob sidecar")
+	})
+}
@@ -0,0 +1,235 @@
+package node_utils
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// DefaultMinNonEmptyBlockFraction is the fraction of blocks that must contain user transactions under load. Some blocks
+// may legitimately be empty, for example when the transactions land right after a block was sealed.
+const DefaultMinNonEmptyBlockFraction = 0.8
+
+// blockTxSource is the subset of apis.EthClient used to inspect the transactions of a block range.
+type blockTxSource interface {
+	InfoAndTxsByNumber(ctx context.Context, number uint64) (eth.BlockInfo, types.Transactions, error)
+}
+
+// blockInfoSource is the subset of apis.EthClient used to read the headers of a block range.
+type blockInfoSource interface {
+	InfoByNumber(ctx context.Context, number uint64) (eth.BlockInfo, error)
+}
+
+// AssertNoEmptyBlocksUnderLoad checks that, over the blocks in [from, to] produced while transactions are flowing, at
+// least DefaultMinNonEmptyBlockFraction of the blocks contain a user transaction. This catches a sequencer that drops
+// its mempool.
+func AssertNoEmptyBlocksUnderLoad(t devtest.T, el dsl.L2ELNode, from, to uint64) {
+	AssertNonEmptyBlockFraction(t, el, from, to, DefaultMinNonEmptyBlockFraction)
+}
+
+// AssertNonEmptyBlockFraction checks that at least `minFraction` of the blocks in [from, to] contain a user transaction.
+func AssertNonEmptyBlockFraction(t devtest.T, el dsl.L2ELNode, from, to uint64, minFraction float64) {
+	t.Require().NoError(checkNonEmptyBlockFraction(t.Ctx(), el.Escape().EthClient(), from, to, minFraction), "too many empty blocks on %s", el.Escape().ID().Key())
+}
+
+func checkNonEmptyBlockFraction(ctx context.Context, el blockTxSource, from, to uint64, minFraction float64) error {
+	if to < from {
+		return fmt.Errorf("invalid block range [%d, %d]", from, to)
+	}
+
+	total := to - from + 1
+	nonEmpty := uint64(0)
+	for number := from; number <= to; number++ {
+		_, txs, err := el.InfoAndTxsByNumber(ctx, number)
+		if err != nil {
+			return fmt.Errorf("failed to fetch block %d: %w", number, err)
+		}
+
+		// Deposit transactions, starting with the L1 info transaction, are included in every block.
+		for _, tx := range txs {
+			if !tx.IsDepositTx() {
+				nonEmpty++
+				break
+			}
+		}
+	}
+
+	if fraction := float64(nonEmpty) / float64(total); fraction < minFraction {
+		return fmt.Errorf("only %d out of %d blocks in [%d, %d] contain user transactions (%.2f < %.2f)", nonEmpty, total, from, to, fraction, minFraction)
+	}
+
+	return nil
+}
+
+// AssertELExecutionAgreement walks the blocks in [from, to] and checks that both ELs report the same state root,
+// receipts root and gas used for each of them. This catches geth and reth diverging when executing the same chain.
+func AssertELExecutionAgreement(t devtest.T, gethEL, rethEL dsl.L2ELNode, from, to uint64) {
+	t.Require().NoError(checkELExecutionAgreement(t.Ctx(), gethEL.Escape().EthClient(), rethEL.Escape().EthClient(), from, to), "%s and %s disagree", gethEL.Escape().ID().Key(), rethEL.Escape().ID().Key())
+}
+
+func checkELExecutionAgreement(ctx context.Context, a, b blockInfoSource, from, to uint64) error {
+	if to < from {
+		return fmt.Errorf("invalid block range [%d, %d]", from, to)
+	}
+
+	for number := from; number <= to; number++ {
+		infoA, err := a.InfoByNumber(ctx, number)
+		if err != nil {
+			return fmt.Errorf("failed to fetch block %d from the first EL: %w", number, err)
+		}
+		infoB, err := b.InfoByNumber(ctx, number)
+		if err != nil {
+			return fmt.Errorf("failed to fetch block %d from the second EL: %w", number, err)
+		}
+
+		if infoA.Root() != infoB.Root() {
+			return fmt.Errorf("block %d: state roots differ (%s vs %s)", number, infoA.Root(), infoB.Root())
+		}
+		if infoA.ReceiptHash() != infoB.ReceiptHash() {
+			return fmt.Errorf("block %d: receipts roots differ (%s vs %s)", number, infoA.ReceiptHash(), infoB.ReceiptHash())
+		}
+		if infoA.GasUsed() != infoB.GasUsed() {
+			return fmt.Errorf("block %d: gas used differs (%d vs %d)", number, infoA.GasUsed(), infoB.GasUsed())
+		}
+	}
+
+	return nil
+}
+
+// AssertBaseFeeConsistentPostReorg walks the blocks in [from, to], typically produced while recovering from a reorg,
+// and checks that the base fee of each of them follows the EIP-1559 update rule from its parent. The EIP-1559
+// parameters are read from the extra data of the parent, so the range must start after Holocene activation.
+func AssertBaseFeeConsistentPostReorg(ctx context.Context, el dsl.L2ELNode, from, to uint64) error {
+	return checkBaseFeeProgression(ctx, el.Escape().EthClient(), from, to)
+}
+
+func checkBaseFeeProgression(ctx context.Context, el blockInfoSource, from, to uint64) error {
+	if to < from || from == 0 {
+		return fmt.Errorf("invalid block range [%d, %d]", from, to)
+	}
+
+	parent, err := headerByNumber(ctx, el, from-1)
+	if err != nil {
+		return err
+	}
+	for number := from; number <= to; number++ {
+		header, err := headerByNumber(ctx, el, number)
+		if err != nil {
+			return err
+		}
+
+		expected, err := expectedBaseFee(parent, header.Time)
+		if err != nil {
+			return fmt.Errorf("block %d: %w", number, err)
+		}
+		if header.BaseFee == nil || header.BaseFee.Cmp(expected) != 0 {
+			return fmt.Errorf("block %d: base fee is %v, expected %v from its parent", number, header.BaseFee, expected)
+		}
+
+		parent = header
+	}
+
+	return nil
+}
+
+func headerByNumber(ctx context.Context, el blockInfoSource, number uint64) (*types.Header, error) {
+	info, err := el.InfoByNumber(ctx, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch block %d: %w", number, err)
+	}
+	raw, err := info.HeaderRLP()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the header of block %d: %w", number, err)
+	}
+	var header types.Header
+	if err := rlp.DecodeBytes(raw, &header); err != nil {
+		return nil, fmt.Errorf("failed to decode the header of block %d: %w", number, err)
+	}
+	return &header, nil
+}
+
+// expectedBaseFee computes the base fee of the child of `parent`, with the EIP-1559 parameters encoded in the extra
+// data of the parent.
+func expectedBaseFee(parent *types.Header, time uint64) (*big.Int, error) {
+	if len(parent.Extra) == 0 {
+		return nil, fmt.Errorf("parent %d predates Holocene, its EIP-1559 parameters are unknown", parent.Number)
+	}
+
+	// Only the forks that select how the extra data is decoded matter to the base fee computation.
+	activation := uint64(0)
+	config := &params.ChainConfig{
+		LondonBlock:  common.Big0,
+		HoloceneTime: &activation,
+		Optimism:     &params.OptimismConfig{},
+	}
+	if parent.Extra[0] == eip1559.MinBaseFeeExtraDataVersionByte {
+		config.JovianTime = &activation
+	}
+
+	if err := eip1559.ValidateOptimismExtraData(config, parent.Time, parent.Extra); err != nil {
+		return nil, fmt.Errorf("invalid extra data in parent %d: %w", parent.Number, err)
+	}
+	return eip1559.CalcBaseFee(config, parent, time), nil
+}
+
+// safeFinalizedDistanceSlack is the number of blocks by which the distances of the safe and finalized heads to the
+// unsafe head may exceed the configured ones. The block builder derives them from the head it builds on, which is one
+// block behind the head it then seals, and new blocks may land while the labels are read.
+const safeFinalizedDistanceSlack = 2
+
+// blockLabelSource is the subset of apis.EthClient used to read the labeled heads of a chain.
+type blockLabelSource interface {
+	InfoByLabel(ctx context.Context, label eth.BlockLabel) (eth.BlockInfo, error)
+}
+
+// AssertSafeFinalizedDistances checks that the safe and finalized heads of the L1 chain served by `l1`, the EL the POS
+// block builder builds on, trail its unsafe head by approximately `safeDist` and `finalizedDist` blocks, the distances
+// the builder is configured to maintain.
+func AssertSafeFinalizedDistances(ctx context.Context, l1 dsl.L1ELNode, safeDist, finalizedDist uint64) error {
+	return checkSafeFinalizedDistances(ctx, l1.EthClient(), safeDist, finalizedDist)
+}
+
+func checkSafeFinalizedDistances(ctx context.Context, el blockLabelSource, safeDist, finalizedDist uint64) error {
+	head, err := el.InfoByLabel(ctx, eth.Unsafe)
+	if err != nil {
+		return fmt.Errorf("failed to fetch the unsafe head: %w", err)
+	}
+
+	for _, expected := range []struct {
+		label    eth.BlockLabel
+		distance uint64
+	}{
+		{eth.Safe, safeDist},
+		{eth.Finalized, finalizedDist},
+	} {
+		info, err := el.InfoByLabel(ctx, expected.label)
+		if err != nil {
+			return fmt.Errorf("failed to fetch the %s head: %w", expected.label, err)
+		}
+		if info.NumberU64() > head.NumberU64() {
+			return fmt.Errorf("%s head %d is ahead of the unsafe head %d", expected.label, info.NumberU64(), head.NumberU64())
+		}
+		// The builder leaves the labels at genesis until the chain is longer than the distance.
+		if head.NumberU64() <= expected.distance {
+			if info.NumberU64() != 0 {
+				return fmt.Errorf("%s head %d should be at genesis while the unsafe head %d is within %d blocks", expected.label, info.NumberU64(), head.NumberU64(), expected.distance)
+			}
+			continue
+		}
+		distance := head.NumberU64() - info.NumberU64()
+		if distance < expected.distance || distance > expected.distance+safeFinalizedDistanceSlack {
+			return fmt.Errorf("%s head %d is %d blocks behind the unsafe head %d, expected %d", expected.label, info.NumberU64(), distance, head.NumberU64(), expected.distance)
+		}
+	}
+
+	return nil
+}
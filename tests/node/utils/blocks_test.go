@@ -0,0 +1,165 @@
+package node_utils
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/ethereum-optimism/optimism/op-service/testutils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBlocks serves blocks, indexed by number, that are either empty or contain a user transaction.
+type fakeBlocks struct {
+	full []bool
+}
+
+func (f *fakeBlocks) InfoAndTxsByNumber(ctx context.Context, number uint64) (eth.BlockInfo, types.Transactions, error) {
+	txs := types.Transactions{types.NewTx(&types.DepositTx{To: &common.Address{0x42}})}
+	if f.full[number] {
+		txs = append(txs, types.NewTx(&types.DynamicFeeTx{ChainID: big.NewInt(901), To: &common.Address{0x42}}))
+	}
+	return &testutils.MockBlockInfo{InfoNum: number}, txs, nil
+}
+
+func TestNonEmptyBlockFraction(t *testing.T) {
+	// Blocks 1 to 10 have a single empty block.
+	blocks := &fakeBlocks{full: []bool{false, true, true, true, false, true, true, true, true, true, true, false, false, false}}
+
+	t.Run("tolerates a few empty blocks", func(t *testing.T) {
+		require.NoError(t, checkNonEmptyBlockFraction(context.Background(), blocks, 1, 10, DefaultMinNonEmptyBlockFraction))
+	})
+
+	t.Run("mostly empty blocks", func(t *testing.T) {
+		require.ErrorContains(t, checkNonEmptyBlockFraction(context.Background(), blocks, 8, 13, DefaultMinNonEmptyBlockFraction), "only 3 out of 6 blocks")
+	})
+
+	t.Run("no tolerance", func(t *testing.T) {
+		require.Error(t, checkNonEmptyBlockFraction(context.Background(), blocks, 1, 10, 1))
+	})
+}
+
+// fakeExecution serves block headers derived from their number, except for the block at `divergeAt`.
+type fakeExecution struct {
+	divergeAt uint64
+}
+
+func (f *fakeExecution) InfoByNumber(ctx context.Context, number uint64) (eth.BlockInfo, error) {
+	info := &testutils.MockBlockInfo{
+		InfoNum:         number,
+		InfoRoot:        common.Hash{byte(number)},
+		InfoReceiptRoot: common.Hash{0xee, byte(number)},
+		InfoGasUsed:     21_000 * number,
+	}
+	if number == f.divergeAt {
+		info.InfoGasUsed++
+	}
+	return info, nil
+}
+
+func TestELExecutionAgreement(t *testing.T) {
+	t.Run("ELs agree", func(t *testing.T) {
+		require.NoError(t, checkELExecutionAgreement(context.Background(), &fakeExecution{}, &fakeExecution{}, 1, 10))
+	})
+
+	t.Run("ELs diverge at one block", func(t *testing.T) {
+		require.ErrorContains(t, checkELExecutionAgreement(context.Background(), &fakeExecution{}, &fakeExecution{divergeAt: 7}, 1, 10), "block 7: gas used differs")
+	})
+}
+
+// fakeHeaders serves the given headers, indexed by number.
+type fakeHeaders []*types.Header
+
+func (f fakeHeaders) InfoByNumber(ctx context.Context, number uint64) (eth.BlockInfo, error) {
+	return eth.HeaderBlockInfo(f[number]), nil
+}
+
+func TestBaseFeeProgression(t *testing.T) {
+	holocene := eip1559.EncodeHoloceneExtraData(250, 6)
+
+	// With a 30M gas limit and an elasticity of 6, the gas target is 5M: the base fee moves by 1/250 when the parent is
+	// either full or empty.
+	chain := func(extra []byte, baseFees ...int64) fakeHeaders {
+		gasUsed := []uint64{10_000_000, 0, 5_000_000, 5_000_000}
+		headers := make(fakeHeaders, len(baseFees))
+		for i, baseFee := range baseFees {
+			headers[i] = &types.Header{
+				Number:   big.NewInt(int64(i)),
+				Time:     uint64(i) * 2,
+				GasLimit: 30_000_000,
+				GasUsed:  gasUsed[i],
+				BaseFee:  big.NewInt(baseFee),
+				Extra:    extra,
+			}
+		}
+		return headers
+	}
+
+	t.Run("valid progression", func(t *testing.T) {
+		headers := chain(holocene, 1_000_000_000, 1_004_000_000, 999_984_000, 999_984_000)
+		require.NoError(t, checkBaseFeeProgression(context.Background(), headers, 1, 3))
+	})
+
+	t.Run("base fee not updated", func(t *testing.T) {
+		headers := chain(holocene, 1_000_000_000, 1_004_000_000, 1_004_000_000, 1_004_000_000)
+		require.ErrorContains(t, checkBaseFeeProgression(context.Background(), headers, 1, 3), "block 2: base fee is 1004000000, expected 999984000")
+	})
+
+	t.Run("minimum base fee", func(t *testing.T) {
+		headers := chain(eip1559.EncodeMinBaseFeeExtraData(250, 6, 1_004_000_000), 1_000_000_000, 1_004_000_000, 1_004_000_000, 1_004_000_000)
+		require.NoError(t, checkBaseFeeProgression(context.Background(), headers, 1, 3))
+	})
+
+	t.Run("pre-Holocene parent", func(t *testing.T) {
+		headers := chain(nil, 1_000_000_000, 1_004_000_000, 999_984_000, 999_984_000)
+		require.ErrorContains(t, checkBaseFeeProgression(context.Background(), headers, 1, 3), "predates Holocene")
+	})
+}
+
+// fakeLabeledHeads serves the labeled heads of the L1 chain the block builder builds on.
+type fakeLabeledHeads map[eth.BlockLabel]uint64
+
+func (f fakeLabeledHeads) InfoByLabel(ctx context.Context, label eth.BlockLabel) (eth.BlockInfo, error) {
+	number, ok := f[label]
+	if !ok {
+		return nil, fmt.Errorf("unknown label %s", label)
+	}
+	return eth.HeaderBlockInfo(&types.Header{Number: new(big.Int).SetUint64(number)}), nil
+}
+
+func TestSafeFinalizedDistances(t *testing.T) {
+	t.Run("distances maintained", func(t *testing.T) {
+		heads := fakeLabeledHeads{eth.Unsafe: 100, eth.Safe: 90, eth.Finalized: 80}
+		require.NoError(t, checkSafeFinalizedDistances(context.Background(), heads, 10, 20))
+	})
+
+	t.Run("within slack", func(t *testing.T) {
+		heads := fakeLabeledHeads{eth.Unsafe: 100, eth.Safe: 89, eth.Finalized: 78}
+		require.NoError(t, checkSafeFinalizedDistances(context.Background(), heads, 10, 20))
+	})
+
+	t.Run("chain shorter than the distances", func(t *testing.T) {
+		heads := fakeLabeledHeads{eth.Unsafe: 15, eth.Safe: 5, eth.Finalized: 0}
+		require.NoError(t, checkSafeFinalizedDistances(context.Background(), heads, 10, 20))
+	})
+
+	t.Run("safe head lagging", func(t *testing.T) {
+		heads := fakeLabeledHeads{eth.Unsafe: 100, eth.Safe: 80, eth.Finalized: 80}
+		require.ErrorContains(t, checkSafeFinalizedDistances(context.Background(), heads, 10, 20), "safe head 80 is 20 blocks behind the unsafe head 100, expected 10")
+	})
+
+	t.Run("finalized head too close", func(t *testing.T) {
+		heads := fakeLabeledHeads{eth.Unsafe: 100, eth.Safe: 90, eth.Finalized: 90}
+		require.ErrorContains(t, checkSafeFinalizedDistances(context.Background(), heads, 10, 20), "finalized head 90 is 10 blocks behind the unsafe head 100, expected 20")
+	})
+
+	t.Run("finalized before the distance is reached", func(t *testing.T) {
+		heads := fakeLabeledHeads{eth.Unsafe: 15, eth.Safe: 5, eth.Finalized: 3}
+		require.ErrorContains(t, checkSafeFinalizedDistances(context.Background(), heads, 10, 20), "should be at genesis")
+	})
+}
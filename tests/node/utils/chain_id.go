@@ -0,0 +1,66 @@
+package node_utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-node/rollup"
+	"github.com/ethereum-optimism/optimism/op-service/apis"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+)
+
+// selfInfoSource is the subset of apis.P2PClient used to read the peer info a node advertises.
+type selfInfoSource interface {
+	Self(ctx context.Context) (*apis.PeerInfo, error)
+}
+
+// rollupConfigSource is the subset of apis.RollupClient used to read the rollup config of a node.
+type rollupConfigSource interface {
+	RollupConfig(ctx context.Context) (*rollup.Config, error)
+}
+
+// chainIDNode is a node whose chain ID is checked against the deployment.
+type chainIDNode struct {
+	name   string
+	p2p    selfInfoSource
+	rollup rollupConfigSource
+}
+
+// AssertChainIDMatchesDeployment checks that every CL node advertises, over p2p and in its rollup config, the chain ID
+// the L2 chain was deployed with. Unlike TestP2PChainID, which only compares the nodes to each other, this catches
+// nodes that all agree on a chain ID that does not match the deployment.
+func AssertChainIDMatchesDeployment(t devtest.T, out *MixedOpKonaPreset) {
+	clNodes := out.L2CLNodes()
+	nodes := make([]chainIDNode, 0, len(clNodes))
+	for _, node := range clNodes {
+		nodes = append(nodes, chainIDNode{
+			name:   node.Escape().ID().Key(),
+			p2p:    node.Escape().P2PAPI(),
+			rollup: node.Escape().RollupAPI(),
+		})
+	}
+
+	t.Require().NoError(checkChainIDMatchesDeployment(t.Ctx(), nodes, out.L2Chain.ChainID()))
+}
+
+func checkChainIDMatchesDeployment(ctx context.Context, nodes []chainIDNode, deployed eth.ChainID) error {
+	var errs []error
+	for _, node := range nodes {
+		self, err := node.p2p.Self(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: failed to get peer info: %w", node.name, err))
+		} else if advertised := eth.ChainIDFromUInt64(self.ChainID); advertised != deployed {
+			errs = append(errs, fmt.Errorf("%s advertises chain ID %s over p2p, deployed chain ID is %s", node.name, advertised, deployed))
+		}
+
+		cfg, err := node.rollup.RollupConfig(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: failed to get rollup config: %w", node.name, err))
+		} else if configured := eth.ChainIDFromBig(cfg.L2ChainID); configured != deployed {
+			errs = append(errs, fmt.Errorf("%s has chain ID %s in its rollup config, deployed chain ID is %s", node.name, configured, deployed))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,51 @@
+package node_utils
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-node/rollup"
+	"github.com/ethereum-optimism/optimism/op-service/apis"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeChainIDs advertises `p2pChainID` over p2p and `configChainID` in its rollup config.
+type fakeChainIDs struct {
+	p2pChainID    uint64
+	configChainID uint64
+}
+
+func (f *fakeChainIDs) Self(ctx context.Context) (*apis.PeerInfo, error) {
+	return &apis.PeerInfo{ChainID: f.p2pChainID}, nil
+}
+
+func (f *fakeChainIDs) RollupConfig(ctx context.Context) (*rollup.Config, error) {
+	return &rollup.Config{L2ChainID: new(big.Int).SetUint64(f.configChainID)}, nil
+}
+
+func TestChainIDMatchesDeployment(t *testing.T) {
+	deployed := eth.ChainIDFromUInt64(901)
+	node := func(name string, p2pChainID, configChainID uint64) chainIDNode {
+		ids := &fakeChainIDs{p2pChainID: p2pChainID, configChainID: configChainID}
+		return chainIDNode{name: name, p2p: ids, rollup: ids}
+	}
+
+	t.Run("matching chain IDs", func(t *testing.T) {
+		nodes := []chainIDNode{node("node-a", 901, 901), node("node-b", 901, 901)}
+		require.NoError(t, checkChainIDMatchesDeployment(context.Background(), nodes, deployed))
+	})
+
+	t.Run("nodes agree on another chain ID", func(t *testing.T) {
+		nodes := []chainIDNode{node("node-a", 902, 901), node("node-b", 902, 901)}
+		err := checkChainIDMatchesDeployment(context.Background(), nodes, deployed)
+		require.ErrorContains(t, err, "node-a advertises chain ID 902 over p2p")
+		require.ErrorContains(t, err, "node-b advertises chain ID 902 over p2p")
+	})
+
+	t.Run("diverging rollup config", func(t *testing.T) {
+		nodes := []chainIDNode{node("node-a", 901, 901), node("node-b", 901, 902)}
+		require.ErrorContains(t, checkChainIDMatchesDeployment(context.Background(), nodes, deployed), "node-b has chain ID 902 in its rollup config")
+	})
+}
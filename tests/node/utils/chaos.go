@@ -0,0 +1,263 @@
+package node_utils
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/ethereum-optimism/optimism/op-supervisor/supervisor/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ChaosActionKind is the kind of disruption applied to the network during a chaos round.
+type ChaosActionKind string
+
+const (
+	ChaosStop       ChaosActionKind = "stop"
+	ChaosStart      ChaosActionKind = "start"
+	ChaosDisconnect ChaosActionKind = "disconnect"
+	ChaosReconnect  ChaosActionKind = "reconnect"
+)
+
+// chaosRoundDelay is the time left to the network between two chaos actions.
+const chaosRoundDelay = 4 * time.Second
+
+// chaosConvergenceBlocks is the number of blocks the network must produce after the chaos before checking that all
+// the nodes agree on the unsafe head.
+const chaosConvergenceBlocks = 10
+
+// ChaosAction is a single step of a chaos sequence. `Node` and `Peer` are indices in the list of nodes, `Peer` is
+// only set for disconnect and reconnect actions.
+type ChaosAction struct {
+	Kind ChaosActionKind
+	Node int
+	Peer int
+}
+
+func (a ChaosAction) String() string {
+	switch a.Kind {
+	case ChaosDisconnect, ChaosReconnect:
+		return fmt.Sprintf("%s %d-%d", a.Kind, a.Node, a.Peer)
+	default:
+		return fmt.Sprintf("%s %d", a.Kind, a.Node)
+	}
+}
+
+// ChaosPlan returns the sequence of `rounds` chaos actions that ChaosRestart runs over `numNodes` nodes for the given
+// seed. The sequence only depends on its arguments, so a failing run can be reproduced from its seed.
+// Every action is valid given the previous ones: only running nodes are stopped, only stopped nodes are started and
+// only running peers are disconnected or reconnected.
+func ChaosPlan(numNodes, rounds int, seed int64) []ChaosAction {
+	r := rand.New(rand.NewSource(seed))
+
+	running := make([]bool, numNodes)
+	for i := range running {
+		running[i] = true
+	}
+	// connected[i][j] tracks whether nodes i and j are connected. All the nodes start fully connected.
+	connected := make([][]bool, numNodes)
+	for i := range connected {
+		connected[i] = make([]bool, numNodes)
+		for j := range connected[i] {
+			connected[i][j] = i != j
+		}
+	}
+
+	actions := make([]ChaosAction, 0, rounds)
+	for range rounds {
+		var candidates []ChaosAction
+		for i := range numNodes {
+			if running[i] {
+				candidates = append(candidates, ChaosAction{Kind: ChaosStop, Node: i})
+			} else {
+				candidates = append(candidates, ChaosAction{Kind: ChaosStart, Node: i})
+			}
+			for j := i + 1; j < numNodes; j++ {
+				if !running[i] || !running[j] {
+					continue
+				}
+				if connected[i][j] {
+					candidates = append(candidates, ChaosAction{Kind: ChaosDisconnect, Node: i, Peer: j})
+				} else {
+					candidates = append(candidates, ChaosAction{Kind: ChaosReconnect, Node: i, Peer: j})
+				}
+			}
+		}
+		if len(candidates) == 0 {
+			break
+		}
+
+		action := candidates[r.Intn(len(candidates))]
+		switch action.Kind {
+		case ChaosStop:
+			running[action.Node] = false
+			// A stopped node loses all its connections.
+			for j := range numNodes {
+				connected[action.Node][j] = false
+				connected[j][action.Node] = false
+			}
+		case ChaosStart:
+			running[action.Node] = true
+		case ChaosDisconnect:
+			connected[action.Node][action.Peer] = false
+			connected[action.Peer][action.Node] = false
+		case ChaosReconnect:
+			connected[action.Node][action.Peer] = true
+			connected[action.Peer][action.Node] = true
+		}
+		actions = append(actions, action)
+	}
+
+	return actions
+}
+
+// chaosProgressAttempts is the number of attempts given to the heads of a node to advance after a chaos round.
+const chaosProgressAttempts = 60
+
+// ChaosRoundCheck runs after each chaos round, with the nodes still running at that point.
+type ChaosRoundCheck func(t devtest.T, round int, running []bool)
+
+// ChaosProgressCheck returns a ChaosRoundCheck that, whenever the sequencer `nodes[sequencer]` is running, checks that
+// the unsafe and safe heads of every running node advance, so that the chain keeps making progress through the chaos.
+func ChaosProgressCheck(nodes []dsl.L2CLNode, sequencer int) ChaosRoundCheck {
+	return func(t devtest.T, round int, running []bool) {
+		if !running[sequencer] {
+			t.Logf("chaos round %d: sequencer %s is stopped, skipping the progress check", round, nodes[sequencer].Escape().ID().Key())
+			return
+		}
+		var checks []dsl.CheckFunc
+		for i := range nodes {
+			if running[i] {
+				checks = append(checks, nodes[i].AdvancedFn(types.LocalUnsafe, 1, chaosProgressAttempts), nodes[i].AdvancedFn(types.LocalSafe, 1, chaosProgressAttempts))
+			}
+		}
+		dsl.CheckAll(t, checks...)
+	}
+}
+
+// AssertStoppedNodesRecovered checks that every node stopped during the chaos `actions` came back: its unsafe and safe
+// heads advance again.
+func AssertStoppedNodesRecovered(t devtest.T, nodes []dsl.L2CLNode, actions []ChaosAction) {
+	var checks []dsl.CheckFunc
+	for _, i := range StoppedNodes(actions) {
+		t.Logf("checking that node %s recovered", nodes[i].Escape().ID().Key())
+		checks = append(checks, nodes[i].AdvancedFn(types.LocalUnsafe, chaosConvergenceBlocks, chaosProgressAttempts), nodes[i].AdvancedFn(types.LocalSafe, 1, chaosProgressAttempts))
+	}
+	dsl.CheckAll(t, checks...)
+}
+
+// StoppedNodes returns the sorted indices of the nodes stopped at least once by `actions`.
+func StoppedNodes(actions []ChaosAction) []int {
+	var stopped []int
+	for _, action := range actions {
+		if action.Kind == ChaosStop && !slices.Contains(stopped, action.Node) {
+			stopped = append(stopped, action.Node)
+		}
+	}
+	slices.Sort(stopped)
+	return stopped
+}
+
+// ChaosRestart runs the chaos sequence returned by ChaosPlan over the nodes: over `rounds`, it randomly stops, starts,
+// disconnects and reconnects nodes, running `afterRound`, if set, after each of them. It then restarts and reconnects
+// every node and checks that the whole network converges to a single unsafe head. It returns the actions that were run.
+func ChaosRestart(t devtest.T, nodes []dsl.L2CLNode, rounds int, seed int64, afterRound ChaosRoundCheck) []ChaosAction {
+	t.Logf("running %d chaos rounds over %d nodes with seed %d", rounds, len(nodes), seed)
+
+	running := make([]bool, len(nodes))
+	for i := range running {
+		running[i] = true
+	}
+
+	actions := ChaosPlan(len(nodes), rounds, seed)
+	for round, action := range actions {
+		t.Logf("chaos round %d: %s", round, action)
+
+		node := &nodes[action.Node]
+		switch action.Kind {
+		case ChaosStop:
+			node.Stop()
+			running[action.Node] = false
+		case ChaosStart:
+			node.Start()
+			running[action.Node] = true
+		case ChaosDisconnect:
+			node.DisconnectPeer(&nodes[action.Peer])
+		case ChaosReconnect:
+			node.ConnectPeer(&nodes[action.Peer])
+		}
+
+		time.Sleep(chaosRoundDelay)
+		if afterRound != nil {
+			afterRound(t, round, running)
+		}
+	}
+
+	// Bring the network back to a fully connected state.
+	for i := range nodes {
+		if !running[i] {
+			t.Logf("restarting node %s", nodes[i].Escape().ID().Key())
+			nodes[i].Start()
+		}
+	}
+	for i := range nodes {
+		for j := range i {
+			nodes[i].ConnectPeer(&nodes[j])
+		}
+	}
+
+	assertSingleUnsafeHead(t, nodes)
+
+	return actions
+}
+
+// assertSingleUnsafeHead waits for all the nodes to go past the highest unsafe head in the network and checks that they
+// all hold the same block at that height.
+func assertSingleUnsafeHead(t devtest.T, nodes []dsl.L2CLNode) {
+	var target uint64
+	for _, node := range nodes {
+		target = max(target, node.SyncStatus().UnsafeL2.Number)
+	}
+	target += chaosConvergenceBlocks
+
+	var checks []dsl.CheckFunc
+	for _, node := range nodes {
+		checks = append(checks, node.ReachedFn(types.LocalUnsafe, target, 100))
+	}
+	dsl.CheckAll(t, checks...)
+
+	hashes := make(map[string]common.Hash, len(nodes))
+	for _, node := range nodes {
+		output, err := node.Escape().RollupAPI().OutputAtBlock(t.Ctx(), target)
+		t.Require().NoError(err, "failed to get output at block %d for %s", target, node.Escape().ID().Key())
+		hashes[node.Escape().ID().Key()] = output.BlockRef.Hash
+	}
+
+	t.Require().NoError(checkSingleUnsafeHead(hashes), "nodes diverged at block %d", target)
+}
+
+// checkSingleUnsafeHead checks that all the nodes hold the same block hash.
+func checkSingleUnsafeHead(hashes map[string]common.Hash) error {
+	nodesByHash := make(map[common.Hash][]string)
+	for nodeName, hash := range hashes {
+		nodesByHash[hash] = append(nodesByHash[hash], nodeName)
+	}
+
+	if len(nodesByHash) <= 1 {
+		return nil
+	}
+
+	forks := make([]string, 0, len(nodesByHash))
+	for hash, nodeNames := range nodesByHash {
+		sort.Strings(nodeNames)
+		forks = append(forks, fmt.Sprintf("%s: %s", hash, strings.Join(nodeNames, ", ")))
+	}
+	sort.Strings(forks)
+
+	return fmt.Errorf("nodes hold %d different blocks: %s", len(nodesByHash), strings.Join(forks, "; "))
+}
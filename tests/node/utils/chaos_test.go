@@ -0,0 +1,65 @@
+package node_utils
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChaosPlan(t *testing.T) {
+	t.Run("same seed gives the same sequence", func(t *testing.T) {
+		require.Equal(t, ChaosPlan(4, 50, 42), ChaosPlan(4, 50, 42))
+	})
+
+	t.Run("different seeds give different sequences", func(t *testing.T) {
+		require.NotEqual(t, ChaosPlan(4, 50, 42), ChaosPlan(4, 50, 43))
+	})
+
+	t.Run("actions are valid", func(t *testing.T) {
+		running := []bool{true, true, true, true}
+		for _, action := range ChaosPlan(len(running), 200, 7) {
+			switch action.Kind {
+			case ChaosStop:
+				require.True(t, running[action.Node], "stopping a stopped node: %s", action)
+				running[action.Node] = false
+			case ChaosStart:
+				require.False(t, running[action.Node], "starting a running node: %s", action)
+				running[action.Node] = true
+			case ChaosDisconnect, ChaosReconnect:
+				require.NotEqual(t, action.Node, action.Peer)
+				require.True(t, running[action.Node] && running[action.Peer], "connection change on a stopped node: %s", action)
+			}
+		}
+	})
+}
+
+func TestStoppedNodes(t *testing.T) {
+	actions := []ChaosAction{
+		{Kind: ChaosStop, Node: 2},
+		{Kind: ChaosDisconnect, Node: 0, Peer: 1},
+		{Kind: ChaosStart, Node: 2},
+		{Kind: ChaosStop, Node: 0},
+		{Kind: ChaosStop, Node: 2},
+	}
+	require.Equal(t, []int{0, 2}, StoppedNodes(actions))
+	require.Empty(t, StoppedNodes([]ChaosAction{{Kind: ChaosDisconnect, Node: 0, Peer: 1}}))
+}
+
+func TestSingleUnsafeHead(t *testing.T) {
+	t.Run("single head", func(t *testing.T) {
+		require.NoError(t, checkSingleUnsafeHead(map[string]common.Hash{
+			"cl-geth-kona-sequencer-0": {0x1},
+			"cl-geth-kona-validator-0": {0x1},
+		}))
+	})
+
+	t.Run("diverged heads", func(t *testing.T) {
+		err := checkSingleUnsafeHead(map[string]common.Hash{
+			"cl-geth-kona-sequencer-0": {0x1},
+			"cl-geth-kona-validator-0": {0x1},
+			"cl-geth-kona-validator-1": {0x2},
+		})
+		require.ErrorContains(t, err, "cl-geth-kona-validator-1")
+	})
+}
@@ -0,0 +1,66 @@
+package node_utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+)
+
+// diagnosticTimeout bounds the RPC calls made to collect diagnostics, as the nodes may be unresponsive when the
+// deadline expires.
+const diagnosticTimeout = 5 * time.Second
+
+// WithDeadline bounds the wall-clock duration of the test to `d`. When the deadline expires, it logs the output of
+// `diag` and fails the test, so that tests hanging close to the CI limits fail with actionable output.
+// The returned T carries a context that is cancelled at the deadline, so that the context-bound waits of the test
+// return early. `diag` runs outside of the test goroutine and must not use the require helpers.
+func WithDeadline(t devtest.T, d time.Duration, diag func() string) devtest.T {
+	ctx, stop := startDeadline(t.Ctx(), d, diag, func(msg string) {
+		t.Errorf("%s", msg)
+	})
+	t.Cleanup(stop)
+	return t.WithCtx(ctx)
+}
+
+// startDeadline calls `fail` with the output of `diag` once `d` has elapsed, and then cancels the returned context.
+// The returned function stops the deadline.
+func startDeadline(ctx context.Context, d time.Duration, diag func() string, fail func(msg string)) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	timer := time.AfterFunc(d, func() {
+		fail(fmt.Sprintf("test deadline of %s exceeded, diagnostics:\n%s", d, diag()))
+		cancel()
+	})
+	return ctx, func() {
+		timer.Stop()
+		cancel()
+	}
+}
+
+// HeadsDiagnostic returns a diagnostic, for WithDeadline, that reports the current heads of the given nodes.
+func HeadsDiagnostic(nodes []dsl.L2CLNode) func() string {
+	return func() string {
+		lines := make([]string, 0, len(nodes))
+		for _, node := range nodes {
+			lines = append(lines, headsLine(&node))
+		}
+		return strings.Join(lines, "\n")
+	}
+}
+
+func headsLine(node *dsl.L2CLNode) string {
+	ctx, cancel := context.WithTimeout(context.Background(), diagnosticTimeout)
+	defer cancel()
+
+	name := node.Escape().ID().Key()
+	status, err := node.Escape().RollupAPI().SyncStatus(ctx)
+	if err != nil {
+		return fmt.Sprintf("%s: failed to get sync status: %v", name, err)
+	}
+	return fmt.Sprintf("%s: unsafe=%s cross-unsafe=%s local-safe=%s safe=%s finalized=%s l1=%s",
+		name, status.UnsafeL2.ID(), status.CrossUnsafeL2.ID(), status.LocalSafeL2.ID(), status.SafeL2.ID(),
+		status.FinalizedL2.ID(), status.CurrentL1.ID())
+}
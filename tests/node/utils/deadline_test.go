@@ -0,0 +1,46 @@
+package node_utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadline(t *testing.T) {
+	t.Run("deadline exceeded", func(t *testing.T) {
+		failures := make(chan string, 1)
+		ctx, stop := startDeadline(context.Background(), 10*time.Millisecond, func() string {
+			return "node-a: unsafe=42"
+		}, func(msg string) {
+			failures <- msg
+		})
+		defer stop()
+
+		select {
+		case msg := <-failures:
+			require.Contains(t, msg, "test deadline of 10ms exceeded")
+			require.Contains(t, msg, "node-a: unsafe=42")
+		case <-time.After(time.Second):
+			t.Fatal("deadline did not fire")
+		}
+
+		<-ctx.Done()
+	})
+
+	t.Run("stopped before the deadline", func(t *testing.T) {
+		ran := make(chan struct{}, 1)
+		_, stop := startDeadline(context.Background(), 50*time.Millisecond, func() string {
+			ran <- struct{}{}
+			return ""
+		}, func(string) {})
+		stop()
+
+		select {
+		case <-ran:
+			t.Fatal("diagnostic ran after the deadline was stopped")
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package node_utils
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/stack"
+	"github.com/ethereum-optimism/optimism/op-devstack/sysgo"
+)
+
+// L2ELSpawner returns the option spawning an L2 EL node of a given client with the ID `id`.
+type L2ELSpawner func(id stack.L2ELNodeID) stack.Option[*sysgo.Orchestrator]
+
+var (
+	l2ELSpawnersMu sync.RWMutex
+	// l2ELSpawners maps each EL kind to the spawner of its nodes. op-erigon and op-nethermind have no sysgo support, so
+	// Topology.Validate rejects the topologies using them until a spawner is registered for them.
+	l2ELSpawners = map[L2ELKind]L2ELSpawner{
+		GethEL: func(id stack.L2ELNodeID) stack.Option[*sysgo.Orchestrator] { return sysgo.WithOpGeth(id) },
+		RethEL: func(id stack.L2ELNodeID) stack.Option[*sysgo.Orchestrator] { return sysgo.WithOpReth(id) },
+	}
+)
+
+// L2ELKinds returns the EL clients nodes can be configured with, whether a spawner is registered for them or not.
+func L2ELKinds() []L2ELKind {
+	return []L2ELKind{GethEL, RethEL, ErigonEL, NethermindEL}
+}
+
+// RegisterL2EL makes the nodes of kind `kind` spawn through `spawner`, replacing the previous spawner of that kind. It
+// is meant to be called from the TestMain of a package, before the system is built.
+func RegisterL2EL(kind L2ELKind, spawner L2ELSpawner) {
+	l2ELSpawnersMu.Lock()
+	defer l2ELSpawnersMu.Unlock()
+	l2ELSpawners[kind] = spawner
+}
+
+// WithL2EL spawns an L2 EL node of kind `kind` with the ID `id`. It panics when no spawner is registered for the kind,
+// as the system can't be built without it.
+func WithL2EL(kind L2ELKind, id stack.L2ELNodeID) stack.Option[*sysgo.Orchestrator] {
+	spawner, err := l2ELSpawner(kind)
+	if err != nil {
+		panic(err.Error())
+	}
+	return spawner(id)
+}
+
+func l2ELSpawner(kind L2ELKind) (L2ELSpawner, error) {
+	l2ELSpawnersMu.RLock()
+	defer l2ELSpawnersMu.RUnlock()
+
+	spawner, ok := l2ELSpawners[kind]
+	if !ok {
+		return nil, fmt.Errorf("no spawner registered for %s ELs, register one with RegisterL2EL", kind)
+	}
+	return spawner, nil
+}
@@ -0,0 +1,70 @@
+package node_utils
+
+import (
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/stack"
+	"github.com/ethereum-optimism/optimism/op-devstack/sysgo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestL2ELSpawners(t *testing.T) {
+	t.Run("geth and reth are built in", func(t *testing.T) {
+		for _, kind := range []L2ELKind{GethEL, RethEL} {
+			_, err := l2ELSpawner(kind)
+			require.NoError(t, err, kind)
+		}
+	})
+
+	t.Run("other clients need a spawner", func(t *testing.T) {
+		_, err := l2ELSpawner(NethermindEL)
+		require.ErrorContains(t, err, "no spawner registered for nethermind ELs")
+		require.Panics(t, func() { WithL2EL(NethermindEL, stack.L2ELNodeID{}) })
+	})
+
+	t.Run("registered spawner", func(t *testing.T) {
+		t.Cleanup(func() {
+			l2ELSpawnersMu.Lock()
+			defer l2ELSpawnersMu.Unlock()
+			delete(l2ELSpawners, ErigonEL)
+		})
+
+		spawned := false
+		RegisterL2EL(ErigonEL, func(id stack.L2ELNodeID) stack.Option[*sysgo.Orchestrator] {
+			spawned = true
+			return stack.Combine[*sysgo.Orchestrator]()
+		})
+
+		WithL2EL(ErigonEL, stack.L2ELNodeID{})
+		require.True(t, spawned)
+	})
+
+	t.Run("topology accepts every client", func(t *testing.T) {
+		chain := TopologyChain{Nodes: []TopologyNode{
+			{Name: "a", CL: KonaNode, EL: ErigonEL, Role: Sequencer},
+			{Name: "b", CL: OpNode, EL: NethermindEL, Role: Validator},
+		}}
+		require.NoError(t, chain.validate())
+
+		chain.Nodes[1].EL = "besu"
+		require.ErrorContains(t, chain.validate(), `unknown EL kind "besu"`)
+	})
+
+	t.Run("topology needs a spawner for every client", func(t *testing.T) {
+		topology := Topology{Nodes: []TopologyNode{
+			{Name: "a", CL: KonaNode, EL: GethEL, Role: Sequencer},
+			{Name: "b", CL: OpNode, EL: ErigonEL, Role: Validator},
+		}}
+		require.ErrorContains(t, topology.Validate(), "node b: no spawner registered for erigon ELs")
+
+		t.Cleanup(func() {
+			l2ELSpawnersMu.Lock()
+			defer l2ELSpawnersMu.Unlock()
+			delete(l2ELSpawners, ErigonEL)
+		})
+		RegisterL2EL(ErigonEL, func(id stack.L2ELNodeID) stack.Option[*sysgo.Orchestrator] {
+			return stack.Combine[*sysgo.Orchestrator]()
+		})
+		require.NoError(t, topology.Validate())
+	})
+}
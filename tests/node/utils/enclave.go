@@ -0,0 +1,73 @@
+package node_utils
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/kurtosis-tech/kurtosis/api/golang/engine/lib/kurtosis_context"
+)
+
+// enclaveCleanupTimeout bounds the teardown of an enclave. The test context is already done when the cleanup runs.
+const enclaveCleanupTimeout = 2 * time.Minute
+
+// enclaveManager is the subset of the kurtosis engine used to tear down an enclave.
+type enclaveManager interface {
+	DestroyEnclave(ctx context.Context, enclaveIdentifier string) error
+	EnclaveNames(ctx context.Context) ([]string, error)
+}
+
+// kurtosisEnclaves adapts the kurtosis context to enclaveManager.
+type kurtosisEnclaves struct {
+	*kurtosis_context.KurtosisContext
+}
+
+func (k kurtosisEnclaves) EnclaveNames(ctx context.Context) ([]string, error) {
+	enclaves, err := k.GetEnclaves(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(enclaves.GetEnclavesByName()))
+	for name := range enclaves.GetEnclavesByName() {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// RegisterEnclaveCleanup destroys the kurtosis enclave `enclaveName` at the end of the test, and checks that it is gone,
+// so that failed tests do not leak enclaves between CI runs. If the kurtosis engine can not be reached, the enclave is
+// left as is and this is only logged.
+func RegisterEnclaveCleanup(t devtest.T, enclaveName string) {
+	t.Cleanup(func() {
+		kurtosisCtx, err := kurtosis_context.NewKurtosisContextFromLocalEngine()
+		if err != nil {
+			t.Logf("failed to create kurtosis context, enclave %s is left running: %v", enclaveName, err)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), enclaveCleanupTimeout)
+		defer cancel()
+
+		t.Logf("destroying enclave %s", enclaveName)
+		t.Require().NoError(cleanupEnclave(ctx, kurtosisEnclaves{kurtosisCtx}, enclaveName))
+	})
+}
+
+func cleanupEnclave(ctx context.Context, mgr enclaveManager, enclaveName string) error {
+	if err := mgr.DestroyEnclave(ctx, enclaveName); err != nil {
+		return fmt.Errorf("failed to destroy enclave %s: %w", enclaveName, err)
+	}
+
+	names, err := mgr.EnclaveNames(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list enclaves: %w", err)
+	}
+	if slices.Contains(names, enclaveName) {
+		return fmt.Errorf("enclave %s is still running after being destroyed", enclaveName)
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package node_utils
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEnclaves tracks the running enclaves. Destroying an enclave is a no-op when `stuck` is set.
+type fakeEnclaves struct {
+	running   []string
+	destroyed []string
+	stuck     bool
+	err       error
+}
+
+func (f *fakeEnclaves) DestroyEnclave(ctx context.Context, enclaveIdentifier string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.destroyed = append(f.destroyed, enclaveIdentifier)
+	if !f.stuck {
+		f.running = slices.DeleteFunc(f.running, func(name string) bool { return name == enclaveIdentifier })
+	}
+	return nil
+}
+
+func (f *fakeEnclaves) EnclaveNames(ctx context.Context) ([]string, error) {
+	return f.running, nil
+}
+
+func TestCleanupEnclave(t *testing.T) {
+	t.Run("enclave destroyed", func(t *testing.T) {
+		mgr := &fakeEnclaves{running: []string{"devnet", "other"}}
+		require.NoError(t, cleanupEnclave(context.Background(), mgr, "devnet"))
+		require.Equal(t, []string{"devnet"}, mgr.destroyed)
+		require.Equal(t, []string{"other"}, mgr.running)
+	})
+
+	t.Run("enclave still running", func(t *testing.T) {
+		mgr := &fakeEnclaves{running: []string{"devnet"}, stuck: true}
+		require.ErrorContains(t, cleanupEnclave(context.Background(), mgr, "devnet"), "still running")
+	})
+
+	t.Run("destroy fails", func(t *testing.T) {
+		mgr := &fakeEnclaves{running: []string{"devnet"}, err: errors.New("engine unreachable")}
+		require.ErrorContains(t, cleanupEnclave(context.Background(), mgr, "devnet"), "engine unreachable")
+	})
+}
@@ -0,0 +1,100 @@
+package node_utils
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/op-rs/kona/supervisor/utils"
+)
+
+// payloadResubmitter is the subset of *utils.TestBlockBuilder used to submit a block twice.
+type payloadResubmitter interface {
+	BuildBlock(ctx context.Context, parentHash *common.Hash, opts ...utils.BuildOption) (*engine.ExecutionPayloadEnvelope, error)
+	ResubmitLastPayload(ctx context.Context) (engine.PayloadStatusV1, error)
+	LatestBlockHash(ctx context.Context) (common.Hash, error)
+}
+
+// AssertDuplicateBlockIgnored builds a block on top of the current head, submits its payload a second time through
+// engine_newPayload and checks that the duplicate is accepted as VALID without erroring nor moving the head. This
+// guards against the EL misbehaving when it is handed a block it already knows.
+func AssertDuplicateBlockIgnored(t devtest.T, builder *utils.TestBlockBuilder, ctx context.Context) {
+	t.Require().NoError(checkDuplicateBlockIgnored(ctx, builder))
+}
+
+func checkDuplicateBlockIgnored(ctx context.Context, builder payloadResubmitter) error {
+	payload, err := builder.BuildBlock(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("no block was built: %w", err)
+	}
+	blockHash := payload.ExecutionPayload.BlockHash
+
+	status, err := builder.ResubmitLastPayload(ctx)
+	if err != nil {
+		return fmt.Errorf("duplicate submission of block %s failed: %w", blockHash, err)
+	}
+	if status.Status != engine.VALID {
+		return fmt.Errorf("duplicate submission of block %s returned status %s, expected %s", blockHash, status.Status, engine.VALID)
+	}
+
+	head, err := builder.LatestBlockHash(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch the latest block: %w", err)
+	}
+	if head != blockHash {
+		return fmt.Errorf("head moved to %s after the duplicate submission of block %s", head, blockHash)
+	}
+
+	return nil
+}
+
+// badPayloadSubmitter is the subset of *utils.TestBlockBuilder used to submit a malformed block.
+type badPayloadSubmitter interface {
+	BuildBlock(ctx context.Context, parentHash *common.Hash, opts ...utils.BuildOption) (*engine.ExecutionPayloadEnvelope, error)
+	ResubmitLastPayloadWithParent(ctx context.Context, parent common.Hash) (engine.PayloadStatusV1, error)
+	LatestBlockHash(ctx context.Context) (common.Hash, error)
+}
+
+// AssertRecoversFromBadPayload builds a block, submits a copy of it pointing at a wrong parent through
+// engine_newPayload and checks that the EL rejects it as INVALID. It then checks that the next valid block is accepted
+// on top of the original one, so that the chain continues past the rejected payload.
+func AssertRecoversFromBadPayload(t devtest.T, builder *utils.TestBlockBuilder, ctx context.Context) {
+	t.Require().NoError(checkRecoversFromBadPayload(ctx, builder))
+}
+
+func checkRecoversFromBadPayload(ctx context.Context, builder badPayloadSubmitter) error {
+	payload, err := builder.BuildBlock(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("no block was built: %w", err)
+	}
+	blockHash := payload.ExecutionPayload.BlockHash
+
+	bogusParent := common.Hash{0xde, 0xad}
+	status, err := builder.ResubmitLastPayloadWithParent(ctx, bogusParent)
+	if err != nil {
+		return fmt.Errorf("submission of block %s with parent %s failed: %w", blockHash, bogusParent, err)
+	}
+	if status.Status != engine.INVALID {
+		return fmt.Errorf("submission of block %s with parent %s returned status %s, expected %s", blockHash, bogusParent, status.Status, engine.INVALID)
+	}
+
+	next, err := builder.BuildBlock(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("no block was built after the bad payload: %w", err)
+	}
+	if next.ExecutionPayload.ParentHash != blockHash {
+		return fmt.Errorf("block %s was built on %s instead of %s", next.ExecutionPayload.BlockHash, next.ExecutionPayload.ParentHash, blockHash)
+	}
+
+	head, err := builder.LatestBlockHash(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch the latest block: %w", err)
+	}
+	if head != next.ExecutionPayload.BlockHash {
+		return fmt.Errorf("head is %s instead of %s after the bad payload", head, next.ExecutionPayload.BlockHash)
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package node_utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/op-rs/kona/supervisor/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEngine builds a single block, unless `buildErr` is set, and answers the duplicate submission with the given
+// result.
+type fakeEngine struct {
+	head common.Hash
+
+	buildErr        error
+	duplicateStatus string
+	duplicateErr    error
+}
+
+func (f *fakeEngine) BuildBlock(ctx context.Context, parentHash *common.Hash, opts ...utils.BuildOption) (*engine.ExecutionPayloadEnvelope, error) {
+	if f.buildErr != nil {
+		return nil, f.buildErr
+	}
+	payload := &engine.ExecutionPayloadEnvelope{ExecutionPayload: &engine.ExecutableData{BlockHash: common.Hash{0x01}}}
+	f.head = payload.ExecutionPayload.BlockHash
+	return payload, nil
+}
+
+func (f *fakeEngine) ResubmitLastPayload(ctx context.Context) (engine.PayloadStatusV1, error) {
+	if f.duplicateErr != nil {
+		return engine.PayloadStatusV1{}, f.duplicateErr
+	}
+	return engine.PayloadStatusV1{Status: f.duplicateStatus}, nil
+}
+
+func (f *fakeEngine) LatestBlockHash(ctx context.Context) (common.Hash, error) {
+	return f.head, nil
+}
+
+func TestDuplicateBlockIgnored(t *testing.T) {
+	t.Run("duplicate ignored", func(t *testing.T) {
+		require.NoError(t, checkDuplicateBlockIgnored(context.Background(), &fakeEngine{duplicateStatus: engine.VALID}))
+	})
+
+	t.Run("duplicate errors", func(t *testing.T) {
+		el := &fakeEngine{duplicateErr: errors.New("RPC error: block already known")}
+		require.ErrorContains(t, checkDuplicateBlockIgnored(context.Background(), el), "block already known")
+	})
+
+	t.Run("duplicate rejected", func(t *testing.T) {
+		el := &fakeEngine{duplicateStatus: engine.INVALID}
+		require.ErrorContains(t, checkDuplicateBlockIgnored(context.Background(), el), "returned status INVALID")
+	})
+
+	t.Run("build failed", func(t *testing.T) {
+		buildErr := errors.New("newPayload returned invalid status: INVALID")
+		el := &fakeEngine{buildErr: buildErr}
+		require.ErrorIs(t, checkDuplicateBlockIgnored(context.Background(), el), buildErr)
+	})
+}
+
+// fakeBadPayloadEngine builds a chain of blocks and answers the submission of a re-parented payload with `badStatus`.
+// With `stall`, the head does not move past the first block.
+type fakeBadPayloadEngine struct {
+	head   common.Hash
+	number byte
+
+	badStatus string
+	stall     bool
+}
+
+func (f *fakeBadPayloadEngine) BuildBlock(ctx context.Context, parentHash *common.Hash, opts ...utils.BuildOption) (*engine.ExecutionPayloadEnvelope, error) {
+	f.number++
+	payload := &engine.ExecutionPayloadEnvelope{ExecutionPayload: &engine.ExecutableData{
+		ParentHash: f.head,
+		BlockHash:  common.Hash{f.number},
+	}}
+	if !f.stall || f.number == 1 {
+		f.head = payload.ExecutionPayload.BlockHash
+	}
+	return payload, nil
+}
+
+func (f *fakeBadPayloadEngine) ResubmitLastPayloadWithParent(ctx context.Context, parent common.Hash) (engine.PayloadStatusV1, error) {
+	return engine.PayloadStatusV1{Status: f.badStatus}, nil
+}
+
+func (f *fakeBadPayloadEngine) LatestBlockHash(ctx context.Context) (common.Hash, error) {
+	return f.head, nil
+}
+
+func TestRecoversFromBadPayload(t *testing.T) {
+	t.Run("bad payload rejected", func(t *testing.T) {
+		require.NoError(t, checkRecoversFromBadPayload(context.Background(), &fakeBadPayloadEngine{badStatus: engine.INVALID}))
+	})
+
+	t.Run("bad payload accepted", func(t *testing.T) {
+		el := &fakeBadPayloadEngine{badStatus: engine.VALID}
+		require.ErrorContains(t, checkRecoversFromBadPayload(context.Background(), el), "returned status VALID")
+	})
+
+	t.Run("chain stalls after the bad payload", func(t *testing.T) {
+		el := &fakeBadPayloadEngine{badStatus: engine.INVALID, stall: true}
+		require.ErrorContains(t, checkRecoversFromBadPayload(context.Background(), el), "after the bad payload")
+	})
+}
@@ -0,0 +1,311 @@
+package node_utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/ethereum-optimism/optimism/devnet-sdk/descriptors"
+	"github.com/ethereum-optimism/optimism/devnet-sdk/shell/env"
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-node/rollup"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"gopkg.in/yaml.v3"
+)
+
+// ExternalNetworkEnvVar points to a YAML or JSON file describing an already running network. When set,
+// UseExternalNetwork makes the presets hydrate from that network instead of spawning one.
+const ExternalNetworkEnvVar = "KONA_EXTERNAL_NETWORK"
+
+// orchestratorEnvVar selects the op-devstack backend. The sysext backend hydrates the system from the devnet
+// descriptor found at env.EnvURLVar.
+const orchestratorEnvVar = "DEVSTACK_ORCHESTRATOR"
+
+// ExternalNetwork describes a running network by its endpoints: an L1, and the nodes of a single L2 chain.
+type ExternalNetwork struct {
+	Name string     `yaml:"name"`
+	L1   ExternalL1 `yaml:"l1"`
+	// Faucet is the URL of an op-faucet serving the L1 and the L2 chain, used to fund the test accounts.
+	Faucet string         `yaml:"faucet"`
+	Nodes  []ExternalNode `yaml:"nodes"`
+}
+
+// ExternalL1 is the L1 of an ExternalNetwork.
+type ExternalL1 struct {
+	// EL is the URL of the execution RPC.
+	EL string `yaml:"el"`
+	// CL is the URL of the beacon API.
+	CL string `yaml:"cl"`
+}
+
+// ExternalNode is an L2 node of an ExternalNetwork: a CL driving its own EL. Its kinds name it the same way as the nodes
+// the presets spawn, so that the preset matchers and PairedEL work the same.
+type ExternalNode struct {
+	Name string     `yaml:"name"`
+	CL   L2NodeKind `yaml:"cl"`
+	EL   L2ELKind   `yaml:"el"`
+	Role L2NodeKind `yaml:"role"`
+	// CLRPC and ELRPC are the URLs of the RPC endpoints of the CL and the EL.
+	CLRPC string `yaml:"clRpc"`
+	ELRPC string `yaml:"elRpc"`
+}
+
+// LoadExternalNetwork reads and validates the network file at `path`. Unknown fields are rejected, so that a typo
+// doesn't silently drop an endpoint.
+func LoadExternalNetwork(path string) (*ExternalNetwork, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read network file: %w", err)
+	}
+
+	var network ExternalNetwork
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&network); err != nil {
+		return nil, fmt.Errorf("failed to parse network file: %w", err)
+	}
+
+	if err := network.Validate(); err != nil {
+		return nil, err
+	}
+	return &network, nil
+}
+
+// Validate checks that every endpoint is a valid URL and that the nodes form a chain the presets can use. The nodes are
+// validated as those of a Topology, so the same naming rules apply.
+func (n ExternalNetwork) Validate() error {
+	var errs []error
+	if n.Name == "" {
+		errs = append(errs, errors.New("network has no name"))
+	}
+
+	endpoints := map[string]string{"l1.el": n.L1.EL, "l1.cl": n.L1.CL, "faucet": n.Faucet}
+	nodes := make([]TopologyNode, len(n.Nodes))
+	for i, node := range n.Nodes {
+		endpoints[node.Name+".clRpc"] = node.CLRPC
+		endpoints[node.Name+".elRpc"] = node.ELRPC
+		nodes[i] = node.topologyNode()
+	}
+	for name, endpoint := range endpoints {
+		if _, err := portInfo(endpoint); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	if err := (TopologyChain{Nodes: nodes}).validate(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func (n ExternalNode) topologyNode() TopologyNode {
+	return TopologyNode{Name: n.Name, CL: n.CL, EL: n.EL, Role: n.Role}
+}
+
+// UseExternalNetwork points the presets at the network described by the file at ExternalNetworkEnvVar, if set. It
+// writes a devnet descriptor of the network, with the chain configs fetched from its nodes, and selects the sysext
+// backend to hydrate from it. It must be called from TestMain, before the presets are set up.
+func UseExternalNetwork(ctx context.Context) error {
+	path := os.Getenv(ExternalNetworkEnvVar)
+	if path == "" {
+		return nil
+	}
+
+	network, err := LoadExternalNetwork(path)
+	if err != nil {
+		return err
+	}
+
+	descriptor, err := describeExternalNetwork(ctx, network, rpcChainInfo{})
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(descriptor)
+	if err != nil {
+		return fmt.Errorf("failed to encode the devnet descriptor: %w", err)
+	}
+	descriptorPath := filepath.Join(os.TempDir(), fmt.Sprintf("%s-devnet.json", network.Name))
+	if err := os.WriteFile(descriptorPath, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write the devnet descriptor: %w", err)
+	}
+
+	if err := os.Setenv(env.EnvURLVar, descriptorPath); err != nil {
+		return err
+	}
+	return os.Setenv(orchestratorEnvVar, "sysext")
+}
+
+// NewExternalMixedOpKona returns the preset of the network selected by UseExternalNetwork. The batcher and the proposer
+// of an external network are not exposed, so the preset leaves them nil.
+func NewExternalMixedOpKona(t devtest.T) *MixedOpKonaPreset {
+	t.Gate().NotEmpty(os.Getenv(ExternalNetworkEnvVar), "no external network is configured")
+	return NewMixedOpKona(t)
+}
+
+// chainInfoSource fetches the configs of the chains of an external network from its nodes.
+type chainInfoSource interface {
+	RollupConfig(ctx context.Context, clURL string) (*rollup.Config, error)
+	ChainConfig(ctx context.Context, elURL string) (*params.ChainConfig, error)
+}
+
+// rpcChainInfo fetches the chain configs over JSON-RPC.
+type rpcChainInfo struct{}
+
+func (rpcChainInfo) RollupConfig(ctx context.Context, clURL string) (*rollup.Config, error) {
+	var config rollup.Config
+	if err := callRPC(ctx, clURL, &config, "optimism_rollupConfig"); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func (rpcChainInfo) ChainConfig(ctx context.Context, elURL string) (*params.ChainConfig, error) {
+	var config params.ChainConfig
+	if err := callRPC(ctx, elURL, &config, "debug_chainConfig"); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func callRPC(ctx context.Context, endpoint string, result any, method string) error {
+	client, err := rpc.DialContext(ctx, endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to dial %s: %w", endpoint, err)
+	}
+	defer client.Close()
+
+	if err := client.CallContext(ctx, result, method); err != nil {
+		return fmt.Errorf("%s failed on %s: %w", method, endpoint, err)
+	}
+	return nil
+}
+
+// describeExternalNetwork builds the devnet descriptor of the network. The rollup config is read from the first
+// sequencer, and the chain configs from the L1 EL and the EL of that sequencer.
+func describeExternalNetwork(ctx context.Context, network *ExternalNetwork, info chainInfoSource) (*descriptors.DevnetEnvironment, error) {
+	var sequencer *ExternalNode
+	for i := range network.Nodes {
+		if network.Nodes[i].Role == Sequencer {
+			sequencer = &network.Nodes[i]
+			break
+		}
+	}
+	if sequencer == nil {
+		return nil, errors.New("network has no sequencer")
+	}
+
+	rollupConfig, err := info.RollupConfig(ctx, sequencer.CLRPC)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the rollup config: %w", err)
+	}
+	l1Config, err := info.ChainConfig(ctx, network.L1.EL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the L1 chain config: %w", err)
+	}
+	l2Config, err := info.ChainConfig(ctx, sequencer.ELRPC)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the L2 chain config: %w", err)
+	}
+
+	l1EL, err := portInfo(network.L1.EL)
+	if err != nil {
+		return nil, err
+	}
+	l1CL, err := portInfo(network.L1.CL)
+	if err != nil {
+		return nil, err
+	}
+	faucet, err := portInfo(network.Faucet)
+	if err != nil {
+		return nil, err
+	}
+
+	l1 := &descriptors.Chain{
+		Name:   "l1",
+		ID:     rollupConfig.L1ChainID.String(),
+		Config: l1Config,
+		Nodes: []descriptors.Node{{
+			Name: "l1",
+			Services: descriptors.ServiceMap{
+				"el": &descriptors.Service{Name: "el-l1", Endpoints: descriptors.EndpointMap{"rpc": l1EL}},
+				"cl": &descriptors.Service{Name: "cl-l1", Endpoints: descriptors.EndpointMap{"http": l1CL}},
+			},
+		}},
+	}
+
+	l2 := &descriptors.Chain{
+		Name:   network.Name,
+		ID:     rollupConfig.L2ChainID.String(),
+		Config: l2Config,
+		Services: descriptors.RedundantServiceMap{
+			"faucet": []*descriptors.Service{{Name: "faucet", Endpoints: descriptors.EndpointMap{"rpc": faucet}}},
+		},
+		Addresses: descriptors.AddressMap{
+			"SystemConfigProxy":   rollupConfig.L1SystemConfigAddress,
+			"OptimismPortalProxy": rollupConfig.DepositContractAddress,
+		},
+	}
+	for _, node := range network.Nodes {
+		clID, elID := node.topologyNode().nodeIDs(eth.ChainIDFromBig(rollupConfig.L2ChainID))
+		cl, err := portInfo(node.CLRPC)
+		if err != nil {
+			return nil, err
+		}
+		el, err := portInfo(node.ELRPC)
+		if err != nil {
+			return nil, err
+		}
+		l2.Nodes = append(l2.Nodes, descriptors.Node{
+			Name: node.Name,
+			Services: descriptors.ServiceMap{
+				"cl": &descriptors.Service{Name: clID.Key(), Endpoints: descriptors.EndpointMap{"rpc": cl}},
+				"el": &descriptors.Service{Name: elID.Key(), Endpoints: descriptors.EndpointMap{"rpc": el}},
+			},
+		})
+	}
+
+	return &descriptors.DevnetEnvironment{
+		Name: network.Name,
+		L1:   l1,
+		L2:   []*descriptors.L2Chain{{Chain: l2, RollupConfig: rollupConfig}},
+	}, nil
+}
+
+// portInfo splits an endpoint URL into the parts of a devnet descriptor endpoint, defaulting the port on the scheme.
+// Descriptors have no room for a path, so endpoints must be served at the root of their host.
+func portInfo(endpoint string) (*descriptors.PortInfo, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+	}
+	if u.Scheme == "" || u.Hostname() == "" {
+		return nil, fmt.Errorf("endpoint %q must be an absolute URL", endpoint)
+	}
+	if u.Path != "" && u.Path != "/" {
+		return nil, fmt.Errorf("endpoint %q has a path, which devnet descriptors can't hold", endpoint)
+	}
+
+	port := 0
+	switch {
+	case u.Port() != "":
+		port, err = strconv.Atoi(u.Port())
+		if err != nil {
+			return nil, fmt.Errorf("invalid port in endpoint %q: %w", endpoint, err)
+		}
+	case u.Scheme == "https" || u.Scheme == "wss":
+		port = 443
+	default:
+		port = 80
+	}
+
+	return &descriptors.PortInfo{Host: u.Hostname(), Scheme: u.Scheme, Port: port}, nil
+}
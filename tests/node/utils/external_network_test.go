@@ -0,0 +1,112 @@
+package node_utils
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-node/rollup"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/require"
+)
+
+const externalNetwork = `
+name: staging
+faucet: https://faucet.staging.example
+l1:
+  el: https://l1.staging.example
+  cl: https://beacon.staging.example:5052
+nodes:
+  - name: a
+    cl: kona
+    el: reth
+    role: sequencer
+    clRpc: http://10.0.0.1:9545
+    elRpc: http://10.0.0.1:8545
+  - name: b
+    cl: op
+    el: geth
+    role: validator
+    clRpc: http://10.0.0.2:9545
+    elRpc: ws://10.0.0.2:8546
+`
+
+// fakeChainInfo serves fixed chain configs, and fails for any other endpoint than the ones it knows.
+type fakeChainInfo struct {
+	rollupCL string
+	chainELs map[string]*params.ChainConfig
+}
+
+func (f fakeChainInfo) RollupConfig(ctx context.Context, clURL string) (*rollup.Config, error) {
+	if clURL != f.rollupCL {
+		return nil, errors.New("unexpected endpoint")
+	}
+	return &rollup.Config{L1ChainID: big.NewInt(900), L2ChainID: big.NewInt(901), L1SystemConfigAddress: common.Address{0x01}}, nil
+}
+
+func (f fakeChainInfo) ChainConfig(ctx context.Context, elURL string) (*params.ChainConfig, error) {
+	config, ok := f.chainELs[elURL]
+	if !ok {
+		return nil, errors.New("unexpected endpoint")
+	}
+	return config, nil
+}
+
+func TestExternalNetwork(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "network.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(externalNetwork), 0o644))
+
+	network, err := LoadExternalNetwork(path)
+	require.NoError(t, err)
+	require.Len(t, network.Nodes, 2)
+
+	t.Run("descriptor", func(t *testing.T) {
+		l1Config, l2Config := &params.ChainConfig{ChainID: big.NewInt(900)}, &params.ChainConfig{ChainID: big.NewInt(901)}
+		info := fakeChainInfo{
+			rollupCL: "http://10.0.0.1:9545",
+			chainELs: map[string]*params.ChainConfig{"https://l1.staging.example": l1Config, "http://10.0.0.1:8545": l2Config},
+		}
+
+		descriptor, err := describeExternalNetwork(context.Background(), network, info)
+		require.NoError(t, err)
+		require.Equal(t, "900", descriptor.L1.ID)
+		require.Same(t, l1Config, descriptor.L1.Config)
+		require.Equal(t, 443, descriptor.L1.Nodes[0].Services["el"].Endpoints["rpc"].Port)
+		require.Equal(t, 5052, descriptor.L1.Nodes[0].Services["cl"].Endpoints["http"].Port)
+
+		require.Len(t, descriptor.L2, 1)
+		l2 := descriptor.L2[0]
+		require.Equal(t, "901", l2.ID)
+		require.Same(t, l2Config, l2.Config)
+		require.Equal(t, common.Address{0x01}, l2.Addresses["SystemConfigProxy"])
+		require.Equal(t, "cl-reth-kona-sequencer-a", l2.Nodes[0].Services["cl"].Name)
+		require.Equal(t, "el-geth-op-validator-b", l2.Nodes[1].Services["el"].Name)
+		require.Equal(t, "ws", l2.Nodes[1].Services["el"].Endpoints["rpc"].Scheme)
+	})
+
+	t.Run("invalid endpoints", func(t *testing.T) {
+		broken := *network
+		broken.Faucet = "faucet.staging.example"
+		broken.L1.EL = "https://l1.staging.example/v1/key"
+		err := broken.Validate()
+		require.ErrorContains(t, err, "faucet: endpoint \"faucet.staging.example\" must be an absolute URL")
+		require.ErrorContains(t, err, "l1.el: endpoint \"https://l1.staging.example/v1/key\" has a path")
+	})
+
+	t.Run("no sequencer", func(t *testing.T) {
+		validators := *network
+		validators.Nodes = validators.Nodes[1:]
+		require.ErrorContains(t, validators.Validate(), "chain has no sequencer")
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "network.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(externalNetwork+"batcher: http://10.0.0.3:8548\n"), 0o644))
+		_, err := LoadExternalNetwork(path)
+		require.ErrorContains(t, err, "field batcher not found")
+	})
+}
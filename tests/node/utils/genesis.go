@@ -0,0 +1,55 @@
+package node_utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+)
+
+// genesisNode is a node whose rollup config genesis is compared to the other nodes'.
+type genesisNode struct {
+	name   string
+	rollup rollupConfigSource
+}
+
+// AssertGenesisTimeAgreement checks that every node reports the same L2 genesis time in its rollup config. It only
+// fetches the rollup configs once, so it is a cheap gate to run before the longer sync tests. The nodes that disagree
+// with the first one are reported.
+func AssertGenesisTimeAgreement(t devtest.T, nodes []dsl.L2CLNode) {
+	genesisNodes := make([]genesisNode, 0, len(nodes))
+	for _, node := range nodes {
+		genesisNodes = append(genesisNodes, genesisNode{
+			name:   node.Escape().ID().Key(),
+			rollup: node.Escape().RollupAPI(),
+		})
+	}
+
+	t.Require().NoError(checkGenesisTimeAgreement(t.Ctx(), genesisNodes))
+}
+
+func checkGenesisTimeAgreement(ctx context.Context, nodes []genesisNode) error {
+	if len(nodes) == 0 {
+		return fmt.Errorf("no nodes to compare")
+	}
+
+	var reference *genesisNode
+	var referenceTime uint64
+	var errs []error
+	for i, node := range nodes {
+		cfg, err := node.rollup.RollupConfig(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: failed to get rollup config: %w", node.name, err))
+			continue
+		}
+
+		if reference == nil {
+			reference, referenceTime = &nodes[i], cfg.Genesis.L2Time
+		} else if cfg.Genesis.L2Time != referenceTime {
+			errs = append(errs, fmt.Errorf("%s has L2 genesis time %d, %s has %d", node.name, cfg.Genesis.L2Time, reference.name, referenceTime))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,47 @@
+package node_utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-node/rollup"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGenesis serves a rollup config with the given L2 genesis time, or fails with `err`.
+type fakeGenesis struct {
+	l2Time uint64
+	err    error
+}
+
+func (f *fakeGenesis) RollupConfig(ctx context.Context) (*rollup.Config, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &rollup.Config{Genesis: rollup.Genesis{L2Time: f.l2Time}}, nil
+}
+
+func TestGenesisTimeAgreement(t *testing.T) {
+	node := func(name string, l2Time uint64) genesisNode {
+		return genesisNode{name: name, rollup: &fakeGenesis{l2Time: l2Time}}
+	}
+
+	t.Run("same genesis time", func(t *testing.T) {
+		nodes := []genesisNode{node("node-a", 1_700_000_000), node("node-b", 1_700_000_000), node("node-c", 1_700_000_000)}
+		require.NoError(t, checkGenesisTimeAgreement(context.Background(), nodes))
+	})
+
+	t.Run("diverging genesis time", func(t *testing.T) {
+		nodes := []genesisNode{node("node-a", 1_700_000_000), node("node-b", 1_700_000_002), node("node-c", 1_700_000_000), node("node-d", 1_600_000_000)}
+		err := checkGenesisTimeAgreement(context.Background(), nodes)
+		require.ErrorContains(t, err, "node-b has L2 genesis time 1700000002, node-a has 1700000000")
+		require.ErrorContains(t, err, "node-d has L2 genesis time 1600000000, node-a has 1700000000")
+		require.NotContains(t, err.Error(), "node-c")
+	})
+
+	t.Run("unreachable node", func(t *testing.T) {
+		nodes := []genesisNode{{name: "node-a", rollup: &fakeGenesis{err: errors.New("connection refused")}}, node("node-b", 1_700_000_000)}
+		require.ErrorContains(t, checkGenesisTimeAgreement(context.Background(), nodes), "node-a: failed to get rollup config: connection refused")
+	})
+}
@@ -0,0 +1,123 @@
+package node_utils
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+)
+
+// ExpectMonotonicHeads consumes the head stream `heads` until it is closed, and checks that it received heads and that
+// their number never went down.
+func ExpectMonotonicHeads(heads <-chan eth.L2BlockRef) dsl.CheckFunc {
+	return func() error {
+		var last *eth.L2BlockRef
+		for head := range heads {
+			if last != nil && head.Number < last.Number {
+				return fmt.Errorf("head went back from block %d to block %d", last.Number, head.Number)
+			}
+			last = &head
+		}
+		if last == nil {
+			return errors.New("no head received")
+		}
+		return nil
+	}
+}
+
+// ExpectNoReorg consumes the head stream `heads` until it is closed, and checks that every head extends the previous
+// one, as HeadReorgs sees it.
+func ExpectNoReorg(heads <-chan eth.L2BlockRef) dsl.CheckFunc {
+	return func() error {
+		var reorgs []HeadReorg
+		for reorg := range HeadReorgs(heads) {
+			reorgs = append(reorgs, reorg)
+		}
+		if len(reorgs) > 0 {
+			return fmt.Errorf("%d reorgs, the first from %s to %s", len(reorgs), reorgs[0].Old, reorgs[0].New)
+		}
+		return nil
+	}
+}
+
+// ExpectConsolidation consumes the unsafe and safe head streams until both are closed, and checks that the unsafe
+// heads become safe within `within` of being received. An unsafe head is safe once a safe head reaches its number, and
+// must then match the safe head of the same number, if any. At least one unsafe head must become safe, while those
+// received less than `within` before the streams closed are not checked.
+func ExpectConsolidation(unsafe, safe <-chan eth.L2BlockRef, within time.Duration) dsl.CheckFunc {
+	return func() error {
+		return checkConsolidation(unsafe, safe, within, time.Now)
+	}
+}
+
+// receivedHead is an unsafe head waiting to become safe.
+type receivedHead struct {
+	ref      eth.L2BlockRef
+	received time.Time
+}
+
+func checkConsolidation(unsafe, safe <-chan eth.L2BlockRef, within time.Duration, now func() time.Time) error {
+	var pending []receivedHead
+	var safeHead *eth.L2BlockRef
+	consolidated := 0
+
+	expired := func(at time.Time) error {
+		for _, head := range pending {
+			if at.Sub(head.received) > within {
+				return fmt.Errorf("unsafe block %d did not become safe within %s", head.ref.Number, within)
+			}
+		}
+		return nil
+	}
+
+	for unsafe != nil || safe != nil {
+		select {
+		case head, ok := <-unsafe:
+			if !ok {
+				unsafe = nil
+				continue
+			}
+			// The safe head may be reported before the unsafe one when the node is catching up.
+			if safeHead != nil && safeHead.Number >= head.Number {
+				consolidated++
+				continue
+			}
+			pending = append(pending, receivedHead{ref: head, received: now()})
+
+		case head, ok := <-safe:
+			if !ok {
+				safe = nil
+				continue
+			}
+			safeHead = &head
+
+			at := now()
+			if err := expired(at); err != nil {
+				return err
+			}
+
+			remaining := pending[:0]
+			for _, p := range pending {
+				switch {
+				case p.ref.Number > head.Number:
+					remaining = append(remaining, p)
+				case p.ref.Number == head.Number && p.ref != head:
+					return fmt.Errorf("unsafe block %s does not match safe block %s", p.ref, head)
+				default:
+					consolidated++
+				}
+			}
+			pending = remaining
+		}
+	}
+
+	if err := expired(now()); err != nil {
+		return err
+	}
+	if consolidated == 0 {
+		return errors.New("no unsafe head became safe")
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package node_utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func headStream(heads ...eth.L2BlockRef) <-chan eth.L2BlockRef {
+	ch := make(chan eth.L2BlockRef, len(heads))
+	for _, head := range heads {
+		ch <- head
+	}
+	close(ch)
+	return ch
+}
+
+func chainBlock(number uint64, fork byte) eth.L2BlockRef {
+	return eth.L2BlockRef{
+		Number:     number,
+		Hash:       common.Hash{fork, byte(number)},
+		ParentHash: common.Hash{fork, byte(number - 1)},
+	}
+}
+
+func TestExpectMonotonicHeads(t *testing.T) {
+	require.NoError(t, ExpectMonotonicHeads(headStream(chainBlock(1, 0), chainBlock(1, 0), chainBlock(3, 0)))())
+	require.EqualError(t, ExpectMonotonicHeads(headStream(chainBlock(3, 0), chainBlock(2, 0)))(), "head went back from block 3 to block 2")
+	require.EqualError(t, ExpectMonotonicHeads(headStream())(), "no head received")
+}
+
+func TestExpectNoReorg(t *testing.T) {
+	require.NoError(t, ExpectNoReorg(headStream(chainBlock(1, 0), chainBlock(2, 0), chainBlock(3, 0)))())
+	require.ErrorContains(t, ExpectNoReorg(headStream(chainBlock(1, 0), chainBlock(2, 0), chainBlock(3, 1)))(), "1 reorgs")
+}
+
+// steppingClock advances by a second on each reading.
+func steppingClock() func() time.Time {
+	now := time.Unix(0, 0)
+	return func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+}
+
+func TestCheckConsolidation(t *testing.T) {
+	t.Run("unsafe heads become safe", func(t *testing.T) {
+		unsafe := headStream(chainBlock(1, 0), chainBlock(2, 0), chainBlock(3, 0))
+		err := checkConsolidation(unsafe, nil, time.Minute, steppingClock())
+		require.EqualError(t, err, "no unsafe head became safe")
+
+		unsafe = headStream(chainBlock(1, 0), chainBlock(2, 0), chainBlock(3, 0))
+		safe := make(chan eth.L2BlockRef)
+		go func() {
+			// Let the unsafe heads be received first.
+			time.Sleep(10 * time.Millisecond)
+			safe <- chainBlock(2, 0)
+			close(safe)
+		}()
+		require.NoError(t, checkConsolidation(unsafe, safe, time.Minute, steppingClock()))
+	})
+
+	t.Run("safe head reported first", func(t *testing.T) {
+		safe := headStream(chainBlock(5, 0))
+		unsafe := make(chan eth.L2BlockRef)
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			unsafe <- chainBlock(4, 0)
+			close(unsafe)
+		}()
+		require.NoError(t, checkConsolidation(unsafe, safe, time.Minute, steppingClock()))
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		unsafe := headStream(chainBlock(1, 0), chainBlock(2, 0))
+		safe := make(chan eth.L2BlockRef)
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			safe <- chainBlock(2, 1)
+			close(safe)
+		}()
+		require.ErrorContains(t, checkConsolidation(unsafe, safe, time.Minute, steppingClock()), "does not match safe block")
+	})
+
+	t.Run("too slow", func(t *testing.T) {
+		unsafe := headStream(chainBlock(1, 0), chainBlock(2, 0))
+		safe := make(chan eth.L2BlockRef)
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			safe <- chainBlock(1, 0)
+			close(safe)
+		}()
+		// Both unsafe heads are received a second apart, and the safe head a second after the last one.
+		require.EqualError(t, checkConsolidation(unsafe, safe, time.Second, steppingClock()), "unsafe block 1 did not become safe within 1s")
+	})
+}
@@ -0,0 +1,163 @@
+package node_utils
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/ethereum-optimism/optimism/op-node/rollup"
+	"github.com/ethereum-optimism/optimism/op-service/apis"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// KonaNodeClient exposes the RPC of a kona node with typed methods, mirroring the namespaces of the node: optimism,
+// opp2p, admin, dev and ws. Renaming or retyping a method of the node then only requires fixing this client, and the
+// tests using a removed method fail to compile instead of failing at runtime. The optimism and opp2p namespaces are
+// shared with op-node, so those methods can be called on op-node CLs as well.
+type KonaNodeClient struct {
+	t    devtest.T
+	node *dsl.L2CLNode
+	rpc  rpcCaller
+
+	wsOnce sync.Once
+	ws     *WsSubscriber
+}
+
+// NewKonaNodeClient returns the client of the kona node `node`. The websocket connection serving the subscriptions is
+// only opened by the first subscription.
+func NewKonaNodeClient(t devtest.T, node *dsl.L2CLNode) *KonaNodeClient {
+	return &KonaNodeClient{t: t, node: node, rpc: GetNodeRPCEndpoint(node)}
+}
+
+func (c *KonaNodeClient) call(ctx context.Context, result any, method string, params ...any) error {
+	callCtx, cancel := context.WithTimeout(ctx, DEFAULT_TIMEOUT)
+	defer cancel()
+	return c.rpc.CallContext(callCtx, result, method, params...)
+}
+
+// callResult calls `method` and returns its decoded result.
+func callResult[T any](ctx context.Context, c *KonaNodeClient, method string, params ...any) (T, error) {
+	var result T
+	err := c.call(ctx, &result, method, params...)
+	return result, err
+}
+
+// --- optimism ---------------------------------------------------------------
+
+func (c *KonaNodeClient) SyncStatus(ctx context.Context) (*eth.SyncStatus, error) {
+	return callResult[*eth.SyncStatus](ctx, c, "optimism_syncStatus")
+}
+
+func (c *KonaNodeClient) RollupConfig(ctx context.Context) (*rollup.Config, error) {
+	return callResult[*rollup.Config](ctx, c, "optimism_rollupConfig")
+}
+
+func (c *KonaNodeClient) OutputAtBlock(ctx context.Context, number uint64) (*eth.OutputResponse, error) {
+	return callResult[*eth.OutputResponse](ctx, c, "optimism_outputAtBlock", eth.Uint64Quantity(number))
+}
+
+func (c *KonaNodeClient) Version(ctx context.Context) (string, error) {
+	return callResult[string](ctx, c, "optimism_version")
+}
+
+// --- opp2p ------------------------------------------------------------------
+
+func (c *KonaNodeClient) Self(ctx context.Context) (*apis.PeerInfo, error) {
+	return callResult[*apis.PeerInfo](ctx, c, "opp2p_self")
+}
+
+// Peers returns the known peers, or only the connected ones if `connected` is set.
+func (c *KonaNodeClient) Peers(ctx context.Context, connected bool) (*apis.PeerDump, error) {
+	return callResult[*apis.PeerDump](ctx, c, "opp2p_peers", connected)
+}
+
+func (c *KonaNodeClient) PeerStats(ctx context.Context) (*apis.PeerStats, error) {
+	return callResult[*apis.PeerStats](ctx, c, "opp2p_peerStats")
+}
+
+// DiscoveryTable returns the ENRs of the discovery table.
+func (c *KonaNodeClient) DiscoveryTable(ctx context.Context) ([]string, error) {
+	return callResult[[]string](ctx, c, "opp2p_discoveryTable")
+}
+
+func (c *KonaNodeClient) BlockPeer(ctx context.Context, peerID string) error {
+	return c.call(ctx, nil, "opp2p_blockPeer", peerID)
+}
+
+func (c *KonaNodeClient) UnblockPeer(ctx context.Context, peerID string) error {
+	return c.call(ctx, nil, "opp2p_unblockPeer", peerID)
+}
+
+func (c *KonaNodeClient) ListBlockedPeers(ctx context.Context) ([]string, error) {
+	return callResult[[]string](ctx, c, "opp2p_listBlockedPeers")
+}
+
+func (c *KonaNodeClient) ProtectPeer(ctx context.Context, peerID string) error {
+	return c.call(ctx, nil, "opp2p_protectPeer", peerID)
+}
+
+func (c *KonaNodeClient) UnprotectPeer(ctx context.Context, peerID string) error {
+	return c.call(ctx, nil, "opp2p_unprotectPeer", peerID)
+}
+
+// ConnectPeer connects to the peer at the multiaddress `addr`.
+func (c *KonaNodeClient) ConnectPeer(ctx context.Context, addr string) error {
+	return c.call(ctx, nil, "opp2p_connectPeer", addr)
+}
+
+func (c *KonaNodeClient) DisconnectPeer(ctx context.Context, peerID string) error {
+	return c.call(ctx, nil, "opp2p_disconnectPeer", peerID)
+}
+
+// --- admin ------------------------------------------------------------------
+
+func (c *KonaNodeClient) SequencerActive(ctx context.Context) (bool, error) {
+	return callResult[bool](ctx, c, "admin_sequencerActive")
+}
+
+func (c *KonaNodeClient) StartSequencer(ctx context.Context) error {
+	return c.call(ctx, nil, "admin_startSequencer")
+}
+
+// StopSequencer stops the sequencer and returns the hash of its last unsafe block.
+func (c *KonaNodeClient) StopSequencer(ctx context.Context) (common.Hash, error) {
+	return callResult[common.Hash](ctx, c, "admin_stopSequencer")
+}
+
+func (c *KonaNodeClient) ConductorEnabled(ctx context.Context) (bool, error) {
+	return callResult[bool](ctx, c, "admin_conductorEnabled")
+}
+
+// --- dev --------------------------------------------------------------------
+
+// EngineQueueSize returns the number of tasks waiting in the engine queue.
+func (c *KonaNodeClient) EngineQueueSize(ctx context.Context) (uint64, error) {
+	return callResult[uint64](ctx, c, "dev_taskQueueLength")
+}
+
+// --- ws ---------------------------------------------------------------------
+
+func (c *KonaNodeClient) subscriber() *WsSubscriber {
+	c.wsOnce.Do(func() {
+		c.ws = NewWsSubscriber(c.t, c.node)
+	})
+	return c.ws
+}
+
+func (c *KonaNodeClient) SubscribeUnsafeHead() *WsSubscription[eth.L2BlockRef] {
+	return Subscribe(c.subscriber(), UnsafeHeadStream)
+}
+
+func (c *KonaNodeClient) SubscribeSafeHead() *WsSubscription[eth.L2BlockRef] {
+	return Subscribe(c.subscriber(), SafeHeadStream)
+}
+
+func (c *KonaNodeClient) SubscribeFinalizedHead() *WsSubscription[eth.L2BlockRef] {
+	return Subscribe(c.subscriber(), FinalizedHeadStream)
+}
+
+func (c *KonaNodeClient) SubscribeEngineQueueSize() *WsSubscription[uint64] {
+	return Subscribe(c.subscriber(), EngineQueueSizeStream)
+}
@@ -0,0 +1,58 @@
+package node_utils
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingRPCCaller records the calls, and answers them with the result registered for their method.
+type recordingRPCCaller struct {
+	results map[string]string
+	calls   []string
+	params  [][]any
+}
+
+func (f *recordingRPCCaller) CallContext(ctx context.Context, result any, method string, args ...any) error {
+	f.calls = append(f.calls, method)
+	f.params = append(f.params, args)
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal([]byte(f.results[method]), result)
+}
+
+func TestKonaNodeClient(t *testing.T) {
+	ctx := context.Background()
+	caller := &recordingRPCCaller{results: map[string]string{
+		"optimism_syncStatus":  `{"unsafe_l2": {"number": 7}}`,
+		"opp2p_discoveryTable": `["enr:-a", "enr:-b"]`,
+		"admin_stopSequencer":  `"0x0100000000000000000000000000000000000000000000000000000000000000"`,
+		"dev_taskQueueLength":  `3`,
+	}}
+	client := &KonaNodeClient{rpc: caller}
+
+	status, err := client.SyncStatus(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), status.UnsafeL2.Number)
+
+	table, err := client.DiscoveryTable(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"enr:-a", "enr:-b"}, table)
+
+	head, err := client.StopSequencer(ctx)
+	require.NoError(t, err)
+	require.Equal(t, common.Hash{1}, head)
+
+	size, err := client.EngineQueueSize(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), size)
+
+	require.NoError(t, client.BlockPeer(ctx, "16Uiu2"))
+	require.Equal(t, []any{"16Uiu2"}, caller.params[len(caller.params)-1])
+
+	require.Equal(t, []string{"optimism_syncStatus", "opp2p_discoveryTable", "admin_stopSequencer", "dev_taskQueueLength", "opp2p_blockPeer"}, caller.calls)
+}
@@ -0,0 +1,52 @@
+package node_utils
+
+import (
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/ethereum-optimism/optimism/op-supervisor/supervisor/types"
+	"github.com/op-rs/kona/supervisor/utils"
+)
+
+// l1StopGracePeriod is left to the nodes after L1 stops, so that they derive the L1 blocks that were already produced
+// before the safe heads are expected to stall.
+const l1StopGracePeriod = 30 * time.Second
+
+// AssertL1StopHaltsSafe stops the L1 chain and checks that the safe head of every L2 node stalls over `window`, then
+// resumes L1 and checks that the safe heads advance again. This validates that the safe chain is derived from L1.
+// L1 is stopped by killing the L1 CL through the reorg manager, so this only runs against kurtosis devnets. Once
+// resumed, L1 blocks are built by the reorg manager's proof-of-stake driver until the end of the test.
+func AssertL1StopHaltsSafe(t devtest.T, sys *MixedOpKonaPreset, window time.Duration) {
+	trm := utils.NewTestReorgManager(t)
+	t.Require().NotNil(trm, "failed to create the reorg manager")
+
+	nodes := sys.L2CLNodes()
+
+	// Ensure that the safe heads are advancing before stopping L1.
+	var preCheckFuns []dsl.CheckFunc
+	for _, node := range nodes {
+		preCheckFuns = append(preCheckFuns, node.AdvancedFn(types.LocalSafe, 5, 100))
+	}
+	dsl.CheckAll(t, preCheckFuns...)
+
+	t.Logf("stopping L1")
+	trm.StopL1CL()
+	time.Sleep(l1StopGracePeriod)
+
+	attempts := int(window / (2 * time.Second))
+	var stallCheckFuns []dsl.CheckFunc
+	for _, node := range nodes {
+		stallCheckFuns = append(stallCheckFuns, node.NotAdvancedFn(types.LocalSafe, attempts))
+	}
+	dsl.CheckAll(t, stallCheckFuns...)
+
+	t.Logf("resuming L1")
+	t.Require().NoError(trm.GetPOS().Start(), "failed to resume L1 block production")
+
+	var resumeCheckFuns []dsl.CheckFunc
+	for _, node := range nodes {
+		resumeCheckFuns = append(resumeCheckFuns, node.AdvancedFn(types.LocalSafe, 5, 100))
+	}
+	dsl.CheckAll(t, resumeCheckFuns...)
+}
@@ -0,0 +1,96 @@
+package node_utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum-optimism/optimism/devnet-sdk/shell/env"
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/kurtosis-tech/kurtosis/api/golang/core/lib/services"
+	"github.com/kurtosis-tech/kurtosis/api/golang/engine/lib/kurtosis_context"
+)
+
+// CaptureServiceLogs returns the last `lines` log lines of a service of the kurtosis devnet, for example to dump them
+// when a test fails or to scan them for known error messages.
+func CaptureServiceLogs(t devtest.T, ctx context.Context, serviceName string, lines int) ([]string, error) {
+	url := os.Getenv(env.EnvURLVar)
+	if url == "" {
+		return nil, fmt.Errorf("environment variable %s is not set", env.EnvURLVar)
+	}
+
+	devnetEnv, err := env.LoadDevnetFromURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load devnet environment from URL %s: %w", url, err)
+	}
+
+	kurtosisCtx, err := kurtosis_context.NewKurtosisContextFromLocalEngine()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kurtosis context: %w", err)
+	}
+
+	enclaveCtx, err := kurtosisCtx.GetEnclaveContext(ctx, devnetEnv.Env.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get enclave context: %w", err)
+	}
+
+	svcCtx, err := enclaveCtx.GetServiceContext(serviceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service context for %s: %w", serviceName, err)
+	}
+	serviceUUID := svcCtx.GetServiceUUID()
+
+	logsCh, cancel, err := kurtosisCtx.GetServiceLogs(ctx, devnetEnv.Env.Name, map[services.ServiceUUID]bool{serviceUUID: true}, false, false, uint32(lines), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get logs of service %s: %w", serviceName, err)
+	}
+	defer cancel()
+
+	var logs []string
+	for content := range logsCh {
+		if _, notFound := content.GetNotFoundServiceUuids()[serviceUUID]; notFound {
+			return nil, fmt.Errorf("service %s not found", serviceName)
+		}
+		for _, line := range content.GetServiceLogsByServiceUuids()[serviceUUID] {
+			logs = append(logs, line.GetContent())
+		}
+	}
+
+	t.Logf("captured %d log lines from service %s", len(logs), serviceName)
+	return lastLines(logs, lines), nil
+}
+
+// AssertNoLogPattern checks that none of the log lines matches the regular expression `pattern`.
+func AssertNoLogPattern(t devtest.T, logs []string, pattern string) {
+	t.Require().NoError(checkNoLogPattern(logs, pattern))
+}
+
+func checkNoLogPattern(logs []string, pattern string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid log pattern %q: %w", pattern, err)
+	}
+
+	var matches []string
+	for _, line := range logs {
+		if re.MatchString(line) {
+			matches = append(matches, line)
+		}
+	}
+
+	if len(matches) > 0 {
+		return fmt.Errorf("%d log lines match %q:\n%s", len(matches), pattern, strings.Join(matches, "\n"))
+	}
+
+	return nil
+}
+
+// lastLines returns the last `n` lines.
+func lastLines(lines []string, n int) []string {
+	if len(lines) <= n {
+		return lines
+	}
+	return lines[len(lines)-n:]
+}
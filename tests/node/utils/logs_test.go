@@ -0,0 +1,36 @@
+package node_utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// stubLogs is the log output of a kona node that lost its connection to the L1 EL.
+var stubLogs = []string{
+	"2025-09-24T16:28:23.000Z  INFO kona_node_service: Starting node",
+	"2025-09-24T16:28:25.000Z  INFO kona_derive: Advanced safe head number=12",
+	"2025-09-24T16:28:27.000Z ERROR kona_providers_alloy: Failed to fetch L1 block: connection refused",
+	"2025-09-24T16:28:29.000Z  WARN kona_engine: Engine queue is growing length=12",
+}
+
+func TestNoLogPattern(t *testing.T) {
+	t.Run("no match", func(t *testing.T) {
+		require.NoError(t, checkNoLogPattern(stubLogs, `(?i)panic`))
+	})
+
+	t.Run("reports matching lines", func(t *testing.T) {
+		err := checkNoLogPattern(stubLogs, `ERROR .*connection refused`)
+		require.ErrorContains(t, err, "Failed to fetch L1 block")
+		require.NotContains(t, err.Error(), "Advanced safe head")
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		require.ErrorContains(t, checkNoLogPattern(stubLogs, `(`), "invalid log pattern")
+	})
+}
+
+func TestLastLines(t *testing.T) {
+	require.Equal(t, stubLogs[2:], lastLines(stubLogs, 2))
+	require.Equal(t, stubLogs, lastLines(stubLogs, 10))
+}
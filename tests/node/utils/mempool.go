@@ -0,0 +1,84 @@
+package node_utils
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// sequencingPause is how long sequencing stays stopped, so that the injected transactions are stranded in the mempool
+// for a few block times.
+const sequencingPause = 10 * time.Second
+
+// mempoolPollInterval is how often the receipts of the injected transactions are polled.
+const mempoolPollInterval = 2 * time.Second
+
+// sequencerControl is the subset of *dsl.L2CLNode used to pause sequencing.
+type sequencerControl interface {
+	StopSequencer() common.Hash
+	StartSequencer()
+}
+
+// receiptSource is the subset of apis.EthClient used to check the inclusion of transactions.
+type receiptSource interface {
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+// AssertMempoolDrainsAfterResume stops sequencing on `seq`, keeps it stopped while the transactions sit in the mempool
+// of `el`, restarts sequencing and checks that all the `injectedTxs` get included within `timeout`. This validates that
+// paused sequencing does not permanently strand transactions.
+// The transactions must have been sent to `el` right before calling, without waiting for their inclusion.
+func AssertMempoolDrainsAfterResume(t devtest.T, seq dsl.L2CLNode, el dsl.L2ELNode, injectedTxs []common.Hash, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(t.Ctx(), timeout)
+	defer cancel()
+
+	t.Require().NoError(checkMempoolDrainsAfterResume(ctx, &seq, el.Escape().EthClient(), injectedTxs, sequencingPause, mempoolPollInterval), "mempool of %s did not drain", el.Escape().ID().Key())
+}
+
+func checkMempoolDrainsAfterResume(ctx context.Context, seq sequencerControl, el receiptSource, txs []common.Hash, pause, interval time.Duration) error {
+	seq.StopSequencer()
+
+	select {
+	case <-ctx.Done():
+		seq.StartSequencer()
+		return fmt.Errorf("context done while sequencing was stopped: %w", ctx.Err())
+	case <-time.After(pause):
+	}
+
+	seq.StartSequencer()
+
+	return waitForTxsIncluded(ctx, el, txs, interval)
+}
+
+// waitForTxsIncluded waits until all the transactions have a receipt.
+func waitForTxsIncluded(ctx context.Context, el receiptSource, txs []common.Hash, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	pending := make(map[common.Hash]bool, len(txs))
+	for _, tx := range txs {
+		pending[tx] = true
+	}
+
+	for {
+		for tx := range pending {
+			if receipt, err := el.TransactionReceipt(ctx, tx); err == nil && receipt != nil {
+				delete(pending, tx)
+			}
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d of %d transactions were not included", len(pending), len(txs))
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package node_utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSequencingEL includes the pending transactions while sequencing is running, unless it is stuck.
+type fakeSequencingEL struct {
+	sequencing bool
+	stuck      bool
+	stopped    bool
+}
+
+func (f *fakeSequencingEL) StopSequencer() common.Hash {
+	f.sequencing = false
+	f.stopped = true
+	return common.Hash{}
+}
+
+func (f *fakeSequencingEL) StartSequencer() {
+	f.sequencing = true
+}
+
+func (f *fakeSequencingEL) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	if !f.sequencing || f.stuck {
+		return nil, ethereum.NotFound
+	}
+	return &types.Receipt{TxHash: txHash}, nil
+}
+
+func TestMempoolDrainsAfterResume(t *testing.T) {
+	txs := []common.Hash{{0x01}, {0x02}, {0x03}}
+
+	check := func(el *fakeSequencingEL) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		return checkMempoolDrainsAfterResume(ctx, el, el, txs, time.Millisecond, time.Millisecond)
+	}
+
+	t.Run("included after resume", func(t *testing.T) {
+		el := &fakeSequencingEL{sequencing: true}
+		require.NoError(t, check(el))
+		require.True(t, el.stopped)
+		require.True(t, el.sequencing)
+	})
+
+	t.Run("stranded transactions", func(t *testing.T) {
+		el := &fakeSequencingEL{sequencing: true, stuck: true}
+		require.ErrorContains(t, check(el), "3 of 3 transactions were not included")
+	})
+}
@@ -0,0 +1,313 @@
+package node_utils
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/devnet-sdk/shell/env"
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+)
+
+// DefaultMaxFCURate is the number of forkchoiceUpdated calls per second a node is expected to stay under. A node
+// updates its forkchoice a few times per block, so a much higher rate means that it is churning.
+const DefaultMaxFCURate = 5.0
+
+// engineRequestCountMetric counts the engine API calls of a kona node, labeled by method.
+const engineRequestCountMetric = "kona_node_engine_method_request_duration_count"
+
+// fcuMethodLabel is the label of the forkchoiceUpdated calls in engineRequestCountMetric.
+const fcuMethodLabel = `method="engine_forkchoiceUpdated"`
+
+// fcuCounter reads the number of forkchoiceUpdated calls a node has made so far.
+type fcuCounter interface {
+	ForkchoiceUpdates(ctx context.Context) (float64, error)
+}
+
+// MeasureFCUFrequency returns the number of forkchoiceUpdated calls per second the node makes to its EL over
+// `duration`, read from the prometheus metrics of the node. The metrics endpoint is looked up in the kurtosis devnet
+// description, so this only runs against kurtosis devnets.
+func MeasureFCUFrequency(t devtest.T, node dsl.L2CLNode, duration time.Duration) float64 {
+	counter, err := newMetricsFCUCounter(node.Escape().ID().Key())
+	t.Require().NoError(err)
+
+	rate, err := measureFCURate(t.Ctx(), counter, duration)
+	t.Require().NoError(err)
+	t.Logf("node %s made %.2f forkchoiceUpdated calls per second", node.Escape().ID().Key(), rate)
+	return rate
+}
+
+// AssertFCUFrequencyBelow measures the forkchoiceUpdated rate of the node over `duration`, and checks that it stays
+// under `ceiling` calls per second.
+func AssertFCUFrequencyBelow(t devtest.T, node dsl.L2CLNode, duration time.Duration, ceiling float64) {
+	rate := MeasureFCUFrequency(t, node, duration)
+	t.Require().LessOrEqual(rate, ceiling, "node %s makes too many forkchoiceUpdated calls", node.Escape().ID().Key())
+}
+
+func measureFCURate(ctx context.Context, counter fcuCounter, duration time.Duration) (float64, error) {
+	before, err := counter.ForkchoiceUpdates(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the forkchoiceUpdated count: %w", err)
+	}
+	start := time.Now()
+
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-time.After(duration):
+	}
+
+	after, err := counter.ForkchoiceUpdates(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the forkchoiceUpdated count: %w", err)
+	}
+	if after < before {
+		return 0, fmt.Errorf("forkchoiceUpdated count went down from %v to %v, the node restarted", before, after)
+	}
+
+	return (after - before) / time.Since(start).Seconds(), nil
+}
+
+// metricsFCUCounter scrapes the forkchoiceUpdated count from the prometheus endpoint of a node.
+type metricsFCUCounter struct {
+	url    string
+	client *http.Client
+}
+
+// newMetricsFCUCounter looks up the metrics endpoint of the CL service named `name` in the kurtosis devnet description.
+func newMetricsFCUCounter(name string) (*metricsFCUCounter, error) {
+	url, err := metricsEndpoint(name)
+	if err != nil {
+		return nil, err
+	}
+	return &metricsFCUCounter{url: url, client: &http.Client{Timeout: DEFAULT_TIMEOUT}}, nil
+}
+
+// metricsEndpoint returns the URL of the prometheus endpoint of the CL service named `name`, looked up in the kurtosis
+// devnet description.
+func metricsEndpoint(name string) (string, error) {
+	url := os.Getenv(env.EnvURLVar)
+	if url == "" {
+		return "", fmt.Errorf("environment variable %s is not set", env.EnvURLVar)
+	}
+
+	devnet, err := env.LoadDevnetFromURL(url)
+	if err != nil {
+		return "", fmt.Errorf("failed to load devnet environment from URL %s: %w", url, err)
+	}
+
+	for _, chain := range devnet.Env.L2 {
+		for _, node := range chain.Nodes {
+			cl, ok := node.Services["cl"]
+			if !ok || cl.Name != name {
+				continue
+			}
+			metrics, ok := cl.Endpoints["metrics"]
+			if !ok {
+				return "", fmt.Errorf("node %s does not expose metrics", name)
+			}
+			return fmt.Sprintf("http://%s:%d/metrics", metrics.Host, metrics.Port), nil
+		}
+	}
+
+	return "", fmt.Errorf("could not find node %s in the devnet environment", name)
+}
+
+func (m *metricsFCUCounter) ForkchoiceUpdates(ctx context.Context) (float64, error) {
+	var count float64
+	err := scrapeMetrics(ctx, m.client, m.url, func(scanner *bufio.Scanner) (err error) {
+		count, err = parseFCUCount(scanner)
+		return err
+	})
+	return count, err
+}
+
+// scrapeMetrics fetches the prometheus text exposition served at `url` and hands it to `parse`.
+func scrapeMetrics(ctx context.Context, client *http.Client, url string, parse func(*bufio.Scanner) error) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to scrape %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to scrape %s: status %s", url, resp.Status)
+	}
+
+	return parse(bufio.NewScanner(resp.Body))
+}
+
+// parseFCUCount sums the forkchoiceUpdated samples of engineRequestCountMetric in a prometheus text exposition.
+func parseFCUCount(scanner *bufio.Scanner) (float64, error) {
+	var total float64
+	found := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, engineRequestCountMetric+"{") || !strings.Contains(line, fcuMethodLabel) {
+			continue
+		}
+
+		fields := strings.Fields(line[strings.LastIndex(line, "}")+1:])
+		if len(fields) == 0 {
+			return 0, fmt.Errorf("malformed sample %q", line)
+		}
+		value, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return 0, fmt.Errorf("malformed sample %q: %w", line, err)
+		}
+		total += value
+		found = true
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read metrics: %w", err)
+	}
+	if !found {
+		return 0, fmt.Errorf("no %s sample for %s", engineRequestCountMetric, fcuMethodLabel)
+	}
+
+	return total, nil
+}
+
+// AssertMetricsPresent scrapes the prometheus endpoint of the node and checks that every metric in `metricNames` is
+// exposed, so that renaming or dropping a metric the dashboards rely on fails a test. The metrics endpoint is looked up
+// in the kurtosis devnet description, so this only runs against kurtosis devnets.
+func AssertMetricsPresent(t devtest.T, node dsl.L2CLNode, metricNames []string) {
+	url, err := metricsEndpoint(node.Escape().ID().Key())
+	t.Require().NoError(err)
+
+	client := &http.Client{Timeout: DEFAULT_TIMEOUT}
+	t.Require().NoError(checkMetricsPresent(t.Ctx(), client, url, metricNames), "node %s is missing metrics", node.Escape().ID().Key())
+}
+
+func checkMetricsPresent(ctx context.Context, client *http.Client, url string, metricNames []string) error {
+	exposed := make(map[string]struct{})
+	err := scrapeMetrics(ctx, client, url, func(scanner *bufio.Scanner) error {
+		for scanner.Scan() {
+			if name := metricName(scanner.Text()); name != "" {
+				exposed[name] = struct{}{}
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("failed to read metrics: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	for _, name := range metricNames {
+		if _, ok := exposed[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("metrics %s are not exposed", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// metricName returns the name of the metric a line of a prometheus text exposition is about: the name of a sample, or
+// the metric described by a TYPE comment. It returns an empty string for any other line.
+func metricName(line string) string {
+	if rest, ok := strings.CutPrefix(line, "# TYPE "); ok {
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			return ""
+		}
+		return fields[0]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ""
+	}
+
+	if end := strings.IndexAny(line, "{ "); end >= 0 {
+		return line[:end]
+	}
+	return line
+}
+
+// MetricDelta scrapes `metric` from the prometheus endpoint of the node, runs `body`, scrapes it again and returns by
+// how much it changed. Samples of the metric with different labels are summed. The metrics endpoint is looked up in the
+// kurtosis devnet description, so this only runs against kurtosis devnets.
+func MetricDelta(t devtest.T, node dsl.L2CLNode, metric string, body func()) float64 {
+	url, err := metricsEndpoint(node.Escape().ID().Key())
+	t.Require().NoError(err)
+
+	client := &http.Client{Timeout: DEFAULT_TIMEOUT}
+	delta, err := metricDelta(t.Ctx(), client, url, metric, body)
+	t.Require().NoError(err)
+	t.Logf("metric %s of node %s changed by %v", metric, node.Escape().ID().Key(), delta)
+	return delta
+}
+
+func metricDelta(ctx context.Context, client *http.Client, url string, metric string, body func()) (float64, error) {
+	before, err := scrapeMetricValue(ctx, client, url, metric)
+	if err != nil {
+		return 0, err
+	}
+
+	body()
+
+	after, err := scrapeMetricValue(ctx, client, url, metric)
+	if err != nil {
+		return 0, err
+	}
+
+	return after - before, nil
+}
+
+func scrapeMetricValue(ctx context.Context, client *http.Client, url string, metric string) (float64, error) {
+	var value float64
+	err := scrapeMetrics(ctx, client, url, func(scanner *bufio.Scanner) (err error) {
+		value, err = sumMetric(scanner, metric)
+		return err
+	})
+	return value, err
+}
+
+// sumMetric sums the samples of `metric` in a prometheus text exposition, whatever their labels.
+func sumMetric(scanner *bufio.Scanner, metric string) (float64, error) {
+	var total float64
+	found := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") || metricName(line) != metric {
+			continue
+		}
+
+		rest := line[len(metric):]
+		if strings.HasPrefix(rest, "{") {
+			rest = rest[strings.LastIndex(rest, "}")+1:]
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			return 0, fmt.Errorf("malformed sample %q", line)
+		}
+		value, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return 0, fmt.Errorf("malformed sample %q: %w", line, err)
+		}
+		total += value
+		found = true
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read metrics: %w", err)
+	}
+	if !found {
+		return 0, fmt.Errorf("no %s sample", metric)
+	}
+
+	return total, nil
+}
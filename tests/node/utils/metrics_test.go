@@ -0,0 +1,129 @@
+package node_utils
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFCUCounter serves a forkchoiceUpdated count that grows at `rate` calls per second, or drops to zero after the
+// first read with `restart`.
+type fakeFCUCounter struct {
+	rate    float64
+	restart bool
+
+	start time.Time
+}
+
+func (f *fakeFCUCounter) ForkchoiceUpdates(ctx context.Context) (float64, error) {
+	if f.start.IsZero() {
+		f.start = time.Now()
+		return 1000, nil
+	}
+	if f.restart {
+		return 0, nil
+	}
+	return 1000 + f.rate*time.Since(f.start).Seconds(), nil
+}
+
+func TestMeasureFCURate(t *testing.T) {
+	t.Run("known rate", func(t *testing.T) {
+		rate, err := measureFCURate(context.Background(), &fakeFCUCounter{rate: 4}, 50*time.Millisecond)
+		require.NoError(t, err)
+		require.InDelta(t, 4, rate, 0.5)
+	})
+
+	t.Run("node restarted", func(t *testing.T) {
+		_, err := measureFCURate(context.Background(), &fakeFCUCounter{rate: 4, restart: true}, time.Millisecond)
+		require.ErrorContains(t, err, "the node restarted")
+	})
+}
+
+func TestParseFCUCount(t *testing.T) {
+	t.Run("sums forkchoiceUpdated samples", func(t *testing.T) {
+		metrics := strings.Join([]string{
+			"# TYPE kona_node_engine_method_request_duration summary",
+			`kona_node_engine_method_request_duration{method="engine_forkchoiceUpdated",quantile="0.5"} 0.002`,
+			`kona_node_engine_method_request_duration_sum{method="engine_forkchoiceUpdated"} 1.5`,
+			`kona_node_engine_method_request_duration_count{method="engine_forkchoiceUpdated"} 120`,
+			`kona_node_engine_method_request_duration_count{method="engine_newPayload"} 40`,
+			`kona_node_engine_method_request_duration_count{instance="b",method="engine_forkchoiceUpdated"} 30 1700000000000`,
+		}, "\n")
+		count, err := parseFCUCount(bufio.NewScanner(strings.NewReader(metrics)))
+		require.NoError(t, err)
+		require.Equal(t, 150.0, count)
+	})
+
+	t.Run("no forkchoiceUpdated samples", func(t *testing.T) {
+		metrics := `kona_node_engine_method_request_duration_count{method="engine_newPayload"} 40`
+		_, err := parseFCUCount(bufio.NewScanner(strings.NewReader(metrics)))
+		require.ErrorContains(t, err, "no kona_node_engine_method_request_duration_count sample")
+	})
+}
+
+func TestMetricsPresent(t *testing.T) {
+	exposition := strings.Join([]string{
+		"# HELP kona_node_peer_count Number of connected peers",
+		"# TYPE kona_node_peer_count gauge",
+		"kona_node_peer_count 4",
+		"# TYPE kona_node_engine_method_request_duration summary",
+		`kona_node_engine_method_request_duration{method="engine_newPayload",quantile="0.5"} 0.002`,
+		`kona_node_engine_method_request_duration_count{method="engine_newPayload"} 40`,
+		"# TYPE kona_node_gossip_events_total counter",
+	}, "\n")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, exposition)
+	}))
+	defer server.Close()
+
+	check := func(names ...string) error {
+		return checkMetricsPresent(context.Background(), server.Client(), server.URL, names)
+	}
+
+	t.Run("all present", func(t *testing.T) {
+		require.NoError(t, check("kona_node_peer_count", "kona_node_engine_method_request_duration", "kona_node_engine_method_request_duration_count", "kona_node_gossip_events_total"))
+	})
+
+	t.Run("metric missing", func(t *testing.T) {
+		err := check("kona_node_peer_count", "kona_node_derived_blocks_total")
+		require.ErrorContains(t, err, "metrics kona_node_derived_blocks_total are not exposed")
+	})
+
+	t.Run("endpoint down", func(t *testing.T) {
+		broken := httptest.NewServer(http.NotFoundHandler())
+		defer broken.Close()
+		err := checkMetricsPresent(context.Background(), broken.Client(), broken.URL, []string{"kona_node_peer_count"})
+		require.ErrorContains(t, err, "status 404")
+	})
+}
+
+func TestMetricDelta(t *testing.T) {
+	processed := 10
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "# TYPE kona_node_unsafe_blocks_processed counter")
+		fmt.Fprintf(w, "kona_node_unsafe_blocks_processed{source=\"gossip\"} %d\n", processed)
+		fmt.Fprintf(w, "kona_node_unsafe_blocks_processed{source=\"rpc\"} %d\n", processed/2)
+		fmt.Fprintln(w, "kona_node_unsafe_blocks_processed_created 1700000000")
+	}))
+	defer server.Close()
+
+	t.Run("value increments during body", func(t *testing.T) {
+		delta, err := metricDelta(context.Background(), server.Client(), server.URL, "kona_node_unsafe_blocks_processed", func() {
+			processed += 4
+		})
+		require.NoError(t, err)
+		require.Equal(t, 6.0, delta)
+	})
+
+	t.Run("metric missing", func(t *testing.T) {
+		_, err := metricDelta(context.Background(), server.Client(), server.URL, "kona_node_derived_blocks", func() {})
+		require.ErrorContains(t, err, "no kona_node_derived_blocks sample")
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -41,6 +42,34 @@ type L2NodeConfig struct {
 	OpNodesWithReth            int
 	KonaNodesWithGeth          int
 	KonaNodesWithReth          int
+
+	// Topology, when set, describes the L2 nodes one by one and takes precedence over the counts above, which are then
+	// only a summary of it.
+	Topology *Topology
+
+	// L2CLOverrides holds extra options for individual CL nodes, keyed by node ID key, e.g. "cl-geth-kona-validator-0".
+	// They are applied after the defaults of the preset, so they can change any of its settings. With several chains,
+	// they apply to the nodes with that key on every chain.
+	L2CLOverrides map[string][]sysgo.L2CLOption
+
+	// P2PTopology decides which nodes of each chain are connected to each other. It defaults to a full mesh. Nodes of
+	// a Topology that lists peers are connected as listed instead.
+	P2PTopology P2PTopology
+
+	// NoBatcher leaves the chains without a batcher, so that their safe head never advances.
+	NoBatcher bool
+}
+
+// WithL2CLOverride returns a copy of the config adding `opts` to the overrides of the CL node with the given ID key.
+func (l2NodeConfig L2NodeConfig) WithL2CLOverride(key string, opts ...sysgo.L2CLOption) L2NodeConfig {
+	overrides := make(map[string][]sysgo.L2CLOption, len(l2NodeConfig.L2CLOverrides)+1)
+	for k, v := range l2NodeConfig.L2CLOverrides {
+		overrides[k] = slices.Clone(v)
+	}
+	overrides[key] = append(overrides[key], opts...)
+
+	l2NodeConfig.L2CLOverrides = overrides
+	return l2NodeConfig
 }
 
 const (
@@ -58,6 +87,14 @@ const (
 )
 
 func ParseL2NodeConfigFromEnv() L2NodeConfig {
+	if path := os.Getenv(TopologyEnvVar); path != "" {
+		topology, err := LoadTopology(path)
+		if err != nil {
+			panic(fmt.Sprintf("invalid %s: %v", TopologyEnvVar, err))
+		}
+		return topology.L2NodeConfig()
+	}
+
 	// Get environment variable: OP_SEQUENCER_NODES. Convert to int.
 	opSequencerGeth := os.Getenv("OP_SEQUENCER_WITH_GETH")
 	opSequencerGethInt, err := strconv.Atoi(opSequencerGeth)
@@ -149,9 +186,15 @@ type MixedOpKonaPreset struct {
 
 	L1Network *dsl.L1Network
 	L1EL      *dsl.L1ELNode
+	// L1CL is the L1 consensus node. On sysgo systems, it is a fake proof-of-stake driver that builds the L1 blocks, which
+	// StopL1FakePoS and StartL1FakePoS pause and resume.
+	L1CL *dsl.L1CLNode
 
-	L2Chain   *dsl.L2Network
-	L2Batcher *dsl.L2Batcher
+	L2Chain    *dsl.L2Network
+	L2Batcher  *dsl.L2Batcher
+	L2Proposer *dsl.L2Proposer
+	// Batchers holds all the batchers of the chain, L2Batcher being the first active one.
+	Batchers *BatcherSet
 
 	L2ELKonaSequencerNodes []dsl.L2ELNode
 	L2CLKonaSequencerNodes []dsl.L2CLNode
@@ -171,6 +214,12 @@ type MixedOpKonaPreset struct {
 	Faucet   *dsl.Faucet
 	FunderL1 *dsl.Funder
 	Funder   *dsl.Funder
+
+	// orch is the orchestrator the system was built by, used to spawn nodes at runtime.
+	orch stack.Orchestrator
+
+	// prefunded holds the accounts returned by PrefundedEOAs, once looked up.
+	prefunded prefundedAccounts
 }
 
 // L2ELNodes returns all the L2EL nodes in the network (op-reth, op-geth, etc.), validator and sequencer.
@@ -207,6 +256,57 @@ func (m *MixedOpKonaPreset) L2CLKonaNodes() []dsl.L2CLNode {
 	return append(m.L2CLKonaValidatorNodes, m.L2CLKonaSequencerNodes...)
 }
 
+// RequireMixedImpl skips the test unless the preset runs at least `minOp` op-nodes and `minKona` kona-nodes. Tests that
+// compare the two implementations must call it, as they would otherwise pass vacuously or index out of range on an
+// all-op or all-kona config.
+func RequireMixedImpl(t devtest.T, out *MixedOpKonaPreset, minOp, minKona int) {
+	if err := checkMixedImpl(out, minOp, minKona); err != nil {
+		t.Skip("skipping cross-implementation test: " + err.Error())
+	}
+}
+
+func checkMixedImpl(out *MixedOpKonaPreset, minOp, minKona int) error {
+	opNodes := len(out.L2CLOpSequencerNodes) + len(out.L2CLOpValidatorNodes)
+	konaNodes := len(out.L2CLKonaNodes())
+
+	if opNodes < minOp || konaNodes < minKona {
+		return fmt.Errorf("need at least %d op-nodes and %d kona-nodes, got %d and %d", minOp, minKona, opNodes, konaNodes)
+	}
+
+	return nil
+}
+
+// StopL1FakePoS stops the fake proof-of-stake driver of the L1 CL, which halts L1 block production until
+// StartL1FakePoS is called. L1 blocks can still be built by hand, e.g. with a test sequencer.
+func (m *MixedOpKonaPreset) StopL1FakePoS() {
+	m.ControlPlane.FakePoSState(m.L1CL.Escape().ID(), stack.Stop)
+}
+
+// StartL1FakePoS resumes the L1 block production stopped by StopL1FakePoS.
+func (m *MixedOpKonaPreset) StartL1FakePoS() {
+	m.ControlPlane.FakePoSState(m.L1CL.Escape().ID(), stack.Start)
+}
+
+// PairedEL returns the EL node driven by the given CL node, and whether it was found. The IDs of paired nodes only differ
+// by their "cl-" and "el-" prefixes.
+func (m *MixedOpKonaPreset) PairedEL(cl dsl.L2CLNode) (dsl.L2ELNode, bool) {
+	return m.L2ELNodeForCL(cl.Escape().ID())
+}
+
+func pairedELKey(clKey string) string {
+	return "el-" + strings.TrimPrefix(clKey, "cl-")
+}
+
+// SortedCLNodes returns all the L2CL nodes in the network sorted by their ID key. Unlike L2CLNodes, the order doesn't
+// depend on slice concatenation or matcher results, so tests picking a node by index get the same node across runs.
+func (m *MixedOpKonaPreset) SortedCLNodes() []dsl.L2CLNode {
+	nodes := slices.Clone(m.L2CLNodes())
+	slices.SortStableFunc(nodes, func(a, b dsl.L2CLNode) int {
+		return strings.Compare(a.Escape().ID().Key(), b.Escape().ID().Key())
+	})
+	return nodes
+}
+
 func L2NodeMatcher[
 	I interface {
 		comparable
@@ -228,7 +328,12 @@ func (m *MixedOpKonaPreset) L2Networks() []*dsl.L2Network {
 	}
 }
 
+// WithMixedOpKona builds the system described by `l2NodeConfig`. A topology with several chains is built with
+// MultiChainMixedOpKonaSystem, and its chains are then accessed through NewMixedOpKonaChains.
 func WithMixedOpKona(l2NodeConfig L2NodeConfig) stack.CommonOption {
+	if l2NodeConfig.Topology != nil && len(l2NodeConfig.Topology.chains()) > 1 {
+		return stack.MakeCommon(MultiChainMixedOpKonaSystem(&[]DefaultMixedOpKonaSystemIDs{}, l2NodeConfig))
+	}
 	return stack.MakeCommon(DefaultMixedOpKonaSystem(&DefaultMixedOpKonaSystemIDs{}, l2NodeConfig))
 }
 
@@ -259,27 +364,59 @@ func NewMixedOpKona(t devtest.T) *MixedOpKonaPreset {
 	l1Net := system.L1Network(match.FirstL1Network)
 	l2Net := system.L2Network(match.Assume(t, match.L2ChainA))
 
+	return newMixedOpKonaChain(t, orch, l1Net, l2Net)
+}
+
+// NewMixedOpKonaChains returns a preset for each L2 chain of a system built by MultiChainMixedOpKonaSystem, ordered by
+// chain ID. The presets share the L1 but each exposes the nodes, batcher, proposer and faucet of its own chain.
+func NewMixedOpKonaChains(t devtest.T) []*MixedOpKonaPreset {
+	system := shim.NewSystem(t)
+	orch := presets.Orchestrator()
+	orch.Hydrate(system)
+
+	t.Gate().Equal(len(system.L1Networks()), 1, "expected exactly one L1 network")
+	t.Gate().GreaterOrEqual(len(system.L2Networks()), 1, "expected at least one L2 network")
+
+	l1Net := system.L1Network(match.FirstL1Network)
+	l2Nets := slices.Clone(system.L2Networks())
+	slices.SortFunc(l2Nets, func(a, b stack.L2Network) int {
+		return a.ChainID().ToBig().Cmp(b.ChainID().ToBig())
+	})
+
+	out := make([]*MixedOpKonaPreset, len(l2Nets))
+	for i, l2Net := range l2Nets {
+		out[i] = newMixedOpKonaChain(t, orch, l1Net, l2Net)
+	}
+	return out
+}
+
+// newMixedOpKonaChain returns the preset exposing the nodes of `l2Net`.
+func newMixedOpKonaChain(t devtest.T, orch stack.Orchestrator, l1Net stack.L1Network, l2Net stack.L2Network) *MixedOpKonaPreset {
 	t.Gate().GreaterOrEqual(len(l2Net.L2CLNodes()), 2, "expected at least two L2CL nodes")
 
-	opSequencerCLNodes := L2NodeMatcher[stack.L2CLNodeID, stack.L2CLNode](string(OpNode), string(Sequencer)).Match(l2Net.L2CLNodes())
-	konaSequencerCLNodes := L2NodeMatcher[stack.L2CLNodeID, stack.L2CLNode](string(KonaNode), string(Sequencer)).Match(l2Net.L2CLNodes())
+	// The nodes added at runtime by other tests are not part of this preset.
+	clNodes := withoutAddedNodes[stack.L2CLNodeID, stack.L2CLNode](l2Net.L2CLNodes())
+	elNodes := withoutAddedNodes[stack.L2ELNodeID, stack.L2ELNode](l2Net.L2ELNodes())
+
+	opSequencerCLNodes := L2NodeMatcher[stack.L2CLNodeID, stack.L2CLNode](string(OpNode), string(Sequencer)).Match(clNodes)
+	konaSequencerCLNodes := L2NodeMatcher[stack.L2CLNodeID, stack.L2CLNode](string(KonaNode), string(Sequencer)).Match(clNodes)
 
-	opCLNodes := L2NodeMatcher[stack.L2CLNodeID, stack.L2CLNode](string(OpNode), string(Validator)).Match(l2Net.L2CLNodes())
-	konaCLNodes := L2NodeMatcher[stack.L2CLNodeID, stack.L2CLNode](string(KonaNode), string(Validator)).Match(l2Net.L2CLNodes())
+	opCLNodes := L2NodeMatcher[stack.L2CLNodeID, stack.L2CLNode](string(OpNode), string(Validator)).Match(clNodes)
+	konaCLNodes := L2NodeMatcher[stack.L2CLNodeID, stack.L2CLNode](string(KonaNode), string(Validator)).Match(clNodes)
 
-	opSequencerELNodes := L2NodeMatcher[stack.L2ELNodeID, stack.L2ELNode](string(OpNode), string(Sequencer)).Match(l2Net.L2ELNodes())
-	konaSequencerELNodes := L2NodeMatcher[stack.L2ELNodeID, stack.L2ELNode](string(KonaNode), string(Sequencer)).Match(l2Net.L2ELNodes())
-	opELNodes := L2NodeMatcher[stack.L2ELNodeID, stack.L2ELNode](string(OpNode), string(Validator)).Match(l2Net.L2ELNodes())
-	konaELNodes := L2NodeMatcher[stack.L2ELNodeID, stack.L2ELNode](string(KonaNode), string(Validator)).Match(l2Net.L2ELNodes())
+	opSequencerELNodes := L2NodeMatcher[stack.L2ELNodeID, stack.L2ELNode](string(OpNode), string(Sequencer)).Match(elNodes)
+	konaSequencerELNodes := L2NodeMatcher[stack.L2ELNodeID, stack.L2ELNode](string(KonaNode), string(Sequencer)).Match(elNodes)
+	opELNodes := L2NodeMatcher[stack.L2ELNodeID, stack.L2ELNode](string(OpNode), string(Validator)).Match(elNodes)
+	konaELNodes := L2NodeMatcher[stack.L2ELNodeID, stack.L2ELNode](string(KonaNode), string(Validator)).Match(elNodes)
 
 	out := &MixedOpKonaPreset{
 		Log:          t.Logger(),
 		T:            t,
 		ControlPlane: orch.ControlPlane(),
-		L1Network:    dsl.NewL1Network(system.L1Network(match.FirstL1Network)),
+		L1Network:    dsl.NewL1Network(l1Net),
 		L1EL:         dsl.NewL1ELNode(l1Net.L1ELNode(match.Assume(t, match.FirstL1EL))),
+		L1CL:         dsl.NewL1CLNode(l1Net.L1CLNode(match.Assume(t, match.FirstL1CL))),
 		L2Chain:      dsl.NewL2Network(l2Net, orch.ControlPlane()),
-		L2Batcher:    dsl.NewL2Batcher(l2Net.L2Batcher(match.Assume(t, match.FirstL2Batcher))),
 
 		L2ELOpSequencerNodes: L2ELNodes(opSequencerELNodes, orch),
 		L2CLOpSequencerNodes: L2CLNodes(opSequencerCLNodes, orch),
@@ -295,7 +432,24 @@ func NewMixedOpKona(t devtest.T) *MixedOpKonaPreset {
 
 		Wallet: dsl.NewHDWallet(t, devkeys.TestMnemonic, 30),
 		Faucet: dsl.NewFaucet(l2Net.Faucet(match.Assume(t, match.FirstFaucet))),
+
+		orch: orch,
+	}
+
+	// The batcher is left nil, and the batcher set empty, on systems built with NoBatcher. Both the batcher and the
+	// proposer are left nil on external networks, which don't expose them.
+	batchers := make([]*dsl.L2Batcher, 0, len(l2Net.L2Batchers()))
+	for _, b := range l2Net.L2Batchers() {
+		batchers = append(batchers, dsl.NewL2Batcher(b))
+	}
+	out.Batchers = newBatcherSet(batchers)
+	if len(out.Batchers.Active) > 0 {
+		out.L2Batcher = out.Batchers.Active[0]
 	}
+	if len(l2Net.L2Proposers()) > 0 {
+		out.L2Proposer = dsl.NewL2Proposer(l2Net.L2Proposer(match.Assume(t, match.FirstL2Proposer)))
+	}
+
 	return out
 }
 
@@ -332,6 +486,24 @@ type DefaultMixedOpKonaSystemIDs struct {
 
 	L2Batcher  stack.L2BatcherID
 	L2Proposer stack.L2ProposerID
+
+	// Batchers, when set, replace the single L2Batcher driven by the first sequencer.
+	Batchers []BatcherIDs
+}
+
+// BatcherIDs are the IDs of a batcher and of the sequencer it reads from.
+type BatcherIDs struct {
+	ID stack.L2BatcherID
+	CL stack.L2CLNodeID
+	EL stack.L2ELNodeID
+}
+
+// batchers returns the batchers of the chain, defaulting to L2Batcher driven by the first sequencer.
+func (ids *DefaultMixedOpKonaSystemIDs) batchers() []BatcherIDs {
+	if len(ids.Batchers) > 0 {
+		return ids.Batchers
+	}
+	return []BatcherIDs{{ID: ids.L2Batcher, CL: ids.L2CLNodes()[0], EL: ids.L2ELNodes()[0]}}
 }
 
 func (ids *DefaultMixedOpKonaSystemIDs) L2CLSequencerNodes() []stack.L2CLNodeID {
@@ -467,9 +639,75 @@ func NewDefaultMixedOpKonaSystemIDs(l1ID, l2ID eth.ChainID, l2NodeConfig L2NodeC
 }
 
 func DefaultMixedOpKonaSystem(dest *DefaultMixedOpKonaSystemIDs, l2NodeConfig L2NodeConfig) stack.CombinedOption[*sysgo.Orchestrator] {
+	ids, nodes := singleChain(l2NodeConfig)
+
+	opt := mixedOpKonaSystem([]DefaultMixedOpKonaSystemIDs{ids}, []stack.Option[*sysgo.Orchestrator]{nodes}, nil, !l2NodeConfig.NoBatcher)
+
+	opt.Add(stack.Finally(func(orch *sysgo.Orchestrator) {
+		*dest = ids
+	}))
+
+	return opt
+}
+
+// singleChain returns the IDs and the L2 nodes of the chain described by `l2NodeConfig`, the first chain of its topology
+// if it has one.
+func singleChain(l2NodeConfig L2NodeConfig) (DefaultMixedOpKonaSystemIDs, stack.Option[*sysgo.Orchestrator]) {
 	l1ID := eth.ChainIDFromUInt64(DefaultL1ID)
 	l2ID := eth.ChainIDFromUInt64(DefaultL2ID)
+	if l2NodeConfig.Topology != nil {
+		chain := l2NodeConfig.Topology.chains()[0]
+		ids := chain.systemIDs(l1ID, l2ID)
+		return ids, chain.l2Nodes(ids.L1CL, ids.L1EL, l2ID, l2NodeConfig)
+	}
+
 	ids := NewDefaultMixedOpKonaSystemIDs(l1ID, l2ID, l2NodeConfig)
+	return ids, defaultL2Nodes(ids, l2NodeConfig)
+}
+
+// singleChainIDs returns the IDs of the chain described by `l2NodeConfig`, the first chain of its topology if it has
+// one.
+func singleChainIDs(l2NodeConfig L2NodeConfig) DefaultMixedOpKonaSystemIDs {
+	ids, _ := singleChain(l2NodeConfig)
+	return ids
+}
+
+// MultiChainMixedOpKonaSystem builds every chain of the topology of `l2NodeConfig` on a shared L1, the i-th chain having the chain ID
+// DefaultL2ID+i. The chains are independent: they are not part of an interop dependency set and no supervisor manages
+// their nodes.
+func MultiChainMixedOpKonaSystem(dest *[]DefaultMixedOpKonaSystemIDs, l2NodeConfig L2NodeConfig) stack.CombinedOption[*sysgo.Orchestrator] {
+	l1ID := eth.ChainIDFromUInt64(DefaultL1ID)
+
+	chains := l2NodeConfig.Topology.chains()
+	ids := make([]DefaultMixedOpKonaSystemIDs, len(chains))
+	nodes := make([]stack.Option[*sysgo.Orchestrator], len(chains))
+	for i, chain := range chains {
+		l2ID := eth.ChainIDFromUInt64(DefaultL2ID + uint64(i))
+		ids[i] = chain.systemIDs(l1ID, l2ID)
+		nodes[i] = chain.l2Nodes(ids[i].L1CL, ids[i].L1EL, l2ID, l2NodeConfig)
+	}
+
+	opt := mixedOpKonaSystem(ids, nodes, nil, !l2NodeConfig.NoBatcher)
+
+	opt.Add(stack.Finally(func(orch *sysgo.Orchestrator) {
+		*dest = ids
+	}))
+
+	return opt
+}
+
+// supervisorIDs are the IDs of the supervisor managing the CL nodes of a system, and of its dependency set.
+type supervisorIDs struct {
+	Supervisor stack.SupervisorID
+	Cluster    stack.ClusterID
+}
+
+// mixedOpKonaSystem deploys the L2 chains of `chains` on their shared L1, spawns the L2 nodes of each chain with the
+// matching option of `l2Nodes`, and gives each chain a proposer, a faucet and, with `batcher`, a batcher driven by its
+// first sequencer. When `supervisor` is set, interop is active at genesis and the supervisor manages every CL node,
+// which must then run in indexing mode.
+func mixedOpKonaSystem(chains []DefaultMixedOpKonaSystemIDs, l2Nodes []stack.Option[*sysgo.Orchestrator], supervisor *supervisorIDs, batcher bool) stack.CombinedOption[*sysgo.Orchestrator] {
+	l1 := chains[0]
 
 	opt := stack.Combine[*sysgo.Orchestrator]()
 	opt.Add(stack.BeforeDeploy(func(o *sysgo.Orchestrator) {
@@ -484,100 +722,137 @@ func DefaultMixedOpKonaSystem(dest *DefaultMixedOpKonaSystemIDs, l2NodeConfig L2
 		panic("OP_DEPLOYER_ARTIFACTS is not set")
 	}
 
+	deployerOptions := []sysgo.DeployerOption{
+		func(_ devtest.P, _ devkeys.Keys, builder intentbuilder.Builder) {
+			builder.WithL1ContractsLocator(artifacts.MustNewFileLocator(filepath.Join(artifactsPath, "src")))
+			builder.WithL2ContractsLocator(artifacts.MustNewFileLocator(filepath.Join(artifactsPath, "src")))
+		},
+		sysgo.WithCommons(l1.L1.ChainID()),
+	}
+	for _, ids := range chains {
+		deployerOptions = append(deployerOptions, sysgo.WithPrefundedL2(l1.L1.ChainID(), ids.L2.ChainID()))
+	}
+	if supervisor != nil {
+		deployerOptions = append(deployerOptions, sysgo.WithInteropAtGenesis())
+	}
+
 	opt.Add(sysgo.WithDeployer(),
 		sysgo.WithDeployerPipelineOption(
 			sysgo.WithDeployerCacheDir(artifactsPath),
 		),
-		sysgo.WithDeployerOptions(
-			func(_ devtest.P, _ devkeys.Keys, builder intentbuilder.Builder) {
-				builder.WithL1ContractsLocator(artifacts.MustNewFileLocator(filepath.Join(artifactsPath, "src")))
-				builder.WithL2ContractsLocator(artifacts.MustNewFileLocator(filepath.Join(artifactsPath, "src")))
-			},
-			sysgo.WithCommons(ids.L1.ChainID()),
-			sysgo.WithPrefundedL2(ids.L1.ChainID(), ids.L2.ChainID()),
-		),
+		sysgo.WithDeployerOptions(deployerOptions...),
 	)
 
-	opt.Add(sysgo.WithL1Nodes(ids.L1EL, ids.L1CL))
+	opt.Add(sysgo.WithL1Nodes(l1.L1EL, l1.L1CL))
+
+	var supervisorID *stack.SupervisorID
+	if supervisor != nil {
+		opt.Add(sysgo.WithSupervisor(supervisor.Supervisor, supervisor.Cluster, l1.L1EL))
+		supervisorID = &supervisor.Supervisor
+	}
+
+	if batcher {
+		opt.Add(sysgo.WithBatcherOption(withStandbyBatchersStopped))
+	}
+
+	var faucetELs []stack.L2ELNodeID
+	for i, ids := range chains {
+		opt.Add(l2Nodes[i])
+
+		CLNodeIDs := ids.L2CLNodes()
+		ELNodeIDs := ids.L2ELNodes()
+
+		if supervisor != nil {
+			for _, cl := range CLNodeIDs {
+				opt.Add(sysgo.WithManagedBySupervisor(cl, supervisor.Supervisor))
+			}
+		}
+
+		if batcher {
+			for _, b := range ids.batchers() {
+				opt.Add(sysgo.WithBatcher(b.ID, ids.L1EL, b.CL, b.EL))
+			}
+		}
+		opt.Add(sysgo.WithProposer(ids.L2Proposer, ids.L1EL, &CLNodeIDs[0], supervisorID))
+
+		faucetELs = append(faucetELs, ELNodeIDs[0])
+	}
+
+	opt.Add(sysgo.WithFaucets([]stack.L1ELNodeID{l1.L1EL}, faucetELs))
+
+	return opt
+}
+
+// defaultL2Nodes spawns the L2 nodes of `ids`, kona-nodes running in EL sync, and connects them along the p2p topology
+// of `l2NodeConfig`. The overrides of each CL node are applied after these defaults.
+func defaultL2Nodes(ids DefaultMixedOpKonaSystemIDs, l2NodeConfig L2NodeConfig) stack.CombinedOption[*sysgo.Orchestrator] {
+	opt := stack.Combine[*sysgo.Orchestrator]()
+
+	sequencer := sysgo.L2CLOptionFn(func(p devtest.P, id stack.L2CLNodeID, cfg *sysgo.L2CLConfig) {
+		cfg.IsSequencer = true
+	})
+	elSync := L2CLSyncMode(sync.ELSync)
+	options := func(id stack.L2CLNodeID, defaults ...sysgo.L2CLOption) []sysgo.L2CLOption {
+		return append(defaults, l2NodeConfig.L2CLOverrides[id.Key()]...)
+	}
 
 	// Spawn all nodes.
-	for i := range ids.L2CLKonaGethSequencerNodes {
+	for i, id := range ids.L2CLKonaGethSequencerNodes {
 		opt.Add(sysgo.WithOpGeth(ids.L2ELKonaGethSequencerNodes[i]))
-		opt.Add(sysgo.WithKonaNode(ids.L2CLKonaGethSequencerNodes[i], ids.L1CL, ids.L1EL, ids.L2ELKonaGethSequencerNodes[i], sysgo.L2CLOptionFn(func(p devtest.P, id stack.L2CLNodeID, cfg *sysgo.L2CLConfig) {
-			cfg.IsSequencer = true
-			cfg.SequencerSyncMode = sync.ELSync
-			cfg.VerifierSyncMode = sync.ELSync
-		})))
+		opt.Add(sysgo.WithKonaNode(id, ids.L1CL, ids.L1EL, ids.L2ELKonaGethSequencerNodes[i], options(id, sequencer, elSync)...))
 	}
 
-	for i := range ids.L2CLOpGethSequencerNodes {
+	for i, id := range ids.L2CLOpGethSequencerNodes {
 		opt.Add(sysgo.WithOpGeth(ids.L2ELOpGethSequencerNodes[i]))
-		opt.Add(sysgo.WithOpNode(ids.L2CLOpGethSequencerNodes[i], ids.L1CL, ids.L1EL, ids.L2ELOpGethSequencerNodes[i], sysgo.L2CLOptionFn(func(p devtest.P, id stack.L2CLNodeID, cfg *sysgo.L2CLConfig) {
-			cfg.IsSequencer = true
-		})))
+		opt.Add(sysgo.WithOpNode(id, ids.L1CL, ids.L1EL, ids.L2ELOpGethSequencerNodes[i], options(id, sequencer)...))
 	}
 
-	for i := range ids.L2CLKonaRethSequencerNodes {
+	for i, id := range ids.L2CLKonaRethSequencerNodes {
 		opt.Add(sysgo.WithOpReth(ids.L2ELKonaRethSequencerNodes[i]))
-		opt.Add(sysgo.WithKonaNode(ids.L2CLKonaRethSequencerNodes[i], ids.L1CL, ids.L1EL, ids.L2ELKonaRethSequencerNodes[i], sysgo.L2CLOptionFn(func(p devtest.P, id stack.L2CLNodeID, cfg *sysgo.L2CLConfig) {
-			cfg.IsSequencer = true
-			cfg.SequencerSyncMode = sync.ELSync
-			cfg.VerifierSyncMode = sync.ELSync
-		})))
+		opt.Add(sysgo.WithKonaNode(id, ids.L1CL, ids.L1EL, ids.L2ELKonaRethSequencerNodes[i], options(id, sequencer, elSync)...))
 	}
 
-	for i := range ids.L2CLOpRethSequencerNodes {
+	for i, id := range ids.L2CLOpRethSequencerNodes {
 		opt.Add(sysgo.WithOpReth(ids.L2ELOpRethSequencerNodes[i]))
-		opt.Add(sysgo.WithOpNode(ids.L2CLOpRethSequencerNodes[i], ids.L1CL, ids.L1EL, ids.L2ELOpRethSequencerNodes[i], sysgo.L2CLOptionFn(func(p devtest.P, id stack.L2CLNodeID, cfg *sysgo.L2CLConfig) {
-			cfg.IsSequencer = true
-		})))
+		opt.Add(sysgo.WithOpNode(id, ids.L1CL, ids.L1EL, ids.L2ELOpRethSequencerNodes[i], options(id, sequencer)...))
 	}
 
-	for i := range ids.L2CLKonaGethNodes {
+	for i, id := range ids.L2CLKonaGethNodes {
 		opt.Add(sysgo.WithOpGeth(ids.L2ELKonaGethNodes[i]))
-		opt.Add(sysgo.WithKonaNode(ids.L2CLKonaGethNodes[i], ids.L1CL, ids.L1EL, ids.L2ELKonaGethNodes[i], sysgo.L2CLOptionFn(func(p devtest.P, id stack.L2CLNodeID, cfg *sysgo.L2CLConfig) {
-			cfg.SequencerSyncMode = sync.ELSync
-			cfg.VerifierSyncMode = sync.ELSync
-		})))
+		opt.Add(sysgo.WithKonaNode(id, ids.L1CL, ids.L1EL, ids.L2ELKonaGethNodes[i], options(id, elSync)...))
 	}
 
-	for i := range ids.L2ELOpGethNodes {
+	for i, id := range ids.L2CLOpGethNodes {
 		opt.Add(sysgo.WithOpGeth(ids.L2ELOpGethNodes[i]))
-		opt.Add(sysgo.WithOpNode(ids.L2CLOpGethNodes[i], ids.L1CL, ids.L1EL, ids.L2ELOpGethNodes[i]))
+		opt.Add(sysgo.WithOpNode(id, ids.L1CL, ids.L1EL, ids.L2ELOpGethNodes[i], options(id)...))
 	}
 
-	for i := range ids.L2CLKonaRethNodes {
+	for i, id := range ids.L2CLKonaRethNodes {
 		opt.Add(sysgo.WithOpReth(ids.L2ELKonaRethNodes[i]))
-		opt.Add(sysgo.WithKonaNode(ids.L2CLKonaRethNodes[i], ids.L1CL, ids.L1EL, ids.L2ELKonaRethNodes[i], sysgo.L2CLOptionFn(func(p devtest.P, id stack.L2CLNodeID, cfg *sysgo.L2CLConfig) {
-			cfg.SequencerSyncMode = sync.ELSync
-			cfg.VerifierSyncMode = sync.ELSync
-		})))
+		opt.Add(sysgo.WithKonaNode(id, ids.L1CL, ids.L1EL, ids.L2ELKonaRethNodes[i], options(id, elSync)...))
 	}
 
-	for i := range ids.L2ELOpRethNodes {
+	for i, id := range ids.L2CLOpRethNodes {
 		opt.Add(sysgo.WithOpReth(ids.L2ELOpRethNodes[i]))
-		opt.Add(sysgo.WithOpNode(ids.L2CLOpRethNodes[i], ids.L1CL, ids.L1EL, ids.L2ELOpRethNodes[i]))
+		opt.Add(sysgo.WithOpNode(id, ids.L1CL, ids.L1EL, ids.L2ELOpRethNodes[i], options(id)...))
 	}
 
-	// Connect all nodes to each other in the p2p network.
+	// Connect the nodes in the p2p network.
 	CLNodeIDs := ids.L2CLNodes()
 	ELNodeIDs := ids.L2ELNodes()
 
-	for i := range CLNodeIDs {
-		for j := range i {
-			opt.Add(sysgo.WithL2CLP2PConnection(CLNodeIDs[i], CLNodeIDs[j]))
-			opt.Add(sysgo.WithL2ELP2PConnection(ELNodeIDs[i], ELNodeIDs[j]))
-		}
+	for _, pair := range p2pConnections(l2NodeConfig.P2PTopology, len(CLNodeIDs)) {
+		opt.Add(sysgo.WithL2CLP2PConnection(CLNodeIDs[pair[0]], CLNodeIDs[pair[1]]))
+		opt.Add(sysgo.WithL2ELP2PConnection(ELNodeIDs[pair[0]], ELNodeIDs[pair[1]]))
 	}
 
-	opt.Add(sysgo.WithBatcher(ids.L2Batcher, ids.L1EL, CLNodeIDs[0], ELNodeIDs[0]))
-	opt.Add(sysgo.WithProposer(ids.L2Proposer, ids.L1EL, &CLNodeIDs[0], nil))
-
-	opt.Add(sysgo.WithFaucets([]stack.L1ELNodeID{ids.L1EL}, []stack.L2ELNodeID{ELNodeIDs[0]}))
-
-	opt.Add(stack.Finally(func(orch *sysgo.Orchestrator) {
-		*dest = ids
-	}))
-
 	return opt
 }
+
+// L2CLSyncMode returns an option running the CL node in the given sync mode, whether it sequences or not.
+func L2CLSyncMode(mode sync.Mode) sysgo.L2CLOption {
+	return sysgo.L2CLOptionFn(func(p devtest.P, id stack.L2CLNodeID, cfg *sysgo.L2CLConfig) {
+		cfg.SequencerSyncMode = mode
+		cfg.VerifierSyncMode = mode
+	})
+}
@@ -0,0 +1,154 @@
+package node_utils
+
+import (
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/ethereum-optimism/optimism/op-devstack/stack"
+	"github.com/ethereum-optimism/optimism/op-devstack/sysgo"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStackL2CLNode is a stack.L2CLNode that only knows its ID. Calling any other backend method panics through the
+// nil embedded interface.
+type fakeStackL2CLNode struct {
+	stack.L2CLNode
+
+	t  devtest.T
+	id stack.L2CLNodeID
+}
+
+func (f *fakeStackL2CLNode) T() devtest.T {
+	return f.t
+}
+
+func (f *fakeStackL2CLNode) ID() stack.L2CLNodeID {
+	return f.id
+}
+
+// fakeStackL2ELNode is a stack.L2ELNode that only knows its ID.
+type fakeStackL2ELNode struct {
+	stack.L2ELNode
+
+	t  devtest.T
+	id stack.L2ELNodeID
+}
+
+func (f *fakeStackL2ELNode) T() devtest.T {
+	return f.t
+}
+
+func (f *fakeStackL2ELNode) ID() stack.L2ELNodeID {
+	return f.id
+}
+
+func fakeELNodes(t devtest.T, keys ...string) []dsl.L2ELNode {
+	nodes := make([]dsl.L2ELNode, len(keys))
+	for i, key := range keys {
+		nodes[i] = *dsl.NewL2ELNode(&fakeStackL2ELNode{t: t, id: stack.NewL2ELNodeID(key, eth.ChainIDFromUInt64(DefaultL2ID))}, nil)
+	}
+	return nodes
+}
+
+func fakeCLNodes(t devtest.T, keys ...string) []dsl.L2CLNode {
+	nodes := make([]dsl.L2CLNode, len(keys))
+	for i, key := range keys {
+		nodes[i] = *dsl.NewL2CLNode(&fakeStackL2CLNode{t: t, id: stack.NewL2CLNodeID(key, eth.ChainIDFromUInt64(DefaultL2ID))}, nil)
+	}
+	return nodes
+}
+
+func clNodeKeys(nodes []dsl.L2CLNode) []string {
+	keys := make([]string, len(nodes))
+	for i, node := range nodes {
+		keys[i] = node.Escape().ID().Key()
+	}
+	return keys
+}
+
+func TestSortedCLNodes(gt *testing.T) {
+	t := devtest.SerialT(gt)
+
+	preset := &MixedOpKonaPreset{
+		L2CLKonaSequencerNodes: fakeCLNodes(t, "cl-reth-kona-sequencer-0"),
+		L2CLOpValidatorNodes:   fakeCLNodes(t, "cl-geth-op-validator-1", "cl-geth-op-validator-0"),
+		L2CLKonaValidatorNodes: fakeCLNodes(t, "cl-reth-kona-validator-0", "cl-geth-kona-validator-0"),
+	}
+
+	expected := []string{
+		"cl-geth-kona-validator-0",
+		"cl-geth-op-validator-0",
+		"cl-geth-op-validator-1",
+		"cl-reth-kona-sequencer-0",
+		"cl-reth-kona-validator-0",
+	}
+
+	require.Equal(t, expected, clNodeKeys(preset.SortedCLNodes()))
+	require.Equal(t, expected, clNodeKeys(preset.SortedCLNodes()), "order should be stable across calls")
+
+	require.Equal(t, []string{"cl-geth-op-validator-1", "cl-geth-op-validator-0"}, clNodeKeys(preset.L2CLOpValidatorNodes), "preset node slices should not be reordered")
+}
+
+func TestPairedEL(gt *testing.T) {
+	t := devtest.SerialT(gt)
+
+	preset := &MixedOpKonaPreset{
+		L2CLKonaSequencerNodes: fakeCLNodes(t, "cl-geth-kona-sequencer-0"),
+		L2ELKonaSequencerNodes: fakeELNodes(t, "el-geth-kona-sequencer-0"),
+		L2CLKonaValidatorNodes: fakeCLNodes(t, "cl-geth-kona-validator-0", "cl-reth-kona-validator-0"),
+		L2ELKonaValidatorNodes: fakeELNodes(t, "el-geth-kona-validator-0"),
+	}
+
+	el, ok := preset.PairedEL(preset.L2CLKonaValidatorNodes[0])
+	require.True(t, ok)
+	require.Equal(t, "el-geth-kona-validator-0", el.Escape().ID().Key())
+
+	el, ok = preset.PairedEL(preset.L2CLKonaSequencerNodes[0])
+	require.True(t, ok)
+	require.Equal(t, "el-geth-kona-sequencer-0", el.Escape().ID().Key())
+
+	_, ok = preset.PairedEL(preset.L2CLKonaValidatorNodes[1])
+	require.False(t, ok, "the reth validator has no EL in the preset")
+}
+
+func TestMixedImpl(t *testing.T) {
+	preset := func(op, kona int) *MixedOpKonaPreset {
+		return &MixedOpKonaPreset{
+			L2CLOpSequencerNodes:   make([]dsl.L2CLNode, min(op, 1)),
+			L2CLOpValidatorNodes:   make([]dsl.L2CLNode, op-min(op, 1)),
+			L2CLKonaValidatorNodes: make([]dsl.L2CLNode, kona),
+		}
+	}
+
+	t.Run("mixed config", func(t *testing.T) {
+		require.NoError(t, checkMixedImpl(preset(2, 1), 1, 1))
+	})
+
+	t.Run("all op config", func(t *testing.T) {
+		require.ErrorContains(t, checkMixedImpl(preset(3, 0), 1, 1), "need at least 1 op-nodes and 1 kona-nodes, got 3 and 0")
+	})
+
+	t.Run("all kona config", func(t *testing.T) {
+		require.ErrorContains(t, checkMixedImpl(preset(0, 3), 1, 1), "got 0 and 3")
+	})
+
+	t.Run("not enough kona nodes", func(t *testing.T) {
+		require.Error(t, checkMixedImpl(preset(1, 1), 1, 2))
+	})
+}
+
+func TestWithL2CLOverride(t *testing.T) {
+	key := "cl-geth-kona-validator-0"
+	noop := sysgo.L2CLOptionFn(func(p devtest.P, id stack.L2CLNodeID, cfg *sysgo.L2CLConfig) {})
+
+	base := L2NodeConfig{KonaNodesWithGeth: 1}
+	withOne := base.WithL2CLOverride(key, noop)
+	withTwo := withOne.WithL2CLOverride(key, noop)
+
+	require.Nil(t, base.L2CLOverrides, "the original config must not be modified")
+	require.Len(t, withOne.L2CLOverrides[key], 1, "adding an override must not modify the config it was added to")
+	require.Len(t, withTwo.L2CLOverrides[key], 2, "overrides of a node accumulate")
+	require.Equal(t, 1, withTwo.KonaNodesWithGeth)
+}
@@ -1,12 +1,19 @@
 package node_utils
 
 import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-conductor/consensus"
 	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
 	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
 	"github.com/ethereum-optimism/optimism/op-devstack/presets"
 	"github.com/ethereum-optimism/optimism/op-devstack/shim"
 	"github.com/ethereum-optimism/optimism/op-devstack/stack"
 	"github.com/ethereum-optimism/optimism/op-devstack/stack/match"
+	"github.com/ethereum-optimism/optimism/op-devstack/sysgo"
 )
 
 type MinimalWithConductors struct {
@@ -32,3 +39,128 @@ func NewMixedOpKonaWithConductors(t devtest.T) *MinimalWithConductors {
 		ConductorSets:     conductorSets,
 	}
 }
+
+// DefaultMixedOpKonaWithConductorsIDs are the IDs of a MixedOpKona system whose kona sequencers are run by conductors.
+type DefaultMixedOpKonaWithConductorsIDs struct {
+	DefaultMixedOpKonaSystemIDs DefaultMixedOpKonaSystemIDs
+	Conductors                  []stack.ConductorID
+}
+
+// WithMixedOpKonaConductors builds the network described by `l2NodeConfig` with a conductor in front of each kona
+// sequencer. Only a single chain is supported.
+func WithMixedOpKonaConductors(l2NodeConfig L2NodeConfig) stack.CommonOption {
+	return stack.MakeCommon(DefaultMixedOpKonaWithConductorsSystem(&DefaultMixedOpKonaWithConductorsIDs{}, l2NodeConfig))
+}
+
+// DefaultMixedOpKonaWithConductorsSystem provisions a conductor for each kona sequencer of the chain. The conductors
+// form a single raft cluster in which all of them are voters, so that the leadership can be transferred to any of
+// them. It panics if the chain has less than two kona sequencers, as there is nothing to fail over to.
+func DefaultMixedOpKonaWithConductorsSystem(dest *DefaultMixedOpKonaWithConductorsIDs, l2NodeConfig L2NodeConfig) stack.CombinedOption[*sysgo.Orchestrator] {
+	ids, nodes := singleChain(l2NodeConfig)
+
+	cls := slices.Concat(ids.L2CLKonaGethSequencerNodes, ids.L2CLKonaRethSequencerNodes)
+	els := slices.Concat(ids.L2ELKonaGethSequencerNodes, ids.L2ELKonaRethSequencerNodes)
+	if len(cls) < 2 {
+		panic(fmt.Sprintf("conductors need at least two kona sequencers, got %d", len(cls)))
+	}
+
+	opt := mixedOpKonaSystem([]DefaultMixedOpKonaSystemIDs{ids}, []stack.Option[*sysgo.Orchestrator]{nodes}, nil, !l2NodeConfig.NoBatcher)
+
+	conductors := make([]stack.ConductorID, len(cls))
+	for i, cl := range cls {
+		conductors[i] = conductorID(cl)
+		opt.Add(sysgo.WithConductor(conductors[i], cl, els[i]))
+	}
+
+	opt.Add(stack.Finally(func(orch *sysgo.Orchestrator) {
+		*dest = DefaultMixedOpKonaWithConductorsIDs{
+			DefaultMixedOpKonaSystemIDs: ids,
+			Conductors:                  conductors,
+		}
+	}))
+
+	return opt
+}
+
+// conductorID returns the ID of the conductor running the sequencer `cl`. The conductor is named after its sequencer,
+// and so is its raft server, which is how the sequencer of a raft server is found back.
+func conductorID(cl stack.L2CLNodeID) stack.ConductorID {
+	return stack.ConductorID(cl.Key())
+}
+
+// conductorAPI is the subset of the conductor RPC used to find and transfer the leadership of the cluster.
+type conductorAPI interface {
+	LeaderWithID(ctx context.Context) (*consensus.ServerInfo, error)
+	ClusterMembership(ctx context.Context) (*consensus.ClusterMembership, error)
+	TransferLeaderToServer(ctx context.Context, id string, addr string) error
+}
+
+// ActiveSequencer returns the sequencer of the chain run by the conductor leading the cluster. It only works on systems
+// whose conductors are named after their sequencer, like those of DefaultMixedOpKonaWithConductorsSystem.
+func (m *MinimalWithConductors) ActiveSequencer(t devtest.T) dsl.L2CLNode {
+	conductors := m.conductors(t)
+
+	leader, err := conductors[0].Escape().RpcAPI().LeaderWithID(t.Ctx())
+	t.Require().NoError(err, "failed to get the leader of the cluster")
+
+	cl, ok := m.L2CLNodeByName(leader.ID)
+	t.Require().True(ok, "the leader %s does not run a sequencer of the preset", leader.ID)
+	return cl
+}
+
+// TransferLeadership moves the leadership of the cluster from the conductor of the sequencer `from` to that of the
+// sequencer `to`, and waits for `to` to become the leader.
+func (m *MinimalWithConductors) TransferLeadership(t devtest.T, from, to stack.L2CLNodeID) {
+	fromConductor := m.conductorOf(t, from)
+	toConductor := m.conductorOf(t, to)
+
+	t.Require().NoError(transferLeadership(t.Ctx(), fromConductor.Escape().RpcAPI(), from.Key(), to.Key()))
+	t.Require().Eventually(toConductor.IsLeader, 10*time.Second, time.Second, "%s did not become the leader", to.Key())
+	t.Logf("transferred the leadership from %s to %s", from.Key(), to.Key())
+}
+
+func (m *MinimalWithConductors) conductors(t devtest.T) dsl.ConductorSet {
+	conductors := m.ConductorSets[m.L2Chain.Escape().ID()]
+	t.Require().NotEmpty(conductors, "the chain has no conductor")
+	return conductors
+}
+
+func (m *MinimalWithConductors) conductorOf(t devtest.T, cl stack.L2CLNodeID) *dsl.Conductor {
+	for _, conductor := range m.conductors(t) {
+		if conductor.Escape().ID() == conductorID(cl) {
+			return conductor
+		}
+	}
+	t.Require().FailNow(fmt.Sprintf("sequencer %s has no conductor", cl.Key()))
+	return nil
+}
+
+// transferLeadership asks the conductor `from`, with server ID `fromID`, to hand the leadership over to the voter with
+// server ID `to`. Only the leader can transfer the leadership, so `from` must be leading the cluster.
+func transferLeadership(ctx context.Context, from conductorAPI, fromID, to string) error {
+	leader, err := from.LeaderWithID(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the leader of the cluster: %w", err)
+	}
+	if leader.ID != fromID {
+		return fmt.Errorf("%s is not the leader, %s is", fromID, leader.ID)
+	}
+	if fromID == to {
+		return fmt.Errorf("%s is already the leader", to)
+	}
+
+	membership, err := from.ClusterMembership(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the cluster membership: %w", err)
+	}
+	for _, server := range membership.Servers {
+		if server.ID != to {
+			continue
+		}
+		if server.Suffrage != consensus.Voter {
+			return fmt.Errorf("%s is not a voter", to)
+		}
+		return from.TransferLeaderToServer(ctx, server.ID, server.Addr)
+	}
+	return fmt.Errorf("%s is not part of the cluster", to)
+}
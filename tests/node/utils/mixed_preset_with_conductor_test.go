@@ -0,0 +1,65 @@
+package node_utils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-conductor/consensus"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeConductor struct {
+	leader      string
+	servers     []consensus.ServerInfo
+	transferred *consensus.ServerInfo
+}
+
+func (c *fakeConductor) LeaderWithID(ctx context.Context) (*consensus.ServerInfo, error) {
+	return &consensus.ServerInfo{ID: c.leader}, nil
+}
+
+func (c *fakeConductor) ClusterMembership(ctx context.Context) (*consensus.ClusterMembership, error) {
+	return &consensus.ClusterMembership{Servers: c.servers}, nil
+}
+
+func (c *fakeConductor) TransferLeaderToServer(ctx context.Context, id string, addr string) error {
+	c.transferred = &consensus.ServerInfo{ID: id, Addr: addr}
+	return nil
+}
+
+func TestTransferLeadership(t *testing.T) {
+	newConductor := func() *fakeConductor {
+		return &fakeConductor{
+			leader: "a",
+			servers: []consensus.ServerInfo{
+				{ID: "a", Addr: "127.0.0.1:5000", Suffrage: consensus.Voter},
+				{ID: "b", Addr: "127.0.0.1:5001", Suffrage: consensus.Voter},
+				{ID: "c", Addr: "127.0.0.1:5002", Suffrage: consensus.Nonvoter},
+			},
+		}
+	}
+
+	t.Run("to a voter", func(t *testing.T) {
+		conductor := newConductor()
+		require.NoError(t, transferLeadership(context.Background(), conductor, "a", "b"))
+		require.Equal(t, &consensus.ServerInfo{ID: "b", Addr: "127.0.0.1:5001"}, conductor.transferred)
+	})
+
+	invalid := []struct {
+		name     string
+		from, to string
+		err      string
+	}{
+		{"from a follower", "b", "a", "b is not the leader, a is"},
+		{"to the leader", "a", "a", "a is already the leader"},
+		{"to a non-voter", "a", "c", "c is not a voter"},
+		{"to an unknown server", "a", "d", "d is not part of the cluster"},
+	}
+	for _, tc := range invalid {
+		t.Run(tc.name, func(t *testing.T) {
+			conductor := newConductor()
+			require.EqualError(t, transferLeadership(context.Background(), conductor, tc.from, tc.to), tc.err)
+			require.Nil(t, conductor.transferred)
+		})
+	}
+}
@@ -24,8 +24,10 @@ const (
 	DEFAULT_TIMEOUT = 10 * time.Second
 )
 
+// GetNodeRPCEndpoint returns the RPC client of the node. Its calls are recorded in the RPC stats written at the end of
+// the tests.
 func GetNodeRPCEndpoint(node *dsl.L2CLNode) client.RPC {
-	return node.Escape().ClientRPC()
+	return instrumentRPC(node.Escape().ID().Key(), node.Escape().ClientRPC(), rpcStats)
 }
 
 func SendRPCRequest[T any](clientRPC client.RPC, method string, resOutput *T, params ...any) error {
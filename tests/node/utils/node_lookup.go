@@ -0,0 +1,84 @@
+package node_utils
+
+import (
+	"strings"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/ethereum-optimism/optimism/op-devstack/stack"
+)
+
+// NodeSelector picks L2 nodes of the preset by their labels. A zero field matches any value, so the zero selector
+// matches every node.
+type NodeSelector struct {
+	// Kind is the CL client of the node, OpNode or KonaNode.
+	Kind L2NodeKind
+	// Role is Sequencer or Validator.
+	Role L2NodeKind
+	// EL is the EL client paired with the node, GethEL or RethEL.
+	EL L2ELKind
+}
+
+// L2CLNodeByName returns the CL node whose ID key is `name`, e.g. "cl-geth-kona-validator-0", and whether it was found.
+func (m *MixedOpKonaPreset) L2CLNodeByName(name string) (dsl.L2CLNode, bool) {
+	for _, cl := range m.L2CLNodes() {
+		if cl.Escape().ID().Key() == name {
+			return cl, true
+		}
+	}
+	return dsl.L2CLNode{}, false
+}
+
+// L2ELNodeForCL returns the EL node driven by the CL node `clID`, and whether it was found.
+func (m *MixedOpKonaPreset) L2ELNodeForCL(clID stack.L2CLNodeID) (dsl.L2ELNode, bool) {
+	key := pairedELKey(clID.Key())
+	for _, el := range m.L2ELNodes() {
+		if el.Escape().ID().Key() == key {
+			return el, true
+		}
+	}
+	return dsl.L2ELNode{}, false
+}
+
+// SelectL2CLNodes returns the CL nodes matching `sel`, sequencers first. The CL client and the role of a node are known
+// from the slice of the preset holding it, the EL client from its ID.
+func (m *MixedOpKonaPreset) SelectL2CLNodes(sel NodeSelector) []dsl.L2CLNode {
+	groups := []struct {
+		kind, role L2NodeKind
+		nodes      []dsl.L2CLNode
+	}{
+		{OpNode, Sequencer, m.L2CLOpSequencerNodes},
+		{KonaNode, Sequencer, m.L2CLKonaSequencerNodes},
+		{OpNode, Validator, m.L2CLOpValidatorNodes},
+		{KonaNode, Validator, m.L2CLKonaValidatorNodes},
+	}
+
+	var out []dsl.L2CLNode
+	for _, group := range groups {
+		if (sel.Kind != "" && sel.Kind != group.kind) || (sel.Role != "" && sel.Role != group.role) {
+			continue
+		}
+		for _, cl := range group.nodes {
+			if sel.EL == "" || pairedELKind(cl.Escape().ID()) == sel.EL {
+				out = append(out, cl)
+			}
+		}
+	}
+	return out
+}
+
+// SelectL2ELNodes returns the EL nodes driven by the CL nodes matching `sel`, in the same order.
+func (m *MixedOpKonaPreset) SelectL2ELNodes(sel NodeSelector) []dsl.L2ELNode {
+	var out []dsl.L2ELNode
+	for _, cl := range m.SelectL2CLNodes(sel) {
+		if el, ok := m.L2ELNodeForCL(cl.Escape().ID()); ok {
+			out = append(out, el)
+		}
+	}
+	return out
+}
+
+// pairedELKind returns the EL client paired with the CL node `id`, which its ID names right after the "cl-" prefix.
+func pairedELKind(id stack.L2CLNodeID) L2ELKind {
+	kind, _, _ := strings.Cut(strings.TrimPrefix(id.Key(), "cl-"), "-")
+	return L2ELKind(kind)
+}
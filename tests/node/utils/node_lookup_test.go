@@ -0,0 +1,55 @@
+package node_utils
+
+import (
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/stretchr/testify/require"
+)
+
+func elNodeKeys(nodes []dsl.L2ELNode) []string {
+	keys := make([]string, len(nodes))
+	for i, node := range nodes {
+		keys[i] = node.Escape().ID().Key()
+	}
+	return keys
+}
+
+func TestNodeLookup(gt *testing.T) {
+	t := devtest.SerialT(gt)
+
+	preset := &MixedOpKonaPreset{
+		L2CLKonaSequencerNodes: fakeCLNodes(t, "cl-reth-kona-sequencer-0"),
+		L2ELKonaSequencerNodes: fakeELNodes(t, "el-reth-kona-sequencer-0"),
+		L2CLOpValidatorNodes:   fakeCLNodes(t, "cl-geth-op-validator-0"),
+		L2ELOpValidatorNodes:   fakeELNodes(t, "el-geth-op-validator-0"),
+		L2CLKonaValidatorNodes: fakeCLNodes(t, "cl-geth-kona-validator-0", "cl-reth-kona-validator-0"),
+		L2ELKonaValidatorNodes: fakeELNodes(t, "el-geth-kona-validator-0", "el-reth-kona-validator-0"),
+	}
+
+	t.Run("by name", func(t devtest.T) {
+		cl, ok := preset.L2CLNodeByName("cl-geth-op-validator-0")
+		require.True(t, ok)
+		require.Equal(t, "cl-geth-op-validator-0", cl.Escape().ID().Key())
+
+		_, ok = preset.L2CLNodeByName("cl-geth-op-validator-1")
+		require.False(t, ok)
+	})
+
+	t.Run("paired EL", func(t devtest.T) {
+		cl, _ := preset.L2CLNodeByName("cl-reth-kona-sequencer-0")
+		el, ok := preset.L2ELNodeForCL(cl.Escape().ID())
+		require.True(t, ok)
+		require.Equal(t, "el-reth-kona-sequencer-0", el.Escape().ID().Key())
+	})
+
+	t.Run("by labels", func(t devtest.T) {
+		require.Len(t, preset.SelectL2CLNodes(NodeSelector{}), 4)
+		require.Equal(t, []string{"cl-reth-kona-sequencer-0", "cl-geth-kona-validator-0", "cl-reth-kona-validator-0"}, clNodeKeys(preset.SelectL2CLNodes(NodeSelector{Kind: KonaNode})))
+		require.Equal(t, []string{"cl-geth-op-validator-0", "cl-geth-kona-validator-0"}, clNodeKeys(preset.SelectL2CLNodes(NodeSelector{Role: Validator, EL: GethEL})))
+		require.Empty(t, preset.SelectL2CLNodes(NodeSelector{Kind: OpNode, Role: Sequencer}))
+
+		require.Equal(t, []string{"el-reth-kona-sequencer-0", "el-reth-kona-validator-0"}, elNodeKeys(preset.SelectL2ELNodes(NodeSelector{EL: RethEL})))
+	})
+}
@@ -0,0 +1,456 @@
+package node_utils
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/ethereum-optimism/optimism/op-service/apis"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/ethereum-optimism/optimism/op-supervisor/supervisor/types"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// reGossipBlocks is the number of blocks the sink must receive from the source through the middle node.
+const reGossipBlocks = 20
+
+// p2pPollInterval is how often the p2p state of the nodes is polled by the helpers that wait on it.
+const p2pPollInterval = 2 * time.Second
+
+// peerDumpSource is the subset of apis.P2PClient used to inspect the peers of a node.
+type peerDumpSource interface {
+	Peers(ctx context.Context, connected bool) (*apis.PeerDump, error)
+}
+
+// AssertBlocksTopicV4Populated checks that every node has at least `min` peers subscribed to the v4 blocks topic,
+// which is the newest gossip topic that kona must support.
+func AssertBlocksTopicV4Populated(t devtest.T, nodes []dsl.L2CLNode, min uint) {
+	stats := make(map[string]*apis.PeerStats, len(nodes))
+	for _, node := range nodes {
+		nodeName := node.Escape().ID().Key()
+		peerStats, err := node.Escape().P2PAPI().PeerStats(t.Ctx())
+		t.Require().NoError(err, "failed to get peer stats for %s", nodeName)
+		stats[nodeName] = peerStats
+	}
+
+	t.Require().NoError(checkBlocksTopicV4Populated(stats, min))
+}
+
+func checkBlocksTopicV4Populated(stats map[string]*apis.PeerStats, min uint) error {
+	var missing []string
+	for nodeName, peerStats := range stats {
+		if peerStats.BlocksTopicV4 < min {
+			missing = append(missing, fmt.Sprintf("%s (%d)", nodeName, peerStats.BlocksTopicV4))
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("nodes with less than %d peers in the blocks topic v4: %s", min, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// AssertNodeReGossips connects `source`<->`middle`<->`sink` while preventing any direct link between `source` and `sink`,
+// and checks that `sink` keeps receiving the unsafe blocks of `source`. This proves that `middle` forwards the blocks it
+// receives through gossip instead of only consuming them.
+func AssertNodeReGossips(t devtest.T, source, middle, sink dsl.L2CLNode) {
+	sourceID := source.PeerInfo().PeerID
+	sinkID := sink.PeerInfo().PeerID
+
+	// Block the source and the sink from each other so that they can't reconnect through discovery.
+	t.Require().NoError(source.Escape().P2PAPI().BlockPeer(t.Ctx(), sinkID), "failed to block sink on source")
+	t.Require().NoError(sink.Escape().P2PAPI().BlockPeer(t.Ctx(), sourceID), "failed to block source on sink")
+	t.Cleanup(func() {
+		t.Require().NoError(source.Escape().P2PAPI().UnblockPeer(t.Ctx(), sinkID), "failed to unblock sink on source")
+		t.Require().NoError(sink.Escape().P2PAPI().UnblockPeer(t.Ctx(), sourceID), "failed to unblock source on sink")
+	})
+
+	sink.DisconnectPeer(&source)
+
+	middle.ConnectPeer(&source)
+	middle.ConnectPeer(&sink)
+	t.Require().NoError(checkNotDirectPeers(sink.Peers(), sourceID))
+
+	target := source.SyncStatus().UnsafeL2.Number + reGossipBlocks
+	t.Logf("waiting for %s to reach unsafe block %d through %s", sink.Escape().ID().Key(), target, middle.Escape().ID().Key())
+	dsl.CheckAll(t, sink.ReachedFn(types.LocalUnsafe, target, 2*reGossipBlocks))
+
+	// The source and the sink must not have reconnected while the blocks were propagating.
+	t.Require().NoError(checkNotDirectPeers(sink.Peers(), sourceID))
+}
+
+// checkNotDirectPeers checks that the peer dump of connected peers does not contain the given peer ID.
+func checkNotDirectPeers(peers *apis.PeerDump, id peer.ID) error {
+	if containsPeer(peers, id) {
+		return fmt.Errorf("node is directly connected to peer %s", id)
+	}
+
+	return nil
+}
+
+// containsPeer returns whether the peer dump contains the given peer ID.
+func containsPeer(peers *apis.PeerDump, id peer.ID) bool {
+	for _, p := range peers.Peers {
+		if p.PeerID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// AssertCrossImplPeeringStable connects a kona node and an op-node and checks that, over `window`, neither of them bans
+// or drops the other. This guards against peer scoring incompatibilities between the two implementations.
+func AssertCrossImplPeeringStable(t devtest.T, konaNode, opNode dsl.L2CLNode, window time.Duration) {
+	konaNode.ConnectPeer(&opNode)
+
+	kona := peeringSide{name: konaNode.Escape().ID().Key(), id: konaNode.PeerInfo().PeerID, peers: konaNode.Escape().P2PAPI()}
+	op := peeringSide{name: opNode.Escape().ID().Key(), id: opNode.PeerInfo().PeerID, peers: opNode.Escape().P2PAPI()}
+
+	ctx, cancel := context.WithTimeout(t.Ctx(), window)
+	defer cancel()
+
+	t.Require().NoError(checkPeeringStable(ctx, kona, op, p2pPollInterval))
+}
+
+// peeringSide is one of the two nodes of a peering.
+type peeringSide struct {
+	name  string
+	id    peer.ID
+	peers peerDumpSource
+}
+
+// checkPeeringStable polls the peers of both sides until the context is done, and returns an error as soon as one side
+// bans the other or is no longer connected to it.
+func checkPeeringStable(ctx context.Context, a, b peeringSide, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for _, sides := range [][2]peeringSide{{a, b}, {b, a}} {
+			self, other := sides[0], sides[1]
+
+			dump, err := self.peers.Peers(ctx, true)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return fmt.Errorf("failed to get peers of %s: %w", self.name, err)
+			}
+
+			if slices.Contains(dump.BannedPeers, other.id) {
+				return fmt.Errorf("%s banned %s", self.name, other.name)
+			}
+			if !containsPeer(dump, other.id) {
+				return fmt.Errorf("%s is no longer connected to %s", self.name, other.name)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// WaitForDiscoveryTableSize waits, up to `timeout`, until the node has at least `min` entries in its discovery table.
+// Unlike the connected peers, this reflects the health of discovery independently of the gossip connectivity.
+func WaitForDiscoveryTableSize(t devtest.T, node dsl.L2CLNode, min int, timeout time.Duration) {
+	clRPC := GetNodeRPCEndpoint(&node)
+	tableSize := func() (int, error) {
+		var table []string
+		if err := SendRPCRequest(clRPC, "opp2p_discoveryTable", &table); err != nil {
+			return 0, err
+		}
+		return len(table), nil
+	}
+
+	ctx, cancel := context.WithTimeout(t.Ctx(), timeout)
+	defer cancel()
+
+	t.Require().NoError(waitForDiscoveryTableSize(ctx, tableSize, min, p2pPollInterval), "node %s discovery table is too small", node.Escape().ID().Key())
+}
+
+func waitForDiscoveryTableSize(ctx context.Context, tableSize func() (int, error), min int, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		size, err := tableSize()
+		if err == nil && size >= min {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if err != nil {
+				return fmt.Errorf("failed to get discovery table: %w", err)
+			}
+			return fmt.Errorf("discovery table has %d entries, expected at least %d", size, min)
+		case <-ticker.C:
+		}
+	}
+}
+
+// futureBlockDrift is how far in the future the timestamp of the block gossiped by AssertFutureBlockRejected is set,
+// well beyond the few seconds of drift the gossip validation tolerates.
+const futureBlockDrift = time.Hour
+
+// futureBlockWindow is how long the node is watched for adopting the future block.
+const futureBlockWindow = 30 * time.Second
+
+// gossipPollInterval is how often the unsafe head of a node is polled for a single gossiped block, which the node may
+// only keep as its head until the next block of the sequencer arrives.
+const gossipPollInterval = 100 * time.Millisecond
+
+// payloadSource is the subset of apis.L2EthClient used to fetch the payload of a block.
+type payloadSource interface {
+	PayloadByHash(ctx context.Context, hash common.Hash) (*eth.ExecutionPayloadEnvelope, error)
+}
+
+// AssertFutureBlockRejected takes the payload of a recent unsafe block of `builder`, read from its execution client,
+// and has `builder` broadcast rebuilt siblings of it over gossip through admin_postUnsafePayload twice: once with a new
+// prev randao, which `node` has never seen and must adopt, and once with its timestamp moved far in the future, which
+// `node` must never adopt as its unsafe head. `builder` must be a kona node, which publishes the payloads posted
+// through its admin API.
+func AssertFutureBlockRejected(t devtest.T, builder dsl.L2CLNode, node dsl.L2CLNode) {
+	els := builder.Escape().ELs()
+	t.Require().NotEmpty(els, "node %s has no execution client", builder.Escape().ID().Key())
+
+	ctx, cancel := context.WithTimeout(t.Ctx(), futureBlockWindow)
+	defer cancel()
+
+	base, err := depositOnlyHead(ctx, &builder, els[0].L2EthClient(), p2pPollInterval)
+	t.Require().NoError(err, "failed to fetch a payload of node %s", builder.Escape().ID().Key())
+
+	t.Require().NoError(checkCurrentBlockAccepted(ctx, GetNodeRPCEndpoint(&builder), &node, base, gossipPollInterval), "node %s did not accept a block with a current timestamp", node.Escape().ID().Key())
+
+	ctx, cancel = context.WithTimeout(t.Ctx(), futureBlockWindow)
+	defer cancel()
+
+	t.Require().NoError(checkFutureBlockRejected(ctx, GetNodeRPCEndpoint(&builder), &node, base, time.Now(), p2pPollInterval), "node %s adopted a block from the future", node.Escape().ID().Key())
+}
+
+// depositOnlyHead waits for an unsafe head of `builder` whose transactions are all deposits, and returns its payload.
+// None of them reads the prev randao, so that a sibling rebuilt with another prev randao executes to the same state.
+func depositOnlyHead(ctx context.Context, builder headSource, el payloadSource, interval time.Duration) (*eth.ExecutionPayloadEnvelope, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		head := builder.HeadBlockRef(types.LocalUnsafe)
+		envelope, err := el.PayloadByHash(ctx, head.Hash)
+		if err == nil && depositsOnly(envelope.ExecutionPayload) {
+			return envelope, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch the payload of block %s: %w", head, err)
+			}
+			return nil, fmt.Errorf("no block with only deposit transactions up to %s", head)
+		case <-ticker.C:
+		}
+	}
+}
+
+// depositsOnly reports whether all the transactions of `payload` are deposits.
+func depositsOnly(payload *eth.ExecutionPayload) bool {
+	for _, tx := range payload.Transactions {
+		if len(tx) == 0 || tx[0] != ethtypes.DepositTxType {
+			return false
+		}
+	}
+	return true
+}
+
+// checkCurrentBlockAccepted posts a sibling of `base` with another prev randao, which `node` cannot have seen before,
+// and waits until `node` adopts exactly that block. This shows that the payloads built by withTimestamp are only
+// rejected for their timestamp.
+func checkCurrentBlockAccepted(ctx context.Context, builder rpcCaller, node headSource, base *eth.ExecutionPayloadEnvelope, interval time.Duration) error {
+	prevRandao := base.ExecutionPayload.PrevRandao
+	prevRandao[0] ^= 0xff
+	envelope, err := withPrevRandao(base, prevRandao)
+	if err != nil {
+		return err
+	}
+	sibling := envelope.ExecutionPayload.ID()
+
+	if err := builder.CallContext(ctx, nil, "admin_postUnsafePayload", envelope); err != nil {
+		return fmt.Errorf("failed to post block %s: %w", sibling, err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		unsafe := node.HeadBlockRef(types.LocalUnsafe)
+		if unsafe.Hash == sibling.Hash {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("unsafe head %s never was the posted block %s", unsafe, sibling)
+		case <-ticker.C:
+		}
+	}
+}
+
+func checkFutureBlockRejected(ctx context.Context, builder rpcCaller, node headSource, base *eth.ExecutionPayloadEnvelope, now time.Time, interval time.Duration) error {
+	envelope, err := withTimestamp(base, uint64(now.Add(futureBlockDrift).Unix()))
+	if err != nil {
+		return err
+	}
+	future := envelope.ExecutionPayload.ID()
+
+	if err := builder.CallContext(ctx, nil, "admin_postUnsafePayload", envelope); err != nil {
+		return fmt.Errorf("failed to post block %s: %w", future, err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		unsafe := node.HeadBlockRef(types.LocalUnsafe)
+		if unsafe.Hash == future.Hash {
+			return fmt.Errorf("unsafe head is the future block %s with timestamp %d", future, envelope.ExecutionPayload.Timestamp)
+		}
+		if unsafe.Time > uint64(now.Add(futureBlockDrift/2).Unix()) {
+			return fmt.Errorf("unsafe head %s has timestamp %d, in the future", unsafe, unsafe.Time)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// withTimestamp returns a copy of `envelope` at the given timestamp, with its block hash recomputed so that it still
+// matches the content of the payload. Only the timestamp differs from the original block.
+func withTimestamp(envelope *eth.ExecutionPayloadEnvelope, timestamp uint64) (*eth.ExecutionPayloadEnvelope, error) {
+	return rehashed(envelope, func(payload *eth.ExecutionPayload) {
+		payload.Timestamp = eth.Uint64Quantity(timestamp)
+	})
+}
+
+// withPrevRandao returns a copy of `envelope` with the given prev randao, with its block hash recomputed so that it
+// still matches the content of the payload. Only the prev randao differs from the original block.
+func withPrevRandao(envelope *eth.ExecutionPayloadEnvelope, prevRandao eth.Bytes32) (*eth.ExecutionPayloadEnvelope, error) {
+	return rehashed(envelope, func(payload *eth.ExecutionPayload) {
+		payload.PrevRandao = prevRandao
+	})
+}
+
+// rehashed returns a copy of `envelope` changed by `edit`, with its block hash recomputed.
+func rehashed(envelope *eth.ExecutionPayloadEnvelope, edit func(*eth.ExecutionPayload)) (*eth.ExecutionPayloadEnvelope, error) {
+	payload := *envelope.ExecutionPayload
+	edit(&payload)
+
+	changed := &eth.ExecutionPayloadEnvelope{
+		ParentBeaconBlockRoot: envelope.ParentBeaconBlockRoot,
+		ExecutionPayload:      &payload,
+	}
+	actual, _ := changed.CheckBlockHash()
+	if actual == (common.Hash{}) {
+		return nil, fmt.Errorf("failed to compute the block hash of block %s", envelope.ExecutionPayload.ID())
+	}
+	payload.BlockHash = actual
+	return changed, nil
+}
+
+// crossChainIsolationWindow is how long the nodes of AssertCrossChainPeeringRejected are watched once connected.
+const crossChainIsolationWindow = time.Minute
+
+// AssertCrossChainPeeringRejected connects `nodeA` and `nodeB`, which must follow different chains, and checks that,
+// over crossChainIsolationWindow, neither of them lists the other in the blocks topic nor adopts an unsafe block of the
+// other chain. The connection itself may be refused, which is the expected outcome.
+func AssertCrossChainPeeringRejected(t devtest.T, nodeA, nodeB dsl.L2CLNode) {
+	infoA, infoB := nodeA.PeerInfo(), nodeB.PeerInfo()
+	t.Require().NotEqual(infoA.ChainID, infoB.ChainID, "nodes must follow different chains")
+	t.Require().NotEmpty(infoB.Addresses, "node %s has no address to connect to", nodeB.Escape().ID().Key())
+
+	if err := nodeA.Escape().P2PAPI().ConnectPeer(t.Ctx(), infoB.Addresses[0]); err != nil {
+		t.Logf("connection between chains %d and %d refused: %v", infoA.ChainID, infoB.ChainID, err)
+	}
+
+	a := isolatedSide{peeringSide: peeringSide{name: nodeA.Escape().ID().Key(), id: infoA.PeerID, peers: nodeA.Escape().P2PAPI()}, heads: &nodeA}
+	b := isolatedSide{peeringSide: peeringSide{name: nodeB.Escape().ID().Key(), id: infoB.PeerID, peers: nodeB.Escape().P2PAPI()}, heads: &nodeB}
+
+	ctx, cancel := context.WithTimeout(t.Ctx(), crossChainIsolationWindow)
+	defer cancel()
+
+	t.Require().NoError(checkCrossChainIsolation(ctx, a, b, p2pPollInterval))
+}
+
+// isolatedSide is one of the two nodes of AssertCrossChainPeeringRejected.
+type isolatedSide struct {
+	peeringSide
+	heads headSource
+}
+
+// checkCrossChainIsolation polls both sides until the context is done, and returns an error as soon as one side lists
+// the other as a blocks topic peer, or an unsafe head of one side shows up on the other. Isolation only means something
+// for chains that move, so both sides must have advanced their unsafe head by the time the context is done. A zero
+// hash is no head at all: it neither counts as progress nor as a block of the other chain.
+func checkCrossChainIsolation(ctx context.Context, a, b isolatedSide, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	seen := map[string]map[common.Hash]struct{}{a.name: {}, b.name: {}}
+	for {
+		for _, sides := range [][2]isolatedSide{{a, b}, {b, a}} {
+			self, other := sides[0], sides[1]
+
+			dump, err := self.peers.Peers(ctx, true)
+			if err != nil {
+				if ctx.Err() != nil {
+					return checkIsolatedSidesAdvanced(seen, a, b)
+				}
+				return fmt.Errorf("failed to get peers of %s: %w", self.name, err)
+			}
+			for _, p := range dump.Peers {
+				if p.PeerID == other.id && p.GossipBlocks {
+					return fmt.Errorf("%s lists %s, from another chain, in the blocks topic", self.name, other.name)
+				}
+			}
+
+			unsafe := self.heads.HeadBlockRef(types.LocalUnsafe)
+			if unsafe.Hash == (common.Hash{}) {
+				continue
+			}
+			if _, ok := seen[other.name][unsafe.Hash]; ok {
+				return fmt.Errorf("%s adopted the unsafe block %s of %s", self.name, unsafe, other.name)
+			}
+			seen[self.name][unsafe.Hash] = struct{}{}
+		}
+
+		select {
+		case <-ctx.Done():
+			return checkIsolatedSidesAdvanced(seen, a, b)
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkIsolatedSidesAdvanced returns an error if a side was seen at fewer than two unsafe heads.
+func checkIsolatedSidesAdvanced(seen map[string]map[common.Hash]struct{}, sides ...isolatedSide) error {
+	for _, side := range sides {
+		if len(seen[side.name]) < 2 {
+			return fmt.Errorf("unsafe head of %s did not advance, so its isolation cannot be told from a stall", side.name)
+		}
+	}
+	return nil
+}
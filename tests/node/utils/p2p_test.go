@@ -0,0 +1,335 @@
+package node_utils
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-service/apis"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/ethereum-optimism/optimism/op-supervisor/supervisor/types"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlocksTopicV4Populated(t *testing.T) {
+	t.Run("all nodes populated", func(t *testing.T) {
+		stats := map[string]*apis.PeerStats{
+			"cl-reth-kona-sequencer-0": {BlocksTopicV4: 3},
+			"cl-geth-op-validator-0":   {BlocksTopicV4: 2},
+		}
+		require.NoError(t, checkBlocksTopicV4Populated(stats, 2))
+	})
+
+	t.Run("reports nodes missing peers", func(t *testing.T) {
+		stats := map[string]*apis.PeerStats{
+			"cl-reth-kona-sequencer-0": {BlocksTopicV4: 3},
+			"cl-geth-kona-validator-0": {BlocksTopicV3: 3, BlocksTopicV4: 1},
+		}
+		err := checkBlocksTopicV4Populated(stats, 2)
+		require.ErrorContains(t, err, "cl-geth-kona-validator-0")
+		require.NotContains(t, err.Error(), "cl-reth-kona-sequencer-0")
+	})
+}
+
+func TestNotDirectPeers(t *testing.T) {
+	source := peer.ID("source")
+	middle := peer.ID("middle")
+
+	t.Run("only connected through the middle node", func(t *testing.T) {
+		peers := &apis.PeerDump{Peers: map[string]*apis.PeerInfo{middle.String(): {PeerID: middle}}}
+		require.NoError(t, checkNotDirectPeers(peers, source))
+	})
+
+	t.Run("directly connected to the source", func(t *testing.T) {
+		peers := &apis.PeerDump{Peers: map[string]*apis.PeerInfo{
+			middle.String(): {PeerID: middle},
+			source.String(): {PeerID: source},
+		}}
+		require.Error(t, checkNotDirectPeers(peers, source))
+	})
+}
+
+// fakePeerDumps serves a fixed peer dump.
+type fakePeerDumps struct {
+	dump *apis.PeerDump
+}
+
+func (f *fakePeerDumps) Peers(ctx context.Context, connected bool) (*apis.PeerDump, error) {
+	return f.dump, nil
+}
+
+func TestPeeringStable(t *testing.T) {
+	konaID := peer.ID("kona")
+	opID := peer.ID("op")
+
+	connectedTo := func(id peer.ID) *apis.PeerDump {
+		return &apis.PeerDump{Peers: map[string]*apis.PeerInfo{id.String(): {PeerID: id}}}
+	}
+
+	check := func(konaDump, opDump *apis.PeerDump) error {
+		kona := peeringSide{name: "kona", id: konaID, peers: &fakePeerDumps{dump: konaDump}}
+		op := peeringSide{name: "op", id: opID, peers: &fakePeerDumps{dump: opDump}}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		return checkPeeringStable(ctx, kona, op, 10*time.Millisecond)
+	}
+
+	t.Run("stable peering", func(t *testing.T) {
+		require.NoError(t, check(connectedTo(opID), connectedTo(konaID)))
+	})
+
+	t.Run("kona bans op", func(t *testing.T) {
+		banned := &apis.PeerDump{Peers: map[string]*apis.PeerInfo{}, BannedPeers: []peer.ID{opID}}
+		require.ErrorContains(t, check(banned, connectedTo(konaID)), "kona banned op")
+	})
+
+	t.Run("op bans kona", func(t *testing.T) {
+		banned := &apis.PeerDump{Peers: map[string]*apis.PeerInfo{}, BannedPeers: []peer.ID{konaID}}
+		require.ErrorContains(t, check(connectedTo(opID), banned), "op banned kona")
+	})
+}
+
+func TestWaitForDiscoveryTableSize(t *testing.T) {
+	// growingTable returns a table size that grows by one entry at each call.
+	growingTable := func() func() (int, error) {
+		size := 0
+		return func() (int, error) {
+			size++
+			return size, nil
+		}
+	}
+
+	wait := func(min int) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		return waitForDiscoveryTableSize(ctx, growingTable(), min, time.Millisecond)
+	}
+
+	t.Run("table grows to the minimum", func(t *testing.T) {
+		require.NoError(t, wait(5))
+	})
+
+	t.Run("table never reaches the minimum", func(t *testing.T) {
+		require.ErrorContains(t, wait(1_000_000), "expected at least 1000000")
+	})
+}
+
+// fakeGossipNode receives the payloads posted to its peer. It adopts them as its unsafe head if their block hash matches
+// their content, unless it rejects blocks from the future.
+type fakeGossipNode struct {
+	head          eth.L2BlockRef
+	rejectsFuture bool
+	now           time.Time
+}
+
+func (f *fakeGossipNode) CallContext(ctx context.Context, result any, method string, args ...any) error {
+	if method != "admin_postUnsafePayload" {
+		return fmt.Errorf("unexpected method %s", method)
+	}
+	envelope := args[0].(*eth.ExecutionPayloadEnvelope)
+	payload := envelope.ExecutionPayload
+	if _, ok := envelope.CheckBlockHash(); !ok {
+		return nil
+	}
+	if f.rejectsFuture && uint64(payload.Timestamp) > uint64(f.now.Unix())+5 {
+		return nil
+	}
+	f.head = eth.L2BlockRef{Hash: payload.BlockHash, Number: uint64(payload.BlockNumber), Time: uint64(payload.Timestamp)}
+	return nil
+}
+
+func (f *fakeGossipNode) HeadBlockRef(lvl types.SafetyLevel) eth.L2BlockRef {
+	return f.head
+}
+
+// testPayload returns a well-formed payload extending `parent` at the given timestamp.
+func testPayload(t *testing.T, parent eth.L2BlockRef, timestamp uint64) *eth.ExecutionPayloadEnvelope {
+	parentBeaconBlockRoot := common.Hash{0x02}
+	blobGasUsed, excessBlobGas := eth.Uint64Quantity(0), eth.Uint64Quantity(0)
+	envelope := &eth.ExecutionPayloadEnvelope{
+		ParentBeaconBlockRoot: &parentBeaconBlockRoot,
+		ExecutionPayload: &eth.ExecutionPayload{
+			ParentHash:    parent.Hash,
+			FeeRecipient:  common.Address{0x03},
+			StateRoot:     eth.Bytes32{0x04},
+			ReceiptsRoot:  eth.Bytes32(ethtypes.EmptyReceiptsHash),
+			BlockNumber:   eth.Uint64Quantity(parent.Number + 1),
+			GasLimit:      30_000_000,
+			Timestamp:     eth.Uint64Quantity(timestamp),
+			BaseFeePerGas: eth.Uint256Quantity(*uint256.NewInt(7)),
+			Withdrawals:   &ethtypes.Withdrawals{},
+			BlobGasUsed:   &blobGasUsed,
+			ExcessBlobGas: &excessBlobGas,
+		},
+	}
+	hash, _ := envelope.CheckBlockHash()
+	envelope.ExecutionPayload.BlockHash = hash
+	_, ok := envelope.CheckBlockHash()
+	require.True(t, ok)
+	return envelope
+}
+
+func TestFutureBlockRejected(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	parent := eth.L2BlockRef{Hash: common.Hash{0x01}, Number: 99, Time: uint64(now.Unix()) - 2}
+	base := testPayload(t, parent, uint64(now.Unix()))
+
+	check := func(node *fakeGossipNode) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		return checkFutureBlockRejected(ctx, node, node, base, now, time.Millisecond)
+	}
+
+	t.Run("future block keeps a valid hash", func(t *testing.T) {
+		future, err := withTimestamp(base, uint64(now.Add(futureBlockDrift).Unix()))
+		require.NoError(t, err)
+		_, ok := future.CheckBlockHash()
+		require.True(t, ok)
+		require.NotEqual(t, base.ExecutionPayload.BlockHash, future.ExecutionPayload.BlockHash)
+		require.Equal(t, uint64(base.ExecutionPayload.Timestamp), uint64(now.Unix()))
+	})
+
+	t.Run("unseen sibling accepted", func(t *testing.T) {
+		node := &fakeGossipNode{head: parent, rejectsFuture: true, now: now}
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		require.NoError(t, checkCurrentBlockAccepted(ctx, node, node, base, time.Millisecond))
+		require.NotEqual(t, base.ExecutionPayload.BlockHash, node.head.Hash, "the posted block is not the one the node may have seen")
+		require.Equal(t, base.ExecutionPayload.BlockNumber, eth.Uint64Quantity(node.head.Number))
+	})
+
+	t.Run("already seen block does not count", func(t *testing.T) {
+		// The node is at the base block already, and ignores everything posted to it.
+		node := &fakeGossipNode{head: eth.L2BlockRef{Hash: base.ExecutionPayload.BlockHash, Number: uint64(base.ExecutionPayload.BlockNumber)}, now: now.Add(-time.Hour), rejectsFuture: true}
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		require.ErrorContains(t, checkCurrentBlockAccepted(ctx, node, node, base, time.Millisecond), "never was the posted block")
+	})
+
+	t.Run("future block rejected", func(t *testing.T) {
+		node := &fakeGossipNode{head: parent, rejectsFuture: true, now: now}
+		require.NoError(t, check(node))
+		require.Equal(t, parent, node.head)
+	})
+
+	t.Run("future block adopted", func(t *testing.T) {
+		node := &fakeGossipNode{head: parent, now: now}
+		require.ErrorContains(t, check(node), "unsafe head is the future block")
+	})
+
+	t.Run("current block never reached", func(t *testing.T) {
+		node := &fakeGossipNode{head: parent, now: now.Add(-time.Hour), rejectsFuture: true}
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		require.ErrorContains(t, checkCurrentBlockAccepted(ctx, node, node, base, time.Millisecond), "never was the posted block")
+	})
+}
+
+func TestDepositOnlyHead(t *testing.T) {
+	deposit := ethtypes.NewTx(&ethtypes.DepositTx{})
+	depositRaw, err := deposit.MarshalBinary()
+	require.NoError(t, err)
+	userRaw, err := ethtypes.NewTx(&ethtypes.LegacyTx{Nonce: 1}).MarshalBinary()
+	require.NoError(t, err)
+
+	withTxs := func(hash common.Hash, txs ...[]byte) *eth.ExecutionPayloadEnvelope {
+		payload := &eth.ExecutionPayload{BlockHash: hash}
+		for _, tx := range txs {
+			payload.Transactions = append(payload.Transactions, eth.Data(tx))
+		}
+		return &eth.ExecutionPayloadEnvelope{ExecutionPayload: payload}
+	}
+	el := fakePayloads{
+		{0x01}: withTxs(common.Hash{0x01}, depositRaw, userRaw),
+		{0x02}: withTxs(common.Hash{0x02}, depositRaw),
+	}
+
+	t.Run("skips blocks with user transactions", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		envelope, err := depositOnlyHead(ctx, &fakeChainHeads{hashes: []common.Hash{{0x01}, {0x02}}}, el, time.Millisecond)
+		require.NoError(t, err)
+		require.Equal(t, common.Hash{0x02}, envelope.ExecutionPayload.BlockHash)
+	})
+
+	t.Run("no deposit only block", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, err := depositOnlyHead(ctx, &fakeChainHeads{hashes: []common.Hash{{0x01}}}, el, time.Millisecond)
+		require.ErrorContains(t, err, "no block with only deposit transactions")
+	})
+}
+
+// fakePayloads serves the payloads it holds by block hash.
+type fakePayloads map[common.Hash]*eth.ExecutionPayloadEnvelope
+
+func (f fakePayloads) PayloadByHash(ctx context.Context, hash common.Hash) (*eth.ExecutionPayloadEnvelope, error) {
+	envelope, ok := f[hash]
+	if !ok {
+		return nil, fmt.Errorf("unknown block %s", hash)
+	}
+	return envelope, nil
+}
+
+// fakeChainHeads serves the unsafe heads in `hashes` one after the other, then stays at the last one.
+type fakeChainHeads struct {
+	hashes []common.Hash
+}
+
+func (f *fakeChainHeads) HeadBlockRef(lvl types.SafetyLevel) eth.L2BlockRef {
+	hash := f.hashes[0]
+	if len(f.hashes) > 1 {
+		f.hashes = f.hashes[1:]
+	}
+	return eth.L2BlockRef{Hash: hash}
+}
+
+func TestCrossChainIsolation(t *testing.T) {
+	idA := peer.ID("chain-a")
+	idB := peer.ID("chain-b")
+
+	connectedTo := func(id peer.ID, gossipBlocks bool) *apis.PeerDump {
+		return &apis.PeerDump{Peers: map[string]*apis.PeerInfo{id.String(): {PeerID: id, GossipBlocks: gossipBlocks}}}
+	}
+
+	check := func(dumpA, dumpB *apis.PeerDump, headsA, headsB []common.Hash) error {
+		a := isolatedSide{peeringSide: peeringSide{name: "chain-a", id: idA, peers: &fakePeerDumps{dump: dumpA}}, heads: &fakeChainHeads{hashes: headsA}}
+		b := isolatedSide{peeringSide: peeringSide{name: "chain-b", id: idB, peers: &fakePeerDumps{dump: dumpB}}, heads: &fakeChainHeads{hashes: headsB}}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		return checkCrossChainIsolation(ctx, a, b, time.Millisecond)
+	}
+
+	headsA := []common.Hash{{0xa1}, {0xa2}, {0xa3}}
+	headsB := []common.Hash{{0xb1}, {0xb2}, {0xb3}}
+
+	t.Run("chains isolated", func(t *testing.T) {
+		require.NoError(t, check(connectedTo(idB, false), &apis.PeerDump{}, headsA, headsB))
+	})
+
+	t.Run("peer in blocks topic", func(t *testing.T) {
+		require.ErrorContains(t, check(&apis.PeerDump{}, connectedTo(idA, true), headsA, headsB), "chain-b lists chain-a, from another chain, in the blocks topic")
+	})
+
+	t.Run("stalled side", func(t *testing.T) {
+		require.ErrorContains(t, check(&apis.PeerDump{}, &apis.PeerDump{}, headsA, []common.Hash{{0xb1}}), "unsafe head of chain-b did not advance")
+	})
+
+	t.Run("no heads", func(t *testing.T) {
+		zero := []common.Hash{{}}
+		require.ErrorContains(t, check(&apis.PeerDump{}, &apis.PeerDump{}, zero, zero), "did not advance")
+		require.ErrorContains(t, check(&apis.PeerDump{}, &apis.PeerDump{}, []common.Hash{{}, {0xa1}}, headsB), "unsafe head of chain-a did not advance")
+	})
+
+	t.Run("block of the other chain adopted", func(t *testing.T) {
+		adopting := []common.Hash{{0xa1}, {0xa2}, {0xb2}}
+		require.ErrorContains(t, check(&apis.PeerDump{}, &apis.PeerDump{}, adopting, headsB), "chain-a adopted the unsafe block")
+	})
+}
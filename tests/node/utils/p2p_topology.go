@@ -0,0 +1,136 @@
+package node_utils
+
+import (
+	"fmt"
+)
+
+// P2PTopology decides which L2 nodes of a chain are connected to each other in the p2p network, both for their CLs
+// and their ELs. Nodes are designated by their index in the chain: the order of TopologyChain.Nodes for a chain built
+// from a Topology, as listed in the topology file, and the order of DefaultMixedOpKonaSystemIDs.L2CLNodes, sequencers
+// first, for a chain built from the node counts of an L2NodeConfig.
+type P2PTopology interface {
+	// Connections returns the pairs of node indexes to connect among `n` nodes, each pair once.
+	Connections(n int) ([][2]int, error)
+}
+
+// FullMesh connects every node to every other node. It is the default topology.
+type FullMesh struct{}
+
+func (FullMesh) Connections(n int) ([][2]int, error) {
+	var pairs [][2]int
+	for i := range n {
+		for j := range i {
+			pairs = append(pairs, [2]int{j, i})
+		}
+	}
+	return pairs, nil
+}
+
+// Ring connects each node to the next one, and the last node back to the first, so that gossip has to go through
+// about half of the nodes to reach the opposite side of the ring.
+type Ring struct{}
+
+func (Ring) Connections(n int) ([][2]int, error) {
+	if n < 2 {
+		return nil, nil
+	}
+	if n == 2 {
+		return [][2]int{{0, 1}}, nil
+	}
+
+	pairs := make([][2]int, 0, n)
+	for i := range n - 1 {
+		pairs = append(pairs, [2]int{i, i + 1})
+	}
+	return append(pairs, [2]int{0, n - 1}), nil
+}
+
+// Star connects every node to the Hub node only, so that all gossip goes through it.
+type Star struct {
+	Hub int
+}
+
+func (s Star) Connections(n int) ([][2]int, error) {
+	if s.Hub < 0 || s.Hub >= n {
+		return nil, fmt.Errorf("star hub %d is out of range for %d nodes", s.Hub, n)
+	}
+
+	var pairs [][2]int
+	for i := range n {
+		if i != s.Hub {
+			pairs = append(pairs, [2]int{min(i, s.Hub), max(i, s.Hub)})
+		}
+	}
+	return pairs, nil
+}
+
+// Partitioned splits the nodes into Islands that are fully meshed but not connected to each other. Nodes that are not
+// part of any island have no peer at all.
+type Partitioned struct {
+	Islands [][]int
+}
+
+func (p Partitioned) Connections(n int) ([][2]int, error) {
+	island := make(map[int]int)
+	for i, nodes := range p.Islands {
+		for _, node := range nodes {
+			if node < 0 || node >= n {
+				return nil, fmt.Errorf("island %d: node %d is out of range for %d nodes", i, node, n)
+			}
+			if other, ok := island[node]; ok {
+				return nil, fmt.Errorf("node %d is part of both island %d and island %d", node, other, i)
+			}
+			island[node] = i
+		}
+	}
+
+	var pairs [][2]int
+	for _, nodes := range p.Islands {
+		for i := range nodes {
+			for j := range i {
+				pairs = append(pairs, [2]int{min(nodes[i], nodes[j]), max(nodes[i], nodes[j])})
+			}
+		}
+	}
+	return pairs, nil
+}
+
+// Custom connects exactly the pairs of nodes listed in Edges. Connections go both ways, so each pair only needs to be
+// listed once.
+type Custom struct {
+	Edges [][2]int
+}
+
+func (c Custom) Connections(n int) ([][2]int, error) {
+	var pairs [][2]int
+	seen := make(map[[2]int]bool)
+	for _, edge := range c.Edges {
+		if edge[0] < 0 || edge[0] >= n || edge[1] < 0 || edge[1] >= n {
+			return nil, fmt.Errorf("edge %v is out of range for %d nodes", edge, n)
+		}
+		if edge[0] == edge[1] {
+			return nil, fmt.Errorf("edge %v connects a node to itself", edge)
+		}
+
+		pair := [2]int{min(edge[0], edge[1]), max(edge[0], edge[1])}
+		if !seen[pair] {
+			seen[pair] = true
+			pairs = append(pairs, pair)
+		}
+	}
+	return pairs, nil
+}
+
+// p2pConnections returns the connections of `topology` among `n` nodes, a nil topology being a full mesh. It panics
+// on an invalid topology, as the system can't be built from it.
+func p2pConnections(topology P2PTopology, n int) [][2]int {
+	if topology == nil {
+		topology = FullMesh{}
+	}
+
+	pairs, err := topology.Connections(n)
+	if err != nil {
+		panic(fmt.Sprintf("invalid p2p topology: %v", err))
+	}
+	return pairs
+}
@@ -0,0 +1,59 @@
+package node_utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestP2PTopology(t *testing.T) {
+	connections := func(t *testing.T, topology P2PTopology, n int) [][2]int {
+		pairs, err := topology.Connections(n)
+		require.NoError(t, err)
+		return pairs
+	}
+
+	t.Run("full mesh", func(t *testing.T) {
+		require.Equal(t, [][2]int{{0, 1}, {0, 2}, {1, 2}}, connections(t, FullMesh{}, 3))
+		require.Empty(t, connections(t, FullMesh{}, 1))
+	})
+
+	t.Run("ring", func(t *testing.T) {
+		require.Equal(t, [][2]int{{0, 1}, {1, 2}, {2, 3}, {0, 3}}, connections(t, Ring{}, 4))
+		require.Equal(t, [][2]int{{0, 1}}, connections(t, Ring{}, 2), "two nodes are connected once")
+		require.Empty(t, connections(t, Ring{}, 1))
+	})
+
+	t.Run("star", func(t *testing.T) {
+		require.Equal(t, [][2]int{{0, 2}, {1, 2}, {2, 3}}, connections(t, Star{Hub: 2}, 4))
+
+		_, err := Star{Hub: 4}.Connections(4)
+		require.ErrorContains(t, err, "out of range")
+	})
+
+	t.Run("partitioned", func(t *testing.T) {
+		islands := Partitioned{Islands: [][]int{{0, 1}, {3, 2, 4}}}
+		require.Equal(t, [][2]int{{0, 1}, {2, 3}, {3, 4}, {2, 4}}, connections(t, islands, 6))
+
+		_, err := Partitioned{Islands: [][]int{{0, 1}, {1, 2}}}.Connections(3)
+		require.ErrorContains(t, err, "part of both island 0 and island 1")
+
+		_, err = Partitioned{Islands: [][]int{{0, 3}}}.Connections(3)
+		require.ErrorContains(t, err, "out of range")
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		require.Equal(t, [][2]int{{0, 2}, {1, 2}}, connections(t, Custom{Edges: [][2]int{{2, 0}, {1, 2}, {0, 2}}}, 3))
+
+		_, err := Custom{Edges: [][2]int{{0, 3}}}.Connections(3)
+		require.ErrorContains(t, err, "out of range")
+
+		_, err = Custom{Edges: [][2]int{{1, 1}}}.Connections(3)
+		require.ErrorContains(t, err, "to itself")
+	})
+
+	t.Run("invalid topology panics", func(t *testing.T) {
+		require.Equal(t, [][2]int{{0, 1}}, p2pConnections(nil, 2))
+		require.Panics(t, func() { p2pConnections(Star{Hub: 5}, 2) })
+	})
+}
@@ -0,0 +1,93 @@
+package node_utils
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/ethereum-optimism/optimism/op-chain-ops/devkeys"
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/ethereum-optimism/optimism/op-devstack/stack"
+	"github.com/ethereum-optimism/optimism/op-devstack/sysgo"
+	"github.com/ethereum-optimism/optimism/op-e2e/e2eutils/intentbuilder"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// prefundedEOAStartIndex is the first devkeys user index of the prefunded EOAs. It is far above the indices used by
+// the preset wallet so that the prefunded accounts are never handed out by it.
+const prefundedEOAStartIndex = 100_000
+
+// maxPrefundedEOAs bounds the number of prefunded EOAs looked up in the L2 genesis.
+const maxPrefundedEOAs = 1_000
+
+// PrefundedEOAKey returns the devkeys user key of the i-th prefunded EOA.
+func PrefundedEOAKey(i int) devkeys.UserKey {
+	return devkeys.UserKey(prefundedEOAStartIndex + uint64(i))
+}
+
+// WithPrefundedEOAs allocates `amount` to `count` deterministic accounts in the L2 genesis, so that tests can use them
+// right away instead of funding them through the faucet. The accounts are exposed on the preset as PrefundedEOAs.
+// It must be passed after WithMixedOpKona, which declares the L2 chain in the deployer intent.
+func WithPrefundedEOAs(count int, amount eth.ETH) stack.CommonOption {
+	return stack.MakeCommon(sysgo.WithDeployerOptions(
+		func(p devtest.P, keys devkeys.Keys, builder intentbuilder.Builder) {
+			l2ID := eth.ChainIDFromUInt64(DefaultL2ID)
+			for _, l2Config := range builder.L2s() {
+				if l2Config.ChainID() != l2ID {
+					continue
+				}
+
+				for i := range count {
+					addr, err := keys.Address(PrefundedEOAKey(i))
+					p.Require().NoError(err, "failed to derive prefunded EOA %d", i)
+					l2Config.WithPrefundedAccount(addr, *amount.ToU256())
+				}
+				return
+			}
+			p.Require().Fail("L2 chain not found in the deployer intent", "chain %s", l2ID)
+		},
+	))
+}
+
+// prefundedAccounts are the accounts funded at genesis on the chain of a preset, looked up on first use.
+type prefundedAccounts struct {
+	once sync.Once
+	eoas []*dsl.EOA
+}
+
+// PrefundedEOAs returns the accounts funded at genesis by WithPrefundedEOAs, bound to the first sequencer EL. They are
+// looked up on the first call. Only the default L2 chain has prefunded accounts, and a chain without a sequencer EL
+// has none to bind them to.
+func (m *MixedOpKonaPreset) PrefundedEOAs() []*dsl.EOA {
+	m.prefunded.once.Do(func() {
+		sequencers := m.L2ELSequencerNodes()
+		if m.L2Chain.ChainID() != eth.ChainIDFromUInt64(DefaultL2ID) || len(sequencers) == 0 {
+			return
+		}
+		m.prefunded.eoas = prefundedEOAs(m.T, &sequencers[0])
+	})
+	return m.prefunded.eoas
+}
+
+// prefundedEOAs returns the accounts prefunded by WithPrefundedEOAs, bound to the given EL node. The accounts are read
+// from the L2 genesis of the node: the prefunded EOAs are the consecutive keys, from the first one, holding a balance
+// at genesis.
+func prefundedEOAs(t devtest.T, el *dsl.L2ELNode) []*dsl.EOA {
+	keys, err := devkeys.NewMnemonicDevKeys(devkeys.TestMnemonic)
+	t.Require().NoError(err, "failed to create devkeys")
+
+	var eoas []*dsl.EOA
+	for i := range maxPrefundedEOAs {
+		priv, err := keys.Secret(PrefundedEOAKey(i))
+		t.Require().NoError(err, "failed to derive prefunded EOA %d", i)
+
+		balance, err := el.Escape().EthClient().BalanceAt(t.Ctx(), crypto.PubkeyToAddress(priv.PublicKey), big.NewInt(0))
+		t.Require().NoError(err, "failed to fetch the genesis balance of prefunded EOA %d", i)
+		if balance.Sign() == 0 {
+			break
+		}
+		eoas = append(eoas, dsl.NewKey(t, priv).User(el))
+	}
+	return eoas
+}
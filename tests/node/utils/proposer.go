@@ -0,0 +1,316 @@
+package node_utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// proposerStopGracePeriod is left to the proposer after it is stopped, so that a proposal it already sent lands on L1
+// before the submissions are expected to halt.
+const proposerStopGracePeriod = 30 * time.Second
+
+// proposerResumeTimeout bounds the wait for the first proposal once the proposer is restarted.
+const proposerResumeTimeout = 5 * time.Minute
+
+// proposalTopics are the topics of the events emitted on L1 when an output root is proposed: by the dispute game
+// factory, and by the legacy L2OutputOracle.
+var proposalTopics = []common.Hash{
+	crypto.Keccak256Hash([]byte("DisputeGameCreated(address,uint32,bytes32)")),
+	crypto.Keccak256Hash([]byte("OutputProposed(bytes32,uint256,uint256,uint256)")),
+}
+
+// disputeGameCreateSelector is the selector of DisputeGameFactory.create, whose extra data starts with the L2 block
+// number of the proposed output.
+var disputeGameCreateSelector = crypto.Keccak256([]byte("create(uint32,bytes32,bytes)"))[:4]
+
+// proposerControl is the subset of *dsl.L2Proposer used to stop and restart the proposer.
+type proposerControl interface {
+	Stop()
+	Start()
+}
+
+// proposalSource is the subset of apis.EthClient used to find the output proposals on L1.
+type proposalSource interface {
+	InfoByLabel(ctx context.Context, label eth.BlockLabel) (eth.BlockInfo, error)
+	InfoByNumber(ctx context.Context, number uint64) (eth.BlockInfo, error)
+	FetchReceipts(ctx context.Context, blockHash common.Hash) (eth.BlockInfo, types.Receipts, error)
+}
+
+// l1HeadSource is the subset of apis.EthClient used to read the L1 head.
+type l1HeadSource interface {
+	InfoByLabel(ctx context.Context, label eth.BlockLabel) (eth.BlockInfo, error)
+}
+
+// proposalTxSource is the subset of apis.EthClient used to read the output proposals on L1 along with the transactions
+// that made them.
+type proposalTxSource interface {
+	InfoByLabel(ctx context.Context, label eth.BlockLabel) (eth.BlockInfo, error)
+	InfoAndTxsByNumber(ctx context.Context, number uint64) (eth.BlockInfo, types.Transactions, error)
+	FetchReceipts(ctx context.Context, blockHash common.Hash) (eth.BlockInfo, types.Receipts, error)
+}
+
+// proposalOutputSource is the subset of apis.RollupClient used to check the proposed outputs against a node.
+type proposalOutputSource interface {
+	OutputAtBlock(ctx context.Context, blockNum uint64) (*eth.OutputResponse, error)
+	SyncStatus(ctx context.Context) (*eth.SyncStatus, error)
+}
+
+// ProposedOutput is an output root proposed on L1.
+type ProposedOutput struct {
+	L1Block uint64
+	L2Block uint64
+	Root    eth.Bytes32
+}
+
+// AssertProposerStopHaltsSubmissions stops the proposer and checks that no output root is proposed on L1 over `window`,
+// then restarts it and checks that the proposals resume. This validates that the proposer lifecycle is controllable.
+func AssertProposerStopHaltsSubmissions(t devtest.T, proposer dsl.L2Proposer, l1 dsl.L1ELNode, window time.Duration) {
+	ctx, cancel := context.WithTimeout(t.Ctx(), proposerStopGracePeriod+window+proposerResumeTimeout)
+	defer cancel()
+
+	t.Require().NoError(checkProposerStopHaltsSubmissions(ctx, &proposer, l1.EthClient(), proposerStopGracePeriod, window, syncPollInterval))
+}
+
+func checkProposerStopHaltsSubmissions(ctx context.Context, proposer proposerControl, l1 proposalSource, grace, window, interval time.Duration) error {
+	proposer.Stop()
+	if err := sleepCtx(ctx, grace); err != nil {
+		proposer.Start()
+		return fmt.Errorf("context done while the proposer was stopped: %w", err)
+	}
+
+	from, err := l1Head(ctx, l1)
+	if err != nil {
+		proposer.Start()
+		return err
+	}
+	if err := sleepCtx(ctx, window); err != nil {
+		proposer.Start()
+		return fmt.Errorf("context done while the proposer was stopped: %w", err)
+	}
+	to, err := l1Head(ctx, l1)
+	if err != nil {
+		proposer.Start()
+		return err
+	}
+
+	proposals, err := countProposals(ctx, l1, from+1, to)
+	proposer.Start()
+	if err != nil {
+		return err
+	}
+	if proposals > 0 {
+		return fmt.Errorf("%d output roots were proposed in L1 blocks [%d, %d] while the proposer was stopped", proposals, from+1, to)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	checked := to
+	for {
+		head, err := l1Head(ctx, l1)
+		if err == nil && head > checked {
+			proposals, err := countProposals(ctx, l1, checked+1, head)
+			if err != nil {
+				return err
+			}
+			if proposals > 0 {
+				return nil
+			}
+			checked = head
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("no output root was proposed in L1 blocks [%d, %d] after restarting the proposer", to+1, checked)
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProposedOutputs returns the output roots proposed in the L1 blocks [from, to], through the dispute game factory or the
+// legacy L2OutputOracle, in the order they were proposed.
+func ProposedOutputs(t devtest.T, l1 dsl.L1ELNode, from, to uint64) []ProposedOutput {
+	outputs, err := proposedOutputs(t.Ctx(), l1.EthClient(), from, to)
+	t.Require().NoError(err)
+	return outputs
+}
+
+// AssertProposalsTrackSafeHead collects the output roots proposed on L1 over `window` and checks that there is at least
+// one, and that each of them is an output of the node at or below its safe head, which is the cross-safe head once
+// interop is active. Proposing ahead of the safe head, or an output the node doesn't agree with, fails the check.
+func AssertProposalsTrackSafeHead(t devtest.T, l1 dsl.L1ELNode, cl dsl.L2CLNode, window time.Duration) {
+	t.Require().NoError(checkProposalsTrackSafeHead(t.Ctx(), l1.EthClient(), cl.Escape().RollupAPI(), window), "proposals do not track the safe head of %s", cl.Escape().ID().Key())
+}
+
+func checkProposalsTrackSafeHead(ctx context.Context, l1 proposalTxSource, cl proposalOutputSource, window time.Duration) error {
+	from, err := l1Head(ctx, l1)
+	if err != nil {
+		return err
+	}
+	if err := sleepCtx(ctx, window); err != nil {
+		return err
+	}
+	to, err := l1Head(ctx, l1)
+	if err != nil {
+		return err
+	}
+
+	outputs, err := proposedOutputs(ctx, l1, from+1, to)
+	if err != nil {
+		return err
+	}
+	if len(outputs) == 0 {
+		return fmt.Errorf("no output root was proposed in L1 blocks [%d, %d]", from+1, to)
+	}
+
+	// The safe head is read after the proposals, so that it can only be ahead of the one the proposer saw.
+	status, err := cl.SyncStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch the sync status: %w", err)
+	}
+
+	for _, proposed := range outputs {
+		if proposed.L2Block > status.SafeL2.Number {
+			return fmt.Errorf("output of L2 block %d proposed in L1 block %d is ahead of the safe head %d", proposed.L2Block, proposed.L1Block, status.SafeL2.Number)
+		}
+		output, err := cl.OutputAtBlock(ctx, proposed.L2Block)
+		if err != nil {
+			return fmt.Errorf("failed to fetch the output of L2 block %d: %w", proposed.L2Block, err)
+		}
+		if output.OutputRoot != proposed.Root {
+			return fmt.Errorf("output root %s proposed for L2 block %d in L1 block %d does not match the node output root %s", proposed.Root, proposed.L2Block, proposed.L1Block, output.OutputRoot)
+		}
+	}
+	return nil
+}
+
+// proposedOutputs decodes the output proposals in the L1 blocks [from, to].
+func proposedOutputs(ctx context.Context, l1 proposalTxSource, from, to uint64) ([]ProposedOutput, error) {
+	var outputs []ProposedOutput
+	for number := from; number <= to; number++ {
+		info, txs, err := l1.InfoAndTxsByNumber(ctx, number)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch L1 block %d: %w", number, err)
+		}
+		_, receipts, err := l1.FetchReceipts(ctx, info.Hash())
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch the receipts of L1 block %d: %w", number, err)
+		}
+		if len(receipts) != len(txs) {
+			return nil, fmt.Errorf("L1 block %d has %d transactions but %d receipts", number, len(txs), len(receipts))
+		}
+
+		for i, receipt := range receipts {
+			for _, log := range receipt.Logs {
+				output, ok, err := decodeProposal(log, txs[i])
+				if err != nil {
+					return nil, fmt.Errorf("L1 block %d: %w", number, err)
+				}
+				if ok {
+					output.L1Block = number
+					outputs = append(outputs, output)
+				}
+			}
+		}
+	}
+	return outputs, nil
+}
+
+// decodeProposal decodes the output proposed by `log`, emitted by `tx`, and whether the log is a proposal at all. The
+// L2OutputOracle event carries the L2 block number, while the dispute game factory one doesn't: it is read from the
+// extra data of the create call instead.
+func decodeProposal(log *types.Log, tx *types.Transaction) (ProposedOutput, bool, error) {
+	if len(log.Topics) != 4 {
+		return ProposedOutput{}, false, nil
+	}
+
+	switch log.Topics[0] {
+	case proposalTopics[1]:
+		return ProposedOutput{
+			Root:    eth.Bytes32(log.Topics[1]),
+			L2Block: new(big.Int).SetBytes(log.Topics[3].Bytes()).Uint64(),
+		}, true, nil
+	case proposalTopics[0]:
+		l2Block, err := decodeGameL2Block(tx.Data())
+		if err != nil {
+			return ProposedOutput{}, false, fmt.Errorf("dispute game created by transaction %s: %w", tx.Hash(), err)
+		}
+		return ProposedOutput{Root: eth.Bytes32(log.Topics[3]), L2Block: l2Block}, true, nil
+	default:
+		return ProposedOutput{}, false, nil
+	}
+}
+
+// decodeGameL2Block reads the L2 block number from the calldata of DisputeGameFactory.create(gameType, rootClaim,
+// extraData).
+func decodeGameL2Block(data []byte) (uint64, error) {
+	const word = 32
+	if len(data) < 4+3*word || !bytes.Equal(data[:4], disputeGameCreateSelector) {
+		return 0, errors.New("not a create call")
+	}
+	args := data[4:]
+
+	offset := new(big.Int).SetBytes(args[2*word : 3*word])
+	if !offset.IsUint64() || offset.Uint64() > uint64(len(args)-word) {
+		return 0, fmt.Errorf("extra data offset %s is out of bounds", offset)
+	}
+	extraData := args[offset.Uint64()+word:]
+	if length := new(big.Int).SetBytes(args[offset.Uint64() : offset.Uint64()+word]); !length.IsUint64() || length.Uint64() < word || length.Uint64() > uint64(len(extraData)) {
+		return 0, fmt.Errorf("extra data length %s does not hold an L2 block number", length)
+	}
+
+	return new(big.Int).SetBytes(extraData[:word]).Uint64(), nil
+}
+
+func l1Head(ctx context.Context, l1 l1HeadSource) (uint64, error) {
+	head, err := l1.InfoByLabel(ctx, eth.Unsafe)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch the L1 head: %w", err)
+	}
+	return head.NumberU64(), nil
+}
+
+// countProposals counts the output proposal events in the L1 blocks [from, to].
+func countProposals(ctx context.Context, l1 proposalSource, from, to uint64) (int, error) {
+	proposals := 0
+	for number := from; number <= to; number++ {
+		info, err := l1.InfoByNumber(ctx, number)
+		if err != nil {
+			return 0, fmt.Errorf("failed to fetch L1 block %d: %w", number, err)
+		}
+		_, receipts, err := l1.FetchReceipts(ctx, info.Hash())
+		if err != nil {
+			return 0, fmt.Errorf("failed to fetch the receipts of L1 block %d: %w", number, err)
+		}
+		for _, receipt := range receipts {
+			for _, log := range receipt.Logs {
+				if len(log.Topics) > 0 && slices.Contains(proposalTopics, log.Topics[0]) {
+					proposals++
+				}
+			}
+		}
+	}
+	return proposals, nil
+}
+
+// sleepCtx waits for `d`, or until the context is done.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
@@ -0,0 +1,206 @@
+package node_utils
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/ethereum-optimism/optimism/op-service/testutils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProposalL1 produces an L1 block each time its head is read, holding a proposal while the proposer is running.
+// With `ignoresStop`, proposals keep landing while the proposer is stopped; with `stuck`, they never resume.
+type fakeProposalL1 struct {
+	ignoresStop bool
+	stuck       bool
+
+	mu        sync.Mutex
+	running   bool
+	restarted bool
+	blocks    []bool
+}
+
+func (f *fakeProposalL1) Stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.running = false
+}
+
+func (f *fakeProposalL1) Start() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.running = true
+	f.restarted = true
+}
+
+func (f *fakeProposalL1) InfoByLabel(ctx context.Context, label eth.BlockLabel) (eth.BlockInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	proposing := f.running || f.ignoresStop
+	if f.restarted && f.stuck {
+		proposing = false
+	}
+	f.blocks = append(f.blocks, proposing)
+	return &testutils.MockBlockInfo{InfoNum: uint64(len(f.blocks) - 1)}, nil
+}
+
+func (f *fakeProposalL1) InfoByNumber(ctx context.Context, number uint64) (eth.BlockInfo, error) {
+	return &testutils.MockBlockInfo{InfoNum: number, InfoHash: common.Hash{byte(number)}}, nil
+}
+
+func (f *fakeProposalL1) FetchReceipts(ctx context.Context, blockHash common.Hash) (eth.BlockInfo, types.Receipts, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.blocks[blockHash[0]] {
+		return nil, nil, nil
+	}
+	return nil, types.Receipts{{Logs: []*types.Log{{Topics: []common.Hash{proposalTopics[0]}}}}}, nil
+}
+
+func TestProposerStopHaltsSubmissions(t *testing.T) {
+	check := func(l1 *fakeProposalL1) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		return checkProposerStopHaltsSubmissions(ctx, l1, l1, time.Millisecond, 5*time.Millisecond, time.Millisecond)
+	}
+
+	t.Run("submissions halt and resume", func(t *testing.T) {
+		l1 := &fakeProposalL1{running: true}
+		require.NoError(t, check(l1))
+		require.True(t, l1.running)
+	})
+
+	t.Run("submissions continue while stopped", func(t *testing.T) {
+		l1 := &fakeProposalL1{running: true, ignoresStop: true}
+		require.ErrorContains(t, check(l1), "while the proposer was stopped")
+	})
+
+	t.Run("submissions do not resume", func(t *testing.T) {
+		l1 := &fakeProposalL1{running: true, stuck: true}
+		require.ErrorContains(t, check(l1), "after restarting the proposer")
+	})
+}
+
+// createGameCalldata encodes a DisputeGameFactory.create call proposing `root` for `l2Block`.
+func createGameCalldata(root common.Hash, l2Block uint64) []byte {
+	data := append([]byte{}, disputeGameCreateSelector...)
+	data = append(data, common.BigToHash(big.NewInt(1)).Bytes()...)
+	data = append(data, root.Bytes()...)
+	data = append(data, common.BigToHash(big.NewInt(0x60)).Bytes()...)
+	data = append(data, common.BigToHash(big.NewInt(32)).Bytes()...)
+	return append(data, common.BigToHash(new(big.Int).SetUint64(l2Block)).Bytes()...)
+}
+
+func gameCreatedLog(root common.Hash) *types.Log {
+	return &types.Log{Topics: []common.Hash{proposalTopics[0], {}, {}, root}}
+}
+
+func TestDecodeProposal(t *testing.T) {
+	root := common.Hash{0xaa}
+
+	t.Run("dispute game", func(t *testing.T) {
+		tx := types.NewTx(&types.LegacyTx{Data: createGameCalldata(root, 42)})
+		output, ok, err := decodeProposal(gameCreatedLog(root), tx)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, ProposedOutput{L2Block: 42, Root: eth.Bytes32(root)}, output)
+	})
+
+	t.Run("output oracle", func(t *testing.T) {
+		log := &types.Log{Topics: []common.Hash{proposalTopics[1], root, {}, common.BigToHash(big.NewInt(7))}}
+		output, ok, err := decodeProposal(log, types.NewTx(&types.LegacyTx{}))
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, ProposedOutput{L2Block: 7, Root: eth.Bytes32(root)}, output)
+	})
+
+	t.Run("unrelated log", func(t *testing.T) {
+		_, ok, err := decodeProposal(&types.Log{Topics: []common.Hash{{0x01}}}, types.NewTx(&types.LegacyTx{}))
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("game not created by a create call", func(t *testing.T) {
+		_, _, err := decodeProposal(gameCreatedLog(root), types.NewTx(&types.LegacyTx{Data: []byte{0x01, 0x02}}))
+		require.ErrorContains(t, err, "not a create call")
+	})
+
+	t.Run("truncated extra data", func(t *testing.T) {
+		data := createGameCalldata(root, 42)
+		_, _, err := decodeProposal(gameCreatedLog(root), types.NewTx(&types.LegacyTx{Data: data[:len(data)-1]}))
+		require.ErrorContains(t, err, "does not hold an L2 block number")
+	})
+}
+
+// fakeProposalChain is an L1 with a single proposal in block 1, checked against a node with the given safe head and
+// output roots.
+type fakeProposalChain struct {
+	proposal ProposedOutput
+	safeHead uint64
+	outputs  map[uint64]common.Hash
+
+	heads []uint64
+}
+
+func (f *fakeProposalChain) InfoByLabel(ctx context.Context, label eth.BlockLabel) (eth.BlockInfo, error) {
+	head := f.heads[0]
+	f.heads = f.heads[1:]
+	return &testutils.MockBlockInfo{InfoNum: head}, nil
+}
+
+func (f *fakeProposalChain) InfoAndTxsByNumber(ctx context.Context, number uint64) (eth.BlockInfo, types.Transactions, error) {
+	info := &testutils.MockBlockInfo{InfoNum: number, InfoHash: common.Hash{byte(number)}}
+	if number != f.proposal.L1Block {
+		return info, nil, nil
+	}
+	return info, types.Transactions{types.NewTx(&types.LegacyTx{Data: createGameCalldata(common.Hash(f.proposal.Root), f.proposal.L2Block)})}, nil
+}
+
+func (f *fakeProposalChain) FetchReceipts(ctx context.Context, blockHash common.Hash) (eth.BlockInfo, types.Receipts, error) {
+	if uint64(blockHash[0]) != f.proposal.L1Block {
+		return nil, nil, nil
+	}
+	return nil, types.Receipts{{Logs: []*types.Log{gameCreatedLog(common.Hash(f.proposal.Root))}}}, nil
+}
+
+func (f *fakeProposalChain) OutputAtBlock(ctx context.Context, blockNum uint64) (*eth.OutputResponse, error) {
+	return &eth.OutputResponse{OutputRoot: eth.Bytes32(f.outputs[blockNum])}, nil
+}
+
+func (f *fakeProposalChain) SyncStatus(ctx context.Context) (*eth.SyncStatus, error) {
+	return &eth.SyncStatus{SafeL2: eth.L2BlockRef{Number: f.safeHead}}, nil
+}
+
+func TestProposalsTrackSafeHead(t *testing.T) {
+	root := common.Hash{0xaa}
+	check := func(chain *fakeProposalChain) error {
+		chain.heads = []uint64{0, 2}
+		return checkProposalsTrackSafeHead(context.Background(), chain, chain, time.Millisecond)
+	}
+
+	t.Run("proposal at the safe head", func(t *testing.T) {
+		chain := &fakeProposalChain{proposal: ProposedOutput{L1Block: 1, L2Block: 10, Root: eth.Bytes32(root)}, safeHead: 10, outputs: map[uint64]common.Hash{10: root}}
+		require.NoError(t, check(chain))
+	})
+
+	t.Run("proposal ahead of the safe head", func(t *testing.T) {
+		chain := &fakeProposalChain{proposal: ProposedOutput{L1Block: 1, L2Block: 12, Root: eth.Bytes32(root)}, safeHead: 10, outputs: map[uint64]common.Hash{12: root}}
+		require.ErrorContains(t, check(chain), "ahead of the safe head 10")
+	})
+
+	t.Run("mismatching output root", func(t *testing.T) {
+		chain := &fakeProposalChain{proposal: ProposedOutput{L1Block: 1, L2Block: 10, Root: eth.Bytes32(root)}, safeHead: 10, outputs: map[uint64]common.Hash{10: {0xbb}}}
+		require.ErrorContains(t, check(chain), "does not match the node output root")
+	})
+
+	t.Run("no proposal", func(t *testing.T) {
+		chain := &fakeProposalChain{proposal: ProposedOutput{L1Block: 5}, safeHead: 10}
+		require.ErrorContains(t, check(chain), "no output root was proposed in L1 blocks [1, 2]")
+	})
+}
@@ -0,0 +1,73 @@
+package node_utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// AssertReceiptsConsistent fetches the receipt of `txHash` from every EL and checks that their status, gas used, logs
+// and logs bloom are identical. A matching block hash already implies matching receipts roots, but comparing the
+// receipts themselves points at the diverging field when the ELs disagree.
+func AssertReceiptsConsistent(t devtest.T, nodes []dsl.L2ELNode, txHash common.Hash) {
+	sources := make(map[string]receiptSource, len(nodes))
+	for _, node := range nodes {
+		sources[node.Escape().ID().Key()] = node.Escape().EthClient()
+	}
+
+	t.Require().NoError(checkReceiptsConsistent(t.Ctx(), sources, txHash))
+}
+
+func checkReceiptsConsistent(ctx context.Context, nodes map[string]receiptSource, txHash common.Hash) error {
+	names := make([]string, 0, len(nodes))
+	for name := range nodes {
+		names = append(names, name)
+	}
+	if len(names) < 2 {
+		return fmt.Errorf("need at least two nodes to compare receipts, got %d", len(names))
+	}
+	sort.Strings(names)
+
+	reference := names[0]
+	want, err := nodes[reference].TransactionReceipt(ctx, txHash)
+	if err != nil {
+		return fmt.Errorf("failed to get receipt of %s from %s: %w", txHash, reference, err)
+	}
+	wantLogs, err := rlp.EncodeToBytes(want.Logs)
+	if err != nil {
+		return fmt.Errorf("failed to encode logs of %s from %s: %w", txHash, reference, err)
+	}
+
+	for _, name := range names[1:] {
+		got, err := nodes[name].TransactionReceipt(ctx, txHash)
+		if err != nil {
+			return fmt.Errorf("failed to get receipt of %s from %s: %w", txHash, name, err)
+		}
+
+		if got.Status != want.Status {
+			return fmt.Errorf("receipt of %s: status %d on %s but %d on %s", txHash, got.Status, name, want.Status, reference)
+		}
+		if got.GasUsed != want.GasUsed {
+			return fmt.Errorf("receipt of %s: gas used %d on %s but %d on %s", txHash, got.GasUsed, name, want.GasUsed, reference)
+		}
+		if got.Bloom != want.Bloom {
+			return fmt.Errorf("receipt of %s: logs bloom differs between %s and %s", txHash, name, reference)
+		}
+
+		gotLogs, err := rlp.EncodeToBytes(got.Logs)
+		if err != nil {
+			return fmt.Errorf("failed to encode logs of %s from %s: %w", txHash, name, err)
+		}
+		if !bytes.Equal(gotLogs, wantLogs) {
+			return fmt.Errorf("receipt of %s: logs differ between %s (%d logs) and %s (%d logs)", txHash, name, len(got.Logs), reference, len(want.Logs))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package node_utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeReceipts serves a single receipt, or an error when it has none.
+type fakeReceipts struct {
+	receipt *types.Receipt
+}
+
+func (f *fakeReceipts) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	if f.receipt == nil {
+		return nil, errors.New("not found")
+	}
+	return f.receipt, nil
+}
+
+func TestReceiptsConsistent(t *testing.T) {
+	txHash := common.Hash{0x1}
+	newReceipt := func() *types.Receipt {
+		logs := []*types.Log{{Address: common.Address{0xaa}, Topics: []common.Hash{{0xbb}}, Data: []byte{0x1, 0x2}}}
+		return &types.Receipt{
+			Status:  types.ReceiptStatusSuccessful,
+			GasUsed: 21000,
+			Logs:    logs,
+			Bloom:   types.CreateBloom(&types.Receipt{Logs: logs}),
+		}
+	}
+	check := func(receipts ...*types.Receipt) error {
+		nodes := make(map[string]receiptSource, len(receipts))
+		for i, receipt := range receipts {
+			nodes[string(rune('a'+i))] = &fakeReceipts{receipt: receipt}
+		}
+		return checkReceiptsConsistent(context.Background(), nodes, txHash)
+	}
+
+	t.Run("consistent", func(t *testing.T) {
+		require.NoError(t, check(newReceipt(), newReceipt(), newReceipt()))
+	})
+
+	t.Run("status differs", func(t *testing.T) {
+		failed := newReceipt()
+		failed.Status = types.ReceiptStatusFailed
+		require.ErrorContains(t, check(newReceipt(), failed), "status 0 on b but 1 on a")
+	})
+
+	t.Run("gas used differs", func(t *testing.T) {
+		heavier := newReceipt()
+		heavier.GasUsed++
+		require.ErrorContains(t, check(newReceipt(), heavier), "gas used 21001 on b")
+	})
+
+	t.Run("log data differs", func(t *testing.T) {
+		diverging := newReceipt()
+		diverging.Logs[0].Data = []byte{0x1, 0x3}
+		require.ErrorContains(t, check(newReceipt(), diverging), "logs differ between b")
+	})
+
+	t.Run("bloom differs", func(t *testing.T) {
+		diverging := newReceipt()
+		diverging.Bloom = types.Bloom{}
+		require.ErrorContains(t, check(newReceipt(), diverging), "logs bloom differs")
+	})
+
+	t.Run("receipt missing", func(t *testing.T) {
+		require.ErrorContains(t, check(newReceipt(), nil), "failed to get receipt")
+	})
+}
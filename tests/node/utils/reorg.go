@@ -0,0 +1,614 @@
+package node_utils
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/ethereum-optimism/optimism/op-service/apis"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	supervisortypes "github.com/ethereum-optimism/optimism/op-supervisor/supervisor/types"
+	"github.com/ethereum-optimism/optimism/op-test-sequencer/sequencer/seqtypes"
+	"github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/op-rs/kona/supervisor/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// ReorgTestDeadline bounds the duration of the reorg tests, so that a stuck reorg fails the test with the node heads.
+const ReorgTestDeadline = 15 * time.Minute
+
+// ReorgCheckFunc runs sanity checks on the network before or after a reorg.
+type ReorgCheckFunc func(t devtest.T, sys *MinimalWithTestSequencersPreset)
+
+// ReorgScenario describes an L2 reorg triggered by reorging the last `Depth` L1 blocks. `Pre` runs right before the L1
+// reorg and `Post` once the L2 chain has recovered from it; either may be nil.
+type ReorgScenario struct {
+	Description string
+	Depth       int
+	Pre         ReorgCheckFunc
+	Post        ReorgCheckFunc
+}
+
+// RunReorgScenarios runs each scenario as a subtest, named after its description, against a fresh test sequencer.
+func RunReorgScenarios(gt *testing.T, scenarios []ReorgScenario) {
+	runReorgScenarios(gt, scenarios, runL2ReorgAfterL1Reorg)
+}
+
+func runReorgScenarios(gt *testing.T, scenarios []ReorgScenario, run func(gt *testing.T, scenario ReorgScenario)) {
+	for _, scenario := range scenarios {
+		gt.Run(scenario.Description, func(gt *testing.T) {
+			run(gt, scenario)
+		})
+	}
+}
+
+// runL2ReorgAfterL1Reorg tests that the L2 chain reorgs after an L1 reorg of `scenario.Depth` blocks, against a fresh
+// test sequencer.
+// for unsafe reorgs - the depth must be at least >= confDepth, which is 2 in our test deployments
+// for cross-safe reorgs - the depth must be at least >= safe distance, which is 10 in our test deployments
+// pre- and post-checks are sanity checks to ensure that the blocks we expected to be reorged were indeed reorged or not
+func runL2ReorgAfterL1Reorg(gt *testing.T, scenario ReorgScenario) {
+	t := devtest.SerialT(gt)
+	sys := NewMixedOpKonaWithTestSequencer(t)
+
+	reorgL2AfterL1Reorg(t, sys, scenario)
+}
+
+// reorgL2AfterL1Reorg runs `scenario` against `sys`, whose L1 chain must be driven by its test sequencer.
+func reorgL2AfterL1Reorg(t devtest.T, sys *MinimalWithTestSequencersPreset, scenario ReorgScenario) {
+	n := scenario.Depth
+
+	t = WithDeadline(t, ReorgTestDeadline, HeadsDiagnostic(sys.L2CLNodes()))
+	ctx := t.Ctx()
+	ts := sys.TestSequencer.Escape().ControlAPI(sys.L1Network.ChainID())
+
+	sys.L1Network.WaitForBlock()
+
+	sys.StopL1FakePoS()
+
+	// sequence a few L1 and L2 blocks
+	for range n + 1 {
+		sequenceL1Block(t, ts, common.Hash{})
+
+		sys.L2Chain.WaitForBlock()
+		sys.L2Chain.WaitForBlock()
+	}
+
+	// select a divergence block to reorg from
+	var divergence eth.L1BlockRef
+	{
+		tip := sys.L1EL.BlockRefByLabel(eth.Unsafe)
+		require.Greater(t, tip.Number, uint64(n), "n is larger than L1 tip, cannot reorg out block number `tip-n`")
+
+		divergence = sys.L1EL.BlockRefByNumber(tip.Number - uint64(n))
+	}
+
+	// print the chains before sequencing an alternative L1 block
+	sys.L2Chain.PrintChain()
+	sys.L1Network.PrintChain()
+
+	// pre reorg trigger validations and checks
+	if scenario.Pre != nil {
+		scenario.Pre(t, sys)
+	}
+
+	tipL2_preReorg := sys.L2ELSequencerNodes()[0].BlockRefByLabel(eth.Unsafe)
+
+	// reorg the L1 chain -- sequence an alternative L1 block from divergence block parent
+	sequenceL1Block(t, ts, divergence.ParentHash)
+
+	// continue building on the alternative L1 chain
+	sys.StartL1FakePoS()
+
+	// confirm L1 reorged
+	sys.L1EL.ReorgTriggered(divergence, 5)
+
+	// wait until L2 chain cross-safe ref caught up to where it was before the reorg
+	var waitFunc []dsl.CheckFunc
+	for _, clNode := range sys.L2CLNodes() {
+		waitFunc = append(waitFunc, clNode.ReachedFn(supervisortypes.CrossSafe, tipL2_preReorg.Number, 200))
+	}
+
+	dsl.CheckAll(t, waitFunc...)
+
+	// test that latest chain unsafe is not referencing a reorged L1 block (through the L1Origin field)
+	require.Eventually(t, func() bool {
+		for _, elNode := range sys.L2ELNodes() {
+			unsafe := elNode.BlockRefByLabel(eth.Unsafe)
+
+			block, err := sys.L1EL.Escape().EthClient().InfoByNumber(ctx, unsafe.L1Origin.Number)
+			if err != nil {
+				sys.Log.Warn("failed to get L1 block info by number", "number", unsafe.L1Origin.Number, "err", err)
+				return false
+			}
+
+			sys.Log.Info("current unsafe ref", "tip", unsafe, "tip_origin", unsafe.L1Origin, "l1blk", eth.InfoToL1BlockRef(block))
+
+			if block.Hash() != unsafe.L1Origin.Hash {
+				return false
+			}
+		}
+
+		return true
+	}, 120*time.Second, 7*time.Second, "L1 block origin hash should match hash of block on L1 at that number. If not, it means there was a reorg, and L2 blocks L1Origin field is referencing a reorged block.")
+
+	// confirm all L1Origin fields point to canonical blocks
+	require.Eventually(t, func() bool {
+		for _, elNode := range sys.L2ELNodes() {
+			ref := elNode.BlockRefByLabel(eth.Unsafe)
+			var err error
+
+			// wait until L2 chain's L1Origin points to a L1 block after the one that was reorged
+			if ref.L1Origin.Number < divergence.Number {
+				return false
+			}
+
+			sys.Log.Info("L2 chain progressed, pointing to newer L1 block", "ref", ref, "ref_origin", ref.L1Origin, "divergence", divergence)
+
+			for i := ref.Number; i > 0 && ref.L1Origin.Number >= divergence.Number; i-- {
+				ref, err = elNode.Escape().L2EthClient().L2BlockRefByNumber(ctx, i)
+				if err != nil {
+					return false
+				}
+
+				if !sys.L1EL.IsCanonical(ref.L1Origin) {
+					return false
+				}
+			}
+		}
+
+		return true
+	}, 120*time.Second, 5*time.Second, "all L1Origin fields should point to canonical L1 blocks")
+
+	// post reorg test validations and checks
+	if scenario.Post != nil {
+		scenario.Post(t, sys)
+	}
+}
+
+func sequenceL1Block(t devtest.T, ts apis.TestSequencerControlAPI, parent common.Hash) {
+	require.NoError(t, ts.New(t.Ctx(), seqtypes.BuildOpts{Parent: parent}))
+	require.NoError(t, ts.Next(t.Ctx()))
+}
+
+// reorderedBatchesL1 is the subset of apis.EthClient used to read the batches of the reorged L1 blocks, and the
+// alternative branch that replaces them.
+type reorderedBatchesL1 interface {
+	blockTxSource
+	blockInfoSource
+}
+
+// reorderedBatchesNode is the subset of *dsl.L2CLNode used to follow the derivation of the reordered batches.
+type reorderedBatchesNode interface {
+	headSource
+	syncStatusSource
+}
+
+// ReorgL1WithReorderedBatches replaces the L1 blocks in [divergence, tip] with an alternative branch carrying the same
+// batches sent to `batchInbox` by the batcher holding `batcherKey`, in an order shuffled by `seed`, one batch per block.
+// The batches are signed again with `batcherKey`, so that their nonces follow the new order. It then waits up to
+// `timeout` until the local-safe head of `node` converges back to the one it had before the reorg, and checks that the
+// derivation of `node` went through the alternative branch. The batcher must post calldata, as the blobs of the reorged
+// blocks are not available to resubmit them. The L1 sequencer must be stopped, and the L1 origin of the safe head must be
+// older than `divergence` so that the same L2 chain is derived from the alternative branch.
+func ReorgL1WithReorderedBatches(t devtest.T, builder apis.TestSequencerControlAPI, l1 *dsl.L1ELNode, batcherKey *ecdsa.PrivateKey, batchInbox common.Address, divergence eth.L1BlockRef, tip uint64, node dsl.L2CLNode, seed int64, timeout time.Duration) {
+	safe := node.HeadBlockRef(supervisortypes.LocalSafe)
+	t.Require().Less(safe.L1Origin.Number, divergence.Number, "safe head %s is derived from the reorged L1 blocks", safe)
+
+	ctx, cancel := context.WithTimeout(t.Ctx(), timeout)
+	defer cancel()
+
+	blockAt := func(ctx context.Context, number uint64) (eth.BlockID, error) {
+		output, err := node.Escape().RollupAPI().OutputAtBlock(ctx, number)
+		if err != nil {
+			return eth.BlockID{}, err
+		}
+		return output.BlockRef.ID(), nil
+	}
+
+	reorder := batchReordering{key: batcherKey, inbox: batchInbox, seed: seed}
+	t.Require().NoError(checkReorderedBatchesReorg(ctx, builder, l1.EthClient(), reorder, divergence, tip, &node, blockAt, safe.ID(), syncPollInterval), "safe head of %s did not converge after reordering the batches", node.Escape().ID().Key())
+}
+
+// batchReordering describes how the batches of the reorged L1 blocks are submitted again on the alternative branch.
+type batchReordering struct {
+	key   *ecdsa.PrivateKey
+	inbox common.Address
+	seed  int64
+}
+
+func checkReorderedBatchesReorg(ctx context.Context, builder apis.TestSequencerControlAPI, l1 reorderedBatchesL1, reorder batchReordering, divergence eth.L1BlockRef, tip uint64, node reorderedBatchesNode, blockAt func(ctx context.Context, number uint64) (eth.BlockID, error), target eth.BlockID, interval time.Duration) error {
+	batches, err := collectBatches(ctx, l1, crypto.PubkeyToAddress(reorder.key.PublicKey), reorder.inbox, divergence.Number, tip)
+	if err != nil {
+		return err
+	}
+
+	shuffled, err := shuffleBatches(batches, reorder.key, reorder.seed)
+	if err != nil {
+		return err
+	}
+
+	// The alternative branch holds one batch per block, and goes past the original tip so that it becomes canonical.
+	blocks := max(uint64(len(shuffled)), tip-divergence.Number+2)
+	for i := uint64(0); i < blocks; i++ {
+		number := divergence.Number + i
+		opts := seqtypes.BuildOpts{}
+		if i == 0 {
+			opts.Parent = divergence.ParentHash
+		}
+		if err := builder.New(ctx, opts); err != nil {
+			return fmt.Errorf("failed to start alternative L1 block %d: %w", number, err)
+		}
+		if i < uint64(len(shuffled)) {
+			tx := shuffled[i]
+			raw, err := tx.MarshalBinary()
+			if err != nil {
+				return fmt.Errorf("failed to encode batch %s: %w", tx.Hash(), err)
+			}
+			if err := builder.Open(ctx); err != nil {
+				return fmt.Errorf("failed to open alternative L1 block %d: %w", number, err)
+			}
+			if err := builder.IncludeTx(ctx, raw); err != nil {
+				return fmt.Errorf("failed to include batch %s: %w", tx.Hash(), err)
+			}
+		}
+		if err := builder.Next(ctx); err != nil {
+			return fmt.Errorf("failed to seal alternative L1 block %d: %w", number, err)
+		}
+	}
+
+	if err := waitForSafeConvergence(ctx, node, blockAt, target, interval); err != nil {
+		return err
+	}
+	return checkDerivedFromBranch(ctx, l1, node, divergence.Number+uint64(len(shuffled))-1)
+}
+
+// collectBatches returns the calldata batches sent by `batcher` to `inbox` in the L1 blocks [from, to], in inclusion
+// order. The transactions of other senders are left out, as the derivation ignores them.
+func collectBatches(ctx context.Context, l1 blockTxSource, batcher common.Address, inbox common.Address, from, to uint64) (types.Transactions, error) {
+	var batches types.Transactions
+	for number := from; number <= to; number++ {
+		_, txs, err := l1.InfoAndTxsByNumber(ctx, number)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch L1 block %d: %w", number, err)
+		}
+		for _, tx := range txs {
+			if recipient := tx.To(); recipient == nil || *recipient != inbox {
+				continue
+			}
+			sender, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to recover the sender of batch %s: %w", tx.Hash(), err)
+			}
+			if sender != batcher {
+				continue
+			}
+			if tx.Type() == types.BlobTxType {
+				return nil, fmt.Errorf("batch %s is a blob transaction, whose blobs cannot be resubmitted: the batcher must post calldata", tx.Hash())
+			}
+			batches = append(batches, tx)
+		}
+	}
+	if len(batches) == 0 {
+		return nil, fmt.Errorf("no batches in L1 blocks [%d, %d]", from, to)
+	}
+	return batches, nil
+}
+
+// shuffleBatches returns the data of `batches` in an order shuffled by `seed`, which always differs from the original
+// one when there are several batches. The batches are signed again with `key`, with the nonces of the originals, so
+// that they are valid in their new order.
+func shuffleBatches(batches types.Transactions, key *ecdsa.PrivateKey, seed int64) (types.Transactions, error) {
+	order := rand.New(rand.NewSource(seed)).Perm(len(batches))
+	if slices.IsSorted(order) {
+		slices.Reverse(order)
+	}
+
+	signer := types.LatestSignerForChainID(batches[0].ChainId())
+	nonce := batches[0].Nonce()
+	shuffled := make(types.Transactions, len(batches))
+	for i, j := range order {
+		original := batches[j]
+		tx, err := types.SignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   original.ChainId(),
+			Nonce:     nonce + uint64(i),
+			GasTipCap: original.GasTipCap(),
+			GasFeeCap: original.GasFeeCap(),
+			Gas:       original.Gas(),
+			To:        original.To(),
+			Data:      original.Data(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign batch %s again: %w", original.Hash(), err)
+		}
+		shuffled[i] = tx
+	}
+	return shuffled, nil
+}
+
+// checkDerivedFromBranch checks that the derivation of the node went past `number`, the last L1 block holding a
+// reordered batch, on the canonical L1 branch, so that the safe head was derived from the reordered batches.
+func checkDerivedFromBranch(ctx context.Context, l1 blockInfoSource, node syncStatusSource, number uint64) error {
+	current := node.SyncStatus().CurrentL1
+	if current.Number < number {
+		return fmt.Errorf("derivation is at L1 block %s, before the last reordered batch in block %d", current, number)
+	}
+	info, err := l1.InfoByNumber(ctx, current.Number)
+	if err != nil {
+		return fmt.Errorf("failed to fetch L1 block %d: %w", current.Number, err)
+	}
+	if info.Hash() != current.Hash {
+		return fmt.Errorf("derivation is at L1 block %s, which is not on the alternative branch", current)
+	}
+	return nil
+}
+
+// waitForSafeConvergence waits until the local-safe head of the node reaches the height of `target`, and checks that
+// the block the node holds at that height is `target`.
+func waitForSafeConvergence(ctx context.Context, node headSource, blockAt func(ctx context.Context, number uint64) (eth.BlockID, error), target eth.BlockID, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		safe := node.HeadBlockRef(supervisortypes.LocalSafe)
+		if safe.Number >= target.Number {
+			block, err := blockAt(ctx, target.Number)
+			if err != nil {
+				return fmt.Errorf("failed to get block %d: %w", target.Number, err)
+			}
+			if block != target {
+				return fmt.Errorf("derived block %s instead of %s", block, target)
+			}
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("safe head %d did not reach %s", safe.Number, target)
+		case <-ticker.C:
+		}
+	}
+}
+
+// reorgStormDeadline bounds the duration of a reorg storm, including the recovery of the L2 chains.
+const reorgStormDeadline = 10 * time.Minute
+
+// reorgStormDelay is left between two consecutive reorgs of a storm, short enough for a reorg to hit the nodes while
+// they are still processing the previous one.
+const reorgStormDelay = time.Second
+
+// reorgBuilder is the subset of *utils.TestBlockBuilder used to reorg the L1 chain.
+type reorgBuilder interface {
+	BuildBlock(ctx context.Context, parentHash *common.Hash, opts ...utils.BuildOption) (*engine.ExecutionPayloadEnvelope, error)
+	LatestBlockHash(ctx context.Context) (common.Hash, error)
+}
+
+// ReorgStorm triggers `count` consecutive L1 reorgs of `depth` blocks each, with minimal delay between them, then
+// checks that the cross-safe head of every node in `nodes` resumes advancing. This stresses the reorg handling of the
+// nodes beyond a single reorg. The L1 CL is stopped through the reorg manager, so this only runs against kurtosis
+// devnets, and `depth` must stay below the finalized distance of the reorg manager's block builder. The POS loop is
+// paused while the storm runs, so that each reorg replaces exactly `depth` blocks, and drives L1 once it is over.
+func ReorgStorm(t devtest.T, trm *utils.TestReorgManager, count int, depth int, nodes ...dsl.L2CLNode) {
+	t.Require().NotNil(trm, "failed to create the reorg manager")
+	t.Require().Positive(count, "a reorg storm needs at least one reorg")
+	t.Require().Positive(depth, "reorgs must replace at least one block")
+
+	ctx, cancel := context.WithTimeout(t.Ctx(), reorgStormDeadline)
+	defer cancel()
+
+	trm.StopL1CL()
+	pos := trm.GetPOS()
+	pos.Stop()
+
+	t.Logf("triggering %d L1 reorgs of depth %d", count, depth)
+	t.Require().NoError(runReorgStorm(ctx, trm.GetBlockBuilder(), count, depth, reorgStormDelay))
+
+	t.Logf("resuming L1")
+	t.Require().NoError(pos.Start(), "failed to resume L1 block production")
+	t.Cleanup(pos.Stop)
+
+	heads := make([]headSource, 0, len(nodes))
+	for i := range nodes {
+		heads = append(heads, &nodes[i])
+	}
+	t.Require().NoError(waitForCrossSafeAdvance(ctx, heads, recoveryBlocks, syncPollInterval), "L2 chains did not stabilize after the reorg storm")
+}
+
+// runReorgStorm builds `depth` blocks on top of the L1 head, then replaces them with a single block built on their
+// parent, `count` times. It checks that each reorg took effect before moving on to the next one.
+func runReorgStorm(ctx context.Context, builder reorgBuilder, count, depth int, delay time.Duration) error {
+	for i := range count {
+		parent, err := builder.LatestBlockHash(ctx)
+		if err != nil {
+			return fmt.Errorf("reorg %d: failed to fetch the L1 head: %w", i, err)
+		}
+
+		for range depth {
+			if _, err := builder.BuildBlock(ctx, nil); err != nil {
+				return fmt.Errorf("reorg %d: failed to extend the L1 head: %w", i, err)
+			}
+		}
+		payload, err := builder.BuildBlock(ctx, &parent)
+		if err != nil {
+			return fmt.Errorf("reorg %d: failed to build on %s: %w", i, parent, err)
+		}
+		if payload.ExecutionPayload.ParentHash != parent {
+			return fmt.Errorf("reorg %d: no block was built on %s", i, parent)
+		}
+		head, err := builder.LatestBlockHash(ctx)
+		if err != nil {
+			return fmt.Errorf("reorg %d: failed to fetch the L1 head: %w", i, err)
+		}
+		if head != payload.ExecutionPayload.BlockHash {
+			return fmt.Errorf("reorg %d: L1 head is %s instead of the reorging block %s", i, head, payload.ExecutionPayload.BlockHash)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("reorg %d: %w", i, ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+
+	return nil
+}
+
+// waitForCrossSafeAdvance waits until the cross-safe head of every node has advanced by `blocks` from where it was when
+// called.
+func waitForCrossSafeAdvance(ctx context.Context, nodes []headSource, blocks uint64, interval time.Duration) error {
+	starts := make([]uint64, len(nodes))
+	for i, node := range nodes {
+		starts[i] = node.HeadBlockRef(supervisortypes.CrossSafe).Number
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		lagging := -1
+		var head uint64
+		for i, node := range nodes {
+			head = node.HeadBlockRef(supervisortypes.CrossSafe).Number
+			if head < starts[i]+blocks {
+				lagging = i
+				break
+			}
+		}
+		if lagging < 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("cross-safe head of node %d is at %d, expected to advance from %d to %d", lagging, head, starts[lagging], starts[lagging]+blocks)
+		case <-ticker.C:
+		}
+	}
+}
+
+// canonicalTxSource is the subset of apis.EthClient used to check that transactions are in canonical blocks.
+type canonicalTxSource interface {
+	receiptSource
+	blockInfoSource
+}
+
+// AssertFinalizedTxsSurviveReorg checks, after an L1 reorg, that every transaction in `finalizedTxs`, which were in
+// finalized blocks before the reorg, is still included in a canonical block of `el`. Finalized blocks must never be
+// reorged, so a missing transaction means that the reorg recovery went past the finalized head.
+func AssertFinalizedTxsSurviveReorg(t devtest.T, el dsl.L2ELNode, finalizedTxs []common.Hash) {
+	t.Require().NoError(checkTxsCanonical(t.Ctx(), el.Escape().EthClient(), finalizedTxs), "finalized transactions were reorged out of %s", el.Escape().ID().Key())
+}
+
+func checkTxsCanonical(ctx context.Context, el canonicalTxSource, txs []common.Hash) error {
+	var errs []error
+	for _, tx := range txs {
+		receipt, err := el.TransactionReceipt(ctx, tx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("transaction %s is no longer included: %w", tx, err))
+			continue
+		}
+		if receipt == nil {
+			errs = append(errs, fmt.Errorf("transaction %s is no longer included: no receipt", tx))
+			continue
+		}
+
+		block, err := el.InfoByNumber(ctx, receipt.BlockNumber.Uint64())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("transaction %s: failed to fetch block %d: %w", tx, receipt.BlockNumber, err))
+			continue
+		}
+		if block.Hash() != receipt.BlockHash {
+			errs = append(errs, fmt.Errorf("transaction %s is in block %s, which is no longer canonical at height %d", tx, receipt.BlockHash, receipt.BlockNumber))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deepReorgMinDepth is the safe distance of the test deployments: an L1 reorg must be at least this deep to reach the
+// L1 blocks the cross-safe L2 blocks are derived from.
+const deepReorgMinDepth = 10
+
+// reorgedNode is a CL node, whose heads are recorded before a reorg, along with the EL it drives, which tells whether
+// the recorded blocks are still canonical after it.
+type reorgedNode struct {
+	name   string
+	heads  headSource
+	blocks blockInfoSource
+}
+
+// AssertDeepReorgRecovers reorgs the last `depth` L1 blocks of `sys`, which must be deeper than the safe distance, and
+// checks that every node dropped both its unsafe and its cross-safe heads from before the reorg, then derived a new
+// cross-safe chain at least as high from the alternative L1 branch.
+func AssertDeepReorgRecovers(t devtest.T, sys *MinimalWithTestSequencersPreset, depth int) {
+	t.Require().GreaterOrEqual(depth, deepReorgMinDepth, "a reorg must be at least %d blocks deep to reorg cross-safe blocks", deepReorgMinDepth)
+
+	var nodes []reorgedNode
+	for _, cl := range sys.L2CLNodes() {
+		el, ok := sys.PairedEL(cl)
+		t.Require().True(ok, "no EL paired with %s", cl.Escape().ID().Key())
+		nodes = append(nodes, reorgedNode{name: cl.Escape().ID().Key(), heads: &cl, blocks: el.Escape().EthClient()})
+	}
+
+	var pre map[string][]eth.L2BlockRef
+	reorgL2AfterL1Reorg(t, sys, ReorgScenario{
+		Description: "deep reorg",
+		Depth:       depth,
+		Pre: func(t devtest.T, sys *MinimalWithTestSequencersPreset) {
+			pre = recordReorgedHeads(nodes)
+		},
+		Post: func(t devtest.T, sys *MinimalWithTestSequencersPreset) {
+			t.Require().NoError(checkDeepReorgRecovered(t.Ctx(), nodes, pre))
+		},
+	})
+}
+
+// deepReorgLevels are the heads that a reorg deeper than the safe distance must replace.
+var deepReorgLevels = []supervisortypes.SafetyLevel{supervisortypes.LocalUnsafe, supervisortypes.CrossSafe}
+
+// recordReorgedHeads returns the deepReorgLevels heads of each node, in the same order.
+func recordReorgedHeads(nodes []reorgedNode) map[string][]eth.L2BlockRef {
+	heads := make(map[string][]eth.L2BlockRef, len(nodes))
+	for _, node := range nodes {
+		for _, level := range deepReorgLevels {
+			heads[node.name] = append(heads[node.name], node.heads.HeadBlockRef(level))
+		}
+	}
+	return heads
+}
+
+func checkDeepReorgRecovered(ctx context.Context, nodes []reorgedNode, pre map[string][]eth.L2BlockRef) error {
+	for _, node := range nodes {
+		heads, ok := pre[node.name]
+		if !ok {
+			return fmt.Errorf("no heads recorded for %s before the reorg", node.name)
+		}
+
+		for i, level := range deepReorgLevels {
+			old := heads[i]
+			block, err := node.blocks.InfoByNumber(ctx, old.Number)
+			if err != nil {
+				return fmt.Errorf("failed to get block %d from %s: %w", old.Number, node.name, err)
+			}
+			if block.Hash() == old.Hash {
+				return fmt.Errorf("%s: %s block %s from before the reorg is still canonical", node.name, level, old)
+			}
+		}
+
+		crossSafe := node.heads.HeadBlockRef(supervisortypes.CrossSafe)
+		if old := heads[slices.Index(deepReorgLevels, supervisortypes.CrossSafe)]; crossSafe.Number < old.Number {
+			return fmt.Errorf("%s: cross-safe head %s is behind the one from before the reorg %s", node.name, crossSafe, old)
+		}
+	}
+
+	return nil
+}
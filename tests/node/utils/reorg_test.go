@@ -0,0 +1,456 @@
+package node_utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-service/apis"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/ethereum-optimism/optimism/op-service/testutils"
+	"github.com/ethereum-optimism/optimism/op-supervisor/supervisor/types"
+	"github.com/ethereum-optimism/optimism/op-test-sequencer/sequencer/seqtypes"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+	"github.com/op-rs/kona/supervisor/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunReorgScenarios(t *testing.T) {
+	var calls []string
+	check := func(name string) ReorgCheckFunc {
+		return func(t devtest.T, sys *MinimalWithTestSequencersPreset) {
+			calls = append(calls, name)
+		}
+	}
+
+	scenarios := []ReorgScenario{
+		{Description: "shallow", Depth: 3, Pre: check("shallow pre"), Post: check("shallow post")},
+		{Description: "deep", Depth: 20, Pre: check("deep pre"), Post: check("deep post")},
+	}
+
+	var ran []string
+	runReorgScenarios(t, scenarios, func(gt *testing.T, scenario ReorgScenario) {
+		ran = append(ran, gt.Name())
+		require.Equal(gt, scenario.Description == "deep", scenario.Depth == 20)
+
+		scenario.Pre(nil, nil)
+		scenario.Post(nil, nil)
+	})
+
+	require.Equal(t, []string{"TestRunReorgScenarios/shallow", "TestRunReorgScenarios/deep"}, ran)
+	require.Equal(t, []string{"shallow pre", "shallow post", "deep pre", "deep post"}, calls)
+}
+
+// fakeBatchL1 serves L1 blocks, indexed by number, holding the given transactions.
+type fakeBatchL1 struct {
+	blocks map[uint64]ethtypes.Transactions
+}
+
+func (f *fakeBatchL1) InfoAndTxsByNumber(ctx context.Context, number uint64) (eth.BlockInfo, ethtypes.Transactions, error) {
+	return &testutils.MockBlockInfo{InfoNum: number}, f.blocks[number], nil
+}
+
+func (f *fakeBatchL1) InfoByNumber(ctx context.Context, number uint64) (eth.BlockInfo, error) {
+	return &testutils.MockBlockInfo{InfoNum: number, InfoHash: alternativeL1Hash(number)}, nil
+}
+
+// alternativeL1Hash is the hash of the block at `number` on the alternative L1 branch.
+func alternativeL1Hash(number uint64) common.Hash {
+	return common.Hash{0xa1, byte(number)}
+}
+
+// fakeBatchDerivation is an L1 builder that derives `target` once the alternative branch, starting at `divergence`, goes
+// past `tip`, provided all the `expected` batch data was included in it by `batcher`, with consecutive nonces. With
+// `dropLast`, it loses the last batch it is given, and with `stale`, its derivation stays on the original branch.
+type fakeBatchDerivation struct {
+	apis.TestSequencerControlAPI
+
+	divergence uint64
+	tip        uint64
+	target     eth.BlockID
+	batcher    common.Address
+	expected   [][]byte
+	dropLast   bool
+	stale      bool
+
+	parent   common.Hash
+	included ethtypes.Transactions
+	sealed   uint64
+}
+
+func (f *fakeBatchDerivation) New(ctx context.Context, opts seqtypes.BuildOpts) error {
+	if f.sealed == 0 {
+		f.parent = opts.Parent
+	}
+	return nil
+}
+
+func (f *fakeBatchDerivation) Open(ctx context.Context) error {
+	return nil
+}
+
+func (f *fakeBatchDerivation) IncludeTx(ctx context.Context, raw hexutil.Bytes) error {
+	var tx ethtypes.Transaction
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return err
+	}
+	f.included = append(f.included, &tx)
+	return nil
+}
+
+func (f *fakeBatchDerivation) Next(ctx context.Context) error {
+	f.sealed++
+	return nil
+}
+
+func (f *fakeBatchDerivation) canonical() bool {
+	return f.divergence+f.sealed > f.tip+1
+}
+
+func (f *fakeBatchDerivation) HeadBlockRef(lvl types.SafetyLevel) eth.L2BlockRef {
+	if !f.canonical() {
+		return eth.L2BlockRef{}
+	}
+	return eth.L2BlockRef{Hash: f.target.Hash, Number: f.target.Number}
+}
+
+func (f *fakeBatchDerivation) SyncStatus() *eth.SyncStatus {
+	number := f.divergence + f.sealed - 1
+	hash := alternativeL1Hash(number)
+	if f.stale {
+		hash = common.Hash{0x0d, byte(number)}
+	}
+	return &eth.SyncStatus{CurrentL1: eth.L1BlockRef{Hash: hash, Number: number}}
+}
+
+// derived returns the batch data the derivation accepts: the data of the transactions of the batcher, as long as their
+// nonces follow each other.
+func (f *fakeBatchDerivation) derived() [][]byte {
+	var data [][]byte
+	for i, tx := range f.included {
+		sender, err := ethtypes.Sender(ethtypes.LatestSignerForChainID(tx.ChainId()), tx)
+		if err != nil || sender != f.batcher || tx.Nonce() != uint64(i) {
+			break
+		}
+		data = append(data, tx.Data())
+	}
+	if f.dropLast && len(data) > 0 {
+		data = data[:len(data)-1]
+	}
+	return data
+}
+
+func (f *fakeBatchDerivation) blockAt(ctx context.Context, number uint64) (eth.BlockID, error) {
+	derived := f.derived()
+	if len(derived) != len(f.expected) {
+		return eth.BlockID{Hash: common.Hash{0xba, 0xd}, Number: number}, nil
+	}
+	for _, data := range f.expected {
+		if !slices.ContainsFunc(derived, func(d []byte) bool { return bytes.Equal(d, data) }) {
+			return eth.BlockID{Hash: common.Hash{0xba, 0xd}, Number: number}, nil
+		}
+	}
+	return f.target, nil
+}
+
+func signedBatch(t *testing.T, key *ecdsa.PrivateKey, nonce uint64, inbox common.Address, data []byte) *ethtypes.Transaction {
+	tx, err := ethtypes.SignNewTx(key, ethtypes.LatestSignerForChainID(big.NewInt(900)), &ethtypes.DynamicFeeTx{
+		ChainID:   big.NewInt(900),
+		Nonce:     nonce,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(2),
+		Gas:       50_000,
+		To:        &inbox,
+		Data:      data,
+	})
+	require.NoError(t, err)
+	return tx
+}
+
+func TestReorgL1WithReorderedBatches(t *testing.T) {
+	inbox := common.Address{0xff, 0x01}
+	batcherKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	batcher := crypto.PubkeyToAddress(batcherKey.PublicKey)
+	otherKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	// Blocks 10 to 12 are reorged out. They hold three batches of the batcher, a transaction sent to the inbox by another
+	// account, which the derivation ignores, and a transfer.
+	a0, a1, a2 := signedBatch(t, batcherKey, 0, inbox, []byte{0, 1}), signedBatch(t, batcherKey, 1, inbox, []byte{0, 2}), signedBatch(t, batcherKey, 2, inbox, []byte{0, 3})
+	other := signedBatch(t, otherKey, 0, inbox, []byte{0, 9})
+	transfer := ethtypes.NewTx(&ethtypes.DynamicFeeTx{ChainID: big.NewInt(900), To: &common.Address{0x42}})
+	l1 := &fakeBatchL1{blocks: map[uint64]ethtypes.Transactions{
+		10: {a0, transfer},
+		11: {other, a1},
+		12: {a2},
+	}}
+	divergence := eth.L1BlockRef{Number: 10, ParentHash: common.Hash{0x09}}
+	target := eth.BlockID{Hash: common.Hash{0x05}, Number: 5}
+	expected := [][]byte{a0.Data(), a1.Data(), a2.Data()}
+	reorder := batchReordering{key: batcherKey, inbox: inbox, seed: 1}
+
+	newDerivation := func() *fakeBatchDerivation {
+		return &fakeBatchDerivation{divergence: 10, tip: 12, target: target, batcher: batcher, expected: expected}
+	}
+	run := func(l1 *fakeBatchL1, derivation *fakeBatchDerivation) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		return checkReorderedBatchesReorg(ctx, derivation, l1, reorder, divergence, 12, derivation, derivation.blockAt, target, time.Millisecond)
+	}
+
+	t.Run("safe head converges", func(t *testing.T) {
+		derivation := newDerivation()
+		require.NoError(t, run(l1, derivation))
+		require.Equal(t, divergence.ParentHash, derivation.parent)
+		require.EqualValues(t, 4, derivation.sealed)
+
+		included := make([][]byte, len(derivation.included))
+		for i, tx := range derivation.included {
+			included[i] = tx.Data()
+		}
+		require.ElementsMatch(t, expected, included)
+		require.NotEqual(t, expected, included, "the batches are included out of order")
+	})
+
+	t.Run("safe head diverges", func(t *testing.T) {
+		derivation := newDerivation()
+		derivation.dropLast = true
+		require.ErrorContains(t, run(l1, derivation), "instead of")
+	})
+
+	t.Run("derivation stays on the original branch", func(t *testing.T) {
+		derivation := newDerivation()
+		derivation.stale = true
+		require.ErrorContains(t, run(l1, derivation), "not on the alternative branch")
+	})
+
+	t.Run("no batches", func(t *testing.T) {
+		empty := &fakeBatchL1{blocks: map[uint64]ethtypes.Transactions{10: {transfer, other}}}
+		require.ErrorContains(t, run(empty, newDerivation()), "no batches")
+	})
+
+	t.Run("blob batches", func(t *testing.T) {
+		blob, err := ethtypes.SignNewTx(batcherKey, ethtypes.LatestSignerForChainID(big.NewInt(900)), &ethtypes.BlobTx{
+			ChainID:    uint256.NewInt(900),
+			GasTipCap:  uint256.NewInt(1),
+			GasFeeCap:  uint256.NewInt(1),
+			Gas:        21_000,
+			To:         inbox,
+			BlobFeeCap: uint256.NewInt(1),
+			BlobHashes: []common.Hash{{0x01}},
+		})
+		require.NoError(t, err)
+		blobs := &fakeBatchL1{blocks: map[uint64]ethtypes.Transactions{10: {blob}}}
+		require.ErrorContains(t, run(blobs, newDerivation()), "must post calldata")
+	})
+}
+
+func TestShuffleBatches(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	inbox := common.Address{0xff, 0x01}
+	batches := ethtypes.Transactions{signedBatch(t, key, 7, inbox, []byte{1}), signedBatch(t, key, 8, inbox, []byte{2})}
+
+	for seed := int64(0); seed < 8; seed++ {
+		shuffled, err := shuffleBatches(batches, key, seed)
+		require.NoError(t, err)
+		require.Equal(t, []byte{2}, shuffled[0].Data(), "two batches are always swapped")
+		require.Equal(t, []byte{1}, shuffled[1].Data())
+		require.EqualValues(t, 7, shuffled[0].Nonce())
+		require.EqualValues(t, 8, shuffled[1].Nonce())
+	}
+}
+
+// fakeReorgBuilder builds blocks on an in-memory chain. With `ignoreParent`, it keeps extending the head instead of
+// reorging, like a builder whose rewind silently failed, and with `rewindErr` it fails to rewind.
+type fakeReorgBuilder struct {
+	chain        []common.Hash
+	ignoreParent bool
+	rewindErr    error
+
+	reorgs int
+}
+
+func (f *fakeReorgBuilder) BuildBlock(ctx context.Context, parentHash *common.Hash, opts ...utils.BuildOption) (*engine.ExecutionPayloadEnvelope, error) {
+	if parentHash != nil && f.rewindErr != nil {
+		return nil, f.rewindErr
+	}
+	if parentHash != nil && !f.ignoreParent {
+		f.chain = f.chain[:slices.Index(f.chain, *parentHash)+1]
+		f.reorgs++
+	}
+	parent := f.chain[len(f.chain)-1]
+	block := common.Hash{byte(len(f.chain)), byte(f.reorgs)}
+	f.chain = append(f.chain, block)
+	return &engine.ExecutionPayloadEnvelope{ExecutionPayload: &engine.ExecutableData{BlockHash: block, ParentHash: parent}}, nil
+}
+
+func (f *fakeReorgBuilder) LatestBlockHash(ctx context.Context) (common.Hash, error) {
+	return f.chain[len(f.chain)-1], nil
+}
+
+func TestRunReorgStorm(t *testing.T) {
+	t.Run("reorgs take effect", func(t *testing.T) {
+		builder := &fakeReorgBuilder{chain: []common.Hash{{0x01}}}
+		require.NoError(t, runReorgStorm(context.Background(), builder, 5, 3, time.Millisecond))
+		require.Equal(t, 5, builder.reorgs)
+		require.Len(t, builder.chain, 6)
+	})
+
+	t.Run("reorg ignored", func(t *testing.T) {
+		builder := &fakeReorgBuilder{chain: []common.Hash{{0x01}}, ignoreParent: true}
+		require.ErrorContains(t, runReorgStorm(context.Background(), builder, 5, 3, time.Millisecond), "reorg 0: no block was built on")
+	})
+
+	t.Run("rewind failed", func(t *testing.T) {
+		rewindErr := errors.New("refusing to rewind to block: rewind target 1 is at or below the finalized block 2")
+		builder := &fakeReorgBuilder{chain: []common.Hash{{0x01}}, rewindErr: rewindErr}
+		err := runReorgStorm(context.Background(), builder, 5, 3, time.Millisecond)
+		require.ErrorIs(t, err, rewindErr)
+		require.ErrorContains(t, err, "reorg 0: failed to build on")
+	})
+}
+
+// fakeRecoveringNode serves a cross-safe head that advances by one block each time it is read, once `stalled` reads
+// have been served.
+type fakeRecoveringNode struct {
+	head    uint64
+	stalled int
+}
+
+func (f *fakeRecoveringNode) HeadBlockRef(lvl types.SafetyLevel) eth.L2BlockRef {
+	if f.stalled > 0 {
+		f.stalled--
+	} else {
+		f.head++
+	}
+	return eth.L2BlockRef{Number: f.head}
+}
+
+func TestCrossSafeAdvance(t *testing.T) {
+	run := func(nodes ...headSource) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		return waitForCrossSafeAdvance(ctx, nodes, 5, time.Millisecond)
+	}
+
+	t.Run("all nodes recover", func(t *testing.T) {
+		require.NoError(t, run(&fakeRecoveringNode{head: 10}, &fakeRecoveringNode{head: 20, stalled: 10}))
+	})
+
+	t.Run("node stuck", func(t *testing.T) {
+		require.ErrorContains(t, run(&fakeRecoveringNode{head: 10}, &fakeRecoveringNode{head: 20, stalled: 1_000_000}), "cross-safe head of node 1 is at 20")
+	})
+}
+
+// fakeCanonicalEL serves receipts for `included` transactions, in blocks whose canonical hash is `canonical`.
+type fakeCanonicalEL struct {
+	included  map[common.Hash]*ethtypes.Receipt
+	canonical map[uint64]common.Hash
+}
+
+func (f *fakeCanonicalEL) TransactionReceipt(ctx context.Context, txHash common.Hash) (*ethtypes.Receipt, error) {
+	receipt, ok := f.included[txHash]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return receipt, nil
+}
+
+func (f *fakeCanonicalEL) InfoByNumber(ctx context.Context, number uint64) (eth.BlockInfo, error) {
+	return &testutils.MockBlockInfo{InfoNum: number, InfoHash: f.canonical[number]}, nil
+}
+
+func TestTxsCanonical(t *testing.T) {
+	txA, txB := common.Hash{0x0a}, common.Hash{0x0b}
+	receipt := func(tx common.Hash, number uint64, block common.Hash) *ethtypes.Receipt {
+		return &ethtypes.Receipt{TxHash: tx, BlockNumber: new(big.Int).SetUint64(number), BlockHash: block}
+	}
+	canonical := map[uint64]common.Hash{10: {0x10}, 11: {0x11}}
+
+	t.Run("finalized transactions survive", func(t *testing.T) {
+		el := &fakeCanonicalEL{
+			included:  map[common.Hash]*ethtypes.Receipt{txA: receipt(txA, 10, common.Hash{0x10}), txB: receipt(txB, 11, common.Hash{0x11})},
+			canonical: canonical,
+		}
+		require.NoError(t, checkTxsCanonical(context.Background(), el, []common.Hash{txA, txB}))
+	})
+
+	t.Run("finalized transaction dropped", func(t *testing.T) {
+		el := &fakeCanonicalEL{
+			included:  map[common.Hash]*ethtypes.Receipt{txA: receipt(txA, 10, common.Hash{0x10})},
+			canonical: canonical,
+		}
+		require.ErrorContains(t, checkTxsCanonical(context.Background(), el, []common.Hash{txA, txB}), "is no longer included")
+	})
+
+	t.Run("receipt missing without error", func(t *testing.T) {
+		el := &fakeCanonicalEL{
+			included:  map[common.Hash]*ethtypes.Receipt{txA: nil},
+			canonical: canonical,
+		}
+		err := checkTxsCanonical(context.Background(), el, []common.Hash{txA})
+		require.EqualError(t, err, "transaction "+txA.String()+" is no longer included: no receipt")
+	})
+
+	t.Run("finalized block reorged", func(t *testing.T) {
+		el := &fakeCanonicalEL{
+			included:  map[common.Hash]*ethtypes.Receipt{txA: receipt(txA, 10, common.Hash{0x10}), txB: receipt(txB, 11, common.Hash{0xba, 0xd})},
+			canonical: canonical,
+		}
+		require.ErrorContains(t, checkTxsCanonical(context.Background(), el, []common.Hash{txA, txB}), "no longer canonical at height 11")
+	})
+}
+
+// fakeLevelHeads serves a fixed head per safety level.
+type fakeLevelHeads map[types.SafetyLevel]eth.L2BlockRef
+
+func (f fakeLevelHeads) HeadBlockRef(lvl types.SafetyLevel) eth.L2BlockRef {
+	return f[lvl]
+}
+
+func TestDeepReorgRecovered(t *testing.T) {
+	block := func(number uint64, hash byte) eth.L2BlockRef {
+		return eth.L2BlockRef{Number: number, Hash: common.Hash{hash}}
+	}
+	before := fakeLevelHeads{types.LocalUnsafe: block(30, 0x30), types.CrossSafe: block(12, 0x12)}
+
+	check := func(after fakeLevelHeads, canonical map[uint64]common.Hash) error {
+		node := reorgedNode{name: "node", heads: before, blocks: &fakeCanonicalEL{canonical: canonical}}
+		pre := recordReorgedHeads([]reorgedNode{node})
+
+		node.heads = after
+		return checkDeepReorgRecovered(context.Background(), []reorgedNode{node}, pre)
+	}
+
+	t.Run("cross-safe reorged and re-derived", func(t *testing.T) {
+		after := fakeLevelHeads{types.LocalUnsafe: block(32, 0xb2), types.CrossSafe: block(14, 0xa4)}
+		require.NoError(t, check(after, map[uint64]common.Hash{30: {0xb0}, 12: {0xa2}}))
+	})
+
+	t.Run("only unsafe reorged", func(t *testing.T) {
+		after := fakeLevelHeads{types.LocalUnsafe: block(32, 0xb2), types.CrossSafe: block(14, 0x14)}
+		err := check(after, map[uint64]common.Hash{30: {0xb0}, 12: {0x12}})
+		require.ErrorContains(t, err, "cross-safe block")
+		require.ErrorContains(t, err, "is still canonical")
+	})
+
+	t.Run("cross-safe not re-derived", func(t *testing.T) {
+		after := fakeLevelHeads{types.LocalUnsafe: block(32, 0xb2), types.CrossSafe: block(8, 0xa8)}
+		err := check(after, map[uint64]common.Hash{30: {0xb0}, 12: {0xa2}})
+		require.ErrorContains(t, err, "is behind the one from before the reorg")
+	})
+}
@@ -0,0 +1,188 @@
+package node_utils
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/ethereum-optimism/optimism/op-supervisor/supervisor/types"
+)
+
+// recoveryBlocks is the number of unsafe blocks a node pair must produce or sync after a combined restart.
+const recoveryBlocks = 5
+
+// restartReadyTimeout bounds the time a restarted node is given to answer its RPC with its state reloaded.
+const restartReadyTimeout = time.Minute
+
+// restartPollInterval is how often a restarted node is queried until it is ready.
+const restartPollInterval = time.Second
+
+// restartableNode is the subset of *dsl.L2CLNode used by the restart helpers.
+type restartableNode interface {
+	Stop()
+	Start()
+}
+
+// syncStatusRPC is the subset of apis.RollupClient used to read the sync status of a node.
+type syncStatusRPC interface {
+	SyncStatus(ctx context.Context) (*eth.SyncStatus, error)
+}
+
+// AssertIdentityStableAcrossRestart restarts the node and checks that it keeps the same peer ID and listen addresses,
+// which means that its p2p key is persisted and that its peers can reconnect to it.
+func AssertIdentityStableAcrossRestart(t devtest.T, node dsl.L2CLNode) {
+	ctx, cancel := context.WithTimeout(t.Ctx(), restartReadyTimeout)
+	defer cancel()
+
+	t.Require().NoError(checkIdentityStableAcrossRestart(ctx, &node, node.Escape().P2PAPI(), restartPollInterval), "node %s identity changed across restart", node.Escape().ID().Key())
+}
+
+// checkIdentityStableAcrossRestart restarts `node` and queries its peer info until it matches the one from before the
+// restart. The p2p stack of the node may not answer, or only advertise part of its addresses, right after the restart,
+// so a mismatch is only reported once the context is done.
+func checkIdentityStableAcrossRestart(ctx context.Context, node restartableNode, p2p selfInfoSource, interval time.Duration) error {
+	before, err := p2p.Self(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the peer info before the restart: %w", err)
+	}
+	beforeAddrs := slices.Sorted(slices.Values(before.Addresses))
+
+	node.Stop()
+	node.Start()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := func() error {
+			after, err := p2p.Self(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get the peer info after the restart: %w", err)
+			}
+			if before.PeerID != after.PeerID {
+				return fmt.Errorf("peer ID changed from %s to %s", before.PeerID, after.PeerID)
+			}
+			afterAddrs := slices.Sorted(slices.Values(after.Addresses))
+			if !slices.Equal(beforeAddrs, afterAddrs) {
+				return fmt.Errorf("listen addresses changed from %v to %v", beforeAddrs, afterAddrs)
+			}
+			return nil
+		}()
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-ticker.C:
+		}
+	}
+}
+
+// AssertFinalizedPersistsAcrossRestart restarts the node and checks that, once it answers again, its finalized head is
+// at least as high as before, rather than being reset to genesis. This means that finalization is persisted.
+func AssertFinalizedPersistsAcrossRestart(t devtest.T, node dsl.L2CLNode) {
+	ctx, cancel := context.WithTimeout(t.Ctx(), restartReadyTimeout)
+	defer cancel()
+
+	t.Require().NoError(checkFinalizedPersistsAcrossRestart(ctx, &node, node.Escape().RollupAPI(), restartPollInterval), "node %s lost its finalized head across restart", node.Escape().ID().Key())
+}
+
+// checkFinalizedPersistsAcrossRestart restarts `node` and queries its sync status until its finalized head is back to
+// where it was. The node may not answer, or not have loaded its heads from its database yet, right after the restart,
+// so a finalized head behind is only reported once the context is done. Finalizing the same blocks again from genesis
+// takes far longer than the context, so this can't hide a reset.
+func checkFinalizedPersistsAcrossRestart(ctx context.Context, node restartableNode, rollup syncStatusRPC, interval time.Duration) error {
+	status, err := rollup.SyncStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the sync status before the restart: %w", err)
+	}
+	before := status.FinalizedL2
+
+	node.Stop()
+	node.Start()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		status, err := rollup.SyncStatus(ctx)
+		if err != nil {
+			err = fmt.Errorf("failed to get the sync status after the restart: %w", err)
+		} else if after := status.FinalizedL2; after.Number < before.Number {
+			err = fmt.Errorf("finalized head went back from %s to %s", before.ID(), after.ID())
+		} else {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-ticker.C:
+		}
+	}
+}
+
+// AssertRecoversAfterELAndCLRestart stops both the CL node and its paired EL, restarts them, and checks that the pair
+// resumes advancing the unsafe head within `timeout`. This validates that the CL reconnects to the engine API of the
+// restarted EL rather than only recovering from a CL restart.
+func AssertRecoversAfterELAndCLRestart(t devtest.T, cl dsl.L2CLNode, el dsl.L2ELNode, timeout time.Duration) {
+	clName := cl.Escape().ID().Key()
+	elName := el.Escape().ID().Key()
+	before := cl.HeadBlockRef(types.LocalUnsafe)
+
+	t.Logf("stopping %s and %s", clName, elName)
+	cl.Stop()
+	el.Stop()
+
+	t.Logf("restarting %s and %s", elName, clName)
+	el.Start()
+	cl.Start()
+
+	ctx, cancel := context.WithTimeout(t.Ctx(), timeout)
+	defer cancel()
+
+	t.Require().NoError(waitForRPCReady(ctx, restartPollInterval,
+		func(ctx context.Context) error {
+			_, err := el.Escape().EthClient().InfoByLabel(ctx, eth.Unsafe)
+			return err
+		},
+		func(ctx context.Context) error {
+			_, err := cl.Escape().RollupAPI().SyncStatus(ctx)
+			return err
+		},
+	), "%s and %s did not answer after the restart", elName, clName)
+
+	attempts := int(timeout / (2 * time.Second))
+	dsl.CheckAll(t, cl.ReachedFn(types.LocalUnsafe, before.Number+recoveryBlocks, attempts))
+
+	elHead := el.BlockRefByLabel(eth.Unsafe)
+	t.Require().GreaterOrEqual(elHead.Number, before.Number+recoveryBlocks, "EL %s did not follow the unsafe head of %s", elName, clName)
+}
+
+// waitForRPCReady calls every probe until it succeeds, and returns the last error of a probe that never did.
+func waitForRPCReady(ctx context.Context, interval time.Duration, probes ...func(ctx context.Context) error) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for _, probe := range probes {
+		for {
+			err := probe(ctx)
+			if err == nil {
+				break
+			}
+
+			select {
+			case <-ctx.Done():
+				return err
+			case <-ticker.C:
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package node_utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-service/apis"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRestartableNode serves its current peer info and swaps in `restarted` once it has been restarted. Its p2p stack
+// fails the first `unready` calls, then advertises `partial` for the next `partialCalls` ones, after each restart.
+type fakeRestartableNode struct {
+	info      *apis.PeerInfo
+	restarted *apis.PeerInfo
+
+	unready      int
+	partial      *apis.PeerInfo
+	partialCalls int
+
+	stopped    bool
+	restarts   int
+	callsSince int
+}
+
+func (f *fakeRestartableNode) Self(ctx context.Context) (*apis.PeerInfo, error) {
+	if f.restarts > 0 {
+		f.callsSince++
+		switch {
+		case f.callsSince <= f.unready:
+			return nil, errors.New("connection refused")
+		case f.callsSince <= f.unready+f.partialCalls:
+			return f.partial, nil
+		}
+	}
+	return f.info, nil
+}
+
+func (f *fakeRestartableNode) Stop() {
+	f.stopped = true
+}
+
+func (f *fakeRestartableNode) Start() {
+	if f.stopped && f.restarted != nil {
+		f.info = f.restarted
+	}
+	f.stopped = false
+	f.restarts++
+	f.callsSince = 0
+}
+
+func TestIdentityStableAcrossRestart(t *testing.T) {
+	identity := &apis.PeerInfo{
+		PeerID:    peer.ID("node-a"),
+		Addresses: []string{"/ip4/127.0.0.1/tcp/9222", "/ip4/10.0.0.2/tcp/9222"},
+	}
+	check := func(node *fakeRestartableNode) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		return checkIdentityStableAcrossRestart(ctx, node, node, time.Millisecond)
+	}
+
+	t.Run("preserves identity", func(t *testing.T) {
+		reordered := &apis.PeerInfo{
+			PeerID:    identity.PeerID,
+			Addresses: []string{identity.Addresses[1], identity.Addresses[0]},
+		}
+		require.NoError(t, check(&fakeRestartableNode{info: identity, restarted: reordered}))
+	})
+
+	t.Run("answers late with partial addresses first", func(t *testing.T) {
+		partial := &apis.PeerInfo{PeerID: identity.PeerID, Addresses: identity.Addresses[:1]}
+		require.NoError(t, check(&fakeRestartableNode{info: identity, restarted: identity, unready: 3, partial: partial, partialCalls: 2}))
+	})
+
+	t.Run("never answers", func(t *testing.T) {
+		require.ErrorContains(t, check(&fakeRestartableNode{info: identity, restarted: identity, unready: 1 << 30}), "failed to get the peer info after the restart")
+	})
+
+	t.Run("regenerates peer ID", func(t *testing.T) {
+		regenerated := &apis.PeerInfo{PeerID: peer.ID("node-b"), Addresses: identity.Addresses}
+		require.ErrorContains(t, check(&fakeRestartableNode{info: identity, restarted: regenerated}), "peer ID changed")
+	})
+
+	t.Run("changes listen addresses", func(t *testing.T) {
+		moved := &apis.PeerInfo{PeerID: identity.PeerID, Addresses: []string{"/ip4/127.0.0.1/tcp/9333"}}
+		require.ErrorContains(t, check(&fakeRestartableNode{info: identity, restarted: moved}), "listen addresses changed")
+	})
+}
+
+// fakeFinalityNode serves its finalized head, which is reset to genesis on restart unless `persisted` is set. After a
+// restart, it fails the first `unready` calls, and serves a zero finalized head for the next `loading` ones.
+type fakeFinalityNode struct {
+	finalized uint64
+	persisted bool
+
+	unready    int
+	loading    int
+	restarts   int
+	callsSince int
+}
+
+func (f *fakeFinalityNode) SyncStatus(ctx context.Context) (*eth.SyncStatus, error) {
+	if f.restarts > 0 {
+		f.callsSince++
+		switch {
+		case f.callsSince <= f.unready:
+			return nil, errors.New("connection refused")
+		case f.callsSince <= f.unready+f.loading:
+			return &eth.SyncStatus{}, nil
+		}
+	}
+	return &eth.SyncStatus{FinalizedL2: eth.L2BlockRef{Number: f.finalized}}, nil
+}
+
+func (f *fakeFinalityNode) Stop() {}
+
+func (f *fakeFinalityNode) Start() {
+	if !f.persisted {
+		f.finalized = 0
+	}
+	f.restarts++
+	f.callsSince = 0
+}
+
+func TestFinalizedPersistsAcrossRestart(t *testing.T) {
+	check := func(node *fakeFinalityNode) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		return checkFinalizedPersistsAcrossRestart(ctx, node, node, time.Millisecond)
+	}
+
+	t.Run("preserves finalization", func(t *testing.T) {
+		require.NoError(t, check(&fakeFinalityNode{finalized: 42, persisted: true}))
+	})
+
+	t.Run("answers late and loads its heads", func(t *testing.T) {
+		require.NoError(t, check(&fakeFinalityNode{finalized: 42, persisted: true, unready: 2, loading: 2}))
+	})
+
+	t.Run("resets finalization", func(t *testing.T) {
+		require.ErrorContains(t, check(&fakeFinalityNode{finalized: 42}), "finalized head went back")
+	})
+}
+
+func TestWaitForRPCReady(t *testing.T) {
+	failing := func(n int) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			if n > 0 {
+				n--
+				return errors.New("connection refused")
+			}
+			return nil
+		}
+	}
+	wait := func(probes ...func(ctx context.Context) error) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		return waitForRPCReady(ctx, time.Millisecond, probes...)
+	}
+
+	t.Run("all answer eventually", func(t *testing.T) {
+		require.NoError(t, wait(failing(3), failing(0), failing(2)))
+	})
+
+	t.Run("one never answers", func(t *testing.T) {
+		require.ErrorContains(t, wait(failing(0), failing(1<<30)), "connection refused")
+	})
+}
@@ -0,0 +1,321 @@
+package node_utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/ethereum-optimism/optimism/op-service/retry"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// methodNotFoundCode is the JSON-RPC error code returned for unknown methods.
+const methodNotFoundCode = -32601
+
+// rpcCaller is the subset of client.RPC used to probe RPC methods.
+type rpcCaller interface {
+	CallContext(ctx context.Context, result any, method string, args ...any) error
+}
+
+// outputSource is the subset of apis.RollupClient used to read the output of a block.
+type outputSource interface {
+	OutputAtBlock(ctx context.Context, blockNum uint64) (*eth.OutputResponse, error)
+}
+
+// AssertRPCMethodsAvailable probes each of the methods on the node and checks that they are all served. Tests relying on
+// admin or p2p methods should call it up front, so that a missing method is reported as such instead of failing deep
+// inside the test.
+func AssertRPCMethodsAvailable(t devtest.T, node dsl.L2CLNode, methods []string) {
+	t.Require().NoError(checkRPCMethodsAvailable(t.Ctx(), GetNodeRPCEndpoint(&node), methods), "node %s is missing RPC methods", node.Escape().ID().Key())
+}
+
+// checkRPCMethodsAvailable calls each method without arguments. Any error other than "method not found", for example
+// invalid parameters, means that the method is served.
+func checkRPCMethodsAvailable(ctx context.Context, caller rpcCaller, methods []string) error {
+	var missing []string
+	for _, method := range methods {
+		callCtx, cancel := context.WithTimeout(ctx, DEFAULT_TIMEOUT)
+		var result any
+		err := caller.CallContext(callCtx, &result, method)
+		cancel()
+
+		if isMethodNotFound(err) {
+			missing = append(missing, method)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("unavailable RPC methods: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+func isMethodNotFound(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr.ErrorCode() == methodNotFoundCode
+	}
+
+	return false
+}
+
+// AssertOutputMatchesBlock checks that the block reported by the CL's optimism_outputAtBlock at `number` is the block
+// the EL holds at that height: hashes, numbers and timestamps must agree. This catches stale caches in the CL RPC.
+func AssertOutputMatchesBlock(t devtest.T, cl dsl.L2CLNode, el dsl.L2ELNode, number uint64) {
+	t.Require().NoError(checkOutputMatchesBlock(t.Ctx(), cl.Escape().RollupAPI(), el.Escape().EthClient(), number), "%s and %s disagree on block %d", cl.Escape().ID().Key(), el.Escape().ID().Key(), number)
+}
+
+func checkOutputMatchesBlock(ctx context.Context, cl outputSource, el blockInfoSource, number uint64) error {
+	output, err := cl.OutputAtBlock(ctx, number)
+	if err != nil {
+		return fmt.Errorf("failed to get output at block %d: %w", number, err)
+	}
+	info, err := el.InfoByNumber(ctx, number)
+	if err != nil {
+		return fmt.Errorf("failed to get block %d: %w", number, err)
+	}
+
+	ref := output.BlockRef
+	if ref.Number != info.NumberU64() {
+		return fmt.Errorf("output block number %d differs from the EL block number %d", ref.Number, info.NumberU64())
+	}
+	if ref.Hash != info.Hash() {
+		return fmt.Errorf("output block hash %s differs from the EL block hash %s", ref.Hash, info.Hash())
+	}
+	if ref.Time != info.Time() {
+		return fmt.Errorf("output block timestamp %d differs from the EL block timestamp %d", ref.Time, info.Time())
+	}
+
+	return nil
+}
+
+// peerInfoSchemaExceptions lists the top-level opp2p_self keys that one implementation may omit, with the reason.
+var peerInfoSchemaExceptions = map[string]string{
+	"ENR": "kona omits the ENR of a peer that is not in its discovery table",
+}
+
+// AssertPeerInfoSchemaParity fetches opp2p_self from the op-node and the kona node as raw JSON, and checks that both
+// return the same top-level keys, apart from the documented exceptions. This catches schema drift between the two
+// implementations, which the decoded apis.PeerInfo hides by zeroing the missing fields and dropping the unknown ones.
+func AssertPeerInfoSchemaParity(t devtest.T, opNode, konaNode dsl.L2CLNode) {
+	t.Require().NoError(checkPeerInfoSchemaParity(t.Ctx(), GetNodeRPCEndpoint(&opNode), GetNodeRPCEndpoint(&konaNode)), "opp2p_self schemas of %s and %s differ", opNode.Escape().ID().Key(), konaNode.Escape().ID().Key())
+}
+
+func checkPeerInfoSchemaParity(ctx context.Context, opNode, konaNode rpcCaller) error {
+	opKeys, err := peerInfoKeys(ctx, opNode)
+	if err != nil {
+		return fmt.Errorf("op-node: %w", err)
+	}
+	konaKeys, err := peerInfoKeys(ctx, konaNode)
+	if err != nil {
+		return fmt.Errorf("kona node: %w", err)
+	}
+
+	var onlyOp, onlyKona []string
+	for key := range opKeys {
+		if _, ok := konaKeys[key]; !ok && peerInfoSchemaExceptions[key] == "" {
+			onlyOp = append(onlyOp, key)
+		}
+	}
+	for key := range konaKeys {
+		if _, ok := opKeys[key]; !ok && peerInfoSchemaExceptions[key] == "" {
+			onlyKona = append(onlyKona, key)
+		}
+	}
+	if len(onlyOp) == 0 && len(onlyKona) == 0 {
+		return nil
+	}
+
+	slices.Sort(onlyOp)
+	slices.Sort(onlyKona)
+	return fmt.Errorf("keys only returned by the op-node: [%s], keys only returned by the kona node: [%s]", strings.Join(onlyOp, ", "), strings.Join(onlyKona, ", "))
+}
+
+func peerInfoKeys(ctx context.Context, caller rpcCaller) (map[string]json.RawMessage, error) {
+	callCtx, cancel := context.WithTimeout(ctx, DEFAULT_TIMEOUT)
+	defer cancel()
+
+	var keys map[string]json.RawMessage
+	if err := caller.CallContext(callCtx, &keys, "opp2p_self"); err != nil {
+		return nil, fmt.Errorf("failed to call opp2p_self: %w", err)
+	}
+	return keys, nil
+}
+
+// RPCErrorKind classifies the failure of an RPC call, to tell a node that is down from a node rejecting the request.
+type RPCErrorKind int
+
+const (
+	// RPCErrorUnknown is any error not classified below.
+	RPCErrorUnknown RPCErrorKind = iota
+	// RPCErrorConnection means the node could not be reached, e.g. the connection was refused or reset.
+	RPCErrorConnection
+	// RPCErrorTimeout means the node did not answer within the call timeout.
+	RPCErrorTimeout
+	// RPCErrorResponse means the node answered with a JSON-RPC error.
+	RPCErrorResponse
+)
+
+func (k RPCErrorKind) String() string {
+	switch k {
+	case RPCErrorConnection:
+		return "connection"
+	case RPCErrorTimeout:
+		return "timeout"
+	case RPCErrorResponse:
+		return "rpc"
+	default:
+		return "unknown"
+	}
+}
+
+// ClassifyRPCError returns the kind of the error returned by an RPC call.
+func ClassifyRPCError(err error) RPCErrorKind {
+	var rpcErr rpc.Error
+	var netErr net.Error
+	switch {
+	case errors.As(err, &rpcErr):
+		return RPCErrorResponse
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return RPCErrorTimeout
+	case errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return RPCErrorConnection
+	default:
+		var opErr *net.OpError
+		if errors.As(err, &opErr) {
+			return RPCErrorConnection
+		}
+		return RPCErrorUnknown
+	}
+}
+
+// RPCRetryOptions configures SendRPCRequestWithRetry. Its zero fields take the value of DefaultRPCRetryOptions.
+type RPCRetryOptions struct {
+	// Attempts is the maximum number of calls.
+	Attempts int
+	Strategy retry.Strategy
+	// Timeout bounds each call, not the whole sequence of attempts.
+	Timeout time.Duration
+	// RetryRPCErrors also retries the errors returned by the node itself. They usually mean that the request is
+	// invalid, so by default only connection failures, timeouts and unknown errors are retried.
+	RetryRPCErrors bool
+}
+
+// DefaultRPCRetryOptions ride out a node restart of about half a minute.
+var DefaultRPCRetryOptions = RPCRetryOptions{
+	Attempts: 6,
+	Strategy: &retry.ExponentialStrategy{Min: 500 * time.Millisecond, Max: 10 * time.Second, MaxJitter: 250 * time.Millisecond},
+	Timeout:  DEFAULT_TIMEOUT,
+}
+
+// withDefaults returns the options with their zero fields set from DefaultRPCRetryOptions.
+func (opts RPCRetryOptions) withDefaults() RPCRetryOptions {
+	if opts.Attempts == 0 {
+		opts.Attempts = DefaultRPCRetryOptions.Attempts
+	}
+	if opts.Strategy == nil {
+		opts.Strategy = DefaultRPCRetryOptions.Strategy
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultRPCRetryOptions.Timeout
+	}
+	return opts
+}
+
+// SendRPCRequestWithRetry is SendRPCRequest, retried following `opts`. The returned error is the one of the last
+// attempt, which ClassifyRPCError can tell apart.
+func SendRPCRequestWithRetry[T any](ctx context.Context, clientRPC rpcCaller, opts RPCRetryOptions, method string, resOutput *T, params ...any) error {
+	opts = opts.withDefaults()
+
+	// A call failing with an error that isn't retried stops the retries by succeeding, leaving its error here.
+	var final error
+	attempt := 0
+	err := retry.Do0(ctx, opts.Attempts, opts.Strategy, func() error {
+		attempt++
+		callCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+
+		err := clientRPC.CallContext(callCtx, resOutput, method, params...)
+		if err != nil && ClassifyRPCError(err) == RPCErrorResponse && !opts.RetryRPCErrors {
+			final = err
+			return nil
+		}
+		return err
+	})
+	if err == nil {
+		err = final
+	}
+	if err != nil {
+		return fmt.Errorf("%s failed after %d attempts: %w", method, attempt, err)
+	}
+	return nil
+}
+
+// rpcBatchCaller is the subset of client.RPC used to send batches of calls.
+type rpcBatchCaller interface {
+	BatchCallContext(ctx context.Context, b []rpc.BatchElem) error
+}
+
+// RPCCall is a call of a batch sent with SendRPCBatch.
+type RPCCall struct {
+	Method string
+	Params []any
+	// Result is where the result is decoded to, if not nil.
+	Result any
+}
+
+// NewRPCCall returns a call of `method` whose result is decoded into `result`.
+func NewRPCCall[T any](method string, result *T, params ...any) RPCCall {
+	call := RPCCall{Method: method, Params: params}
+	if result != nil {
+		call.Result = result
+	}
+	return call
+}
+
+// SendRPCBatch sends all the calls in a single JSON-RPC batch, with the same timeout as SendRPCRequest. The calls that
+// fail don't prevent the others from decoding their results, and their errors are joined in the returned one.
+func SendRPCBatch(clientRPC rpcBatchCaller, calls ...RPCCall) error {
+	ctx, cancel := context.WithTimeout(context.Background(), DEFAULT_TIMEOUT)
+	defer cancel()
+
+	return sendRPCBatch(ctx, clientRPC, calls)
+}
+
+func sendRPCBatch(ctx context.Context, clientRPC rpcBatchCaller, calls []RPCCall) error {
+	batch := make([]rpc.BatchElem, len(calls))
+	for i, call := range calls {
+		result := call.Result
+		if result == nil {
+			result = new(json.RawMessage)
+		}
+		batch[i] = rpc.BatchElem{Method: call.Method, Args: call.Params, Result: result}
+	}
+
+	if err := clientRPC.BatchCallContext(ctx, batch); err != nil {
+		return fmt.Errorf("batch failed: %w", err)
+	}
+
+	var errs []error
+	for _, elem := range batch {
+		if elem.Error != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", elem.Method, elem.Error))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,385 @@
+package node_utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// RPCDiffMode tells how a field of an RPC response is compared between the kona node and the op-node.
+type RPCDiffMode int
+
+const (
+	// RPCDiffIgnore skips the field and everything below it.
+	RPCDiffIgnore RPCDiffMode = iota
+	// RPCDiffStructure only compares the JSON types and object keys below the field, not the values. It fits the fields
+	// that legitimately differ between two nodes, such as their sync status or peer ID.
+	RPCDiffStructure
+	// RPCDiffDynamicKeys compares an object whose keys are identifiers, such as peer IDs, by the structure of its
+	// values. The first value of each side, by key order, is compared.
+	RPCDiffDynamicKeys
+)
+
+// RPCDiffRule relaxes the comparison of the fields at `Path`. The path lists the object keys and array indexes from
+// the root of the response, separated by dots, where "*" matches any key or index and the empty path is the root.
+type RPCDiffRule struct {
+	Path   string
+	Mode   RPCDiffMode
+	Reason string
+}
+
+// RPCDiff is a difference between the responses of the kona node and the op-node.
+type RPCDiff struct {
+	Path    string
+	Message string
+}
+
+func (d RPCDiff) String() string {
+	if d.Path == "" {
+		return "result: " + d.Message
+	}
+	return d.Path + ": " + d.Message
+}
+
+// RPCConformanceCase is a read-only RPC call made to both nodes, with the rules relaxing the comparison of the
+// responses.
+type RPCConformanceCase struct {
+	Method string
+	Params []any
+	Rules  []RPCDiffRule
+}
+
+// RPCConformanceSkipped lists the methods of the optimism, opp2p and admin namespaces that the conformance suite does
+// not call, with the reason. They change the state of the node, which would disturb the other tests of the system.
+var RPCConformanceSkipped = map[string]string{
+	"opp2p_blockPeer":         "changes the peer set",
+	"opp2p_unblockPeer":       "changes the peer set",
+	"opp2p_blockAddr":         "changes the peer set, and is served as opp2p_blocAddr by kona",
+	"opp2p_unblockAddr":       "changes the peer set",
+	"opp2p_blockSubnet":       "changes the peer set",
+	"opp2p_unblockSubnet":     "changes the peer set",
+	"opp2p_protectPeer":       "changes the peer set",
+	"opp2p_unprotectPeer":     "changes the peer set",
+	"opp2p_connectPeer":       "changes the peer set",
+	"opp2p_disconnectPeer":    "changes the peer set",
+	"admin_postUnsafePayload": "inserts a block",
+	"admin_startSequencer":    "changes the sequencer state",
+	"admin_stopSequencer":     "changes the sequencer state",
+	"admin_setRecoverMode":    "changes the sequencer state",
+	"admin_overrideLeader":    "changes the conductor state",
+}
+
+// DefaultRPCConformanceCases returns the conformance cases of every read-only method of the optimism, opp2p and admin
+// namespaces. `l2Block` must be safe on both nodes and `l1Block` must be an L1 block both nodes derived from.
+func DefaultRPCConformanceCases(l2Block, l1Block uint64) []RPCConformanceCase {
+	peerInfoRules := []RPCDiffRule{
+		{Path: "", Mode: RPCDiffStructure, Reason: "each node has its own identity"},
+		{Path: "ENR", Mode: RPCDiffIgnore, Reason: peerInfoSchemaExceptions["ENR"]},
+	}
+
+	return []RPCConformanceCase{
+		{Method: "optimism_rollupConfig", Rules: []RPCDiffRule{
+			{Path: "protocol_versions_address", Mode: RPCDiffIgnore, Reason: "deprecated in kona-node while not yet removed from the op-node"},
+		}},
+		{Method: "optimism_syncStatus", Rules: []RPCDiffRule{
+			{Path: "", Mode: RPCDiffStructure, Reason: "the nodes are not at the same heads at the same time"},
+		}},
+		{Method: "optimism_outputAtBlock", Params: []any{eth.Uint64Quantity(l2Block)}, Rules: []RPCDiffRule{
+			{Path: "syncStatus", Mode: RPCDiffStructure, Reason: "the nodes are not at the same heads at the same time"},
+		}},
+		{Method: "optimism_safeHeadAtL1Block", Params: []any{eth.Uint64Quantity(l1Block)}, Rules: []RPCDiffRule{
+			{Path: "", Mode: RPCDiffStructure, Reason: "the safe head database records the heads as the node derived them"},
+		}},
+		{Method: "optimism_version", Rules: []RPCDiffRule{
+			{Path: "", Mode: RPCDiffStructure, Reason: "each implementation has its own version"},
+		}},
+		{Method: "opp2p_self", Rules: peerInfoRules},
+		{Method: "opp2p_peerCount", Rules: []RPCDiffRule{
+			{Path: "", Mode: RPCDiffStructure, Reason: "the nodes do not have the same peers"},
+		}},
+		{Method: "opp2p_peers", Params: []any{true}, Rules: []RPCDiffRule{
+			{Path: "", Mode: RPCDiffStructure, Reason: "the nodes do not have the same peers"},
+			{Path: "peers", Mode: RPCDiffDynamicKeys, Reason: "the peers are keyed by peer ID"},
+			{Path: "peers.*.ENR", Mode: RPCDiffIgnore, Reason: peerInfoSchemaExceptions["ENR"]},
+		}},
+		{Method: "opp2p_peerStats", Rules: []RPCDiffRule{
+			{Path: "", Mode: RPCDiffStructure, Reason: "the nodes do not have the same peers"},
+		}},
+		{Method: "opp2p_discoveryTable", Rules: []RPCDiffRule{
+			{Path: "", Mode: RPCDiffStructure, Reason: "the nodes do not discover the same peers"},
+		}},
+		{Method: "opp2p_listBlockedPeers"},
+		{Method: "opp2p_listBlockedAddrs"},
+		{Method: "opp2p_listBlockedSubnets"},
+		{Method: "admin_sequencerActive"},
+		{Method: "admin_conductorEnabled"},
+	}
+}
+
+// AssertRPCConformance calls each case on the kona node and on the op-node, and checks that the responses match under
+// the rules of the case. Both nodes must play the same role, as the admin methods report the sequencer state. All the
+// differences are reported at once, by method.
+func AssertRPCConformance(t devtest.T, konaNode, opNode dsl.L2CLNode, cases []RPCConformanceCase) {
+	t.Require().NoError(checkRPCConformance(t.Ctx(), GetNodeRPCEndpoint(&konaNode), GetNodeRPCEndpoint(&opNode), cases), "RPC responses of %s differ from those of %s", konaNode.Escape().ID().Key(), opNode.Escape().ID().Key())
+}
+
+func checkRPCConformance(ctx context.Context, konaNode, opNode rpcCaller, cases []RPCConformanceCase) error {
+	var errs []error
+	for _, c := range cases {
+		if err := checkRPCCaseConformance(ctx, konaNode, opNode, c); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.Method, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func checkRPCCaseConformance(ctx context.Context, konaNode, opNode rpcCaller, c RPCConformanceCase) error {
+	konaResult, konaErr := rawRPCCall(ctx, konaNode, c.Method, c.Params)
+	opResult, opErr := rawRPCCall(ctx, opNode, c.Method, c.Params)
+
+	switch {
+	case konaErr != nil && opErr != nil:
+		// Both nodes may reject the call, e.g. because the safe head database is disabled, as long as they reject it the
+		// same way.
+		return checkRPCErrorCodesMatch(konaErr, opErr)
+	case konaErr != nil:
+		return fmt.Errorf("failed on the kona node only: %w", konaErr)
+	case opErr != nil:
+		return fmt.Errorf("failed on the op-node only: %w", opErr)
+	}
+
+	diffs, err := DiffRPCResponses(konaResult, opResult, c.Rules)
+	if err != nil {
+		return err
+	}
+	if len(diffs) == 0 {
+		return nil
+	}
+
+	lines := make([]string, len(diffs))
+	for i, diff := range diffs {
+		lines[i] = diff.String()
+	}
+	return fmt.Errorf("%d differences:\n\t%s", len(diffs), strings.Join(lines, "\n\t"))
+}
+
+// checkRPCErrorCodesMatch checks that the errors returned by both nodes are JSON-RPC errors with the same code.
+func checkRPCErrorCodesMatch(konaErr, opErr error) error {
+	var konaRPCErr, opRPCErr rpc.Error
+	if !errors.As(konaErr, &konaRPCErr) || !errors.As(opErr, &opRPCErr) {
+		return fmt.Errorf("failed on both nodes: kona node: %w, op-node: %w", konaErr, opErr)
+	}
+	if konaRPCErr.ErrorCode() != opRPCErr.ErrorCode() {
+		return fmt.Errorf("error codes differ: kona node returns %d (%v), op-node returns %d (%v)", konaRPCErr.ErrorCode(), konaErr, opRPCErr.ErrorCode(), opErr)
+	}
+	return nil
+}
+
+func rawRPCCall(ctx context.Context, caller rpcCaller, method string, params []any) (json.RawMessage, error) {
+	callCtx, cancel := context.WithTimeout(ctx, DEFAULT_TIMEOUT)
+	defer cancel()
+
+	var result json.RawMessage
+	err := caller.CallContext(callCtx, &result, method, params...)
+	return result, err
+}
+
+// DiffRPCResponses compares the JSON responses of the kona node and the op-node, and returns their differences that
+// `rules` do not allow. Object keys returned by a single node, JSON type mismatches, array lengths and values are
+// reported, visiting the object keys in sorted order.
+func DiffRPCResponses(kona, op json.RawMessage, rules []RPCDiffRule) ([]RPCDiff, error) {
+	konaValue, err := decodeRPCResponse(kona)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kona node response: %w", err)
+	}
+	opValue, err := decodeRPCResponse(op)
+	if err != nil {
+		return nil, fmt.Errorf("invalid op-node response: %w", err)
+	}
+
+	d := rpcDiffer{rules: rules}
+	d.diff(nil, konaValue, opValue, false)
+	return d.diffs, nil
+}
+
+func decodeRPCResponse(raw json.RawMessage) (any, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	// Keep the numbers as they are written, so that large integers are compared exactly.
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+type rpcDiffer struct {
+	rules []RPCDiffRule
+	diffs []RPCDiff
+}
+
+func (d *rpcDiffer) report(path []string, format string, args ...any) {
+	d.diffs = append(d.diffs, RPCDiff{Path: strings.Join(path, "."), Message: fmt.Sprintf(format, args...)})
+}
+
+// rule returns the first rule matching `path`, if any.
+func (d *rpcDiffer) rule(path []string) (RPCDiffRule, bool) {
+	for _, rule := range d.rules {
+		if rpcPathMatches(rule.Path, path) {
+			return rule, true
+		}
+	}
+	return RPCDiffRule{}, false
+}
+
+func rpcPathMatches(pattern string, path []string) bool {
+	if pattern == "" {
+		return len(path) == 0
+	}
+	segments := strings.Split(pattern, ".")
+	if len(segments) != len(path) {
+		return false
+	}
+	for i, segment := range segments {
+		if segment != "*" && segment != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *rpcDiffer) diff(path []string, kona, op any, structureOnly bool) {
+	if rule, ok := d.rule(path); ok {
+		switch rule.Mode {
+		case RPCDiffIgnore:
+			return
+		case RPCDiffStructure:
+			structureOnly = true
+		case RPCDiffDynamicKeys:
+			konaObject, konaOk := kona.(map[string]any)
+			opObject, opOk := op.(map[string]any)
+			if konaOk && opOk {
+				d.diffFirstValues(append(slices.Clip(path), "*"), konaObject, opObject)
+				return
+			}
+		}
+	}
+
+	konaKind, opKind := jsonKind(kona), jsonKind(op)
+	if konaKind != opKind {
+		d.report(path, "kona node returns %s, op-node returns %s", konaKind, opKind)
+		return
+	}
+
+	switch konaValue := kona.(type) {
+	case map[string]any:
+		d.diffObjects(path, konaValue, op.(map[string]any), structureOnly)
+	case []any:
+		d.diffArrays(path, konaValue, op.([]any), structureOnly)
+	default:
+		if !structureOnly && kona != op {
+			d.report(path, "kona node returns %v, op-node returns %v", kona, op)
+		}
+	}
+}
+
+func (d *rpcDiffer) diffObjects(path []string, kona, op map[string]any, structureOnly bool) {
+	keys := make([]string, 0, len(kona)+len(op))
+	for key := range kona {
+		keys = append(keys, key)
+	}
+	for key := range op {
+		if _, ok := kona[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		keyPath := append(slices.Clip(path), key)
+		konaValue, konaOk := kona[key]
+		opValue, opOk := op[key]
+
+		switch {
+		case konaOk && opOk:
+			d.diff(keyPath, konaValue, opValue, structureOnly)
+		case !d.ignored(keyPath):
+			if konaOk {
+				d.report(keyPath, "only returned by the kona node")
+			} else {
+				d.report(keyPath, "only returned by the op-node")
+			}
+		}
+	}
+}
+
+func (d *rpcDiffer) diffArrays(path []string, kona, op []any, structureOnly bool) {
+	if structureOnly {
+		// The arrays may not have the same length, so only their first elements are compared.
+		if len(kona) > 0 && len(op) > 0 {
+			d.diff(append(slices.Clip(path), "0"), kona[0], op[0], true)
+		}
+		return
+	}
+
+	if len(kona) != len(op) {
+		d.report(path, "kona node returns %d elements, op-node returns %d", len(kona), len(op))
+	}
+	for i := range min(len(kona), len(op)) {
+		d.diff(append(slices.Clip(path), strconv.Itoa(i)), kona[i], op[i], false)
+	}
+}
+
+func (d *rpcDiffer) diffFirstValues(path []string, kona, op map[string]any) {
+	if len(kona) == 0 || len(op) == 0 {
+		return
+	}
+	d.diff(path, kona[slices.Min(mapKeys(kona))], op[slices.Min(mapKeys(op))], true)
+}
+
+// ignored tells if a field missing on one side is allowed by an ignore rule.
+func (d *rpcDiffer) ignored(path []string) bool {
+	rule, ok := d.rule(path)
+	return ok && rule.Mode == RPCDiffIgnore
+}
+
+func mapKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func jsonKind(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "an object"
+	case []any:
+		return "an array"
+	case string:
+		return "a string"
+	case json.Number:
+		return "a number"
+	case bool:
+		return "a boolean"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -0,0 +1,137 @@
+package node_utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func diffStrings(t *testing.T, kona, op string, rules ...RPCDiffRule) []string {
+	diffs, err := DiffRPCResponses(json.RawMessage(kona), json.RawMessage(op), rules)
+	require.NoError(t, err)
+
+	out := make([]string, len(diffs))
+	for i, diff := range diffs {
+		out[i] = diff.String()
+	}
+	return out
+}
+
+func TestDiffRPCResponses(t *testing.T) {
+	t.Run("values", func(t *testing.T) {
+		require.Empty(t, diffStrings(t, `{"a": 1, "b": ["x"]}`, `{"b": ["x"], "a": 1}`))
+		require.Equal(t, []string{
+			"a: kona node returns 1, op-node returns 2",
+			"b: kona node returns 2 elements, op-node returns 1",
+			"c: only returned by the op-node",
+			"d: kona node returns a string, op-node returns null",
+		}, diffStrings(t, `{"a": 1, "b": ["x", "y"], "d": "0x"}`, `{"a": 2, "b": ["x"], "c": true, "d": null}`))
+		require.Equal(t, []string{"result: kona node returns false, op-node returns true"}, diffStrings(t, `false`, `true`))
+	})
+
+	t.Run("large numbers are compared exactly", func(t *testing.T) {
+		require.Len(t, diffStrings(t, `{"n": 18446744073709551615}`, `{"n": 18446744073709551614}`), 1)
+	})
+
+	t.Run("structure only", func(t *testing.T) {
+		rule := RPCDiffRule{Path: "", Mode: RPCDiffStructure}
+		require.Empty(t, diffStrings(t, `{"head": {"number": 1}, "peers": ["a", "b"]}`, `{"head": {"number": 5}, "peers": ["c"]}`, rule))
+		require.Equal(t, []string{
+			"head.hash: only returned by the kona node",
+			"peers.0: kona node returns a string, op-node returns a number",
+		}, diffStrings(t, `{"head": {"number": 1, "hash": "0x"}, "peers": ["a"]}`, `{"head": {"number": 5}, "peers": [1, 2]}`, rule))
+	})
+
+	t.Run("ignored fields", func(t *testing.T) {
+		rules := []RPCDiffRule{
+			{Path: "protocol_versions_address", Mode: RPCDiffIgnore},
+			{Path: "list.*.id", Mode: RPCDiffIgnore},
+		}
+		require.Empty(t, diffStrings(t,
+			`{"protocol_versions_address": "0x1", "list": [{"id": 1}, {"id": 2, "v": 0}]}`,
+			`{"list": [{"id": 3}, {"id": 4, "v": 0}]}`,
+			rules...))
+	})
+
+	t.Run("dynamic keys", func(t *testing.T) {
+		rules := []RPCDiffRule{
+			{Path: "peers", Mode: RPCDiffDynamicKeys},
+			{Path: "peers.*.ENR", Mode: RPCDiffIgnore},
+		}
+		require.Empty(t, diffStrings(t, `{"peers": {"a": {"id": "a", "ENR": ""}}}`, `{"peers": {"b": {"id": "b"}, "c": {"id": "c"}}}`, rules...))
+		require.Empty(t, diffStrings(t, `{"peers": {}}`, `{"peers": {"b": {"id": "b"}}}`, rules...))
+		require.Equal(t, []string{"peers.*.latency: only returned by the op-node"},
+			diffStrings(t, `{"peers": {"a": {"id": "a"}}}`, `{"peers": {"b": {"id": "b", "latency": 1}}}`, rules...))
+	})
+
+	t.Run("invalid response", func(t *testing.T) {
+		_, err := DiffRPCResponses(json.RawMessage(`{`), json.RawMessage(`{}`), nil)
+		require.ErrorContains(t, err, "invalid kona node response")
+	})
+}
+
+// fixedRPCCaller answers each method with the registered response, or fails for an unknown method.
+type fixedRPCCaller map[string]string
+
+func (f fixedRPCCaller) CallContext(ctx context.Context, result any, method string, args ...any) error {
+	response, ok := f[method]
+	if !ok {
+		return &rpcTestError{code: methodNotFoundCode}
+	}
+	return json.Unmarshal([]byte(response), result)
+}
+
+func TestCheckRPCConformance(t *testing.T) {
+	ctx := context.Background()
+	kona := fixedRPCCaller{
+		"optimism_version":       `"v1.0.0"`,
+		"admin_conductorEnabled": `false`,
+		"opp2p_peerCount":        `3`,
+	}
+	op := fixedRPCCaller{
+		"optimism_version":       `"v1.13.0"`,
+		"admin_conductorEnabled": `false`,
+		"opp2p_peerCount":        `"3"`,
+		"opp2p_listBlockedPeers": `[]`,
+	}
+
+	cases := []RPCConformanceCase{
+		{Method: "optimism_version", Rules: []RPCDiffRule{{Path: "", Mode: RPCDiffStructure}}},
+		{Method: "admin_conductorEnabled"},
+		{Method: "optimism_safeHeadAtL1Block"},
+	}
+	require.NoError(t, checkRPCConformance(ctx, kona, op, cases))
+
+	cases = append(cases, RPCConformanceCase{Method: "opp2p_peerCount"}, RPCConformanceCase{Method: "opp2p_listBlockedPeers"})
+	err := checkRPCConformance(ctx, kona, op, cases)
+	require.ErrorContains(t, err, "opp2p_peerCount: 1 differences:\n\tresult: kona node returns a number, op-node returns a string")
+	require.ErrorContains(t, err, "opp2p_listBlockedPeers: failed on the kona node only")
+}
+
+// failingRPCCaller fails every call with `err`.
+type failingRPCCaller struct {
+	err error
+}
+
+func (f failingRPCCaller) CallContext(ctx context.Context, result any, method string, args ...any) error {
+	return f.err
+}
+
+func TestCheckRPCConformanceErrors(t *testing.T) {
+	ctx := context.Background()
+	cases := []RPCConformanceCase{{Method: "optimism_safeHeadAtL1Block"}}
+	notFound := failingRPCCaller{err: &rpcTestError{code: methodNotFoundCode}}
+
+	require.NoError(t, checkRPCConformance(ctx, notFound, failingRPCCaller{err: fmt.Errorf("wrapped: %w", &rpcTestError{code: methodNotFoundCode})}, cases))
+
+	err := checkRPCConformance(ctx, notFound, failingRPCCaller{err: &rpcTestError{code: -32000}}, cases)
+	require.ErrorContains(t, err, "optimism_safeHeadAtL1Block: error codes differ: kona node returns -32601")
+	require.ErrorContains(t, err, "op-node returns -32000")
+
+	err = checkRPCConformance(ctx, notFound, failingRPCCaller{err: errors.New("connection refused")}, cases)
+	require.ErrorContains(t, err, "failed on both nodes")
+}
@@ -0,0 +1,165 @@
+package node_utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math/rand"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/ethereum-optimism/optimism/op-supervisor/supervisor/types"
+)
+
+// RPCFuzzSeedEnvVar sets the seed of the random fuzz cases, to replay a failing run. It defaults to the current time,
+// which is logged.
+const RPCFuzzSeedEnvVar = "KONA_RPC_FUZZ_SEED"
+
+// rpcParamKind is the type of the single parameter of a fuzzed method. The malformed values of a kind exclude those the
+// method may accept.
+type rpcParamKind int
+
+const (
+	rpcParamQuantity rpcParamKind = iota
+	rpcParamBool
+)
+
+// rpcFuzzMethods are the read-only methods of the node taking a parameter, by the kind of the parameter. The nodes
+// fuzzed are shared with the other tests of their package, so the methods changing the state of a node, like
+// opp2p_blockPeer or admin_setRecoverMode, are left out even though their malformed calls should be rejected. The
+// methods without parameters are not fuzzed either, as some of them ignore the extra parameters.
+var rpcFuzzMethods = map[string]rpcParamKind{
+	"optimism_outputAtBlock":     rpcParamQuantity,
+	"optimism_safeHeadAtL1Block": rpcParamQuantity,
+	"opp2p_peers":                rpcParamBool,
+}
+
+// RPCFuzzCase is a call with a malformed parameter, which the node must reject with a JSON-RPC error.
+type RPCFuzzCase struct {
+	Method string
+	Params []any
+}
+
+func (c RPCFuzzCase) String() string {
+	params := fmt.Sprint(c.Params...)
+	if len(params) > 64 {
+		params = params[:64] + "..."
+	}
+	return c.Method + "(" + params + ")"
+}
+
+// malformedRPCParams returns the boundary and structurally invalid values of each kind of parameter.
+func malformedRPCParams(kind rpcParamKind) []any {
+	values := []any{
+		nil,
+		map[string]any{},
+		[]any{},
+		"",
+		"0x",
+		"0xzz",
+		"\x00",
+		strings.Repeat("a", 64*1024),
+		-1,
+		1e30,
+	}
+
+	switch kind {
+	case rpcParamQuantity:
+		return append(values,
+			"-0x1",
+			"0x"+strings.Repeat("f", 70),
+			true,
+			fmt.Sprint(uint64(1)<<63),
+		)
+	default:
+		return append(values, "true", 1, 0)
+	}
+}
+
+// RPCFuzzCases returns the fuzz cases of every fuzzed method: the malformed values of its parameter kind, and `random`
+// random values generated from `seed`.
+func RPCFuzzCases(seed int64, random int) []RPCFuzzCase {
+	methods := slices.Sorted(maps.Keys(rpcFuzzMethods))
+
+	var cases []RPCFuzzCase
+	for _, method := range methods {
+		kind := rpcFuzzMethods[method]
+		for _, param := range malformedRPCParams(kind) {
+			cases = append(cases, RPCFuzzCase{Method: method, Params: []any{param}})
+		}
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	for range random {
+		method := methods[rng.Intn(len(methods))]
+		cases = append(cases, RPCFuzzCase{Method: method, Params: []any{randomRPCParam(rng)}})
+	}
+	return cases
+}
+
+// randomRPCParam returns a random value that no fuzzed method accepts: a garbage string, a negative number or a deeply
+// nested array.
+func randomRPCParam(rng *rand.Rand) any {
+	switch rng.Intn(4) {
+	case 0:
+		buf := make([]byte, rng.Intn(4096))
+		rng.Read(buf)
+		// Raw bytes are not valid in any parameter, but must be valid UTF-8 to be sent.
+		return strings.ToValidUTF8(string(buf), "?")
+	case 1:
+		return "0x" + strconv.FormatInt(-rng.Int63()-1, 16)
+	case 2:
+		return -rng.Int63() - 1
+	default:
+		var nested any = []any{}
+		for range rng.Intn(512) + 1 {
+			nested = []any{nested}
+		}
+		return nested
+	}
+}
+
+// rpcFuzzSeed returns the seed set by RPCFuzzSeedEnvVar, or the current time.
+func rpcFuzzSeed() (int64, error) {
+	value := os.Getenv(RPCFuzzSeedEnvVar)
+	if value == "" {
+		return time.Now().UnixNano(), nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// AssertRPCFuzz sends the fuzz cases, plus `random` random ones, to the node and checks that each of them is rejected
+// with a JSON-RPC error rather than a result, a dropped connection or a timeout. The node must then still sync, so that
+// a handler that crashed or stalled a task of the node is caught.
+func AssertRPCFuzz(t devtest.T, node dsl.L2CLNode, random int) {
+	seed, err := rpcFuzzSeed()
+	t.Require().NoError(err, "invalid %s", RPCFuzzSeedEnvVar)
+	t.Logf("fuzzing the RPC of %s with seed %d", node.Escape().ID().Key(), seed)
+
+	t.Require().NoError(checkRPCFuzz(t.Ctx(), GetNodeRPCEndpoint(&node), RPCFuzzCases(seed, random)), "node %s mishandled malformed RPC params", node.Escape().ID().Key())
+
+	node.Advanced(types.LocalUnsafe, 5, 30)
+}
+
+func checkRPCFuzz(ctx context.Context, caller rpcCaller, cases []RPCFuzzCase) error {
+	var errs []error
+	for _, c := range cases {
+		_, err := rawRPCCall(ctx, caller, c.Method, c.Params)
+		if err == nil {
+			errs = append(errs, fmt.Errorf("%s: accepted", c))
+			continue
+		}
+		if kind := ClassifyRPCError(err); kind != RPCErrorResponse {
+			errs = append(errs, fmt.Errorf("%s: no JSON-RPC error (%s): %w", c, kind, err))
+			// A dropped connection or a timeout means the node is unlikely to answer the next cases.
+			break
+		}
+	}
+	return errors.Join(errs...)
+}
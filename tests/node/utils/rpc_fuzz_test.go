@@ -0,0 +1,71 @@
+package node_utils
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRPCFuzzCases(t *testing.T) {
+	require.Equal(t, RPCFuzzCases(7, 50), RPCFuzzCases(7, 50), "the cases are reproducible from the seed")
+
+	cases := RPCFuzzCases(7, 50)
+	for _, c := range cases {
+		require.Len(t, c.Params, 1, c.Method)
+		_, err := json.Marshal(c.Params)
+		require.NoError(t, err, "%s must be encodable", c)
+
+		// A boolean parameter is a valid call.
+		if rpcFuzzMethods[c.Method] == rpcParamBool {
+			require.NotContains(t, []any{true, false}, c.Params[0], c.Method)
+		}
+	}
+
+	// The fuzzed nodes are shared with other tests, which must not see them change.
+	for method := range rpcFuzzMethods {
+		require.False(t, strings.HasPrefix(method, "admin_"), "%s changes the state of the node", method)
+		require.NotRegexp(t, "block|connect|protect", method, "%s changes the peers of the node", method)
+	}
+
+	require.LessOrEqual(t, len(RPCFuzzCase{Method: "optimism_outputAtBlock", Params: []any{strings.Repeat("a", 1024)}}.String()), 100)
+}
+
+// answeringRPCCaller answers the fuzzed methods with `errs`, and any other method with a JSON-RPC error.
+type answeringRPCCaller struct {
+	errs  map[string]error
+	calls int
+}
+
+func (f *answeringRPCCaller) CallContext(ctx context.Context, result any, method string, args ...any) error {
+	f.calls++
+	if err, ok := f.errs[method]; ok {
+		return err
+	}
+	return &rpcTestError{code: -32602}
+}
+
+func TestCheckRPCFuzz(t *testing.T) {
+	ctx := context.Background()
+	cases := RPCFuzzCases(1, 10)
+
+	t.Run("all rejected", func(t *testing.T) {
+		require.NoError(t, checkRPCFuzz(ctx, &answeringRPCCaller{}, cases))
+	})
+
+	t.Run("accepted", func(t *testing.T) {
+		err := checkRPCFuzz(ctx, &answeringRPCCaller{errs: map[string]error{"optimism_safeHeadAtL1Block": nil}}, cases)
+		require.ErrorContains(t, err, "optimism_safeHeadAtL1Block(")
+		require.ErrorContains(t, err, "): accepted")
+	})
+
+	t.Run("connection dropped", func(t *testing.T) {
+		caller := &answeringRPCCaller{errs: map[string]error{"opp2p_peers": io.EOF}}
+		err := checkRPCFuzz(ctx, caller, cases)
+		require.ErrorContains(t, err, "no JSON-RPC error (connection)")
+		require.Less(t, caller.calls, len(cases), "the fuzzing stops once the connection is lost")
+	})
+}
@@ -0,0 +1,218 @@
+package node_utils
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/stack"
+	"github.com/ethereum-optimism/optimism/op-service/client"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// RPCStatsDirEnvVar is the directory the RPC stats summary is written to, e.g. the artifacts directory of a CI job. The
+// summary is only written when it is set.
+const RPCStatsDirEnvVar = "KONA_RPC_STATS_DIR"
+
+// rpcBatchMethod is the method name batches are recorded under.
+const rpcBatchMethod = "batch"
+
+// rpcLatencyBuckets are the upper bounds of the latency histogram buckets. Slower calls fall in a last, unbounded
+// bucket.
+var rpcLatencyBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	DEFAULT_TIMEOUT,
+}
+
+// rpcStats collects the calls made through the RPC clients of GetNodeRPCEndpoint, across all the tests of the package.
+var rpcStats = newRPCStats()
+
+// RPCMethodStats summarizes the calls of a method on a node. The latencies are in nanoseconds, and the percentiles are
+// the upper bounds of the histogram buckets they fall in.
+type RPCMethodStats struct {
+	Node   string            `json:"node"`
+	Method string            `json:"method"`
+	Calls  uint64            `json:"calls"`
+	Errors map[string]uint64 `json:"errors,omitempty"`
+	Mean   time.Duration     `json:"mean_ns"`
+	P50    time.Duration     `json:"p50_ns"`
+	P99    time.Duration     `json:"p99_ns"`
+	Max    time.Duration     `json:"max_ns"`
+	// Buckets counts the calls per bucket of rpcLatencyBuckets, the last one counting the calls slower than all bounds.
+	Buckets []uint64 `json:"buckets"`
+}
+
+type rpcMethodKey struct {
+	node   string
+	method string
+}
+
+type rpcMethodHistogram struct {
+	buckets []uint64
+	errors  map[RPCErrorKind]uint64
+	total   time.Duration
+	max     time.Duration
+}
+
+// RPCStats records the latency and the errors of RPC calls, per node and method.
+type RPCStats struct {
+	mu      sync.Mutex
+	methods map[rpcMethodKey]*rpcMethodHistogram
+}
+
+func newRPCStats() *RPCStats {
+	return &RPCStats{methods: make(map[rpcMethodKey]*rpcMethodHistogram)}
+}
+
+func (s *RPCStats) observe(node, method string, latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := rpcMethodKey{node: node, method: method}
+	h, ok := s.methods[key]
+	if !ok {
+		h = &rpcMethodHistogram{buckets: make([]uint64, len(rpcLatencyBuckets)+1), errors: make(map[RPCErrorKind]uint64)}
+		s.methods[key] = h
+	}
+
+	bucket, _ := slices.BinarySearch(rpcLatencyBuckets, latency)
+	h.buckets[bucket]++
+	h.total += latency
+	h.max = max(h.max, latency)
+	if err != nil {
+		h.errors[ClassifyRPCError(err)]++
+	}
+}
+
+// Summary returns the stats of every method called, sorted by node and method.
+func (s *RPCStats) Summary() []RPCMethodStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]RPCMethodStats, 0, len(s.methods))
+	for key, h := range s.methods {
+		stats := RPCMethodStats{
+			Node:    key.node,
+			Method:  key.method,
+			Max:     h.max,
+			Buckets: slices.Clone(h.buckets),
+		}
+		for _, count := range h.buckets {
+			stats.Calls += count
+		}
+		stats.Mean = h.total / time.Duration(stats.Calls)
+		stats.P50 = h.percentile(stats.Calls, 0.5)
+		stats.P99 = h.percentile(stats.Calls, 0.99)
+		if len(h.errors) > 0 {
+			stats.Errors = make(map[string]uint64, len(h.errors))
+			for kind, count := range h.errors {
+				stats.Errors[kind.String()] = count
+			}
+		}
+		out = append(out, stats)
+	}
+
+	slices.SortFunc(out, func(a, b RPCMethodStats) int {
+		return cmp.Or(cmp.Compare(a.Node, b.Node), cmp.Compare(a.Method, b.Method))
+	})
+	return out
+}
+
+// percentile returns the upper bound of the bucket the `q` quantile of the `calls` falls in, or the maximum latency for
+// the unbounded bucket.
+func (h *rpcMethodHistogram) percentile(calls uint64, q float64) time.Duration {
+	rank := uint64(math.Ceil(q * float64(calls)))
+	var seen uint64
+	for i, bound := range rpcLatencyBuckets {
+		seen += h.buckets[i]
+		if seen >= rank {
+			return bound
+		}
+	}
+	return h.max
+}
+
+// WriteSummary writes the Summary to `w` as indented JSON.
+func (s *RPCStats) WriteSummary(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s.Summary())
+}
+
+// instrumentedRPC records the latency and the outcome of the calls and batches of the wrapped client. Subscriptions are
+// long-lived and are not recorded.
+type instrumentedRPC struct {
+	client.RPC
+	node  string
+	stats *RPCStats
+}
+
+func instrumentRPC(node string, inner client.RPC, stats *RPCStats) client.RPC {
+	return &instrumentedRPC{RPC: inner, node: node, stats: stats}
+}
+
+func (r *instrumentedRPC) CallContext(ctx context.Context, result any, method string, args ...any) error {
+	start := time.Now()
+	err := r.RPC.CallContext(ctx, result, method, args...)
+	r.stats.observe(r.node, method, time.Since(start), err)
+	return err
+}
+
+func (r *instrumentedRPC) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	start := time.Now()
+	err := r.RPC.BatchCallContext(ctx, b)
+	r.stats.observe(r.node, rpcBatchMethod, time.Since(start), err)
+	return err
+}
+
+// WithRPCStats writes the summary of the RPC calls made by the tests of the package to RPCStatsDirEnvVar, once all the
+// tests have run. It is meant to be passed to presets.DoMain, and does nothing when RPCStatsDirEnvVar is not set. The
+// file is named after the test binary.
+func WithRPCStats() stack.CommonOption {
+	return stack.MakeCommon(stack.BeforeDeploy(func(orch stack.Orchestrator) {
+		dir := os.Getenv(RPCStatsDirEnvVar)
+		if dir == "" {
+			return
+		}
+		p := orch.P()
+		p.Cleanup(func() {
+			if err := writeRPCStatsFile(filepath.Join(dir, "rpc-stats."+filepath.Base(os.Args[0])+".json"), rpcStats); err != nil {
+				p.Logger().Error("failed to write the RPC stats", "err", err)
+			}
+		})
+	}))
+}
+
+func writeRPCStatsFile(path string, stats *RPCStats) error {
+	// Write to a temporary file first, so that the summary is never left truncated.
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := stats.WriteSummary(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
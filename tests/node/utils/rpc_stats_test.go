@@ -0,0 +1,75 @@
+package node_utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-service/client"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRPCStatsSummary(t *testing.T) {
+	stats := newRPCStats()
+	for range 98 {
+		stats.observe("kona", "optimism_syncStatus", 3*time.Millisecond, nil)
+	}
+	stats.observe("kona", "optimism_syncStatus", 40*time.Millisecond, io.EOF)
+	stats.observe("kona", "optimism_syncStatus", time.Minute, context.DeadlineExceeded)
+	stats.observe("kona", "opp2p_self", time.Millisecond, nil)
+	stats.observe("a-op", "opp2p_self", 0, nil)
+
+	summary := stats.Summary()
+	require.Len(t, summary, 3)
+	require.Equal(t, []string{"a-op", "kona", "kona"}, []string{summary[0].Node, summary[1].Node, summary[2].Node})
+	require.Equal(t, "opp2p_self", summary[1].Method)
+	require.Equal(t, time.Millisecond, summary[1].P99, "a bucket includes its upper bound")
+
+	syncStatus := summary[2]
+	require.Equal(t, uint64(100), syncStatus.Calls)
+	require.Equal(t, map[string]uint64{"connection": 1, "timeout": 1}, syncStatus.Errors)
+	require.Equal(t, 5*time.Millisecond, syncStatus.P50)
+	require.Equal(t, 50*time.Millisecond, syncStatus.P99)
+	require.Equal(t, time.Minute, syncStatus.Max)
+	require.Equal(t, uint64(1), syncStatus.Buckets[len(rpcLatencyBuckets)], "slower than all bounds")
+
+	var buf bytes.Buffer
+	require.NoError(t, stats.WriteSummary(&buf))
+	var decoded []RPCMethodStats
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	require.Equal(t, summary, decoded)
+}
+
+// fakeClientRPC answers the calls with `err`. The other client.RPC methods are not implemented.
+type fakeClientRPC struct {
+	client.RPC
+	err error
+}
+
+func (f *fakeClientRPC) CallContext(ctx context.Context, result any, method string, args ...any) error {
+	return f.err
+}
+
+func (f *fakeClientRPC) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	return f.err
+}
+
+func TestInstrumentedRPC(t *testing.T) {
+	stats := newRPCStats()
+	failure := errors.New("boom")
+	instrumented := instrumentRPC("kona", &fakeClientRPC{err: failure}, stats)
+
+	require.ErrorIs(t, instrumented.CallContext(context.Background(), nil, "admin_sequencerActive"), failure)
+	require.ErrorIs(t, instrumented.BatchCallContext(context.Background(), nil), failure)
+
+	summary := stats.Summary()
+	require.Len(t, summary, 2)
+	require.Equal(t, "admin_sequencerActive", summary[0].Method)
+	require.Equal(t, rpcBatchMethod, summary[1].Method)
+	require.Equal(t, map[string]uint64{"unknown": 1}, summary[0].Errors)
+}
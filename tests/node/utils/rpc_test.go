@@ -0,0 +1,253 @@
+package node_utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"slices"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/ethereum-optimism/optimism/op-service/retry"
+	"github.com/ethereum-optimism/optimism/op-service/testutils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// rpcTestError is a JSON-RPC error with a code, like the errors returned by the RPC client.
+type rpcTestError struct {
+	code int
+}
+
+func (e *rpcTestError) Error() string {
+	return fmt.Sprintf("rpc error %d", e.code)
+}
+
+func (e *rpcTestError) ErrorCode() int {
+	return e.code
+}
+
+// fakeRPCCaller serves the given methods, which reject the missing arguments as invalid params.
+type fakeRPCCaller struct {
+	methods []string
+}
+
+func (f *fakeRPCCaller) CallContext(ctx context.Context, result any, method string, args ...any) error {
+	if slices.Contains(f.methods, method) {
+		return &rpcTestError{code: -32602}
+	}
+	return &rpcTestError{code: methodNotFoundCode}
+}
+
+func TestRPCMethodsAvailable(t *testing.T) {
+	caller := &fakeRPCCaller{methods: []string{"opp2p_self", "opp2p_blockPeer"}}
+
+	t.Run("all methods available", func(t *testing.T) {
+		require.NoError(t, checkRPCMethodsAvailable(context.Background(), caller, []string{"opp2p_self", "opp2p_blockPeer"}))
+	})
+
+	t.Run("missing method", func(t *testing.T) {
+		err := checkRPCMethodsAvailable(context.Background(), caller, []string{"opp2p_self", "opp2p_blockPeer", "opp2p_unblockPeer"})
+		require.EqualError(t, err, "unavailable RPC methods: opp2p_unblockPeer")
+	})
+}
+
+// fakeOutput serves the output of a single block.
+type fakeOutput struct {
+	ref eth.L2BlockRef
+}
+
+func (f *fakeOutput) OutputAtBlock(ctx context.Context, blockNum uint64) (*eth.OutputResponse, error) {
+	return &eth.OutputResponse{BlockRef: f.ref}, nil
+}
+
+// fakeBlockInfo serves a single block header.
+type fakeBlockInfo struct {
+	info eth.BlockInfo
+}
+
+func (f *fakeBlockInfo) InfoByNumber(ctx context.Context, number uint64) (eth.BlockInfo, error) {
+	return f.info, nil
+}
+
+func TestOutputMatchesBlock(t *testing.T) {
+	hash := common.Hash{0x42}
+	el := &fakeBlockInfo{info: &testutils.MockBlockInfo{InfoHash: hash, InfoNum: 42, InfoTime: 1000}}
+
+	t.Run("agree", func(t *testing.T) {
+		cl := &fakeOutput{ref: eth.L2BlockRef{Hash: hash, Number: 42, Time: 1000}}
+		require.NoError(t, checkOutputMatchesBlock(context.Background(), cl, el, 42))
+	})
+
+	t.Run("stale hash", func(t *testing.T) {
+		cl := &fakeOutput{ref: eth.L2BlockRef{Hash: common.Hash{0x41}, Number: 42, Time: 1000}}
+		require.ErrorContains(t, checkOutputMatchesBlock(context.Background(), cl, el, 42), "output block hash")
+	})
+
+	t.Run("different timestamp", func(t *testing.T) {
+		cl := &fakeOutput{ref: eth.L2BlockRef{Hash: hash, Number: 42, Time: 1002}}
+		require.ErrorContains(t, checkOutputMatchesBlock(context.Background(), cl, el, 42), "output block timestamp")
+	})
+}
+
+// fakeSelfRPC answers opp2p_self with a raw JSON response.
+type fakeSelfRPC string
+
+func (f fakeSelfRPC) CallContext(ctx context.Context, result any, method string, args ...any) error {
+	if method != "opp2p_self" {
+		return &rpcTestError{code: methodNotFoundCode}
+	}
+	return json.Unmarshal([]byte(f), result)
+}
+
+func TestPeerInfoSchemaParity(t *testing.T) {
+	opSelf := fakeSelfRPC(`{"peerID":"16Uiu2","nodeID":"a1","userAgent":"optimism","protocolVersion":"","ENR":"enr:-J","addresses":[],"protocols":null,"connectedness":1,"direction":0,"protected":false,"chainID":901,"latency":0,"gossipBlocks":true,"scores":{}}`)
+
+	t.Run("same keys", func(t *testing.T) {
+		konaSelf := fakeSelfRPC(`{"peerID":"16Uiu3","nodeID":"b2","userAgent":"kona","protocolVersion":"","ENR":"enr:-K","addresses":["/ip4/127.0.0.1/tcp/9222"],"protocols":[],"connectedness":1,"direction":0,"protected":false,"chainID":901,"latency":0,"gossipBlocks":true,"scores":{}}`)
+		require.NoError(t, checkPeerInfoSchemaParity(context.Background(), opSelf, konaSelf))
+	})
+
+	t.Run("documented exception", func(t *testing.T) {
+		konaSelf := fakeSelfRPC(`{"peerID":"16Uiu3","nodeID":"b2","userAgent":"kona","protocolVersion":"","addresses":[],"protocols":[],"connectedness":1,"direction":0,"protected":false,"chainID":901,"latency":0,"gossipBlocks":true,"scores":{}}`)
+		require.NoError(t, checkPeerInfoSchemaParity(context.Background(), opSelf, konaSelf))
+	})
+
+	t.Run("different keys", func(t *testing.T) {
+		konaSelf := fakeSelfRPC(`{"peerId":"16Uiu3","nodeID":"b2","userAgent":"kona","protocolVersion":"","ENR":"enr:-K","addresses":[],"protocols":[],"connectedness":1,"direction":0,"protected":false,"chainID":901,"latency":0,"scores":{}}`)
+		require.ErrorContains(t, checkPeerInfoSchemaParity(context.Background(), opSelf, konaSelf), "keys only returned by the op-node: [gossipBlocks, peerID], keys only returned by the kona node: [peerId]")
+	})
+}
+
+// flakyRPCCaller fails the first calls with the given errors, then answers "ok".
+type flakyRPCCaller struct {
+	errs  []error
+	calls int
+}
+
+func (f *flakyRPCCaller) CallContext(ctx context.Context, result any, method string, args ...any) error {
+	f.calls++
+	if f.calls <= len(f.errs) {
+		return f.errs[f.calls-1]
+	}
+	return json.Unmarshal([]byte(`"ok"`), result)
+}
+
+func TestClassifyRPCError(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+
+	require.Equal(t, RPCErrorResponse, ClassifyRPCError(&rpcTestError{code: -32602}))
+	require.Equal(t, RPCErrorResponse, ClassifyRPCError(fmt.Errorf("wrapped: %w", &rpcTestError{code: methodNotFoundCode})))
+	require.Equal(t, RPCErrorTimeout, ClassifyRPCError(context.DeadlineExceeded))
+	require.Equal(t, RPCErrorConnection, ClassifyRPCError(refused))
+	require.Equal(t, RPCErrorConnection, ClassifyRPCError(io.EOF))
+	require.Equal(t, RPCErrorUnknown, ClassifyRPCError(errors.New("boom")))
+	require.Equal(t, "connection", RPCErrorConnection.String())
+}
+
+func TestSendRPCRequestWithRetry(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+	opts := RPCRetryOptions{Attempts: 3, Strategy: &retry.FixedStrategy{}, Timeout: time.Second}
+
+	t.Run("retries connection failures", func(t *testing.T) {
+		caller := &flakyRPCCaller{errs: []error{refused, context.DeadlineExceeded}}
+		var out string
+		require.NoError(t, SendRPCRequestWithRetry(context.Background(), caller, opts, "opp2p_self", &out))
+		require.Equal(t, "ok", out)
+		require.Equal(t, 3, caller.calls)
+	})
+
+	t.Run("gives up", func(t *testing.T) {
+		caller := &flakyRPCCaller{errs: []error{refused, refused, refused}}
+		var out string
+		err := SendRPCRequestWithRetry(context.Background(), caller, opts, "opp2p_self", &out)
+		require.ErrorContains(t, err, "opp2p_self failed after 3 attempts")
+		require.Equal(t, RPCErrorConnection, ClassifyRPCError(err))
+	})
+
+	t.Run("does not retry RPC errors", func(t *testing.T) {
+		caller := &flakyRPCCaller{errs: []error{&rpcTestError{code: -32602}}}
+		var out string
+		err := SendRPCRequestWithRetry(context.Background(), caller, opts, "opp2p_self", &out)
+		require.Equal(t, RPCErrorResponse, ClassifyRPCError(err))
+		require.Equal(t, 1, caller.calls)
+	})
+
+	t.Run("zero options use the defaults", func(t *testing.T) {
+		caller := &flakyRPCCaller{errs: []error{refused}}
+		var out string
+		require.NoError(t, SendRPCRequestWithRetry(context.Background(), caller, RPCRetryOptions{}, "opp2p_self", &out))
+		require.Equal(t, "ok", out)
+		require.Equal(t, 2, caller.calls)
+		require.Equal(t, DefaultRPCRetryOptions.Attempts, RPCRetryOptions{}.withDefaults().Attempts)
+		require.Equal(t, 7, RPCRetryOptions{Attempts: 7}.withDefaults().Attempts, "the fields that are set are kept")
+	})
+
+	t.Run("retries RPC errors on demand", func(t *testing.T) {
+		caller := &flakyRPCCaller{errs: []error{&rpcTestError{code: -32000}}}
+		retryAll := opts
+		retryAll.RetryRPCErrors = true
+		var out string
+		require.NoError(t, SendRPCRequestWithRetry(context.Background(), caller, retryAll, "opp2p_self", &out))
+		require.Equal(t, 2, caller.calls)
+	})
+}
+
+// fakeBatchCaller answers each call of a batch with the result or error registered for its method.
+type fakeBatchCaller struct {
+	results map[string]string
+	batches int
+}
+
+func (f *fakeBatchCaller) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	f.batches++
+	for i := range b {
+		result, ok := f.results[b[i].Method]
+		if !ok {
+			b[i].Error = &rpcTestError{code: methodNotFoundCode}
+			continue
+		}
+		b[i].Error = json.Unmarshal([]byte(result), b[i].Result)
+	}
+	return nil
+}
+
+func TestSendRPCBatch(t *testing.T) {
+	caller := &fakeBatchCaller{results: map[string]string{
+		"opp2p_peerStats":     `{"connected": 3}`,
+		"optimism_syncStatus": `{"unsafe_l2": {"number": 12}}`,
+		"opp2p_blockPeer":     `null`,
+	}}
+
+	var stats struct {
+		Connected uint `json:"connected"`
+	}
+	var status eth.SyncStatus
+	err := sendRPCBatch(context.Background(), caller, []RPCCall{
+		NewRPCCall("opp2p_peerStats", &stats),
+		NewRPCCall("optimism_syncStatus", &status),
+		NewRPCCall[any]("opp2p_blockPeer", nil, "peer"),
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, caller.batches)
+	require.Equal(t, uint(3), stats.Connected)
+	require.Equal(t, uint64(12), status.UnsafeL2.Number)
+
+	t.Run("failed calls", func(t *testing.T) {
+		var peers any
+		stats.Connected = 0
+		err := sendRPCBatch(context.Background(), caller, []RPCCall{
+			NewRPCCall("opp2p_peers", &peers, true),
+			NewRPCCall("opp2p_peerStats", &stats),
+		})
+		require.ErrorContains(t, err, "opp2p_peers: rpc error -32601")
+		require.Equal(t, uint(3), stats.Connected, "the other calls still decode")
+	})
+}
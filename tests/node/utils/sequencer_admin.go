@@ -0,0 +1,98 @@
+package node_utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// resetDerivationPipelineMethod resets the derivation pipeline of an op-node. Kona does not serve it.
+const resetDerivationPipelineMethod = "admin_resetDerivationPipeline"
+
+// sequencerAdmin is the subset of KonaNodeClient used to control a sequencer.
+type sequencerAdmin interface {
+	SequencerActive(ctx context.Context) (bool, error)
+	StartSequencer(ctx context.Context) error
+	StopSequencer(ctx context.Context) (common.Hash, error)
+}
+
+// StopSequencer stops the sequencer of the kona node and checks that it reports itself inactive. It returns the hash of
+// the last block the sequencer built.
+func StopSequencer(t devtest.T, node dsl.L2CLNode) common.Hash {
+	head, err := stopSequencer(t.Ctx(), NewKonaNodeClient(t, &node))
+	t.Require().NoError(err, "failed to stop the sequencer of %s", node.Escape().ID().Key())
+	return head
+}
+
+func stopSequencer(ctx context.Context, admin sequencerAdmin) (common.Hash, error) {
+	head, err := admin.StopSequencer(ctx)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("admin_stopSequencer: %w", err)
+	}
+	return head, checkSequencerActive(ctx, admin, false)
+}
+
+// StartSequencer starts the sequencer of the kona node and checks that it reports itself active.
+func StartSequencer(t devtest.T, node dsl.L2CLNode) {
+	t.Require().NoError(startSequencer(t.Ctx(), NewKonaNodeClient(t, &node)), "failed to start the sequencer of %s", node.Escape().ID().Key())
+}
+
+func startSequencer(ctx context.Context, admin sequencerAdmin) error {
+	if err := admin.StartSequencer(ctx); err != nil {
+		return fmt.Errorf("admin_startSequencer: %w", err)
+	}
+	return checkSequencerActive(ctx, admin, true)
+}
+
+// AssertSequencerActive checks that admin_sequencerActive of the kona node reports `active`.
+func AssertSequencerActive(t devtest.T, node dsl.L2CLNode, active bool) {
+	t.Require().NoError(checkSequencerActive(t.Ctx(), NewKonaNodeClient(t, &node), active), "unexpected sequencer state of %s", node.Escape().ID().Key())
+}
+
+func checkSequencerActive(ctx context.Context, admin sequencerAdmin, active bool) error {
+	got, err := admin.SequencerActive(ctx)
+	if err != nil {
+		return fmt.Errorf("admin_sequencerActive: %w", err)
+	}
+	if got != active {
+		return fmt.Errorf("sequencer active is %t, expected %t", got, active)
+	}
+	return nil
+}
+
+// WithSequencerStopped stops the sequencer of the kona node, runs `fn` with the hash of the last block it built, and
+// starts the sequencer again, even if `fn` fails the test.
+func WithSequencerStopped(t devtest.T, node dsl.L2CLNode, fn func(head common.Hash)) {
+	head := StopSequencer(t, node)
+	defer func() {
+		if err := startSequencer(t.Ctx(), NewKonaNodeClient(t, &node)); err != nil {
+			t.Errorf("failed to restart the sequencer of %s: %v", node.Escape().ID().Key(), err)
+		}
+	}()
+	fn(head)
+}
+
+// ResetDerivationPipeline resets the derivation pipeline of the node through admin_resetDerivationPipeline. Only the
+// op-node serves it, so the test is skipped on a node that does not.
+func ResetDerivationPipeline(t devtest.T, node dsl.L2CLNode) {
+	err := resetDerivationPipeline(t.Ctx(), GetNodeRPCEndpoint(&node))
+	if errors.Is(err, errMethodNotServed) {
+		t.Skipf("node %s does not serve %s", node.Escape().ID().Key(), resetDerivationPipelineMethod)
+	}
+	t.Require().NoError(err, "failed to reset the derivation pipeline of %s", node.Escape().ID().Key())
+}
+
+// errMethodNotServed is returned for a call the node answers with "method not found".
+var errMethodNotServed = errors.New("method not served")
+
+func resetDerivationPipeline(ctx context.Context, caller rpcCaller) error {
+	_, err := rawRPCCall(ctx, caller, resetDerivationPipelineMethod, nil)
+	if isMethodNotFound(err) {
+		return fmt.Errorf("%s: %w", resetDerivationPipelineMethod, errMethodNotServed)
+	}
+	return err
+}
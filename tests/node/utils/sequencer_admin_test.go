@@ -0,0 +1,73 @@
+package node_utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSequencerAdmin is a sequencer whose state only changes when `stuck` is unset.
+type fakeSequencerAdmin struct {
+	active bool
+	stuck  bool
+	err    error
+}
+
+func (f *fakeSequencerAdmin) SequencerActive(ctx context.Context) (bool, error) {
+	return f.active, nil
+}
+
+func (f *fakeSequencerAdmin) StartSequencer(ctx context.Context) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.active = f.active || !f.stuck
+	return nil
+}
+
+func (f *fakeSequencerAdmin) StopSequencer(ctx context.Context) (common.Hash, error) {
+	if f.err != nil {
+		return common.Hash{}, f.err
+	}
+	f.active = f.active && f.stuck
+	return common.Hash{7}, nil
+}
+
+func TestSequencerAdmin(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("stop and start", func(t *testing.T) {
+		admin := &fakeSequencerAdmin{active: true}
+		head, err := stopSequencer(ctx, admin)
+		require.NoError(t, err)
+		require.Equal(t, common.Hash{7}, head)
+		require.NoError(t, checkSequencerActive(ctx, admin, false))
+
+		require.NoError(t, startSequencer(ctx, admin))
+		require.NoError(t, checkSequencerActive(ctx, admin, true))
+	})
+
+	t.Run("state not changed", func(t *testing.T) {
+		admin := &fakeSequencerAdmin{active: true, stuck: true}
+		_, err := stopSequencer(ctx, admin)
+		require.EqualError(t, err, "sequencer active is true, expected false")
+	})
+
+	t.Run("call failed", func(t *testing.T) {
+		admin := &fakeSequencerAdmin{err: errors.New("sequencer already running")}
+		require.EqualError(t, startSequencer(ctx, admin), "admin_startSequencer: sequencer already running")
+	})
+}
+
+func TestResetDerivationPipeline(t *testing.T) {
+	ctx := context.Background()
+
+	require.ErrorIs(t, resetDerivationPipeline(ctx, &answeringRPCCaller{errs: map[string]error{
+		resetDerivationPipelineMethod: &rpcTestError{code: methodNotFoundCode},
+	}}), errMethodNotServed)
+	require.NoError(t, resetDerivationPipeline(ctx, &answeringRPCCaller{errs: map[string]error{resetDerivationPipelineMethod: nil}}))
+	require.NotErrorIs(t, resetDerivationPipeline(ctx, &answeringRPCCaller{}), errMethodNotServed, "only missing methods are reported as not served")
+}
@@ -0,0 +1,135 @@
+package node_utils
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-node/rollup"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+)
+
+// smokeAdvanceWindow is how long the unsafe heads are given to advance during the smoke check.
+const smokeAdvanceWindow = 10 * time.Second
+
+// smokeRollupSource is the subset of apis.RollupClient used by the smoke check.
+type smokeRollupSource interface {
+	SyncStatus(ctx context.Context) (*eth.SyncStatus, error)
+	RollupConfig(ctx context.Context) (*rollup.Config, error)
+}
+
+// smokeNode is a node under smoke check.
+type smokeNode struct {
+	name   string
+	rollup smokeRollupSource
+	peers  peerDumpSource
+}
+
+// SmokeCheck runs the cheapest invariants over all the nodes of the preset: heads ordering, peer connectivity,
+// rollup config equality and unsafe head advancement over a short window. All the failures are aggregated into a
+// single report, so that it can gate expensive tests right after the network boots.
+func SmokeCheck(t devtest.T, out *MixedOpKonaPreset) {
+	clNodes := out.L2CLNodes()
+	nodes := make([]smokeNode, 0, len(clNodes))
+	for _, node := range clNodes {
+		nodes = append(nodes, smokeNode{
+			name:   node.Escape().ID().Key(),
+			rollup: node.Escape().RollupAPI(),
+			peers:  node.Escape().P2PAPI(),
+		})
+	}
+
+	t.Require().NoError(checkSmoke(t.Ctx(), nodes, smokeAdvanceWindow))
+}
+
+func checkSmoke(ctx context.Context, nodes []smokeNode, window time.Duration) error {
+	var failures []string
+	fail := func(node smokeNode, check string, err error) {
+		failures = append(failures, fmt.Sprintf("%s: %s: %v", node.name, check, err))
+	}
+
+	var refConfig *rollup.Config
+	unsafeHeads := make(map[string]uint64, len(nodes))
+	for _, node := range nodes {
+		status, err := node.rollup.SyncStatus(ctx)
+		if err != nil {
+			fail(node, "sync status", err)
+		} else {
+			if err := checkHeadsOrdering(status); err != nil {
+				fail(node, "heads ordering", err)
+			}
+			unsafeHeads[node.name] = status.UnsafeL2.Number
+		}
+
+		if len(nodes) > 1 {
+			dump, err := node.peers.Peers(ctx, true)
+			if err != nil {
+				fail(node, "peer connectivity", err)
+			} else if len(dump.Peers) == 0 {
+				fail(node, "peer connectivity", fmt.Errorf("no connected peers"))
+			}
+		}
+
+		config, err := node.rollup.RollupConfig(ctx)
+		switch {
+		case err != nil:
+			fail(node, "rollup config", err)
+		case refConfig == nil:
+			refConfig = config
+		case !reflect.DeepEqual(refConfig, config):
+			fail(node, "rollup config", fmt.Errorf("differs from the rollup config of %s", nodes[0].name))
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(window):
+	}
+
+	for _, node := range nodes {
+		before, ok := unsafeHeads[node.name]
+		if !ok {
+			continue
+		}
+		status, err := node.rollup.SyncStatus(ctx)
+		if err != nil {
+			fail(node, "unsafe head advancement", err)
+		} else if status.UnsafeL2.Number <= before {
+			fail(node, "unsafe head advancement", fmt.Errorf("unsafe head stuck at %d over %s", before, window))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%d smoke checks failed:\n%s", len(failures), strings.Join(failures, "\n"))
+	}
+
+	return nil
+}
+
+// checkHeadsOrdering checks that the heads of a node are ordered from the most to the least safe.
+func checkHeadsOrdering(status *eth.SyncStatus) error {
+	heads := []struct {
+		name   string
+		number uint64
+	}{
+		{"finalized", status.FinalizedL2.Number},
+		{"safe", status.SafeL2.Number},
+		{"local-safe", status.LocalSafeL2.Number},
+		{"unsafe", status.UnsafeL2.Number},
+	}
+	for i := 1; i < len(heads); i++ {
+		if heads[i-1].number > heads[i].number {
+			return fmt.Errorf("%s head %d is ahead of %s head %d", heads[i-1].name, heads[i-1].number, heads[i].name, heads[i].number)
+		}
+	}
+
+	if status.CrossUnsafeL2.Number < status.SafeL2.Number || status.CrossUnsafeL2.Number > status.UnsafeL2.Number {
+		return fmt.Errorf("cross-unsafe head %d is not between the safe head %d and the unsafe head %d", status.CrossUnsafeL2.Number, status.SafeL2.Number, status.UnsafeL2.Number)
+	}
+
+	return nil
+}
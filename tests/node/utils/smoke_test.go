@@ -0,0 +1,83 @@
+package node_utils
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-node/rollup"
+	"github.com/ethereum-optimism/optimism/op-service/apis"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSmokeRollup serves a rollup config and sync statuses whose unsafe head advances by `step` at each call.
+type fakeSmokeRollup struct {
+	status eth.SyncStatus
+	step   uint64
+	config *rollup.Config
+}
+
+func (f *fakeSmokeRollup) SyncStatus(ctx context.Context) (*eth.SyncStatus, error) {
+	status := f.status
+	f.status.UnsafeL2.Number += f.step
+	return &status, nil
+}
+
+func (f *fakeSmokeRollup) RollupConfig(ctx context.Context) (*rollup.Config, error) {
+	return f.config, nil
+}
+
+func TestSmokeCheck(t *testing.T) {
+	healthyStatus := eth.SyncStatus{
+		FinalizedL2:   eth.L2BlockRef{Number: 10},
+		SafeL2:        eth.L2BlockRef{Number: 20},
+		LocalSafeL2:   eth.L2BlockRef{Number: 20},
+		CrossUnsafeL2: eth.L2BlockRef{Number: 28},
+		UnsafeL2:      eth.L2BlockRef{Number: 30},
+	}
+	config := &rollup.Config{L2ChainID: big.NewInt(901)}
+	connected := &fakePeerDumps{dump: &apis.PeerDump{Peers: map[string]*apis.PeerInfo{"peer": {PeerID: peer.ID("peer")}}}}
+
+	healthyNode := func(name string) smokeNode {
+		return smokeNode{name: name, rollup: &fakeSmokeRollup{status: healthyStatus, step: 1, config: config}, peers: connected}
+	}
+
+	t.Run("all invariants hold", func(t *testing.T) {
+		require.NoError(t, checkSmoke(context.Background(), []smokeNode{healthyNode("node-a"), healthyNode("node-b")}, time.Millisecond))
+	})
+
+	t.Run("diverging rollup config", func(t *testing.T) {
+		diverging := healthyNode("node-b")
+		diverging.rollup = &fakeSmokeRollup{status: healthyStatus, step: 1, config: &rollup.Config{L2ChainID: big.NewInt(902)}}
+
+		err := checkSmoke(context.Background(), []smokeNode{healthyNode("node-a"), diverging}, time.Millisecond)
+		require.ErrorContains(t, err, "1 smoke checks failed")
+		require.ErrorContains(t, err, "node-b: rollup config: differs from the rollup config of node-a")
+	})
+
+	t.Run("stalled and isolated node", func(t *testing.T) {
+		stalled := smokeNode{
+			name:   "node-b",
+			rollup: &fakeSmokeRollup{status: healthyStatus, config: config},
+			peers:  &fakePeerDumps{dump: &apis.PeerDump{}},
+		}
+
+		err := checkSmoke(context.Background(), []smokeNode{healthyNode("node-a"), stalled}, time.Millisecond)
+		require.ErrorContains(t, err, "2 smoke checks failed")
+		require.ErrorContains(t, err, "node-b: peer connectivity: no connected peers")
+		require.ErrorContains(t, err, "node-b: unsafe head advancement: unsafe head stuck at 30")
+	})
+
+	t.Run("misordered heads", func(t *testing.T) {
+		misordered := healthyNode("node-b")
+		status := healthyStatus
+		status.FinalizedL2.Number = 25
+		misordered.rollup = &fakeSmokeRollup{status: status, step: 1, config: config}
+
+		err := checkSmoke(context.Background(), []smokeNode{healthyNode("node-a"), misordered}, time.Millisecond)
+		require.ErrorContains(t, err, "node-b: heads ordering: finalized head 25 is ahead of safe head 20")
+	})
+}
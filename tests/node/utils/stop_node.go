@@ -0,0 +1,63 @@
+package node_utils
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/ethereum-optimism/optimism/op-devstack/stack"
+)
+
+// StopNodeForTest takes the CL node `id` and its paired EL out of the preset for the rest of the test: both are stopped
+// and dropped from the node slices of the preset, so that the helpers iterating over the nodes of the preset no longer
+// see them. The removal is temporary, not a permanent node loss: the nodes stay registered in the orchestrator, which is
+// shared by all the tests of the package and has no way to forget a node, and both are started again when the test
+// ends, so that the presets of later tests hydrate live nodes.
+func (m *MixedOpKonaPreset) StopNodeForTest(t devtest.T, id stack.L2CLNodeID) {
+	cl, el, err := m.removeNode(id.Key())
+	t.Require().NoError(err)
+
+	m.ControlPlane.L2CLNodeState(cl.Escape().ID(), stack.Stop)
+	if el != nil {
+		m.ControlPlane.L2ELNodeState(el.Escape().ID(), stack.Stop)
+	}
+	t.Cleanup(func() {
+		if el != nil {
+			m.ControlPlane.L2ELNodeState(el.Escape().ID(), stack.Start)
+		}
+		m.ControlPlane.L2CLNodeState(cl.Escape().ID(), stack.Start)
+		t.Logf("restored node %s", id.Key())
+	})
+	t.Logf("stopped node %s until the end of the test", id.Key())
+}
+
+// removeNode drops the CL node with the given key and its paired EL, if any, from the node slices of the preset, and
+// returns them.
+func (m *MixedOpKonaPreset) removeNode(key string) (*dsl.L2CLNode, *dsl.L2ELNode, error) {
+	var cl *dsl.L2CLNode
+	for _, nodes := range []*[]dsl.L2CLNode{&m.L2CLOpSequencerNodes, &m.L2CLKonaSequencerNodes, &m.L2CLOpValidatorNodes, &m.L2CLKonaValidatorNodes} {
+		i := slices.IndexFunc(*nodes, func(node dsl.L2CLNode) bool { return node.Escape().ID().Key() == key })
+		if i >= 0 {
+			node := (*nodes)[i]
+			cl = &node
+			*nodes = slices.Delete(slices.Clone(*nodes), i, i+1)
+			break
+		}
+	}
+	if cl == nil {
+		return nil, nil, fmt.Errorf("node %s is not part of the preset", key)
+	}
+
+	elKey := pairedELKey(key)
+	for _, nodes := range []*[]dsl.L2ELNode{&m.L2ELOpSequencerNodes, &m.L2ELKonaSequencerNodes, &m.L2ELOpValidatorNodes, &m.L2ELKonaValidatorNodes} {
+		i := slices.IndexFunc(*nodes, func(node dsl.L2ELNode) bool { return node.Escape().ID().Key() == elKey })
+		if i >= 0 {
+			el := (*nodes)[i]
+			*nodes = slices.Delete(slices.Clone(*nodes), i, i+1)
+			return cl, &el, nil
+		}
+	}
+
+	return cl, nil, nil
+}
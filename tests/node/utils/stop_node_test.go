@@ -0,0 +1,50 @@
+package node_utils
+
+import (
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStopNodeForTest(gt *testing.T) {
+	t := devtest.SerialT(gt)
+
+	newPreset := func() *MixedOpKonaPreset {
+		return &MixedOpKonaPreset{
+			L2CLKonaSequencerNodes: fakeCLNodes(t, "cl-geth-kona-sequencer-0"),
+			L2ELKonaSequencerNodes: fakeELNodes(t, "el-geth-kona-sequencer-0"),
+			L2CLKonaValidatorNodes: fakeCLNodes(t, "cl-geth-kona-validator-0", "cl-reth-kona-validator-0"),
+			L2ELKonaValidatorNodes: fakeELNodes(t, "el-geth-kona-validator-0"),
+		}
+	}
+
+	t.Run("removes the pair", func(t devtest.T) {
+		preset := newPreset()
+		validators := preset.L2CLKonaValidatorNodes
+
+		cl, el, err := preset.removeNode("cl-geth-kona-validator-0")
+		require.NoError(t, err)
+		require.Equal(t, "cl-geth-kona-validator-0", cl.Escape().ID().Key())
+		require.Equal(t, "el-geth-kona-validator-0", el.Escape().ID().Key())
+
+		require.Equal(t, []string{"cl-reth-kona-validator-0"}, clNodeKeys(preset.L2CLKonaValidatorNodes))
+		require.Empty(t, preset.L2ELKonaValidatorNodes)
+		require.Len(t, preset.L2CLNodes(), 2)
+		require.Equal(t, "cl-geth-kona-validator-0", validators[0].Escape().ID().Key(), "slices held by the caller should not be modified")
+	})
+
+	t.Run("node without EL", func(t devtest.T) {
+		preset := newPreset()
+		cl, el, err := preset.removeNode("cl-reth-kona-validator-0")
+		require.NoError(t, err)
+		require.NotNil(t, cl)
+		require.Nil(t, el)
+		require.Len(t, preset.L2ELNodes(), 2)
+	})
+
+	t.Run("unknown node", func(t devtest.T) {
+		_, _, err := newPreset().removeNode("cl-geth-op-validator-0")
+		require.ErrorContains(t, err, "not part of the preset")
+	})
+}
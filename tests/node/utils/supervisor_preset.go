@@ -0,0 +1,71 @@
+package node_utils
+
+import (
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/ethereum-optimism/optimism/op-devstack/presets"
+	"github.com/ethereum-optimism/optimism/op-devstack/shim"
+	"github.com/ethereum-optimism/optimism/op-devstack/stack"
+	"github.com/ethereum-optimism/optimism/op-devstack/stack/match"
+	"github.com/ethereum-optimism/optimism/op-devstack/sysgo"
+)
+
+// MixedOpKonaWithSupervisorPreset is a MixedOpKona network whose CL nodes, op-nodes and kona-nodes alike, run in
+// indexing mode and are managed by a supervisor.
+type MixedOpKonaWithSupervisorPreset struct {
+	*MixedOpKonaPreset
+
+	Supervisor *dsl.Supervisor
+}
+
+// DefaultMixedOpKonaWithSupervisorIDs are the IDs of a MixedOpKona system managed by a supervisor.
+type DefaultMixedOpKonaWithSupervisorIDs struct {
+	DefaultMixedOpKonaSystemIDs DefaultMixedOpKonaSystemIDs
+	Supervisor                  stack.SupervisorID
+	Cluster                     stack.ClusterID
+}
+
+// WithMixedOpKonaSupervisor builds the network described by `l2NodeConfig` with a supervisor managing all its CL nodes.
+// Only a single chain is supported.
+func WithMixedOpKonaSupervisor(l2NodeConfig L2NodeConfig) stack.CommonOption {
+	return stack.MakeCommon(DefaultMixedOpKonaWithSupervisorSystem(&DefaultMixedOpKonaWithSupervisorIDs{}, l2NodeConfig))
+}
+
+func NewMixedOpKonaWithSupervisor(t devtest.T) *MixedOpKonaWithSupervisorPreset {
+	system := shim.NewSystem(t)
+	orch := presets.Orchestrator()
+	orch.Hydrate(system)
+
+	t.Gate().Equal(len(system.Supervisors()), 1, "expected exactly one supervisor")
+
+	return &MixedOpKonaWithSupervisorPreset{
+		MixedOpKonaPreset: NewMixedOpKona(t),
+		Supervisor:        dsl.NewSupervisor(system.Supervisor(match.Assume(t, match.FirstSupervisor)), orch.ControlPlane()),
+	}
+}
+
+func DefaultMixedOpKonaWithSupervisorSystem(dest *DefaultMixedOpKonaWithSupervisorIDs, l2NodeConfig L2NodeConfig) stack.CombinedOption[*sysgo.Orchestrator] {
+	supervisor := supervisorIDs{
+		Supervisor: "1-primary",
+		Cluster:    "main",
+	}
+
+	// The supervisor can only manage CL nodes serving the interop RPC, which is only enabled in indexing mode.
+	ids, _ := singleChain(l2NodeConfig)
+	for _, cl := range ids.L2CLNodes() {
+		l2NodeConfig = l2NodeConfig.WithL2CLOverride(cl.Key(), sysgo.L2CLIndexing())
+	}
+	ids, nodes := singleChain(l2NodeConfig)
+
+	opt := mixedOpKonaSystem([]DefaultMixedOpKonaSystemIDs{ids}, []stack.Option[*sysgo.Orchestrator]{nodes}, &supervisor, !l2NodeConfig.NoBatcher)
+
+	opt.Add(stack.Finally(func(orch *sysgo.Orchestrator) {
+		*dest = DefaultMixedOpKonaWithSupervisorIDs{
+			DefaultMixedOpKonaSystemIDs: ids,
+			Supervisor:                  supervisor.Supervisor,
+			Cluster:                     supervisor.Cluster,
+		}
+	}))
+
+	return opt
+}
@@ -0,0 +1,268 @@
+package node_utils
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/ethereum-optimism/optimism/op-supervisor/supervisor/types"
+)
+
+// consolidationDelta is the number of blocks a node's cross-unsafe head may lag behind the reference's.
+const consolidationDelta = 3
+
+// syncPollInterval is how often the heads of the nodes are polled by the helpers that wait on them.
+const syncPollInterval = 2 * time.Second
+
+// implAgreementDelta is the number of blocks two implementations' heads may diverge by at any given time.
+const implAgreementDelta = 3
+
+// syncedL1Delta is the number of L1 blocks the derivation of a synced node may trail the L1 head by, while it processes
+// the newest ones.
+const syncedL1Delta = 2
+
+// syncStatusSource is the subset of *dsl.L2CLNode used to read the sync status of a node.
+type syncStatusSource interface {
+	SyncStatus() *eth.SyncStatus
+}
+
+// headSource is the subset of *dsl.L2CLNode used to read the heads of a node.
+type headSource interface {
+	HeadBlockRef(lvl types.SafetyLevel) eth.L2BlockRef
+}
+
+// AssertCrossUnsafeConsolidates waits, up to `timeout`, until the cross-unsafe head of `node` is within a few blocks of
+// the cross-unsafe head of `reference`. Used after a reconnection, it checks that the node resumes consolidating the
+// unsafe blocks it receives, and not only that its unsafe and safe heads catch up.
+func AssertCrossUnsafeConsolidates(t devtest.T, node, reference dsl.L2CLNode, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(t.Ctx(), timeout)
+	defer cancel()
+
+	t.Require().NoError(waitForCrossUnsafeConsolidation(ctx, &node, &reference, consolidationDelta, syncPollInterval), "node %s did not consolidate with %s", node.Escape().ID().Key(), reference.Escape().ID().Key())
+}
+
+func waitForCrossUnsafeConsolidation(ctx context.Context, node, reference headSource, delta uint64, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		head := node.HeadBlockRef(types.CrossUnsafe)
+		ref := reference.HeadBlockRef(types.CrossUnsafe)
+		if head.Number+delta >= ref.Number {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("cross-unsafe head %d is more than %d blocks behind the reference %d", head.Number, delta, ref.Number)
+		case <-ticker.C:
+		}
+	}
+}
+
+// AssertImplAgreementSteadyState samples, over `duration`, the heads of the kona node and of the op-node at the given
+// safety level, and checks that they never diverge by more than a few blocks. It logs the maximum divergence observed.
+// Unlike a single snapshot, this guards against the two implementations slowly drifting apart.
+func AssertImplAgreementSteadyState(t devtest.T, konaNode, opNode dsl.L2CLNode, level types.SafetyLevel, duration time.Duration) {
+	ctx, cancel := context.WithTimeout(t.Ctx(), duration)
+	defer cancel()
+
+	maxDivergence, err := checkImplAgreement(ctx, &konaNode, &opNode, level, implAgreementDelta, syncPollInterval)
+	t.Logf("maximum %s divergence between %s and %s: %d blocks", level, konaNode.Escape().ID().Key(), opNode.Escape().ID().Key(), maxDivergence)
+	t.Require().NoError(err)
+}
+
+// checkImplAgreement samples both heads until the context is done and returns the maximum divergence observed. It
+// returns an error as soon as the divergence exceeds `delta`.
+func checkImplAgreement(ctx context.Context, a, b headSource, level types.SafetyLevel, delta uint64, interval time.Duration) (uint64, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var maxDivergence uint64
+	for {
+		headA := a.HeadBlockRef(level).Number
+		headB := b.HeadBlockRef(level).Number
+
+		divergence := max(headA, headB) - min(headA, headB)
+		maxDivergence = max(maxDivergence, divergence)
+		if divergence > delta {
+			return maxDivergence, fmt.Errorf("%s heads diverged by %d blocks (%d vs %d), more than %d", level, divergence, headA, headB, delta)
+		}
+
+		select {
+		case <-ctx.Done():
+			return maxDivergence, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// AssertLateJoinerSyncs connects `newNode`, a node that was just started, to `bootstrapPeer` and waits, up to
+// `timeout`, until it reaches `targetHead` purely through sync. This validates the cold-start sync of late-joining
+// nodes. The block the node holds at the height of `targetHead` must be `targetHead` itself.
+func AssertLateJoinerSyncs(t devtest.T, newNode dsl.L2CLNode, bootstrapPeer dsl.L2CLNode, targetHead eth.BlockID, timeout time.Duration) {
+	newNode.ConnectPeer(&bootstrapPeer)
+
+	ctx, cancel := context.WithTimeout(t.Ctx(), timeout)
+	defer cancel()
+
+	blockAt := func(ctx context.Context, number uint64) (eth.BlockID, error) {
+		output, err := newNode.Escape().RollupAPI().OutputAtBlock(ctx, number)
+		if err != nil {
+			return eth.BlockID{}, err
+		}
+		return output.BlockRef.ID(), nil
+	}
+
+	t.Require().NoError(waitForLateJoinerSync(ctx, &newNode, blockAt, targetHead, syncPollInterval), "late joiner %s did not sync from %s", newNode.Escape().ID().Key(), bootstrapPeer.Escape().ID().Key())
+}
+
+func waitForLateJoinerSync(ctx context.Context, node headSource, blockAt func(ctx context.Context, number uint64) (eth.BlockID, error), target eth.BlockID, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		head := node.HeadBlockRef(types.LocalUnsafe)
+		if head.Number >= target.Number {
+			block, err := blockAt(ctx, target.Number)
+			if err != nil {
+				return fmt.Errorf("failed to get block %d: %w", target.Number, err)
+			}
+			if block != target {
+				return fmt.Errorf("synced block %s instead of the target %s", block, target)
+			}
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("unsafe head %d did not reach the target %s", head.Number, target)
+		case <-ticker.C:
+		}
+	}
+}
+
+// AssertNotSyncing reads the sync status of the node and checks that it is caught up: its EL is done syncing, and the
+// derivation pipeline is at the L1 head. Tests should gate their assertions on it once the node has matched the
+// sequencer, rather than on its head having advanced once.
+func AssertNotSyncing(t devtest.T, node dsl.L2CLNode) {
+	t.Require().NoError(checkNotSyncing(&node), "node %s is still syncing", node.Escape().ID().Key())
+}
+
+func checkNotSyncing(node syncStatusSource) error {
+	status := node.SyncStatus()
+
+	// The unsafe head stays at genesis until the EL sync completes.
+	if status.UnsafeL2.Number == 0 {
+		return fmt.Errorf("unsafe head is at genesis, the EL is still syncing")
+	}
+	if status.CurrentL1.Number+syncedL1Delta < status.HeadL1.Number {
+		return fmt.Errorf("derivation is at L1 block %d, %d blocks behind the L1 head %d", status.CurrentL1.Number, status.HeadL1.Number-status.CurrentL1.Number, status.HeadL1.Number)
+	}
+	if status.PendingSafeL2.Number > status.UnsafeL2.Number {
+		return fmt.Errorf("pending-safe head %d is ahead of the unsafe head %d, the node is still consolidating", status.PendingSafeL2.Number, status.UnsafeL2.Number)
+	}
+
+	return nil
+}
+
+// AssertFinalizationLagBounded samples the sync status of the node over `duration` and checks that the gap between its
+// unsafe and finalized heads never exceeds `maxLagBlocks`. It logs the peak lag observed. Under sustained transaction
+// load, this detects finalization falling behind.
+func AssertFinalizationLagBounded(t devtest.T, node dsl.L2CLNode, maxLagBlocks uint64, duration time.Duration) {
+	ctx, cancel := context.WithTimeout(t.Ctx(), duration)
+	defer cancel()
+
+	peakLag, err := checkFinalizationLag(ctx, &node, maxLagBlocks, syncPollInterval)
+	t.Logf("peak finalization lag of %s: %d blocks", node.Escape().ID().Key(), peakLag)
+	t.Require().NoError(err)
+}
+
+// checkFinalizationLag samples the sync status until the context is done and returns the peak lag observed. It returns
+// an error as soon as the lag exceeds `maxLag`.
+func checkFinalizationLag(ctx context.Context, node syncStatusSource, maxLag uint64, interval time.Duration) (uint64, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var peakLag uint64
+	for {
+		status := node.SyncStatus()
+		var lag uint64
+		if status.UnsafeL2.Number > status.FinalizedL2.Number {
+			lag = status.UnsafeL2.Number - status.FinalizedL2.Number
+		}
+		peakLag = max(peakLag, lag)
+		if lag > maxLag {
+			return peakLag, fmt.Errorf("finalized head %d lags %d blocks behind the unsafe head %d, more than %d", status.FinalizedL2.Number, lag, status.UnsafeL2.Number, maxLag)
+		}
+
+		select {
+		case <-ctx.Done():
+			return peakLag, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// AssertFinalizedHashAgreement subscribes, over `window`, to the finalized head stream of every node and checks that no
+// two nodes ever finalize different blocks at the same height. The nodes must be kona nodes, which serve the stream.
+func AssertFinalizedHashAgreement(t devtest.T, nodes []dsl.L2CLNode, window time.Duration) {
+	runUntil := time.After(window)
+	done := make(chan struct{})
+	go func() {
+		<-runUntil
+		close(done)
+	}()
+
+	streams := make(map[string]<-chan eth.L2BlockRef, len(nodes))
+	for _, node := range nodes {
+		streams[node.Escape().ID().Key()] = GetKonaWsAsync(t, &node, "finalized_head", done)
+	}
+
+	t.Require().NoError(checkFinalizedHashAgreement(streams))
+}
+
+// namedBlockRef is a block reported by a named node.
+type namedBlockRef struct {
+	node string
+	ref  eth.L2BlockRef
+}
+
+// checkFinalizedHashAgreement consumes all the streams until they are closed, and returns an error describing the first
+// block height at which two nodes finalized different blocks.
+func checkFinalizedHashAgreement(streams map[string]<-chan eth.L2BlockRef) error {
+	merged := make(chan namedBlockRef)
+	var wg sync.WaitGroup
+	for name, stream := range streams {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ref := range stream {
+				merged <- namedBlockRef{node: name, ref: ref}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(merged)
+	}()
+
+	finalized := make(map[uint64]namedBlockRef)
+	var firstErr error
+	for block := range merged {
+		prev, ok := finalized[block.ref.Number]
+		if !ok {
+			finalized[block.ref.Number] = block
+			continue
+		}
+		if prev.ref.Hash != block.ref.Hash && firstErr == nil {
+			firstErr = fmt.Errorf("nodes finalized different blocks at height %d: %s on %s, %s on %s", block.ref.Number, prev.ref.Hash, prev.node, block.ref.Hash, block.node)
+		}
+	}
+
+	return firstErr
+}
@@ -0,0 +1,192 @@
+package node_utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/ethereum-optimism/optimism/op-supervisor/supervisor/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHeads serves heads that advance by `step` blocks at each call.
+type fakeHeads struct {
+	number uint64
+	step   uint64
+}
+
+func (f *fakeHeads) HeadBlockRef(lvl types.SafetyLevel) eth.L2BlockRef {
+	f.number += f.step
+	return eth.L2BlockRef{Number: f.number}
+}
+
+func TestCrossUnsafeConsolidation(t *testing.T) {
+	wait := func(node, reference *fakeHeads) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		return waitForCrossUnsafeConsolidation(ctx, node, reference, 3, time.Millisecond)
+	}
+
+	t.Run("node consolidates", func(t *testing.T) {
+		require.NoError(t, wait(&fakeHeads{number: 0, step: 2}, &fakeHeads{number: 20, step: 1}))
+	})
+
+	t.Run("node stays behind", func(t *testing.T) {
+		require.ErrorContains(t, wait(&fakeHeads{number: 0, step: 0}, &fakeHeads{number: 20, step: 1}), "blocks behind the reference")
+	})
+}
+
+func TestImplAgreement(t *testing.T) {
+	check := func(kona, op *fakeHeads) (uint64, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		return checkImplAgreement(ctx, kona, op, types.LocalSafe, 3, time.Millisecond)
+	}
+
+	t.Run("heads stay aligned", func(t *testing.T) {
+		maxDivergence, err := check(&fakeHeads{number: 10, step: 1}, &fakeHeads{number: 12, step: 1})
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), maxDivergence)
+	})
+
+	t.Run("heads drift apart", func(t *testing.T) {
+		maxDivergence, err := check(&fakeHeads{number: 10, step: 1}, &fakeHeads{number: 10, step: 2})
+		require.ErrorContains(t, err, "diverged by 4 blocks")
+		require.Equal(t, uint64(4), maxDivergence)
+	})
+}
+
+func TestLateJoinerSync(t *testing.T) {
+	target := eth.BlockID{Number: 20, Hash: common.Hash{0x20}}
+
+	wait := func(node *fakeHeads, canonical common.Hash) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		blockAt := func(ctx context.Context, number uint64) (eth.BlockID, error) {
+			return eth.BlockID{Number: number, Hash: canonical}, nil
+		}
+		return waitForLateJoinerSync(ctx, node, blockAt, target, time.Millisecond)
+	}
+
+	t.Run("node syncs on schedule", func(t *testing.T) {
+		require.NoError(t, wait(&fakeHeads{number: 0, step: 2}, target.Hash))
+	})
+
+	t.Run("node stalls", func(t *testing.T) {
+		require.ErrorContains(t, wait(&fakeHeads{number: 5, step: 0}, target.Hash), "did not reach the target")
+	})
+
+	t.Run("node syncs another chain", func(t *testing.T) {
+		require.ErrorContains(t, wait(&fakeHeads{number: 0, step: 2}, common.Hash{0xba, 0xd}), "instead of the target")
+	})
+}
+
+// fakeFinalization serves sync statuses whose finalization lag follows `lags`, then stays at the last value.
+type fakeFinalization struct {
+	lags   []uint64
+	unsafe uint64
+}
+
+func (f *fakeFinalization) SyncStatus() *eth.SyncStatus {
+	lag := f.lags[0]
+	if len(f.lags) > 1 {
+		f.lags = f.lags[1:]
+	}
+	f.unsafe += 2
+	return &eth.SyncStatus{
+		UnsafeL2:    eth.L2BlockRef{Number: f.unsafe + lag},
+		FinalizedL2: eth.L2BlockRef{Number: f.unsafe},
+	}
+}
+
+func TestFinalizationLag(t *testing.T) {
+	check := func(node *fakeFinalization) (uint64, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		return checkFinalizationLag(ctx, node, 100, time.Millisecond)
+	}
+
+	t.Run("lag bounded", func(t *testing.T) {
+		peak, err := check(&fakeFinalization{lags: []uint64{40, 80, 60, 50}})
+		require.NoError(t, err)
+		require.Equal(t, uint64(80), peak)
+	})
+
+	t.Run("lag spikes", func(t *testing.T) {
+		peak, err := check(&fakeFinalization{lags: []uint64{40, 80, 150, 50}})
+		require.ErrorContains(t, err, "lags 150 blocks behind the unsafe head")
+		require.Equal(t, uint64(150), peak)
+	})
+}
+
+// fakeSyncStatus serves a fixed sync status.
+type fakeSyncStatus eth.SyncStatus
+
+func (f *fakeSyncStatus) SyncStatus() *eth.SyncStatus {
+	return (*eth.SyncStatus)(f)
+}
+
+func TestNotSyncing(t *testing.T) {
+	synced := eth.SyncStatus{
+		CurrentL1:     eth.L1BlockRef{Number: 99},
+		HeadL1:        eth.L1BlockRef{Number: 100},
+		UnsafeL2:      eth.L2BlockRef{Number: 500},
+		PendingSafeL2: eth.L2BlockRef{Number: 480},
+		SafeL2:        eth.L2BlockRef{Number: 480},
+	}
+
+	t.Run("synced", func(t *testing.T) {
+		status := synced
+		require.NoError(t, checkNotSyncing((*fakeSyncStatus)(&status)))
+	})
+
+	t.Run("EL syncing", func(t *testing.T) {
+		status := synced
+		status.UnsafeL2 = eth.L2BlockRef{}
+		require.ErrorContains(t, checkNotSyncing((*fakeSyncStatus)(&status)), "the EL is still syncing")
+	})
+
+	t.Run("derivation behind", func(t *testing.T) {
+		status := synced
+		status.CurrentL1 = eth.L1BlockRef{Number: 60}
+		require.ErrorContains(t, checkNotSyncing((*fakeSyncStatus)(&status)), "derivation is at L1 block 60, 40 blocks behind the L1 head 100")
+	})
+
+	t.Run("consolidating", func(t *testing.T) {
+		status := synced
+		status.PendingSafeL2 = eth.L2BlockRef{Number: 520}
+		require.ErrorContains(t, checkNotSyncing((*fakeSyncStatus)(&status)), "still consolidating")
+	})
+}
+
+func TestFinalizedHashAgreement(t *testing.T) {
+	stream := func(refs ...eth.L2BlockRef) <-chan eth.L2BlockRef {
+		ch := make(chan eth.L2BlockRef, len(refs))
+		for _, ref := range refs {
+			ch <- ref
+		}
+		close(ch)
+		return ch
+	}
+	block := func(number uint64, hash byte) eth.L2BlockRef {
+		return eth.L2BlockRef{Number: number, Hash: common.Hash{hash}}
+	}
+
+	t.Run("nodes agree", func(t *testing.T) {
+		require.NoError(t, checkFinalizedHashAgreement(map[string]<-chan eth.L2BlockRef{
+			"node-a": stream(block(10, 0x10), block(12, 0x12)),
+			"node-b": stream(block(10, 0x10), block(11, 0x11), block(12, 0x12)),
+		}))
+	})
+
+	t.Run("node finalizes a different block", func(t *testing.T) {
+		err := checkFinalizedHashAgreement(map[string]<-chan eth.L2BlockRef{
+			"node-a": stream(block(10, 0x10), block(12, 0x12)),
+			"node-b": stream(block(10, 0x10), block(12, 0xff)),
+		})
+		require.ErrorContains(t, err, "nodes finalized different blocks at height 12")
+	})
+}
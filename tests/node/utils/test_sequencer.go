@@ -0,0 +1,24 @@
+package node_utils
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-service/apis"
+	"github.com/ethereum-optimism/optimism/op-test-sequencer/sequencer/seqtypes"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// AssertBuildOnMissingParentFails checks that the test sequencer refuses to start a block on top of `bogusParent`, a
+// block hash that is not on any chain. Accepting it would let tests silently build orphan chains.
+func AssertBuildOnMissingParentFails(t devtest.T, ctrl apis.TestSequencerControlAPI, bogusParent common.Hash) {
+	t.Require().NoError(checkBuildOnMissingParentFails(t.Ctx(), ctrl, bogusParent))
+}
+
+func checkBuildOnMissingParentFails(ctx context.Context, ctrl apis.TestSequencerControlAPI, bogusParent common.Hash) error {
+	if err := ctrl.New(ctx, seqtypes.BuildOpts{Parent: bogusParent}); err == nil {
+		return fmt.Errorf("test sequencer accepted to build on the missing parent %s", bogusParent)
+	}
+	return nil
+}
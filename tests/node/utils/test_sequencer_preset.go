@@ -8,7 +8,6 @@ import (
 	"github.com/ethereum-optimism/optimism/op-devstack/stack"
 	"github.com/ethereum-optimism/optimism/op-devstack/stack/match"
 	"github.com/ethereum-optimism/optimism/op-devstack/sysgo"
-	"github.com/ethereum-optimism/optimism/op-service/eth"
 )
 
 type MinimalWithTestSequencersPreset struct {
@@ -49,28 +48,31 @@ type DefaultMinimalWithTestSequencerIds struct {
 
 func NewDefaultMinimalWithTestSequencerIds(l2Config L2NodeConfig) DefaultMinimalWithTestSequencerIds {
 	return DefaultMinimalWithTestSequencerIds{
-		DefaultMixedOpKonaSystemIDs: NewDefaultMixedOpKonaSystemIDs(eth.ChainIDFromUInt64(DefaultL1ID), eth.ChainIDFromUInt64(DefaultL2ID), L2NodeConfig{
-			OpSequencerNodesWithGeth: l2Config.OpSequencerNodesWithGeth,
-			OpSequencerNodesWithReth: l2Config.OpSequencerNodesWithReth,
-			OpNodesWithGeth:          l2Config.OpNodesWithGeth,
-			OpNodesWithReth:          l2Config.OpNodesWithReth,
-			KonaNodesWithGeth:        l2Config.KonaNodesWithGeth,
-			KonaNodesWithReth:        l2Config.KonaNodesWithReth,
-		}),
-		TestSequencerId: "test-sequencer",
+		DefaultMixedOpKonaSystemIDs: singleChainIDs(testSequencerL2Config(l2Config)),
+		TestSequencerId:             "test-sequencer",
 	}
 }
 
-func DefaultMixedWithTestSequencer(dest *DefaultMinimalWithTestSequencerIds, l2Config L2NodeConfig) stack.Option[*sysgo.Orchestrator] {
-
-	opt := DefaultMixedOpKonaSystem(&dest.DefaultMixedOpKonaSystemIDs, L2NodeConfig{
+// testSequencerL2Config returns the configuration of the L2 nodes running along the test sequencer: the ones of
+// `l2Config`, without the kona sequencers.
+func testSequencerL2Config(l2Config L2NodeConfig) L2NodeConfig {
+	return L2NodeConfig{
 		OpSequencerNodesWithGeth: l2Config.OpSequencerNodesWithGeth,
 		OpSequencerNodesWithReth: l2Config.OpSequencerNodesWithReth,
 		OpNodesWithGeth:          l2Config.OpNodesWithGeth,
 		OpNodesWithReth:          l2Config.OpNodesWithReth,
 		KonaNodesWithGeth:        l2Config.KonaNodesWithGeth,
 		KonaNodesWithReth:        l2Config.KonaNodesWithReth,
-	})
+		Topology:                 l2Config.Topology,
+		L2CLOverrides:            l2Config.L2CLOverrides,
+		P2PTopology:              l2Config.P2PTopology,
+		NoBatcher:                l2Config.NoBatcher,
+	}
+}
+
+func DefaultMixedWithTestSequencer(dest *DefaultMinimalWithTestSequencerIds, l2Config L2NodeConfig) stack.Option[*sysgo.Orchestrator] {
+
+	opt := DefaultMixedOpKonaSystem(&dest.DefaultMixedOpKonaSystemIDs, testSequencerL2Config(l2Config))
 
 	ids := NewDefaultMinimalWithTestSequencerIds(l2Config)
 
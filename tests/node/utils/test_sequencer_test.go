@@ -0,0 +1,42 @@
+package node_utils
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-service/apis"
+	"github.com/ethereum-optimism/optimism/op-test-sequencer/sequencer/seqtypes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeControlAPI only accepts to build on top of the known blocks, unless it is not validating parents.
+type fakeControlAPI struct {
+	apis.TestSequencerControlAPI
+
+	known      map[common.Hash]bool
+	noValidate bool
+}
+
+func (f *fakeControlAPI) New(ctx context.Context, opts seqtypes.BuildOpts) error {
+	if !f.noValidate && !f.known[opts.Parent] {
+		return fmt.Errorf("unknown parent %s", opts.Parent)
+	}
+	return nil
+}
+
+func TestBuildOnMissingParentFails(t *testing.T) {
+	head := common.Hash{0x01}
+	bogus := common.Hash{0xde, 0xad}
+
+	t.Run("parent validated", func(t *testing.T) {
+		ctrl := &fakeControlAPI{known: map[common.Hash]bool{head: true}}
+		require.NoError(t, checkBuildOnMissingParentFails(context.Background(), ctrl, bogus))
+	})
+
+	t.Run("parent not validated", func(t *testing.T) {
+		ctrl := &fakeControlAPI{known: map[common.Hash]bool{head: true}, noValidate: true}
+		require.ErrorContains(t, checkBuildOnMissingParentFails(context.Background(), ctrl, bogus), "accepted to build on the missing parent")
+	})
+}
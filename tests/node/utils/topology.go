@@ -0,0 +1,395 @@
+package node_utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/stack"
+	"github.com/ethereum-optimism/optimism/op-devstack/sysgo"
+	"github.com/ethereum-optimism/optimism/op-node/rollup/sync"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"gopkg.in/yaml.v3"
+)
+
+// TopologyEnvVar points to a YAML or JSON topology file. When set, ParseL2NodeConfigFromEnv builds the L2 nodes from
+// it instead of the per-kind node counts.
+const TopologyEnvVar = "KONA_DEVNET_TOPOLOGY"
+
+// L2ELKind is the client of an L2 EL node.
+type L2ELKind string
+
+const (
+	GethEL       L2ELKind = "geth"
+	RethEL       L2ELKind = "reth"
+	ErigonEL     L2ELKind = "erigon"
+	NethermindEL L2ELKind = "nethermind"
+)
+
+// Sync modes of a topology node.
+const (
+	ELSyncMode = "el"
+	CLSyncMode = "cl"
+)
+
+// topologyNodeName restricts the node names to what can be used in node IDs.
+var topologyNodeName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Topology describes the L2 nodes of a MixedOpKona system one by one, along with the p2p connections between them.
+// A single chain is described by listing its nodes directly, several chains by listing them in Chains.
+type Topology struct {
+	Nodes    []TopologyNode    `yaml:"nodes,omitempty"`
+	Batchers []TopologyBatcher `yaml:"batchers,omitempty"`
+	Chains   []TopologyChain   `yaml:"chains,omitempty"`
+}
+
+// TopologyChain is an L2 chain of a Topology. Each chain gets its own proposer and faucet, driven by its first
+// sequencer, and the listed batchers, a single batcher driven by its first sequencer if none is listed. The chains are
+// deployed on the same L1, with consecutive chain IDs starting at DefaultL2ID.
+type TopologyChain struct {
+	Nodes    []TopologyNode    `yaml:"nodes"`
+	Batchers []TopologyBatcher `yaml:"batchers,omitempty"`
+}
+
+// TopologyBatcher is a batcher of a TopologyChain.
+type TopologyBatcher struct {
+	Name string `yaml:"name"`
+	// Node is the name of the sequencer the batcher reads the unsafe blocks from.
+	Node string `yaml:"node"`
+	// Standby batchers start stopped, ready to take over from an active one. All the batchers of a chain share the same
+	// key, so only one of them should be running at a time.
+	Standby bool `yaml:"standby,omitempty"`
+}
+
+// TopologyNode is an L2 node of a Topology: a CL driving its own EL.
+type TopologyNode struct {
+	// Name identifies the node in the topology and ends the IDs of its CL and EL. It can't contain any L2NodeKind, as
+	// the preset tells the nodes apart by matching the kinds in their IDs.
+	Name string     `yaml:"name"`
+	CL   L2NodeKind `yaml:"cl"`
+	EL   L2ELKind   `yaml:"el"`
+	Role L2NodeKind `yaml:"role"`
+	// SyncMode is ELSyncMode or CLSyncMode. It defaults to EL sync for kona-nodes and CL sync for op-nodes.
+	SyncMode string `yaml:"syncMode,omitempty"`
+	// Peers are the names of the nodes this node connects to, both for its CL and its EL. Connections go both ways, so
+	// they only need to be listed on one side. When no node of the topology lists any peer, all nodes are connected to
+	// each other.
+	Peers []string `yaml:"peers,omitempty"`
+}
+
+// LoadTopology reads and validates the topology file at `path`. YAML being a superset of JSON, both formats are
+// accepted. Unknown fields are rejected, so that a typo doesn't silently fall back to a default.
+func LoadTopology(path string) (*Topology, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read topology file: %w", err)
+	}
+
+	return parseTopology(data)
+}
+
+func parseTopology(data []byte) (*Topology, error) {
+	var topology Topology
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&topology); err != nil {
+		return nil, fmt.Errorf("failed to parse topology: %w", err)
+	}
+
+	if err := topology.Validate(); err != nil {
+		return nil, err
+	}
+	return &topology, nil
+}
+
+// Validate checks that the topology describes a system that can be built: known node kinds, a spawner registered for
+// each EL kind, unique names within each chain, peers that exist, and at least one sequencer per chain to drive its
+// batcher and proposer.
+func (t Topology) Validate() error {
+	if len(t.Nodes) > 0 && len(t.Chains) > 0 {
+		return errors.New("topology must list either nodes or chains, not both")
+	}
+	if len(t.Batchers) > 0 && len(t.Chains) > 0 {
+		return errors.New("the batchers of a topology listing chains must be listed in the chains")
+	}
+
+	chains := t.chains()
+	if len(chains) == 0 {
+		return errors.New("topology has no chains")
+	}
+	if len(chains) == 1 {
+		return errors.Join(chains[0].validate(), chains[0].validateSpawners())
+	}
+
+	var errs []error
+	for i, chain := range chains {
+		if err := errors.Join(chain.validate(), chain.validateSpawners()); err != nil {
+			errs = append(errs, fmt.Errorf("chain %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// chains returns the chains of the topology, a topology listing its nodes directly having a single chain.
+func (t *Topology) chains() []TopologyChain {
+	if len(t.Chains) > 0 {
+		return t.Chains
+	}
+	if len(t.Nodes) > 0 {
+		return []TopologyChain{{Nodes: t.Nodes, Batchers: t.Batchers}}
+	}
+	return nil
+}
+
+// validateSpawners checks that a spawner is registered for the EL kind of every node, so that building the chain does not
+// panic in WithL2EL. Unknown kinds are reported by validate.
+func (t TopologyChain) validateSpawners() error {
+	var errs []error
+	for _, node := range t.Nodes {
+		if !slices.Contains(L2ELKinds(), node.EL) {
+			continue
+		}
+		if _, err := l2ELSpawner(node.EL); err != nil {
+			errs = append(errs, fmt.Errorf("node %s: %w", node.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t TopologyChain) validate() error {
+	if len(t.Nodes) == 0 {
+		return errors.New("chain has no nodes")
+	}
+
+	names := make(map[string]bool, len(t.Nodes))
+	var errs []error
+	hasSequencer := false
+	for _, node := range t.Nodes {
+		if !topologyNodeName.MatchString(node.Name) {
+			errs = append(errs, fmt.Errorf("node name %q must be lowercase alphanumeric with dashes", node.Name))
+		}
+		for _, kind := range []L2NodeKind{OpNode, KonaNode, Sequencer, Validator} {
+			if strings.Contains(node.Name, string(kind)) {
+				errs = append(errs, fmt.Errorf("node name %q must not contain %q", node.Name, kind))
+			}
+		}
+		if names[node.Name] {
+			errs = append(errs, fmt.Errorf("duplicate node name %q", node.Name))
+		}
+		names[node.Name] = true
+
+		if node.CL != OpNode && node.CL != KonaNode {
+			errs = append(errs, fmt.Errorf("node %s: unknown CL kind %q", node.Name, node.CL))
+		}
+		if !slices.Contains(L2ELKinds(), node.EL) {
+			errs = append(errs, fmt.Errorf("node %s: unknown EL kind %q", node.Name, node.EL))
+		}
+		switch node.Role {
+		case Sequencer:
+			hasSequencer = true
+		case Validator:
+		default:
+			errs = append(errs, fmt.Errorf("node %s: unknown role %q", node.Name, node.Role))
+		}
+		if node.SyncMode != "" && node.SyncMode != ELSyncMode && node.SyncMode != CLSyncMode {
+			errs = append(errs, fmt.Errorf("node %s: unknown sync mode %q", node.Name, node.SyncMode))
+		}
+	}
+
+	for _, node := range t.Nodes {
+		for _, peer := range node.Peers {
+			if peer == node.Name {
+				errs = append(errs, fmt.Errorf("node %s lists itself as a peer", node.Name))
+			} else if !names[peer] {
+				errs = append(errs, fmt.Errorf("node %s: unknown peer %q", node.Name, peer))
+			}
+		}
+	}
+
+	if !hasSequencer {
+		errs = append(errs, errors.New("chain has no sequencer"))
+	}
+
+	return errors.Join(append(errs, t.validateBatchers()...)...)
+}
+
+func (t TopologyChain) validateBatchers() []error {
+	roles := make(map[string]L2NodeKind, len(t.Nodes))
+	for _, node := range t.Nodes {
+		roles[node.Name] = node.Role
+	}
+
+	var errs []error
+	names := make(map[string]bool, len(t.Batchers))
+	active := false
+	for _, batcher := range t.Batchers {
+		if !topologyNodeName.MatchString(batcher.Name) {
+			errs = append(errs, fmt.Errorf("batcher name %q must be lowercase alphanumeric with dashes", batcher.Name))
+		}
+		if strings.HasPrefix(batcher.Name, standbyBatcherPrefix) {
+			errs = append(errs, fmt.Errorf("batcher name %q must not start with %q", batcher.Name, standbyBatcherPrefix))
+		}
+		if names[batcher.Name] {
+			errs = append(errs, fmt.Errorf("duplicate batcher name %q", batcher.Name))
+		}
+		names[batcher.Name] = true
+
+		if role, ok := roles[batcher.Node]; !ok {
+			errs = append(errs, fmt.Errorf("batcher %s: unknown node %q", batcher.Name, batcher.Node))
+		} else if role != Sequencer {
+			errs = append(errs, fmt.Errorf("batcher %s: node %s is not a sequencer", batcher.Name, batcher.Node))
+		}
+		active = active || !batcher.Standby
+	}
+	if len(t.Batchers) > 0 && !active {
+		errs = append(errs, errors.New("chain has no active batcher"))
+	}
+	return errs
+}
+
+// L2NodeConfig returns a config building this topology, with the node counts summarizing its first chain. ELs other than
+// geth are counted with reth, the counts having no field for them.
+func (t *Topology) L2NodeConfig() L2NodeConfig {
+	config := L2NodeConfig{Topology: t}
+	for _, node := range t.chains()[0].Nodes {
+		el := node.EL
+		if el != GethEL {
+			el = RethEL
+		}
+		// The topology is validated, so the node kinds are known.
+		count, _ := config.counter(node.CL, node.Role, el)
+		*count++
+	}
+	return config
+}
+
+// nodeIDs returns the IDs of the CL and the EL of the node. They follow the naming of the default topology, so that the
+// preset matchers and PairedEL work the same.
+func (n TopologyNode) nodeIDs(l2ID eth.ChainID) (stack.L2CLNodeID, stack.L2ELNodeID) {
+	key := fmt.Sprintf("%s-%s-%s-%s", n.EL, n.CL, n.Role, n.Name)
+	return stack.NewL2CLNodeID("cl-"+key, l2ID), stack.NewL2ELNodeID("el-"+key, l2ID)
+}
+
+// syncMode returns the sync mode of the node, defaulting on its CL kind.
+func (n TopologyNode) syncMode() sync.Mode {
+	switch {
+	case n.SyncMode == ELSyncMode:
+		return sync.ELSync
+	case n.SyncMode == CLSyncMode:
+		return sync.CLSync
+	case n.CL == KonaNode:
+		return sync.ELSync
+	default:
+		return sync.CLSync
+	}
+}
+
+// systemIDs returns the IDs of the system of the chain, with each node in the list matching its kinds. ELs other than
+// geth go with the reth ones.
+func (t TopologyChain) systemIDs(l1ID, l2ID eth.ChainID) DefaultMixedOpKonaSystemIDs {
+	ids := NewDefaultMixedOpKonaSystemIDs(l1ID, l2ID, L2NodeConfig{})
+	for _, node := range t.Nodes {
+		cl, el := node.nodeIDs(l2ID)
+
+		var cls *[]stack.L2CLNodeID
+		var els *[]stack.L2ELNodeID
+		switch {
+		case node.CL == OpNode && node.Role == Sequencer && node.EL == GethEL:
+			cls, els = &ids.L2CLOpGethSequencerNodes, &ids.L2ELOpGethSequencerNodes
+		case node.CL == OpNode && node.Role == Sequencer:
+			cls, els = &ids.L2CLOpRethSequencerNodes, &ids.L2ELOpRethSequencerNodes
+		case node.CL == KonaNode && node.Role == Sequencer && node.EL == GethEL:
+			cls, els = &ids.L2CLKonaGethSequencerNodes, &ids.L2ELKonaGethSequencerNodes
+		case node.CL == KonaNode && node.Role == Sequencer:
+			cls, els = &ids.L2CLKonaRethSequencerNodes, &ids.L2ELKonaRethSequencerNodes
+		case node.CL == OpNode && node.EL == GethEL:
+			cls, els = &ids.L2CLOpGethNodes, &ids.L2ELOpGethNodes
+		case node.CL == OpNode:
+			cls, els = &ids.L2CLOpRethNodes, &ids.L2ELOpRethNodes
+		case node.EL == GethEL:
+			cls, els = &ids.L2CLKonaGethNodes, &ids.L2ELKonaGethNodes
+		default:
+			cls, els = &ids.L2CLKonaRethNodes, &ids.L2ELKonaRethNodes
+		}
+		*cls = append(*cls, cl)
+		*els = append(*els, el)
+	}
+
+	for _, batcher := range t.Batchers {
+		i := slices.IndexFunc(t.Nodes, func(node TopologyNode) bool { return node.Name == batcher.Node })
+		cl, el := t.Nodes[i].nodeIDs(l2ID)
+		ids.Batchers = append(ids.Batchers, BatcherIDs{
+			ID: batcherID(batcher.Name, batcher.Standby, l2ID),
+			CL: cl,
+			EL: el,
+		})
+	}
+	return ids
+}
+
+// connections returns the pairs of node indexes to connect, each pair once. When no node lists any peer, the nodes are
+// connected along `p2p`.
+func (t TopologyChain) connections(p2p P2PTopology) [][2]int {
+	index := make(map[string]int, len(t.Nodes))
+	for i, node := range t.Nodes {
+		index[node.Name] = i
+	}
+
+	var pairs [][2]int
+	seen := make(map[[2]int]bool)
+	for i, node := range t.Nodes {
+		for _, peer := range node.Peers {
+			pair := [2]int{min(i, index[peer]), max(i, index[peer])}
+			if !seen[pair] {
+				seen[pair] = true
+				pairs = append(pairs, pair)
+			}
+		}
+	}
+	if len(pairs) > 0 {
+		return pairs
+	}
+
+	return p2pConnections(p2p, len(t.Nodes))
+}
+
+// l2Nodes spawns the L2 nodes of the chain and connects them as described, falling back to the p2p topology of
+// `l2NodeConfig`. The overrides of each CL node are applied after the settings of the topology.
+func (t TopologyChain) l2Nodes(l1CL stack.L1CLNodeID, l1EL stack.L1ELNodeID, l2ID eth.ChainID, l2NodeConfig L2NodeConfig) stack.CombinedOption[*sysgo.Orchestrator] {
+	opt := stack.Combine[*sysgo.Orchestrator]()
+
+	cls := make([]stack.L2CLNodeID, len(t.Nodes))
+	els := make([]stack.L2ELNodeID, len(t.Nodes))
+	for i, node := range t.Nodes {
+		cls[i], els[i] = node.nodeIDs(l2ID)
+
+		opt.Add(WithL2EL(node.EL, els[i]))
+
+		isSequencer := node.Role == Sequencer
+		clOpts := []sysgo.L2CLOption{
+			sysgo.L2CLOptionFn(func(p devtest.P, id stack.L2CLNodeID, cfg *sysgo.L2CLConfig) {
+				cfg.IsSequencer = isSequencer
+			}),
+			L2CLSyncMode(node.syncMode()),
+		}
+		clOpts = append(clOpts, l2NodeConfig.L2CLOverrides[cls[i].Key()]...)
+
+		if node.CL == KonaNode {
+			opt.Add(sysgo.WithKonaNode(cls[i], l1CL, l1EL, els[i], clOpts...))
+		} else {
+			opt.Add(sysgo.WithOpNode(cls[i], l1CL, l1EL, els[i], clOpts...))
+		}
+	}
+
+	for _, pair := range t.connections(l2NodeConfig.P2PTopology) {
+		opt.Add(sysgo.WithL2CLP2PConnection(cls[pair[0]], cls[pair[1]]))
+		opt.Add(sysgo.WithL2ELP2PConnection(els[pair[0]], els[pair[1]]))
+	}
+
+	return opt
+}
@@ -0,0 +1,122 @@
+package node_utils
+
+import (
+	"errors"
+	"fmt"
+)
+
+// TopologyBuilder composes an L2NodeConfig node kind by node kind, e.g.
+//
+//	NewTopology().WithKonaSequencer(RethEL).WithKonaValidators(3, GethEL).WithOpValidators(2, RethEL).WithBatcher().Build()
+//
+// Only geth and reth ELs can be counted. Nodes with other ELs, or with per-node settings, are described with a Topology.
+type TopologyBuilder struct {
+	config  L2NodeConfig
+	batcher bool
+	errs    []error
+}
+
+// NewTopology returns a builder for a system without any node. The chain has no batcher unless WithBatcher is called.
+func NewTopology() *TopologyBuilder {
+	return &TopologyBuilder{}
+}
+
+// WithKonaSequencer adds a kona-node sequencer driving an EL of kind `el`.
+func (b *TopologyBuilder) WithKonaSequencer(el L2ELKind) *TopologyBuilder {
+	return b.add(KonaNode, Sequencer, el, 1)
+}
+
+// WithOpSequencer adds an op-node sequencer driving an EL of kind `el`.
+func (b *TopologyBuilder) WithOpSequencer(el L2ELKind) *TopologyBuilder {
+	return b.add(OpNode, Sequencer, el, 1)
+}
+
+// WithKonaValidators adds `n` kona-node validators, each driving an EL of kind `el`.
+func (b *TopologyBuilder) WithKonaValidators(n int, el L2ELKind) *TopologyBuilder {
+	return b.add(KonaNode, Validator, el, n)
+}
+
+// WithOpValidators adds `n` op-node validators, each driving an EL of kind `el`.
+func (b *TopologyBuilder) WithOpValidators(n int, el L2ELKind) *TopologyBuilder {
+	return b.add(OpNode, Validator, el, n)
+}
+
+// WithBatcher gives the chain a batcher, driven by its first sequencer.
+func (b *TopologyBuilder) WithBatcher() *TopologyBuilder {
+	b.batcher = true
+	return b
+}
+
+// WithP2PTopology connects the nodes along `topology` instead of a full mesh.
+func (b *TopologyBuilder) WithP2PTopology(topology P2PTopology) *TopologyBuilder {
+	b.config.P2PTopology = topology
+	return b
+}
+
+// Build returns the config of the system. It panics if a node could not be added or if there is no sequencer, as the
+// system can't be built then.
+func (b *TopologyBuilder) Build() L2NodeConfig {
+	config, err := b.build()
+	if err != nil {
+		panic(err.Error())
+	}
+	return config
+}
+
+func (b *TopologyBuilder) build() (L2NodeConfig, error) {
+	errs := b.errs
+	if b.config.OpSequencerNodes()+b.config.KonaSequencerNodes() == 0 {
+		errs = append(errs, errors.New("topology has no sequencer"))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return L2NodeConfig{}, fmt.Errorf("invalid topology: %w", err)
+	}
+
+	config := b.config
+	config.NoBatcher = !b.batcher
+	return config, nil
+}
+
+func (b *TopologyBuilder) add(cl, role L2NodeKind, el L2ELKind, n int) *TopologyBuilder {
+	if n < 0 {
+		b.errs = append(b.errs, fmt.Errorf("cannot add %d %s %ss", n, cl, role))
+		return b
+	}
+
+	count, err := b.config.counter(cl, role, el)
+	if err != nil {
+		b.errs = append(b.errs, err)
+		return b
+	}
+	*count += n
+	return b
+}
+
+// counter returns the node count of the config matching the CL kind, role and EL kind.
+func (l2NodeConfig *L2NodeConfig) counter(cl, role L2NodeKind, el L2ELKind) (*int, error) {
+	if el != GethEL && el != RethEL {
+		return nil, fmt.Errorf("%s ELs can't be counted, describe the nodes with a Topology instead", el)
+	}
+	geth := el == GethEL
+
+	switch {
+	case cl == OpNode && role == Sequencer && geth:
+		return &l2NodeConfig.OpSequencerNodesWithGeth, nil
+	case cl == OpNode && role == Sequencer:
+		return &l2NodeConfig.OpSequencerNodesWithReth, nil
+	case cl == KonaNode && role == Sequencer && geth:
+		return &l2NodeConfig.KonaSequencerNodesWithGeth, nil
+	case cl == KonaNode && role == Sequencer:
+		return &l2NodeConfig.KonaSequencerNodesWithReth, nil
+	case cl == OpNode && role == Validator && geth:
+		return &l2NodeConfig.OpNodesWithGeth, nil
+	case cl == OpNode && role == Validator:
+		return &l2NodeConfig.OpNodesWithReth, nil
+	case cl == KonaNode && role == Validator && geth:
+		return &l2NodeConfig.KonaNodesWithGeth, nil
+	case cl == KonaNode && role == Validator:
+		return &l2NodeConfig.KonaNodesWithReth, nil
+	default:
+		return nil, fmt.Errorf("unknown node kind %s %s", cl, role)
+	}
+}
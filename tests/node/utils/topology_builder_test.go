@@ -0,0 +1,41 @@
+package node_utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopologyBuilder(t *testing.T) {
+	t.Run("counts the nodes", func(t *testing.T) {
+		config := NewTopology().WithKonaSequencer(RethEL).WithKonaValidators(3, GethEL).WithOpValidators(2, RethEL).WithBatcher().Build()
+		require.Equal(t, L2NodeConfig{
+			KonaSequencerNodesWithReth: 1,
+			KonaNodesWithGeth:          3,
+			OpNodesWithReth:            2,
+		}, config)
+	})
+
+	t.Run("additions accumulate", func(t *testing.T) {
+		config := NewTopology().WithOpSequencer(GethEL).WithOpValidators(1, GethEL).WithOpValidators(2, GethEL).Build()
+		require.Equal(t, 1, config.OpSequencerNodesWithGeth)
+		require.Equal(t, 3, config.OpNodesWithGeth)
+		require.True(t, config.NoBatcher, "the batcher is opt-in")
+	})
+
+	t.Run("no sequencer", func(t *testing.T) {
+		_, err := NewTopology().WithKonaValidators(2, GethEL).WithBatcher().build()
+		require.ErrorContains(t, err, "topology has no sequencer")
+	})
+
+	t.Run("uncountable EL", func(t *testing.T) {
+		_, err := NewTopology().WithKonaSequencer(GethEL).WithOpValidators(1, ErigonEL).build()
+		require.ErrorContains(t, err, "erigon ELs can't be counted")
+		require.Panics(t, func() { NewTopology().WithKonaSequencer(ErigonEL).Build() })
+	})
+
+	t.Run("negative count", func(t *testing.T) {
+		_, err := NewTopology().WithKonaSequencer(GethEL).WithKonaValidators(-1, GethEL).build()
+		require.ErrorContains(t, err, "cannot add -1 kona validators")
+	})
+}
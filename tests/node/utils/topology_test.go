@@ -0,0 +1,233 @@
+package node_utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/stack"
+	"github.com/ethereum-optimism/optimism/op-node/rollup/sync"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/stretchr/testify/require"
+)
+
+const yamlTopology = `
+nodes:
+  - name: seq
+    cl: kona
+    el: reth
+    role: sequencer
+  - name: a
+    cl: op
+    el: geth
+    role: validator
+    syncMode: el
+    peers: [seq]
+  - name: b
+    cl: kona
+    el: geth
+    role: validator
+    peers: [seq, a]
+`
+
+func TestParseTopology(t *testing.T) {
+	t.Run("yaml", func(t *testing.T) {
+		topology, err := parseTopology([]byte(yamlTopology))
+		require.NoError(t, err)
+		require.Len(t, topology.Nodes, 3)
+		require.Equal(t, TopologyNode{Name: "a", CL: OpNode, EL: GethEL, Role: Validator, SyncMode: ELSyncMode, Peers: []string{"seq"}}, topology.Nodes[1])
+	})
+
+	t.Run("json", func(t *testing.T) {
+		topology, err := parseTopology([]byte(`{"nodes": [{"name": "seq", "cl": "op", "el": "geth", "role": "sequencer"}]}`))
+		require.NoError(t, err)
+		require.Equal(t, []TopologyNode{{Name: "seq", CL: OpNode, EL: GethEL, Role: Sequencer}}, topology.Nodes)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		_, err := parseTopology([]byte(strings.Replace(yamlTopology, "syncMode", "sync_mode", 1)))
+		require.ErrorContains(t, err, "field sync_mode not found")
+	})
+
+	invalid := []struct {
+		name     string
+		topology string
+		err      string
+	}{
+		{"no nodes", `nodes: []`, "topology has no chains"},
+		{"no sequencer", `{"nodes": [{"name": "a", "cl": "op", "el": "geth", "role": "validator"}]}`, "chain has no sequencer"},
+		{"unknown CL", strings.Replace(yamlTopology, "cl: op", "cl: magi", 1), `node a: unknown CL kind "magi"`},
+		{"unknown EL", strings.Replace(yamlTopology, "el: geth", "el: besu", 1), `node a: unknown EL kind "besu"`},
+		{"EL without spawner", strings.Replace(yamlTopology, "el: geth", "el: erigon", 1), "node a: no spawner registered for erigon ELs"},
+		{"unknown sync mode", strings.Replace(yamlTopology, "syncMode: el", "syncMode: snap", 1), `node a: unknown sync mode "snap"`},
+		{"unknown peer", strings.Replace(yamlTopology, "[seq, a]", "[seq, c]", 1), `node b: unknown peer "c"`},
+		{"self peer", strings.Replace(yamlTopology, "[seq, a]", "[seq, b]", 1), "node b lists itself as a peer"},
+		{"duplicate name", strings.Replace(yamlTopology, "name: b", "name: a", 1), `duplicate node name "a"`},
+		{"kind in name", strings.Replace(yamlTopology, "name: b", "name: backup-sequencer", 1), `must not contain "sequencer"`},
+		{"invalid name", strings.Replace(yamlTopology, "name: b", "name: B_1", 1), "must be lowercase alphanumeric"},
+	}
+	for _, tc := range invalid {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseTopology([]byte(tc.topology))
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestTopologySystem(t *testing.T) {
+	topology, err := parseTopology([]byte(yamlTopology))
+	require.NoError(t, err)
+	l2ID := eth.ChainIDFromUInt64(DefaultL2ID)
+
+	t.Run("node config summary", func(t *testing.T) {
+		config := topology.L2NodeConfig()
+		require.Same(t, topology, config.Topology)
+		require.Equal(t, 1, config.KonaSequencerNodesWithReth)
+		require.Equal(t, 1, config.OpNodesWithGeth)
+		require.Equal(t, 1, config.KonaNodesWithGeth)
+		require.Equal(t, 3, config.TotalNodes())
+	})
+
+	t.Run("system IDs", func(t *testing.T) {
+		ids := topology.chains()[0].systemIDs(eth.ChainIDFromUInt64(DefaultL1ID), l2ID)
+		require.Equal(t, []stack.L2CLNodeID{stack.NewL2CLNodeID("cl-reth-kona-sequencer-seq", l2ID)}, ids.L2CLKonaRethSequencerNodes)
+		require.Equal(t, []stack.L2ELNodeID{stack.NewL2ELNodeID("el-geth-op-validator-a", l2ID)}, ids.L2ELOpGethNodes)
+		require.Equal(t, []stack.L2CLNodeID{stack.NewL2CLNodeID("cl-geth-kona-validator-b", l2ID)}, ids.L2CLKonaGethNodes)
+		require.Equal(t, "cl-reth-kona-sequencer-seq", ids.L2CLNodes()[0].Key())
+		require.Len(t, ids.L2ELNodes(), 3)
+	})
+
+	t.Run("test sequencer IDs", func(t *testing.T) {
+		ids := NewDefaultMinimalWithTestSequencerIds(topology.L2NodeConfig()).DefaultMixedOpKonaSystemIDs
+		require.Equal(t, topology.chains()[0].systemIDs(eth.ChainIDFromUInt64(DefaultL1ID), l2ID), ids, "the test sequencer preset runs the nodes of the topology")
+		require.Same(t, topology, testSequencerL2Config(topology.L2NodeConfig()).Topology)
+	})
+
+	t.Run("sync modes", func(t *testing.T) {
+		require.Equal(t, sync.ELSync, topology.Nodes[0].syncMode())
+		require.Equal(t, sync.ELSync, topology.Nodes[1].syncMode())
+		require.Equal(t, sync.CLSync, TopologyNode{CL: OpNode}.syncMode())
+		require.Equal(t, sync.CLSync, TopologyNode{CL: KonaNode, SyncMode: CLSyncMode}.syncMode())
+	})
+
+	t.Run("explicit connections", func(t *testing.T) {
+		require.Equal(t, [][2]int{{0, 1}, {0, 2}, {1, 2}}, topology.chains()[0].connections(nil))
+
+		line := TopologyChain{Nodes: []TopologyNode{{Name: "x"}, {Name: "y", Peers: []string{"x"}}, {Name: "z", Peers: []string{"y"}}}}
+		require.Equal(t, [][2]int{{0, 1}, {1, 2}}, line.connections(nil))
+	})
+
+	t.Run("full mesh by default", func(t *testing.T) {
+		mesh := TopologyChain{Nodes: []TopologyNode{{Name: "x"}, {Name: "y"}, {Name: "z"}}}
+		require.Equal(t, [][2]int{{0, 1}, {0, 2}, {1, 2}}, mesh.connections(nil))
+		require.Equal(t, [][2]int{{0, 1}, {0, 2}}, mesh.connections(Star{}), "the p2p topology replaces the full mesh")
+	})
+}
+
+const multiChainTopology = `
+chains:
+  - nodes:
+      - {name: seq, cl: kona, el: reth, role: sequencer}
+      - {name: a, cl: op, el: geth, role: validator}
+  - nodes:
+      - {name: seq, cl: op, el: geth, role: sequencer}
+      - {name: a, cl: kona, el: reth, role: validator}
+      - {name: b, cl: kona, el: geth, role: validator}
+`
+
+func TestMultiChainTopology(t *testing.T) {
+	t.Run("parse", func(t *testing.T) {
+		topology, err := parseTopology([]byte(multiChainTopology))
+		require.NoError(t, err)
+
+		chains := topology.chains()
+		require.Len(t, chains, 2)
+		require.Len(t, chains[1].Nodes, 3)
+
+		// The node counts summarize the first chain.
+		require.Equal(t, 2, topology.L2NodeConfig().TotalNodes())
+	})
+
+	t.Run("node names are scoped to their chain", func(t *testing.T) {
+		topology, err := parseTopology([]byte(multiChainTopology))
+		require.NoError(t, err)
+
+		l1ID := eth.ChainIDFromUInt64(DefaultL1ID)
+		chainA := topology.chains()[0].systemIDs(l1ID, eth.ChainIDFromUInt64(DefaultL2ID))
+		chainB := topology.chains()[1].systemIDs(l1ID, eth.ChainIDFromUInt64(DefaultL2ID+1))
+		require.NotEqual(t, chainA.L2CLSequencerNodes()[0], chainB.L2CLSequencerNodes()[0])
+		require.NotEqual(t, chainA.L2Batcher, chainB.L2Batcher)
+		require.Equal(t, chainA.L1EL, chainB.L1EL)
+	})
+
+	t.Run("chain without sequencer", func(t *testing.T) {
+		_, err := parseTopology([]byte(strings.Replace(multiChainTopology, "{name: seq, cl: op, el: geth, role: sequencer}", "{name: c, cl: op, el: geth, role: validator}", 1)))
+		require.ErrorContains(t, err, "chain 1: chain has no sequencer")
+	})
+
+	t.Run("nodes and chains", func(t *testing.T) {
+		_, err := parseTopology([]byte(multiChainTopology + yamlTopology))
+		require.ErrorContains(t, err, "either nodes or chains")
+	})
+}
+
+const batchersTopology = `
+nodes:
+  - {name: seq, cl: kona, el: reth, role: sequencer}
+  - {name: seq-b, cl: op, el: geth, role: sequencer}
+  - {name: a, cl: op, el: geth, role: validator}
+batchers:
+  - {name: main, node: seq}
+  - {name: backup, node: seq-b, standby: true}
+`
+
+func TestTopologyBatchers(t *testing.T) {
+	t.Run("system IDs", func(t *testing.T) {
+		topology, err := parseTopology([]byte(batchersTopology))
+		require.NoError(t, err)
+
+		l2ID := eth.ChainIDFromUInt64(DefaultL2ID)
+		ids := topology.chains()[0].systemIDs(eth.ChainIDFromUInt64(DefaultL1ID), l2ID)
+		require.Equal(t, []BatcherIDs{
+			{
+				ID: stack.NewL2BatcherID("main", l2ID),
+				CL: stack.NewL2CLNodeID("cl-reth-kona-sequencer-seq", l2ID),
+				EL: stack.NewL2ELNodeID("el-reth-kona-sequencer-seq", l2ID),
+			},
+			{
+				ID: stack.NewL2BatcherID("standby-backup", l2ID),
+				CL: stack.NewL2CLNodeID("cl-geth-op-sequencer-seq-b", l2ID),
+				EL: stack.NewL2ELNodeID("el-geth-op-sequencer-seq-b", l2ID),
+			},
+		}, ids.batchers())
+	})
+
+	t.Run("default batcher", func(t *testing.T) {
+		topology, err := parseTopology([]byte(yamlTopology))
+		require.NoError(t, err)
+
+		ids := topology.chains()[0].systemIDs(eth.ChainIDFromUInt64(DefaultL1ID), eth.ChainIDFromUInt64(DefaultL2ID))
+		batchers := ids.batchers()
+		require.Len(t, batchers, 1)
+		require.Equal(t, ids.L2Batcher, batchers[0].ID)
+		require.Equal(t, ids.L2CLNodes()[0], batchers[0].CL)
+	})
+
+	invalid := []struct {
+		name     string
+		topology string
+		err      string
+	}{
+		{"unknown node", strings.Replace(batchersTopology, "node: seq-b", "node: c", 1), `batcher backup: unknown node "c"`},
+		{"validator node", strings.Replace(batchersTopology, "node: seq-b", "node: a", 1), "batcher backup: node a is not a sequencer"},
+		{"duplicate name", strings.Replace(batchersTopology, "name: backup", "name: main", 1), `duplicate batcher name "main"`},
+		{"reserved prefix", strings.Replace(batchersTopology, "name: backup", "name: standby-b", 1), `must not start with "standby-"`},
+		{"no active batcher", strings.Replace(batchersTopology, "node: seq}", "node: seq, standby: true}", 1), "chain has no active batcher"},
+		{"top-level batchers with chains", multiChainTopology + "batchers: [{name: main, node: seq}]", "must be listed in the chains"},
+	}
+	for _, tc := range invalid {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseTopology([]byte(tc.topology))
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package node_utils
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/ethereum-optimism/optimism/op-supervisor/supervisor/types"
+)
+
+// watchdogPollInterval is how often the head watched by StartHeadWatchdog is polled.
+const watchdogPollInterval = time.Second
+
+// StartHeadWatchdog watches, in the background, the head of the node at the given safety level and fails the test if
+// it does not advance within any `maxStall` window. This catches stalls that would otherwise only surface as timeouts
+// at the end of the test. The returned function stops the watchdog and waits for it to exit. The watchdog is also
+// stopped when the test ends.
+func StartHeadWatchdog(t devtest.T, node dsl.L2CLNode, level types.SafetyLevel, maxStall time.Duration) (stop func()) {
+	name := node.Escape().ID().Key()
+	rollupAPI := node.Escape().RollupAPI()
+	head := func(ctx context.Context) (uint64, error) {
+		status, err := rollupAPI.SyncStatus(ctx)
+		if err != nil {
+			return 0, err
+		}
+		return headAtLevel(status, level)
+	}
+
+	ctx, cancel := context.WithCancel(t.Ctx())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runHeadWatchdog(ctx, head, maxStall, watchdogPollInterval, func(msg string) {
+			t.Errorf("watchdog of %s %s head: %s", name, level, msg)
+		})
+	}()
+
+	var once sync.Once
+	stop = func() {
+		once.Do(func() {
+			cancel()
+			<-done
+		})
+	}
+	t.Cleanup(stop)
+	return stop
+}
+
+// runHeadWatchdog polls the head until the context is done. It calls `fail` and returns as soon as the head does not
+// advance for `maxStall`. Errors fetching the head count as no progress.
+func runHeadWatchdog(ctx context.Context, head func(ctx context.Context) (uint64, error), maxStall, interval time.Duration, fail func(msg string)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last uint64
+	lastProgress := time.Now()
+	for {
+		current, err := head(ctx)
+		if err == nil && current > last {
+			last = current
+			lastProgress = time.Now()
+		} else if stalled := time.Since(lastProgress); stalled > maxStall {
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				fail(fmt.Sprintf("head stalled at %d for %s, last error: %v", last, stalled, err))
+			} else {
+				fail(fmt.Sprintf("head stalled at %d for %s", last, stalled))
+			}
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// headAtLevel returns the number of the head of the sync status at the given safety level.
+func headAtLevel(status *eth.SyncStatus, level types.SafetyLevel) (uint64, error) {
+	switch level {
+	case types.LocalUnsafe:
+		return status.UnsafeL2.Number, nil
+	case types.CrossUnsafe:
+		return status.CrossUnsafeL2.Number, nil
+	case types.LocalSafe:
+		return status.LocalSafeL2.Number, nil
+	case types.CrossSafe:
+		return status.SafeL2.Number, nil
+	case types.Finalized:
+		return status.FinalizedL2.Number, nil
+	default:
+		return 0, fmt.Errorf("unsupported safety level %s", level)
+	}
+}
@@ -0,0 +1,46 @@
+package node_utils
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeadWatchdog(t *testing.T) {
+	// advancingUntil returns a head that advances at each call until it reaches `stallAt`.
+	advancingUntil := func(stallAt uint64) func(ctx context.Context) (uint64, error) {
+		var number atomic.Uint64
+		return func(ctx context.Context) (uint64, error) {
+			if number.Load() < stallAt {
+				return number.Add(1), nil
+			}
+			return number.Load(), nil
+		}
+	}
+
+	t.Run("fires when the head stalls mid-run", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		var failure string
+		runHeadWatchdog(ctx, advancingUntil(5), 20*time.Millisecond, time.Millisecond, func(msg string) {
+			failure = msg
+		})
+		require.Contains(t, failure, "head stalled at 5")
+		require.NoError(t, ctx.Err(), "watchdog should fire before the context is done")
+	})
+
+	t.Run("stops cleanly while the head advances", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		fired := false
+		runHeadWatchdog(ctx, advancingUntil(1_000_000), 20*time.Millisecond, time.Millisecond, func(msg string) {
+			fired = true
+		})
+		require.False(t, fired)
+	})
+}
@@ -2,7 +2,11 @@ package node_utils
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
 	"strings"
+	"time"
 
 	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
 	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
@@ -43,96 +47,315 @@ type push[Out any] struct {
 
 // ---------------------------------------------------------------------------
 
-func AsyncGetPrefixedWs[T any, Out any](t devtest.T, node *dsl.L2CLNode, prefix string, method string, runUntil <-chan T) <-chan Out {
+// ReconnectPolicy decides how a websocket subscription is redialed after its connection drops. The delay before each
+// attempt doubles from Backoff, up to MaxBackoff.
+type ReconnectPolicy struct {
+	// MaxAttempts is the number of consecutive failed attempts after which the subscription gives up. Zero retries
+	// forever, until the subscription is stopped.
+	MaxAttempts int
+	Backoff     time.Duration
+	// MaxBackoff caps the delay between two attempts. Zero leaves the delay uncapped.
+	MaxBackoff time.Duration
+}
+
+// DefaultReconnectPolicy survives a node restart of a few tens of seconds.
+var DefaultReconnectPolicy = ReconnectPolicy{MaxAttempts: 10, Backoff: 500 * time.Millisecond, MaxBackoff: 10 * time.Second}
+
+// delay returns the delay before the reconnection attempt `attempt`, starting at 1.
+func (p ReconnectPolicy) delay(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < attempt && (p.MaxBackoff == 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+// ReconnectEvent reports the progress of a subscription recovering from a dropped connection.
+type ReconnectEvent struct {
+	// Attempt is the number of the attempt, starting at 1 after each drop.
+	Attempt int
+	// Err is the error that triggered the attempt: the connection drop for the first one, the failure of the previous
+	// attempt for the others.
+	Err error
+	// Resubscribed is set once the subscription is back, on the last event of the recovery.
+	Resubscribed bool
+	// GaveUp is set when the policy runs out of attempts, on the last event of the subscription.
+	GaveUp bool
+}
+
+// WsOption configures a websocket subscription.
+type WsOption func(*wsConfig)
+
+type wsConfig struct {
+	reconnect *ReconnectPolicy
+	events    chan<- ReconnectEvent
+
+	inactivityTimeout time.Duration
+	heartbeat         time.Duration
+}
+
+// errWsStalled is returned when a subscription stops receiving pushes, or its connection stops answering pings.
+var errWsStalled = errors.New("websocket subscription stalled")
+
+// WithInactivityTimeout treats the subscription as stalled when no push is received for `timeout`, so that a stuck node
+// is caught long before the end of the subscription. A stalled subscription fails the test, unless WithReconnect is set,
+// in which case it is redialed like a dropped connection.
+func WithInactivityTimeout(timeout time.Duration) WsOption {
+	return func(cfg *wsConfig) {
+		cfg.inactivityTimeout = timeout
+	}
+}
+
+// WithHeartbeat pings the node every `interval` and treats the subscription as stalled when no pong is received for
+// twice that long. It catches nodes whose connection is alive but unresponsive, on streams that are legitimately quiet,
+// such as the finalized head.
+func WithHeartbeat(interval time.Duration) WsOption {
+	return func(cfg *wsConfig) {
+		cfg.heartbeat = interval
+	}
+}
+
+// WithReconnect redials and resubscribes whenever the connection drops, following `policy`, instead of closing the
+// output channel. Reconnect events are sent to `events`, if not nil, without blocking: they are dropped when the channel
+// is full. The channel is not closed by the subscription.
+func WithReconnect(policy ReconnectPolicy, events chan<- ReconnectEvent) WsOption {
+	return func(cfg *wsConfig) {
+		cfg.reconnect = &policy
+		cfg.events = events
+	}
+}
+
+func (cfg *wsConfig) report(event ReconnectEvent) {
+	if cfg.events == nil {
+		return
+	}
+	select {
+	case cfg.events <- event:
+	default:
+	}
+}
+
+func AsyncGetPrefixedWs[T any, Out any](t devtest.T, node *dsl.L2CLNode, prefix string, method string, runUntil <-chan T, opts ...WsOption) <-chan Out {
 	userRPC := node.Escape().UserRPC()
 	wsRPC := strings.Replace(userRPC, "http", "ws", 1)
 
+	var cfg wsConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
 	output := make(chan Out, 128)
 
 	go func() {
-		conn, _, err := websocket.DefaultDialer.DialContext(t.Ctx(), wsRPC, nil)
-		require.NoError(t, err, "dial: %v", err)
-		defer conn.Close()
 		defer close(output)
 
-		// 1. send the *_subscribe request
-		require.NoError(t, conn.WriteJSON(rpcRequest{
-			JSONRPC: "2.0",
-			ID:      1,
-			Method:  prefix + "_" + "subscribe_" + method,
-			Params:  nil,
-		}), "subscribe: %v", err)
-
-		// 2. read the ack – blocking read just once
-		var a rpcResponse
-		require.NoError(t, conn.ReadJSON(&a), "ack: %v", err)
-		t.Log("subscribed to websocket - id=", string(a.Result))
-
-		// 3. defer the unsubscribe request
-		defer func() {
-			require.NoError(t, conn.WriteJSON(rpcRequest{
-				JSONRPC: "2.0",
-				ID:      2,
-				Method:  prefix + "_unsubscribe_" + method,
-				Params:  []any{a.Result},
-			}), "unsubscribe: %v", err)
-
-			t.Log("gracefully closed websocket connection")
-		}()
-
-		// Function to handle JSON reading with error channel
-		msgChan := make(chan json.RawMessage, 1) // Buffered channel to avoid goroutine leak
-
-		go func() {
-			var msg json.RawMessage
-			defer close(msgChan)
+		attempt := 0
+		onSubscribed := func() {
+			if attempt > 0 {
+				t.Log(method, "subscriber", "resubscribed after", attempt, "attempts")
+				cfg.report(ReconnectEvent{Attempt: attempt, Resubscribed: true})
+			}
+			attempt = 0
+		}
 
-			for {
-				if err := conn.ReadJSON(&msg); err != nil {
-					t.Log("readJSON channel closed")
-					return
+		for {
+			subscribed, err := streamWs(t, wsRPC, prefix, method, runUntil, output, &cfg, onSubscribed)
+			if err == nil {
+				return
+			}
+			if cfg.reconnect == nil {
+				// Without reconnection, failing to subscribe and stalling are fatal, a dropped connection ends the stream.
+				if !subscribed {
+					require.NoError(t, err)
+				}
+				if errors.Is(err, errWsStalled) {
+					t.Errorf("%s subscriber on %s: %v", method, wsRPC, err)
 				}
+				t.Log("readJSON channel closed")
+				return
+			}
 
-				msgChan <- msg
+			attempt++
+			if cfg.reconnect.MaxAttempts > 0 && attempt > cfg.reconnect.MaxAttempts {
+				t.Log(method, "subscriber", "giving up after", attempt-1, "attempts:", err)
+				cfg.report(ReconnectEvent{Attempt: attempt - 1, Err: err, GaveUp: true})
+				return
 			}
-		}()
 
-		// 4. start a goroutine that keeps reading pushes
-		for {
+			delay := cfg.reconnect.delay(attempt)
+			t.Log(method, "subscriber", "reconnecting in", delay, "attempt", attempt, "after:", err)
+			cfg.report(ReconnectEvent{Attempt: attempt, Err: err})
+
 			select {
-			case _, ok := <-runUntil:
-				// Clean‑up if necessary, then exit
-				if ok {
-					t.Log(method, "subscriber", "stopping: runUntil condition met")
-				} else {
-					t.Log(method, "subscriber", "stopping: runUntil channel closed")
-				}
+			case <-time.After(delay):
+			case <-runUntil:
+				t.Log(method, "subscriber", "stopping: runUntil condition met while reconnecting")
 				return
 			case <-t.Ctx().Done():
-				// Clean‑up if necessary, then exit
-				t.Log("unsafe head subscriber", "stopping: context cancelled")
+				t.Log(method, "subscriber", "stopping: context cancelled while reconnecting")
 				return
-			case msg, ok := <-msgChan:
-				if !ok {
-					t.Log("readJSON channel closed")
-					return
-				}
+			}
+		}
+	}()
 
-				var p push[Out]
-				require.NoError(t, json.Unmarshal(msg, &p), "decode: %v", err)
+	return output
+}
+
+// streamWs subscribes to `method` and forwards the pushes to `output` until `runUntil` or the test context ends the
+// stream, in which case it unsubscribes and returns a nil error. It returns an error if subscribing fails or the
+// connection drops or stalls, along with whether the subscription was established.
+func streamWs[T any, Out any](t devtest.T, wsRPC string, prefix string, method string, runUntil <-chan T, output chan<- Out, cfg *wsConfig, onSubscribed func()) (bool, error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(t.Ctx(), wsRPC, nil)
+	if err != nil {
+		return false, fmt.Errorf("dial: %w", err)
+	}
+	defer conn.Close()
+
+	// 1. send the *_subscribe request
+	if err := conn.WriteJSON(rpcRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  prefix + "_" + "subscribe_" + method,
+		Params:  nil,
+	}); err != nil {
+		return false, fmt.Errorf("subscribe: %w", err)
+	}
+
+	// 2. read the ack – blocking read just once
+	var a rpcResponse
+	if err := conn.ReadJSON(&a); err != nil {
+		return false, fmt.Errorf("ack: %w", err)
+	}
+	t.Log("subscribed to websocket - id=", string(a.Result))
+	onSubscribed()
+
+	if cfg.heartbeat > 0 {
+		stop, err := keepAlive(conn, cfg.heartbeat)
+		if err != nil {
+			return true, fmt.Errorf("heartbeat: %w", err)
+		}
+		defer stop()
+	}
+
+	// The inactivity timer never fires when no timeout is set.
+	var inactive <-chan time.Time
+	var inactivity *time.Timer
+	if cfg.inactivityTimeout > 0 {
+		inactivity = time.NewTimer(cfg.inactivityTimeout)
+		defer inactivity.Stop()
+		inactive = inactivity.C
+	}
+
+	// 3. unsubscribe when the stream is stopped
+	unsubscribe := func() {
+		require.NoError(t, conn.WriteJSON(rpcRequest{
+			JSONRPC: "2.0",
+			ID:      2,
+			Method:  prefix + "_unsubscribe_" + method,
+			Params:  []any{a.Result},
+		}), "unsubscribe")
+
+		t.Log("gracefully closed websocket connection")
+	}
 
-				t.Log(wsRPC, method, "received websocket message", p.Params.Result)
-				output <- p.Params.Result
+	// Function to handle JSON reading with error channel
+	msgChan := make(chan json.RawMessage, 1) // Buffered channel to avoid goroutine leak
+	var readErr error
+
+	go func() {
+		defer close(msgChan)
+
+		for {
+			var msg json.RawMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				readErr = err
+				return
 			}
+
+			msgChan <- msg
+		}
+	}()
+
+	// 4. keep reading pushes
+	for {
+		select {
+		case _, ok := <-runUntil:
+			// Clean‑up if necessary, then exit
+			if ok {
+				t.Log(method, "subscriber", "stopping: runUntil condition met")
+			} else {
+				t.Log(method, "subscriber", "stopping: runUntil channel closed")
+			}
+			unsubscribe()
+			return true, nil
+		case <-t.Ctx().Done():
+			// Clean‑up if necessary, then exit
+			t.Log("unsafe head subscriber", "stopping: context cancelled")
+			unsubscribe()
+			return true, nil
+		case <-inactive:
+			return true, fmt.Errorf("%w: no push received for %s", errWsStalled, cfg.inactivityTimeout)
+		case msg, ok := <-msgChan:
+			if !ok {
+				if isTimeout(readErr) {
+					return true, fmt.Errorf("%w: no pong received for %s", errWsStalled, 2*cfg.heartbeat)
+				}
+				return true, fmt.Errorf("connection dropped: %w", readErr)
+			}
+			if inactivity != nil {
+				inactivity.Reset(cfg.inactivityTimeout)
+			}
+
+			var p push[Out]
+			require.NoError(t, json.Unmarshal(msg, &p), "decode")
+
+			t.Log(wsRPC, method, "received websocket message", p.Params.Result)
+			output <- p.Params.Result
 		}
+	}
+}
 
+// keepAlive pings `conn` every `interval`, and makes its reads fail once no pong was received for twice that long. The
+// returned function stops the pings.
+func keepAlive(conn *websocket.Conn, interval time.Duration) (func(), error) {
+	extend := func() error {
+		return conn.SetReadDeadline(time.Now().Add(2 * interval))
+	}
+	if err := extend(); err != nil {
+		return nil, err
+	}
+	conn.SetPongHandler(func(string) error { return extend() })
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				// Control frames can be written concurrently with the other writes. A failed ping shows up as a failed
+				// read once the deadline passes.
+				_ = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(interval))
+			case <-done:
+				return
+			}
+		}
 	}()
+	return func() { close(done) }, nil
+}
 
-	return output
+// isTimeout tells whether `err` is a read deadline passing. The websocket library doesn't keep the original error in the
+// chain, only its timeout flag.
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
 }
 
-func GetPrefixedWs[T any, Out any](t devtest.T, node *dsl.L2CLNode, prefix string, method string, runUntil <-chan T) []Out {
-	output := AsyncGetPrefixedWs[T, Out](t, node, prefix, method, runUntil)
+func GetPrefixedWs[T any, Out any](t devtest.T, node *dsl.L2CLNode, prefix string, method string, runUntil <-chan T, opts ...WsOption) []Out {
+	output := AsyncGetPrefixedWs[T, Out](t, node, prefix, method, runUntil, opts...)
 
 	results := make([]Out, 0)
 	for result := range output {
@@ -142,18 +365,18 @@ func GetPrefixedWs[T any, Out any](t devtest.T, node *dsl.L2CLNode, prefix strin
 	return results
 }
 
-func GetKonaWs[T any](t devtest.T, node *dsl.L2CLNode, method string, runUntil <-chan T) []eth.L2BlockRef {
-	return GetPrefixedWs[T, eth.L2BlockRef](t, node, "ws", method, runUntil)
+func GetKonaWs[T any](t devtest.T, node *dsl.L2CLNode, method string, runUntil <-chan T, opts ...WsOption) []eth.L2BlockRef {
+	return GetPrefixedWs[T, eth.L2BlockRef](t, node, "ws", method, runUntil, opts...)
 }
 
-func GetKonaWsAsync[T any](t devtest.T, node *dsl.L2CLNode, method string, runUntil <-chan T) <-chan eth.L2BlockRef {
-	return AsyncGetPrefixedWs[T, eth.L2BlockRef](t, node, "ws", method, runUntil)
+func GetKonaWsAsync[T any](t devtest.T, node *dsl.L2CLNode, method string, runUntil <-chan T, opts ...WsOption) <-chan eth.L2BlockRef {
+	return AsyncGetPrefixedWs[T, eth.L2BlockRef](t, node, "ws", method, runUntil, opts...)
 }
 
-func GetDevWS[T any](t devtest.T, node *dsl.L2CLNode, method string, runUntil <-chan T) []uint64 {
-	return GetPrefixedWs[T, uint64](t, node, "dev", method, runUntil)
+func GetDevWS[T any](t devtest.T, node *dsl.L2CLNode, method string, runUntil <-chan T, opts ...WsOption) []uint64 {
+	return GetPrefixedWs[T, uint64](t, node, "dev", method, runUntil, opts...)
 }
 
-func GetDevWSAsync[T any](t devtest.T, node *dsl.L2CLNode, method string, runUntil <-chan T) <-chan uint64 {
-	return AsyncGetPrefixedWs[T, uint64](t, node, "dev", method, runUntil)
+func GetDevWSAsync[T any](t devtest.T, node *dsl.L2CLNode, method string, runUntil <-chan T, opts ...WsOption) <-chan uint64 {
+	return AsyncGetPrefixedWs[T, uint64](t, node, "dev", method, runUntil, opts...)
 }
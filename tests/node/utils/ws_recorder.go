@@ -0,0 +1,154 @@
+package node_utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+)
+
+// WsRecordDirEnvVar is the directory the WsRecorder recordings are written to, e.g. the artifacts directory of a CI
+// job. It defaults to the system temporary directory.
+const WsRecordDirEnvVar = "KONA_WS_RECORD_DIR"
+
+// WsRecord is a websocket push, as received by the test.
+type WsRecord struct {
+	Time time.Time `json:"time"`
+	// Node is the ID key of the node the push was received from.
+	Node string `json:"node"`
+	// Stream is the stream the push was received on, e.g. "ws_unsafe_head".
+	Stream string          `json:"stream"`
+	Result json.RawMessage `json:"result"`
+}
+
+// WsRecorder persists the websocket pushes received during a test to a JSONL file, one WsRecord per line, for
+// post-mortem analysis. A recording is replayed with LoadWsRecording and ReplayStream.
+type WsRecorder struct {
+	mu  sync.Mutex
+	w   io.WriteCloser
+	enc *json.Encoder
+	now func() time.Time
+}
+
+// NewWsRecorder creates the recording of the test, named after it in WsRecordDirEnvVar. The recording is closed when
+// the test ends.
+func NewWsRecorder(t devtest.T) *WsRecorder {
+	dir := os.Getenv(WsRecordDirEnvVar)
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	path := filepath.Join(dir, strings.NewReplacer("/", "_", " ", "_").Replace(t.Name())+".ws.jsonl")
+
+	f, err := os.Create(path)
+	t.Require().NoError(err, "failed to create the websocket recording")
+	t.Logf("recording websocket pushes to %s", path)
+
+	r := newWsRecorder(f, time.Now)
+	t.Cleanup(func() {
+		if err := r.Close(); err != nil {
+			t.Logf("failed to close the websocket recording: %v", err)
+		}
+	})
+	return r
+}
+
+func newWsRecorder(w io.WriteCloser, now func() time.Time) *WsRecorder {
+	return &WsRecorder{w: w, enc: json.NewEncoder(w), now: now}
+}
+
+// Record appends a push of `stream` received from `node`.
+func (r *WsRecorder) Record(node string, stream string, result any) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode the push: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.enc.Encode(WsRecord{Time: r.now(), Node: node, Stream: stream, Result: data})
+}
+
+// Close closes the recording.
+func (r *WsRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.w.Close()
+}
+
+// RecordStream records every push of `in`, received from `node` on `stream`, and forwards it to the returned channel,
+// which is closed once `in` is.
+func RecordStream[Out any](t devtest.T, r *WsRecorder, node string, stream KonaWsStream[Out], in <-chan Out) <-chan Out {
+	out := make(chan Out, 128)
+	go func() {
+		defer close(out)
+		for push := range in {
+			if err := r.Record(node, stream.name(), push); err != nil {
+				t.Errorf("failed to record a %s push: %v", stream.name(), err)
+			}
+			out <- push
+		}
+	}()
+	return out
+}
+
+// LoadWsRecording reads the recording at `path`.
+func LoadWsRecording(path string) ([]WsRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the websocket recording: %w", err)
+	}
+	defer f.Close()
+
+	return readWsRecording(f)
+}
+
+func readWsRecording(r io.Reader) ([]WsRecord, error) {
+	var records []WsRecord
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var record WsRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read the websocket recording: %w", err)
+	}
+	return records, nil
+}
+
+// ReplayStream returns the pushes of `stream` received from `node` in `records`, in the order they were received, on a
+// channel that is closed after the last one. The channel can be handed to the helpers consuming live streams, e.g.
+// HeadReorgs, to re-run them deterministically on a recording.
+func ReplayStream[Out any](records []WsRecord, node string, stream KonaWsStream[Out]) (<-chan Out, error) {
+	var pushes []Out
+	for _, record := range records {
+		if record.Node != node || record.Stream != stream.name() {
+			continue
+		}
+		var push Out
+		if err := json.Unmarshal(record.Result, &push); err != nil {
+			return nil, fmt.Errorf("failed to decode the %s push of %s at %s: %w", record.Stream, node, record.Time, err)
+		}
+		pushes = append(pushes, push)
+	}
+
+	out := make(chan Out, len(pushes))
+	for _, push := range pushes {
+		out <- push
+	}
+	close(out)
+	return out, nil
+}
@@ -0,0 +1,63 @@
+package node_utils
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/stretchr/testify/require"
+)
+
+type nopCloser struct{ *bytes.Buffer }
+
+func (nopCloser) Close() error { return nil }
+
+func TestWsRecording(t *testing.T) {
+	var buf bytes.Buffer
+	start := time.Unix(1700000000, 0).UTC()
+	now := start
+	r := newWsRecorder(nopCloser{&buf}, func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	})
+
+	require.NoError(t, r.Record("a", UnsafeHeadStream.name(), eth.L2BlockRef{Number: 1}))
+	require.NoError(t, r.Record("b", UnsafeHeadStream.name(), eth.L2BlockRef{Number: 7}))
+	require.NoError(t, r.Record("a", SafeHeadStream.name(), eth.L2BlockRef{Number: 1}))
+	require.NoError(t, r.Record("a", UnsafeHeadStream.name(), eth.L2BlockRef{Number: 2}))
+	require.NoError(t, r.Record("a", EngineQueueSizeStream.name(), uint64(3)))
+	require.Equal(t, 5, strings.Count(buf.String(), "\n"), "one record per line")
+
+	records, err := readWsRecording(&buf)
+	require.NoError(t, err)
+	require.Len(t, records, 5)
+	require.Equal(t, start.Add(time.Second), records[0].Time)
+	require.Equal(t, "ws_unsafe_head", records[0].Stream)
+
+	t.Run("replay a stream", func(t *testing.T) {
+		heads, err := ReplayStream(records, "a", UnsafeHeadStream)
+		require.NoError(t, err)
+
+		var numbers []uint64
+		for head := range heads {
+			numbers = append(numbers, head.Number)
+		}
+		require.Equal(t, []uint64{1, 2}, numbers)
+
+		sizes, err := ReplayStream(records, "a", EngineQueueSizeStream)
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), <-sizes)
+	})
+
+	t.Run("replay with the wrong type", func(t *testing.T) {
+		_, err := ReplayStream(records, "a", KonaWsStream[uint64]{Prefix: "ws", Method: "unsafe_head"})
+		require.ErrorContains(t, err, "failed to decode the ws_unsafe_head push of a")
+	})
+
+	t.Run("invalid line", func(t *testing.T) {
+		_, err := readWsRecording(strings.NewReader("{}\nnot json\n"))
+		require.ErrorContains(t, err, "line 2")
+	})
+}
@@ -0,0 +1,79 @@
+package node_utils
+
+import (
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+)
+
+// KonaWsStream is a websocket stream served by kona-node, whose pushes decode as Out.
+//
+// kona-node only serves the head streams and the engine queue size. Derivation pipeline and engine task events are not
+// exposed over websockets, so they have no entry here, and reorgs are derived from the head streams by HeadReorgs.
+type KonaWsStream[Out any] struct {
+	Prefix string
+	Method string
+}
+
+var (
+	UnsafeHeadStream    = KonaWsStream[eth.L2BlockRef]{Prefix: "ws", Method: "unsafe_head"}
+	SafeHeadStream      = KonaWsStream[eth.L2BlockRef]{Prefix: "ws", Method: "safe_head"}
+	FinalizedHeadStream = KonaWsStream[eth.L2BlockRef]{Prefix: "ws", Method: "finalized_head"}
+	// EngineQueueSizeStream pushes the number of tasks waiting in the engine queue whenever it changes.
+	EngineQueueSizeStream = KonaWsStream[uint64]{Prefix: "dev", Method: "engine_queue_size"}
+)
+
+// name is the name of the stream in recordings, e.g. "ws_unsafe_head".
+func (s KonaWsStream[Out]) name() string {
+	return s.Prefix + "_" + s.Method
+}
+
+// Subscribe subscribes to `stream` on the connection of `s`.
+func Subscribe[Out any](s *WsSubscriber, stream KonaWsStream[Out]) *WsSubscription[Out] {
+	return SubscribeWs[Out](s, stream.Prefix, stream.Method)
+}
+
+// HeadReorg is a head stream moving to a block that doesn't extend its previous head.
+type HeadReorg struct {
+	Old eth.L2BlockRef
+	New eth.L2BlockRef
+}
+
+// Depth is the number of blocks of the old chain that are no longer part of the new one, at least. The common ancestor
+// isn't known, so a new head replacing the old one at the same height counts as a single block.
+func (r HeadReorg) Depth() uint64 {
+	if r.New.Number > r.Old.Number {
+		return 1
+	}
+	return r.Old.Number - r.New.Number + 1
+}
+
+// HeadReorgs forwards the reorgs seen on the head stream `heads` until it is closed. A head reorgs its predecessor if it
+// is not higher, or is its direct child by number but not by hash. Heads skipping blocks can't be told apart from
+// reorgs, so they are assumed to extend the chain.
+func HeadReorgs(heads <-chan eth.L2BlockRef) <-chan HeadReorg {
+	out := make(chan HeadReorg, 16)
+	go func() {
+		defer close(out)
+
+		var last *eth.L2BlockRef
+		for head := range heads {
+			if last != nil && isHeadReorg(*last, head) {
+				out <- HeadReorg{Old: *last, New: head}
+			}
+			last = &head
+		}
+	}()
+	return out
+}
+
+func isHeadReorg(last, head eth.L2BlockRef) bool {
+	switch {
+	case head.Hash == last.Hash:
+		return false
+	case head.Number <= last.Number:
+		return true
+	case head.Number == last.Number+1:
+		return head.ParentHash != last.Hash
+	default:
+		return false
+	}
+}
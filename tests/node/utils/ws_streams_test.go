@@ -0,0 +1,45 @@
+package node_utils
+
+import (
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeadReorgs(t *testing.T) {
+	block := func(number uint64, fork byte) eth.L2BlockRef {
+		ref := eth.L2BlockRef{Number: number, Hash: common.Hash{fork, byte(number)}}
+		if number > 0 {
+			ref.ParentHash = common.Hash{fork, byte(number - 1)}
+		}
+		return ref
+	}
+
+	heads := make(chan eth.L2BlockRef, 16)
+	for _, head := range []eth.L2BlockRef{
+		block(1, 0),
+		block(2, 0),
+		block(2, 0), // repeated head
+		block(3, 0),
+		block(6, 0), // skipped blocks
+		block(7, 1), // child by number, not by hash
+		block(5, 1), // head going back
+		block(6, 1),
+	} {
+		heads <- head
+	}
+	close(heads)
+
+	var reorgs []HeadReorg
+	for reorg := range HeadReorgs(heads) {
+		reorgs = append(reorgs, reorg)
+	}
+	require.Equal(t, []HeadReorg{
+		{Old: block(6, 0), New: block(7, 1)},
+		{Old: block(7, 1), New: block(5, 1)},
+	}, reorgs)
+	require.Equal(t, uint64(1), reorgs[0].Depth())
+	require.Equal(t, uint64(3), reorgs[1].Depth())
+}
@@ -0,0 +1,358 @@
+package node_utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+// wsCloseTimeout bounds the time Close waits for the node to acknowledge the unsubscriptions.
+const wsCloseTimeout = 5 * time.Second
+
+// errWsSubscriberClosed is returned by the calls made on a WsSubscriber that was closed.
+var errWsSubscriberClosed = errors.New("websocket subscriber closed")
+
+// WsSubscriber holds a single websocket connection to a node and multiplexes several subscriptions over it, e.g. the
+// unsafe, safe and finalized heads, instead of opening a connection per subscription like GetKonaWs does.
+type WsSubscriber struct {
+	t    devtest.T
+	conn *websocket.Conn
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  uint64
+	pending map[uint64]*wsCall
+	subs    map[uint64]*wsSubscription
+	// err is set once the connection is gone, and fails all the calls made afterwards.
+	err error
+
+	readerDone chan struct{}
+	closeOnce  sync.Once
+}
+
+// wsCall is a request waiting for its response. The response of a subscribe or unsubscribe request also registers or
+// unregisters the subscription, so that no push is read before its subscription is known.
+type wsCall struct {
+	subscribe   *wsSubscription
+	unsubscribe *wsSubscription
+	done        chan error
+}
+
+// wsSubscription is a subscription as seen by the connection: raw pushes, delivered in order by the reader.
+type wsSubscription struct {
+	id          uint64
+	unsubscribe string
+	pushes      chan json.RawMessage
+	// stopped is closed when the subscription is no longer consumed, so that its pushes are discarded instead of
+	// blocking the reader.
+	stopped  chan struct{}
+	stopOnce sync.Once
+	// unsubscribing is set, under the lock of the subscriber, while an unsubscription is in flight, and closed when it
+	// completes, so that concurrent unsubscriptions wait for it instead of sending another.
+	unsubscribing chan struct{}
+}
+
+func (sub *wsSubscription) stop() {
+	sub.stopOnce.Do(func() { close(sub.stopped) })
+}
+
+// wsMessage is either the response to a request, or a push of a subscription.
+type wsMessage struct {
+	ID     *uint64         `json:"id"`
+	Method string          `json:"method"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+	Params struct {
+		Subscription uint64          `json:"subscription"`
+		Result       json.RawMessage `json:"result"`
+	} `json:"params"`
+}
+
+// NewWsSubscriber connects to the websocket RPC of `node`. The connection is closed, and all its subscriptions ended,
+// when the test ends or Close is called.
+func NewWsSubscriber(t devtest.T, node *dsl.L2CLNode) *WsSubscriber {
+	wsRPC := strings.Replace(node.Escape().UserRPC(), "http", "ws", 1)
+
+	s, err := dialWsSubscriber(t.Ctx(), wsRPC)
+	require.NoError(t, err, "failed to connect to %s", wsRPC)
+	s.t = t
+	t.Cleanup(s.Close)
+	return s
+}
+
+func dialWsSubscriber(ctx context.Context, url string) (*WsSubscriber, error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("dial: %w", err)
+	}
+
+	s := &WsSubscriber{
+		conn:       conn,
+		pending:    make(map[uint64]*wsCall),
+		subs:       make(map[uint64]*wsSubscription),
+		readerDone: make(chan struct{}),
+	}
+	go s.read()
+	return s, nil
+}
+
+// Close ends all the subscriptions and closes the connection. It waits at most wsCloseTimeout for the node to
+// acknowledge the unsubscriptions.
+func (s *WsSubscriber) Close() {
+	ctx, cancel := context.WithTimeout(context.Background(), wsCloseTimeout)
+	defer cancel()
+
+	if err := s.close(ctx); err != nil {
+		s.t.Log("failed to close the websocket subscriber cleanly:", err)
+	}
+}
+
+// SubscribeKona subscribes to the kona head stream `method`, e.g. "unsafe_head".
+func (s *WsSubscriber) SubscribeKona(method string) *WsSubscription[eth.L2BlockRef] {
+	return SubscribeWs[eth.L2BlockRef](s, "ws", method)
+}
+
+// SubscribeDev subscribes to the kona dev stream `method`, e.g. "engine_queue_size".
+func (s *WsSubscriber) SubscribeDev(method string) *WsSubscription[uint64] {
+	return SubscribeWs[uint64](s, "dev", method)
+}
+
+// WsSubscription is a subscription of a WsSubscriber. Its pushes are delivered on C, which is closed when the
+// subscription ends. C must be drained until Unsubscribe is called: a subscription falling behind stalls the other
+// subscriptions of its connection.
+type WsSubscription[Out any] struct {
+	C <-chan Out
+
+	s   *WsSubscriber
+	sub *wsSubscription
+}
+
+// SubscribeWs subscribes to `prefix`_subscribe_`method` on the connection of `s`, decoding the pushes as `Out`.
+func SubscribeWs[Out any](s *WsSubscriber, prefix string, method string) *WsSubscription[Out] {
+	sub, err := s.subscribe(s.t.Ctx(), prefix, method)
+	require.NoError(s.t, err, "failed to subscribe to %s", method)
+	s.t.Log("subscribed to websocket", method, "- id=", sub.id)
+
+	out := make(chan Out, 128)
+	go func() {
+		defer close(out)
+		for raw := range sub.pushes {
+			var v Out
+			if err := json.Unmarshal(raw, &v); err != nil {
+				s.t.Errorf("failed to decode %s push: %v", method, err)
+				continue
+			}
+			select {
+			case out <- v:
+			case <-sub.stopped:
+			}
+		}
+	}()
+
+	return &WsSubscription[Out]{C: out, s: s, sub: sub}
+}
+
+// Unsubscribe ends the subscription, leaving the connection open for the others.
+func (sub *WsSubscription[Out]) Unsubscribe() {
+	require.NoError(sub.s.t, sub.s.unsubscribe(sub.s.t.Ctx(), sub.sub), "failed to unsubscribe")
+}
+
+func (s *WsSubscriber) subscribe(ctx context.Context, prefix string, method string) (*wsSubscription, error) {
+	sub := &wsSubscription{
+		unsubscribe: prefix + "_unsubscribe_" + method,
+		pushes:      make(chan json.RawMessage, 128),
+		stopped:     make(chan struct{}),
+	}
+	if err := s.call(ctx, prefix+"_subscribe_"+method, nil, &wsCall{subscribe: sub}); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// unsubscribe ends `sub`. It returns right away if the subscription already ended, and waits for the unsubscription in
+// flight if there is one.
+func (s *WsSubscriber) unsubscribe(ctx context.Context, sub *wsSubscription) error {
+	sub.stop()
+
+	for {
+		s.mu.Lock()
+		if s.subs[sub.id] != sub {
+			s.mu.Unlock()
+			return nil
+		}
+		inFlight := sub.unsubscribing
+		if inFlight == nil {
+			break
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-inFlight:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	done := make(chan struct{})
+	sub.unsubscribing = done
+	s.mu.Unlock()
+
+	err := s.call(ctx, sub.unsubscribe, []any{sub.id}, &wsCall{unsubscribe: sub})
+
+	s.mu.Lock()
+	sub.unsubscribing = nil
+	s.mu.Unlock()
+	close(done)
+	return err
+}
+
+func (s *WsSubscriber) call(ctx context.Context, method string, params any, call *wsCall) error {
+	call.done = make(chan error, 1)
+
+	s.mu.Lock()
+	if s.err != nil {
+		s.mu.Unlock()
+		return s.err
+	}
+	s.nextID++
+	id := s.nextID
+	s.pending[id] = call
+	s.mu.Unlock()
+
+	s.writeMu.Lock()
+	err := s.conn.WriteJSON(rpcRequest{JSONRPC: "2.0", ID: id, Method: method, Params: params})
+	s.writeMu.Unlock()
+	if err != nil {
+		s.mu.Lock()
+		delete(s.pending, id)
+		s.mu.Unlock()
+		return fmt.Errorf("%s: %w", method, err)
+	}
+
+	select {
+	case err := <-call.done:
+		if err != nil {
+			return fmt.Errorf("%s: %w", method, err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// read dispatches the messages of the connection until it is closed. It is the only sender on, and closer of, the push
+// channels of the subscriptions.
+func (s *WsSubscriber) read() {
+	defer close(s.readerDone)
+
+	for {
+		var msg wsMessage
+		if err := s.conn.ReadJSON(&msg); err != nil {
+			s.shutdown(err)
+			return
+		}
+
+		if msg.ID == nil {
+			s.mu.Lock()
+			sub := s.subs[msg.Params.Subscription]
+			s.mu.Unlock()
+			if sub != nil {
+				select {
+				case sub.pushes <- msg.Params.Result:
+				case <-sub.stopped:
+				}
+			}
+			continue
+		}
+
+		s.respond(*msg.ID, msg)
+	}
+}
+
+func (s *WsSubscriber) respond(id uint64, msg wsMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	call, ok := s.pending[id]
+	if !ok {
+		return
+	}
+	delete(s.pending, id)
+
+	var err error
+	switch {
+	case msg.Error != nil:
+		err = fmt.Errorf("rpc error %d: %s", msg.Error.Code, msg.Error.Message)
+	case call.subscribe != nil:
+		if err = json.Unmarshal(msg.Result, &call.subscribe.id); err == nil {
+			s.subs[call.subscribe.id] = call.subscribe
+		}
+	case call.unsubscribe != nil:
+		// The subscription may already have ended with the connection.
+		if s.subs[call.unsubscribe.id] == call.unsubscribe {
+			delete(s.subs, call.unsubscribe.id)
+			close(call.unsubscribe.pushes)
+		}
+	}
+	call.done <- err
+}
+
+// shutdown ends all the subscriptions and fails the pending calls once the connection is gone.
+func (s *WsSubscriber) shutdown(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.err = fmt.Errorf("connection closed: %w", err)
+	for id, call := range s.pending {
+		call.done <- s.err
+		delete(s.pending, id)
+	}
+	for id, sub := range s.subs {
+		close(sub.pushes)
+		delete(s.subs, id)
+	}
+}
+
+func (s *WsSubscriber) close(ctx context.Context) error {
+	var err error
+	s.closeOnce.Do(func() {
+		s.mu.Lock()
+		subs := make([]*wsSubscription, 0, len(s.subs))
+		for _, sub := range s.subs {
+			subs = append(subs, sub)
+		}
+		closed := s.err != nil
+		s.mu.Unlock()
+
+		// Stopping every subscription up front unblocks the reader if it is pushing to a subscription nobody drains,
+		// so that it can read the responses to the unsubscriptions.
+		for _, sub := range subs {
+			sub.stop()
+		}
+		if !closed {
+			for _, sub := range subs {
+				err = errors.Join(err, s.unsubscribe(ctx, sub))
+			}
+		}
+
+		if closeErr := s.conn.Close(); closeErr != nil && !closed {
+			err = errors.Join(err, closeErr)
+		}
+		<-s.readerDone
+
+		s.mu.Lock()
+		s.err = errWsSubscriberClosed
+		s.mu.Unlock()
+	})
+	return err
+}
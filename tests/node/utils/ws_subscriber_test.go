@@ -0,0 +1,238 @@
+package node_utils
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWsNode acknowledges the subscriptions with consecutive IDs, and lets the test push to them.
+type fakeWsNode struct {
+	server *httptest.Server
+
+	mu      sync.Mutex
+	conn    *websocket.Conn
+	nextSub uint64
+	methods []string
+	// ignoresUnsubscribe makes the node leave the unsubscriptions unanswered.
+	ignoresUnsubscribe bool
+}
+
+func newFakeWsNode(t *testing.T) *fakeWsNode {
+	node := &fakeWsNode{}
+	upgrader := websocket.Upgrader{}
+	node.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		node.mu.Lock()
+		node.conn = conn
+		node.mu.Unlock()
+
+		for {
+			var req rpcRequest
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+
+			node.mu.Lock()
+			node.methods = append(node.methods, req.Method)
+			if node.ignoresUnsubscribe && strings.Contains(req.Method, "_unsubscribe_") {
+				node.mu.Unlock()
+				continue
+			}
+			var result any = true
+			if strings.Contains(req.Method, "_subscribe_") {
+				node.nextSub++
+				result = node.nextSub
+			}
+			err := conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": result})
+			node.mu.Unlock()
+			if err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(node.server.Close)
+	return node
+}
+
+func (n *fakeWsNode) url() string {
+	return strings.Replace(n.server.URL, "http", "ws", 1)
+}
+
+func (n *fakeWsNode) push(t *testing.T, sub uint64, result any) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	require.NoError(t, n.conn.WriteJSON(map[string]any{
+		"jsonrpc": "2.0",
+		"method":  "ws_subscription",
+		"params":  map[string]any{"subscription": sub, "result": result},
+	}))
+}
+
+func (n *fakeWsNode) drop() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.conn.Close()
+}
+
+func receive(t *testing.T, pushes <-chan json.RawMessage) string {
+	select {
+	case push, ok := <-pushes:
+		require.True(t, ok, "subscription ended")
+		return string(push)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no push received")
+		return ""
+	}
+}
+
+func requireEnded(t *testing.T, pushes <-chan json.RawMessage) {
+	select {
+	case _, ok := <-pushes:
+		require.False(t, ok, "subscription still running")
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "subscription did not end")
+	}
+}
+
+func TestWsSubscriber(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("multiplexes subscriptions", func(t *testing.T) {
+		node := newFakeWsNode(t)
+		s, err := dialWsSubscriber(ctx, node.url())
+		require.NoError(t, err)
+
+		unsafe, err := s.subscribe(ctx, "ws", "unsafe_head")
+		require.NoError(t, err)
+		safe, err := s.subscribe(ctx, "ws", "safe_head")
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), unsafe.id)
+		require.Equal(t, uint64(2), safe.id)
+
+		node.push(t, 2, 10)
+		node.push(t, 1, 11)
+		require.Equal(t, "11", receive(t, unsafe.pushes))
+		require.Equal(t, "10", receive(t, safe.pushes))
+
+		require.NoError(t, s.unsubscribe(ctx, unsafe))
+		requireEnded(t, unsafe.pushes)
+
+		node.push(t, 1, 12)
+		node.push(t, 2, 13)
+		require.Equal(t, "13", receive(t, safe.pushes), "the other subscriptions go on")
+
+		require.NoError(t, s.close(ctx))
+		requireEnded(t, safe.pushes)
+		require.Equal(t, []string{"ws_subscribe_unsafe_head", "ws_subscribe_safe_head", "ws_unsubscribe_unsafe_head", "ws_unsubscribe_safe_head"}, node.methods)
+
+		_, err = s.subscribe(ctx, "ws", "finalized_head")
+		require.ErrorIs(t, err, errWsSubscriberClosed)
+	})
+
+	t.Run("connection drop ends the subscriptions", func(t *testing.T) {
+		node := newFakeWsNode(t)
+		s, err := dialWsSubscriber(ctx, node.url())
+		require.NoError(t, err)
+
+		sub, err := s.subscribe(ctx, "ws", "unsafe_head")
+		require.NoError(t, err)
+
+		node.drop()
+		requireEnded(t, sub.pushes)
+
+		_, err = s.subscribe(ctx, "ws", "safe_head")
+		require.ErrorContains(t, err, "connection closed")
+		require.NoError(t, s.close(ctx))
+	})
+
+	t.Run("close with an undrained subscription", func(t *testing.T) {
+		node := newFakeWsNode(t)
+		s, err := dialWsSubscriber(ctx, node.url())
+		require.NoError(t, err)
+
+		sub, err := s.subscribe(ctx, "ws", "unsafe_head")
+		require.NoError(t, err)
+		// Fills the buffer of the subscription, and blocks the reader on the next push.
+		for i := 0; i <= cap(sub.pushes); i++ {
+			node.push(t, 1, i)
+		}
+
+		closeCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		require.NoError(t, s.close(closeCtx))
+	})
+
+	t.Run("double unsubscribe", func(t *testing.T) {
+		node := newFakeWsNode(t)
+		s, err := dialWsSubscriber(ctx, node.url())
+		require.NoError(t, err)
+
+		sub, err := s.subscribe(ctx, "ws", "unsafe_head")
+		require.NoError(t, err)
+
+		require.NoError(t, s.unsubscribe(ctx, sub))
+		require.NoError(t, s.unsubscribe(ctx, sub), "the subscription already ended")
+		requireEnded(t, sub.pushes)
+
+		require.NoError(t, s.close(ctx))
+		require.Equal(t, []string{"ws_subscribe_unsafe_head", "ws_unsubscribe_unsafe_head"}, node.methods)
+	})
+
+	t.Run("unsubscribe racing close", func(t *testing.T) {
+		node := newFakeWsNode(t)
+		s, err := dialWsSubscriber(ctx, node.url())
+		require.NoError(t, err)
+
+		sub, err := s.subscribe(ctx, "ws", "unsafe_head")
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		var unsubscribeErr, closeErr error
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			unsubscribeErr = s.unsubscribe(ctx, sub)
+		}()
+		go func() {
+			defer wg.Done()
+			closeErr = s.close(ctx)
+		}()
+		wg.Wait()
+
+		require.NoError(t, unsubscribeErr)
+		require.NoError(t, closeErr)
+		requireEnded(t, sub.pushes)
+		node.mu.Lock()
+		defer node.mu.Unlock()
+		require.Equal(t, []string{"ws_subscribe_unsafe_head", "ws_unsubscribe_unsafe_head"}, node.methods, "the subscription is unsubscribed once")
+	})
+
+	t.Run("close with an unresponsive node", func(t *testing.T) {
+		node := newFakeWsNode(t)
+		node.mu.Lock()
+		node.ignoresUnsubscribe = true
+		node.mu.Unlock()
+		s, err := dialWsSubscriber(ctx, node.url())
+		require.NoError(t, err)
+
+		sub, err := s.subscribe(ctx, "ws", "unsafe_head")
+		require.NoError(t, err)
+
+		closeCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, s.close(closeCtx), context.DeadlineExceeded)
+		requireEnded(t, sub.pushes)
+	})
+}
@@ -0,0 +1,102 @@
+package node_utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconnectPolicyDelay(t *testing.T) {
+	policy := ReconnectPolicy{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	var delays []time.Duration
+	for attempt := 1; attempt <= 5; attempt++ {
+		delays = append(delays, policy.delay(attempt))
+	}
+	require.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}, delays)
+
+	uncapped := ReconnectPolicy{Backoff: time.Second}
+	require.Equal(t, 16*time.Second, uncapped.delay(5))
+}
+
+func TestReconnectEvents(t *testing.T) {
+	t.Run("events are dropped when the channel is full", func(t *testing.T) {
+		events := make(chan ReconnectEvent, 1)
+		var cfg wsConfig
+		WithReconnect(DefaultReconnectPolicy, events)(&cfg)
+
+		cfg.report(ReconnectEvent{Attempt: 1})
+		cfg.report(ReconnectEvent{Attempt: 2})
+		require.Equal(t, ReconnectEvent{Attempt: 1}, <-events)
+		require.Empty(t, events)
+	})
+
+	t.Run("no events channel", func(t *testing.T) {
+		var cfg wsConfig
+		WithReconnect(DefaultReconnectPolicy, nil)(&cfg)
+		require.Equal(t, DefaultReconnectPolicy, *cfg.reconnect)
+		cfg.report(ReconnectEvent{Attempt: 1})
+	})
+}
+
+func TestKeepAlive(t *testing.T) {
+	// The server answers the pings as long as it reads from the connection.
+	serve := func(answer bool) string {
+		upgrader := websocket.Upgrader{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			if answer {
+				for {
+					if _, _, err := conn.ReadMessage(); err != nil {
+						return
+					}
+				}
+			}
+			<-r.Context().Done()
+		}))
+		t.Cleanup(server.Close)
+		return strings.Replace(server.URL, "http", "ws", 1)
+	}
+
+	read := func(t *testing.T, url string) <-chan error {
+		conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+
+		stop, err := keepAlive(conn, 20*time.Millisecond)
+		require.NoError(t, err)
+		t.Cleanup(stop)
+
+		errs := make(chan error, 1)
+		go func() {
+			_, _, err := conn.ReadMessage()
+			errs <- err
+		}()
+		return errs
+	}
+
+	t.Run("responsive node", func(t *testing.T) {
+		select {
+		case err := <-read(t, serve(true)):
+			require.FailNow(t, "read failed", err)
+		case <-time.After(300 * time.Millisecond):
+		}
+	})
+
+	t.Run("unresponsive node", func(t *testing.T) {
+		select {
+		case err := <-read(t, serve(false)):
+			require.True(t, isTimeout(err), "unexpected error: %v", err)
+		case <-time.After(time.Second):
+			require.FailNow(t, "stall not detected")
+		}
+	})
+}
@@ -53,7 +53,7 @@ func testL2ReorgAfterL1Reorg(gt *testing.T, reorgAfter *eth.BlockID, preChecks,
 
 	// sequence some l1 blocks initially
 	for range 10 {
-		trm.GetBlockBuilder().BuildBlock(ctx, nil)
+		trm.GetBlockBuilder().MustBuildBlock(ctx, nil)
 		time.Sleep(5 * time.Second)
 	}
 
@@ -64,7 +64,7 @@ func testL2ReorgAfterL1Reorg(gt *testing.T, reorgAfter *eth.BlockID, preChecks,
 
 	// create at least 5 blocks after the divergence point
 	for tip-reorgAfter.Number < 5 {
-		trm.GetBlockBuilder().BuildBlock(ctx, nil)
+		trm.GetBlockBuilder().MustBuildBlock(ctx, nil)
 		time.Sleep(5 * time.Second)
 		tip++
 	}
@@ -78,7 +78,7 @@ func testL2ReorgAfterL1Reorg(gt *testing.T, reorgAfter *eth.BlockID, preChecks,
 
 	// reorg the L1 chain -- sequence an alternative L1 block from divergence block parent
 	t.Log("Building Divergence Chain from:", divergence)
-	trm.GetBlockBuilder().BuildBlock(ctx, &divergence.ParentHash)
+	trm.GetBlockBuilder().MustBuildBlock(ctx, &divergence.ParentHash)
 
 	t.Log("Stopping the batchers")
 	sys.L2BatcherA.Stop()
@@ -16,6 +16,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	gethTypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/op-rs/kona/supervisor/utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -110,6 +111,10 @@ func TestRPCSuperRootAtTimestamp(gt *testing.T) {
 		require.Error(t, err)
 	})
 
+	t.Run("fails with future timestamp", func(gt devtest.T) {
+		utils.AssertSuperRootFutureRejected(t, client.QueryAPI())
+	})
+
 	t.Run("succeeds with valid timestamp", func(gt devtest.T) {
 		timeNow := uint64(time.Now().Unix())
 		root, err := client.QueryAPI().SuperRootAtTimestamp(context.Background(), hexutil.Uint64(timeNow-90))
@@ -199,7 +204,8 @@ func TestRPCCheckAccessList(gt *testing.T) {
 	bob := sys.FunderB.NewFundedEOA(eth.OneHundredthEther)
 
 	eventLoggerAddress := alice.DeployEventLogger()
-	sys.L2ChainB.CatchUpTo(sys.L2ChainA)
+	// CatchUpTo tolerates 6 seconds of drift between the chains, which is 3 blocks at a 2 seconds block time.
+	utils.AssertChainsAligned(t, sys.L2ChainA, sys.L2ChainB, 3)
 
 	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
 	_, initReceipt := alice.SendInitMessage(
@@ -325,7 +331,6 @@ func TestRPCCheckAccessList(gt *testing.T) {
 			ChainID:   bob.ChainID(),
 		}
 
-		err := client.QueryAPI().CheckAccessList(ctx, accessList, types.Finalized, ed)
-		require.Error(t, err, "CheckAccessList should fail due to safety level violation")
+		utils.AssertSafetyLevelEscalationFails(t, client.QueryAPI(), accessList, ed, types.LocalUnsafe, types.Finalized)
 	})
 }
@@ -1,13 +1,16 @@
 package sync
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
 	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
 	"github.com/ethereum-optimism/optimism/op-devstack/presets"
 	"github.com/ethereum-optimism/optimism/op-service/eth"
 	"github.com/ethereum-optimism/optimism/op-supervisor/supervisor/types"
+	"github.com/op-rs/kona/supervisor/utils"
 )
 
 // TestL2CLResync checks that unsafe head advances after restarting L2CL.
@@ -57,18 +60,27 @@ func TestL2CLResync(gt *testing.T) {
 	// supervisor successfully connected with managed L2CLs
 }
 
+// headAdvanceTimeout bounds the wait for a head to advance by a couple of blocks.
+const headAdvanceTimeout = 2 * time.Minute
+
 // TestSupervisorResync checks that heads advances after restarting the Supervisor.
 func TestSupervisorResync(gt *testing.T) {
 	t := devtest.SerialT(gt)
 	sys := presets.NewSimpleInterop(t)
 	logger := sys.Log.With("Test", "TestSupervisorResync")
 
+	levels := []types.SafetyLevel{types.LocalUnsafe, types.LocalSafe, types.CrossUnsafe, types.CrossSafe}
+	waitForHeadsToAdvance := func(level types.SafetyLevel) error {
+		sup := sys.Supervisor.Escape().QueryAPI()
+		return errors.Join(
+			utils.WaitForL2HeadToAdvance(t.Ctx(), sup, sys.L2ChainA.ChainID(), 2, level, headAdvanceTimeout),
+			utils.WaitForL2HeadToAdvance(t.Ctx(), sup, sys.L2ChainB.ChainID(), 2, level, headAdvanceTimeout),
+		)
+	}
+
 	logger.Info("Check unsafe chains are advancing")
 
-	for _, level := range []types.SafetyLevel{types.LocalUnsafe, types.LocalSafe, types.CrossUnsafe, types.CrossSafe} {
-		sys.Supervisor.WaitForL2HeadToAdvance(sys.L2ChainA.ChainID(), 2, level, 20)
-		sys.Supervisor.WaitForL2HeadToAdvance(sys.L2ChainB.ChainID(), 2, level, 20)
-	}
+	t.Require().NoError(utils.ForEachSafetyLevel(levels, waitForHeadsToAdvance))
 
 	logger.Info("Stop Supervisor node")
 	sys.Supervisor.Stop()
@@ -79,8 +91,5 @@ func TestSupervisorResync(gt *testing.T) {
 	logger.Info("Boot up Supervisor node")
 
 	// Re check syncing is not blocked
-	for _, level := range []types.SafetyLevel{types.LocalUnsafe, types.LocalSafe, types.CrossUnsafe, types.CrossSafe} {
-		sys.Supervisor.WaitForL2HeadToAdvance(sys.L2ChainA.ChainID(), 2, level, 20)
-		sys.Supervisor.WaitForL2HeadToAdvance(sys.L2ChainB.ChainID(), 2, level, 20)
-	}
+	t.Require().NoError(utils.ForEachSafetyLevel(levels, waitForHeadsToAdvance))
 }
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"slices"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/ethereum-optimism/optimism/op-service/apis"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/ethereum-optimism/optimism/op-supervisor/supervisor/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// safetyLevelOrder lists the safety levels from the weakest to the strictest.
+var safetyLevelOrder = []types.SafetyLevel{types.LocalUnsafe, types.CrossUnsafe, types.LocalSafe, types.CrossSafe, types.Finalized}
+
+// AssertSafetyLevelEscalationFails checks that the access list of a recently produced message is valid at the `from`
+// safety level, and that it is rejected at the stricter `to` safety level, which the message has not reached yet.
+func AssertSafetyLevelEscalationFails(t devtest.T, sup apis.SupervisorQueryAPI, accessList []common.Hash, ed types.ExecutingDescriptor, from, to types.SafetyLevel) {
+	t.Require().NoError(checkSafetyLevelEscalationFails(t.Ctx(), sup, accessList, ed, from, to))
+}
+
+func checkSafetyLevelEscalationFails(ctx context.Context, sup apis.SupervisorQueryAPI, accessList []common.Hash, ed types.ExecutingDescriptor, from, to types.SafetyLevel) error {
+	fromIdx := slices.Index(safetyLevelOrder, from)
+	toIdx := slices.Index(safetyLevelOrder, to)
+	if fromIdx < 0 || toIdx < 0 {
+		return fmt.Errorf("unsupported safety levels %s and %s", from, to)
+	}
+	if toIdx <= fromIdx {
+		return fmt.Errorf("safety level %s is not stricter than %s", to, from)
+	}
+
+	if err := sup.CheckAccessList(ctx, accessList, from, ed); err != nil {
+		return fmt.Errorf("access list should be valid at safety level %s: %w", from, err)
+	}
+
+	if err := sup.CheckAccessList(ctx, accessList, to, ed); err == nil {
+		return fmt.Errorf("access list should be rejected at safety level %s", to)
+	}
+
+	return nil
+}
+
+// AssertMessageFailsWhenDepBehind checks that the access list references an initiating message on `depChain` at a block
+// the dependency chain has not reached yet, and that the supervisor rejects it. This covers messages that are too new,
+// a failure mode distinct from invalid messages, which tests usually avoid by catching the chains up first.
+func AssertMessageFailsWhenDepBehind(t devtest.T, sup apis.SupervisorQueryAPI, accessList []common.Hash, ed types.ExecutingDescriptor, depChain dsl.L2CLNode) {
+	depHead := depChain.HeadBlockRef(types.LocalUnsafe)
+	t.Require().NoError(checkMessageFailsWhenDepBehind(t.Ctx(), sup, accessList, ed, depChain.ChainID(), depHead.Number))
+}
+
+func checkMessageFailsWhenDepBehind(ctx context.Context, sup apis.SupervisorQueryAPI, accessList []common.Hash, ed types.ExecutingDescriptor, depChainID eth.ChainID, depHead uint64) error {
+	ahead := false
+	for entries := accessList; len(entries) > 0; {
+		var access types.Access
+		var err error
+		entries, access, err = types.ParseAccess(entries)
+		if err != nil {
+			return fmt.Errorf("failed to parse access list: %w", err)
+		}
+		if access.ChainID == depChainID && access.BlockNumber > depHead {
+			ahead = true
+		}
+	}
+	if !ahead {
+		return fmt.Errorf("access list references no message of chain %s beyond its head %d", depChainID, depHead)
+	}
+
+	if err := sup.CheckAccessList(ctx, accessList, types.LocalUnsafe, ed); err == nil {
+		return fmt.Errorf("access list referencing blocks of chain %s beyond its head %d should be rejected", depChainID, depHead)
+	}
+
+	return nil
+}
+
+// AssertAccessListOrderIndependent checks the access list built from `entries`, then a shuffled copy of it, and asserts
+// that the supervisor reaches the same verdict for both, as the validity of a message must not depend on where it
+// appears in the list.
+func AssertAccessListOrderIndependent(t devtest.T, sup apis.SupervisorQueryAPI, entries []types.Access, ed types.ExecutingDescriptor) {
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	t.Require().NoError(checkAccessListOrderIndependent(t.Ctx(), sup, entries, ed, rng))
+}
+
+func checkAccessListOrderIndependent(ctx context.Context, sup apis.SupervisorQueryAPI, entries []types.Access, ed types.ExecutingDescriptor, rng *rand.Rand) error {
+	if len(entries) < 2 {
+		return fmt.Errorf("at least 2 access entries are needed to change their order, got %d", len(entries))
+	}
+
+	shuffled := slices.Clone(entries)
+	rng.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	// The shuffle may keep the original order, rotate the entries so that the supervisor always sees a different list.
+	if slices.Equal(shuffled, entries) {
+		shuffled = append(shuffled[1:], shuffled[0])
+	}
+
+	originalErr := sup.CheckAccessList(ctx, types.EncodeAccessList(entries), types.LocalUnsafe, ed)
+	shuffledErr := sup.CheckAccessList(ctx, types.EncodeAccessList(shuffled), types.LocalUnsafe, ed)
+	if (originalErr == nil) != (shuffledErr == nil) {
+		return fmt.Errorf("access list check depends on the order of the entries: got %v in the original order and %v once shuffled", originalErr, shuffledErr)
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-service/apis"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/ethereum-optimism/optimism/op-supervisor/supervisor/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSafetySupervisor accepts access lists checked at or below the safety level the messages have reached.
+type fakeSafetySupervisor struct {
+	apis.SupervisorQueryAPI
+
+	reached types.SafetyLevel
+}
+
+func (f *fakeSafetySupervisor) CheckAccessList(ctx context.Context, inboxEntries []common.Hash, minSafety types.SafetyLevel, executingDescriptor types.ExecutingDescriptor) error {
+	if slices.Index(safetyLevelOrder, minSafety) > slices.Index(safetyLevelOrder, f.reached) {
+		return errors.New("safety level violation")
+	}
+	return nil
+}
+
+func TestSafetyLevelEscalationFails(t *testing.T) {
+	accessList := []common.Hash{{0x01}}
+	ed := types.ExecutingDescriptor{Timestamp: 100}
+
+	t.Run("stricter level is rejected", func(t *testing.T) {
+		sup := &fakeSafetySupervisor{reached: types.LocalUnsafe}
+		require.NoError(t, checkSafetyLevelEscalationFails(context.Background(), sup, accessList, ed, types.LocalUnsafe, types.Finalized))
+	})
+
+	t.Run("message already reached the stricter level", func(t *testing.T) {
+		sup := &fakeSafetySupervisor{reached: types.Finalized}
+		require.ErrorContains(t, checkSafetyLevelEscalationFails(context.Background(), sup, accessList, ed, types.LocalUnsafe, types.Finalized), "should be rejected")
+	})
+
+	t.Run("message not valid at the weaker level", func(t *testing.T) {
+		sup := &fakeSafetySupervisor{reached: types.LocalUnsafe}
+		require.ErrorContains(t, checkSafetyLevelEscalationFails(context.Background(), sup, accessList, ed, types.CrossUnsafe, types.Finalized), "should be valid")
+	})
+
+	t.Run("levels not escalating", func(t *testing.T) {
+		sup := &fakeSafetySupervisor{reached: types.LocalUnsafe}
+		require.ErrorContains(t, checkSafetyLevelEscalationFails(context.Background(), sup, accessList, ed, types.Finalized, types.LocalUnsafe), "not stricter")
+	})
+}
+
+// fakeDepSupervisor only accepts messages from blocks the dependency chains have reached.
+type fakeDepSupervisor struct {
+	apis.SupervisorQueryAPI
+
+	heads map[eth.ChainID]uint64
+}
+
+func (f *fakeDepSupervisor) CheckAccessList(ctx context.Context, inboxEntries []common.Hash, minSafety types.SafetyLevel, executingDescriptor types.ExecutingDescriptor) error {
+	for len(inboxEntries) > 0 {
+		var access types.Access
+		var err error
+		inboxEntries, access, err = types.ParseAccess(inboxEntries)
+		if err != nil {
+			return err
+		}
+		if access.BlockNumber > f.heads[access.ChainID] {
+			return errors.New("conflicting data: future data")
+		}
+	}
+	return nil
+}
+
+func TestMessageFailsWhenDepBehind(t *testing.T) {
+	depChainID := eth.ChainIDFromUInt64(901)
+	ed := types.ExecutingDescriptor{Timestamp: 100}
+	accessList := func(blockNumber uint64) []common.Hash {
+		return types.EncodeAccessList([]types.Access{{
+			BlockNumber: blockNumber,
+			Timestamp:   90,
+			ChainID:     depChainID,
+			Checksum:    types.MessageChecksum{types.PrefixChecksum},
+		}})
+	}
+
+	t.Run("message too new", func(t *testing.T) {
+		sup := &fakeDepSupervisor{heads: map[eth.ChainID]uint64{depChainID: 10}}
+		require.NoError(t, checkMessageFailsWhenDepBehind(context.Background(), sup, accessList(12), ed, depChainID, 10))
+	})
+
+	t.Run("dependency already reached the message", func(t *testing.T) {
+		sup := &fakeDepSupervisor{heads: map[eth.ChainID]uint64{depChainID: 10}}
+		require.ErrorContains(t, checkMessageFailsWhenDepBehind(context.Background(), sup, accessList(8), ed, depChainID, 10), "references no message")
+	})
+
+	t.Run("supervisor does not enforce the dependency head", func(t *testing.T) {
+		sup := &fakeDepSupervisor{heads: map[eth.ChainID]uint64{depChainID: 20}}
+		require.ErrorContains(t, checkMessageFailsWhenDepBehind(context.Background(), sup, accessList(12), ed, depChainID, 10), "should be rejected")
+	})
+}
+
+// fakeOrderSupervisor accepts access lists whose entries all reference known checksums, in any order unless
+// `requireSorted` is set.
+type fakeOrderSupervisor struct {
+	apis.SupervisorQueryAPI
+
+	known         map[types.MessageChecksum]bool
+	requireSorted bool
+}
+
+func (f *fakeOrderSupervisor) CheckAccessList(ctx context.Context, inboxEntries []common.Hash, minSafety types.SafetyLevel, executingDescriptor types.ExecutingDescriptor) error {
+	prev := uint64(0)
+	for len(inboxEntries) > 0 {
+		var access types.Access
+		var err error
+		inboxEntries, access, err = types.ParseAccess(inboxEntries)
+		if err != nil {
+			return err
+		}
+		if !f.known[access.Checksum] {
+			return errors.New("conflicting data: unknown checksum")
+		}
+		if f.requireSorted && access.BlockNumber < prev {
+			return errors.New("access entries out of order")
+		}
+		prev = access.BlockNumber
+	}
+	return nil
+}
+
+func TestAccessListOrderIndependent(t *testing.T) {
+	chainID := eth.ChainIDFromUInt64(901)
+	ed := types.ExecutingDescriptor{Timestamp: 100}
+	entries := make([]types.Access, 4)
+	known := make(map[types.MessageChecksum]bool)
+	for i := range entries {
+		entries[i] = types.Access{
+			BlockNumber: uint64(i + 1),
+			Timestamp:   90,
+			LogIndex:    uint32(i),
+			ChainID:     chainID,
+			Checksum:    types.MessageChecksum{types.PrefixChecksum, byte(i + 1)},
+		}
+		known[entries[i].Checksum] = true
+	}
+
+	t.Run("valid entries in any order", func(t *testing.T) {
+		sup := &fakeOrderSupervisor{known: known}
+		require.NoError(t, checkAccessListOrderIndependent(context.Background(), sup, entries, ed, rand.New(rand.NewSource(1))))
+	})
+
+	t.Run("invalid entry in any order", func(t *testing.T) {
+		invalid := slices.Clone(entries)
+		invalid[2].Checksum = types.MessageChecksum{types.PrefixChecksum, 0xff}
+		sup := &fakeOrderSupervisor{known: known}
+		require.NoError(t, checkAccessListOrderIndependent(context.Background(), sup, invalid, ed, rand.New(rand.NewSource(1))))
+	})
+
+	t.Run("supervisor depends on the order", func(t *testing.T) {
+		sup := &fakeOrderSupervisor{known: known, requireSorted: true}
+		require.ErrorContains(t, checkAccessListOrderIndependent(context.Background(), sup, entries, ed, rand.New(rand.NewSource(1))), "depends on the order")
+	})
+
+	t.Run("single entry", func(t *testing.T) {
+		sup := &fakeOrderSupervisor{known: known}
+		require.ErrorContains(t, checkAccessListOrderIndependent(context.Background(), sup, entries[:1], ed, rand.New(rand.NewSource(1))), "at least 2")
+	})
+}
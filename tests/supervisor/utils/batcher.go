@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/ethereum-optimism/optimism/op-service/sources"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// maxBatchScanBlocks is how many L1 blocks, starting from the head, are scanned for batcher transactions.
+const maxBatchScanBlocks = 256
+
+// l1TxSource is the subset of apis.EthClient used to look up the batcher transactions.
+type l1TxSource interface {
+	InfoByLabel(ctx context.Context, label eth.BlockLabel) (eth.BlockInfo, error)
+	InfoAndTxsByNumber(ctx context.Context, number uint64) (eth.BlockInfo, types.Transactions, error)
+}
+
+// blobSource is the subset of sources.L1BeaconClient used to fetch the blobs of the batcher transactions.
+type blobSource interface {
+	GetBlobs(ctx context.Context, ref eth.L1BlockRef, hashes []eth.IndexedBlobHash) ([]*eth.Blob, error)
+}
+
+// AssertBatchSizeUnder inspects the `samples` most recent batcher transactions on L1 and checks that the payload of
+// each of them is under `maxBytes`. Oversized batches break derivation.
+// The payload of a calldata transaction is its calldata. The payload of a blob transaction is the data decoded from its
+// blobs, which are fetched from the L1 beacon node as the EL does not serve them.
+func AssertBatchSizeUnder(t devtest.T, l1 dsl.L1ELNode, l1CL *dsl.L1CLNode, batcherAddr common.Address, maxBytes int, samples int) {
+	blobs := sources.NewL1BeaconClient(l1CL.Escape().BeaconClient(), sources.L1BeaconClientConfig{})
+	t.Require().NoError(checkBatchSizeUnder(t.Ctx(), l1.EthClient(), blobs, batcherAddr, maxBytes, samples))
+}
+
+func checkBatchSizeUnder(ctx context.Context, l1 l1TxSource, blobs blobSource, batcherAddr common.Address, maxBytes int, samples int) error {
+	head, err := l1.InfoByLabel(ctx, eth.Unsafe)
+	if err != nil {
+		return fmt.Errorf("failed to fetch L1 head: %w", err)
+	}
+
+	found := 0
+	for i := uint64(0); i < maxBatchScanBlocks && i <= head.NumberU64() && found < samples; i++ {
+		number := head.NumberU64() - i
+		info, txs, err := l1.InfoAndTxsByNumber(ctx, number)
+		if err != nil {
+			return fmt.Errorf("failed to fetch L1 block %d: %w", number, err)
+		}
+
+		// Blobs are indexed across all the blob transactions of the block.
+		blobIndex := uint64(0)
+		for _, tx := range txs {
+			if found == samples {
+				break
+			}
+			firstBlob := blobIndex
+			blobIndex += uint64(len(tx.BlobHashes()))
+
+			sender, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+			if err != nil || sender != batcherAddr {
+				continue
+			}
+			found++
+
+			size, err := batchPayloadSize(ctx, blobs, eth.InfoToL1BlockRef(info), tx, firstBlob)
+			if err != nil {
+				return fmt.Errorf("failed to read the batch data of transaction %s in L1 block %d: %w", tx.Hash(), number, err)
+			}
+			if size > maxBytes {
+				return fmt.Errorf("batcher transaction %s in L1 block %d carries %d bytes, over the %d bytes bound", tx.Hash(), number, size, maxBytes)
+			}
+		}
+	}
+
+	if found < samples {
+		return fmt.Errorf("found %d batcher transactions in the last %d L1 blocks, expected %d", found, maxBatchScanBlocks, samples)
+	}
+
+	return nil
+}
+
+// batchPayloadSize returns the size of the batch data carried by a batcher transaction included in `ref`, whose first
+// blob has index `firstBlob` in the block.
+func batchPayloadSize(ctx context.Context, blobs blobSource, ref eth.L1BlockRef, tx *types.Transaction, firstBlob uint64) (int, error) {
+	if tx.Type() != types.BlobTxType {
+		return len(tx.Data()), nil
+	}
+
+	hashes := make([]eth.IndexedBlobHash, len(tx.BlobHashes()))
+	for i, hash := range tx.BlobHashes() {
+		hashes[i] = eth.IndexedBlobHash{Index: firstBlob + uint64(i), Hash: hash}
+	}
+	fetched, err := blobs.GetBlobs(ctx, ref, hashes)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch blobs: %w", err)
+	}
+
+	size := 0
+	for i, blob := range fetched {
+		data, err := blob.ToData()
+		if err != nil {
+			return 0, fmt.Errorf("failed to decode blob %d: %w", hashes[i].Index, err)
+		}
+		size += len(data)
+	}
+	return size, nil
+}
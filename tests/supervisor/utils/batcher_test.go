@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/ethereum-optimism/optimism/op-service/testutils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeL1 serves a chain of L1 blocks, indexed by number, with the given transactions.
+type fakeL1 struct {
+	blocks []types.Transactions
+}
+
+func (f *fakeL1) InfoByLabel(ctx context.Context, label eth.BlockLabel) (eth.BlockInfo, error) {
+	return &testutils.MockBlockInfo{InfoNum: uint64(len(f.blocks) - 1)}, nil
+}
+
+func (f *fakeL1) InfoAndTxsByNumber(ctx context.Context, number uint64) (eth.BlockInfo, types.Transactions, error) {
+	return &testutils.MockBlockInfo{InfoNum: number}, f.blocks[number], nil
+}
+
+// fakeBlobs serves the blobs of the blob transactions of a fakeL1, indexed by block number and blob index.
+type fakeBlobs struct {
+	blobs map[uint64][]*eth.Blob
+}
+
+func newFakeBlobs(l1 *fakeL1) *fakeBlobs {
+	f := &fakeBlobs{blobs: make(map[uint64][]*eth.Blob)}
+	for number, txs := range l1.blocks {
+		for _, tx := range txs {
+			if sidecar := tx.BlobTxSidecar(); sidecar != nil {
+				for _, blob := range sidecar.Blobs {
+					f.blobs[uint64(number)] = append(f.blobs[uint64(number)], (*eth.Blob)(&blob))
+				}
+			}
+		}
+	}
+	return f
+}
+
+func (f *fakeBlobs) GetBlobs(ctx context.Context, ref eth.L1BlockRef, hashes []eth.IndexedBlobHash) ([]*eth.Blob, error) {
+	blobs := make([]*eth.Blob, len(hashes))
+	for i, hash := range hashes {
+		if hash.Index >= uint64(len(f.blobs[ref.Number])) {
+			return nil, fmt.Errorf("no blob %d in block %d", hash.Index, ref.Number)
+		}
+		blobs[i] = f.blobs[ref.Number][hash.Index]
+	}
+	return blobs, nil
+}
+
+func TestBatchSizeUnder(t *testing.T) {
+	batcherKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	otherKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	batcherAddr := crypto.PubkeyToAddress(batcherKey.PublicKey)
+
+	signer := types.LatestSignerForChainID(big.NewInt(900))
+	nonce := uint64(0)
+	newTx := func(key *ecdsa.PrivateKey, size int) *types.Transaction {
+		nonce++
+		tx, err := types.SignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID: big.NewInt(900),
+			Nonce:   nonce,
+			To:      &common.Address{0xff},
+			Data:    make([]byte, size),
+		})
+		require.NoError(t, err)
+		return tx
+	}
+
+	newBlobTx := func(key *ecdsa.PrivateKey, size int) *types.Transaction {
+		sidecar, err := NewBlobTxSidecar(make([]byte, size))
+		require.NoError(t, err)
+		nonce++
+		tx, err := types.SignNewTx(key, signer, &types.BlobTx{
+			ChainID:    uint256.NewInt(900),
+			Nonce:      nonce,
+			To:         common.Address{0xff},
+			BlobHashes: sidecar.BlobHashes(),
+			Sidecar:    sidecar,
+		})
+		require.NoError(t, err)
+		return tx
+	}
+
+	l1 := &fakeL1{blocks: []types.Transactions{
+		{newTx(batcherKey, 5_000)},
+		{newTx(otherKey, 100_000), newTx(batcherKey, 1_000)},
+		{newTx(batcherKey, 2_000)},
+	}}
+	blobs := newFakeBlobs(l1)
+
+	t.Run("recent batches under bound", func(t *testing.T) {
+		require.NoError(t, checkBatchSizeUnder(context.Background(), l1, blobs, batcherAddr, 3_000, 2))
+	})
+
+	t.Run("oversized batch", func(t *testing.T) {
+		require.ErrorContains(t, checkBatchSizeUnder(context.Background(), l1, blobs, batcherAddr, 3_000, 3), "over the 3000 bytes bound")
+	})
+
+	t.Run("not enough batches", func(t *testing.T) {
+		require.ErrorContains(t, checkBatchSizeUnder(context.Background(), l1, blobs, batcherAddr, 10_000, 4), "found 3 batcher transactions")
+	})
+
+	t.Run("blob batches sized by their data", func(t *testing.T) {
+		// The batch spans two blobs but only carries a few more bytes than fit in one, and follows another
+		// transaction's blobs in its block.
+		l1 := &fakeL1{blocks: []types.Transactions{
+			{newBlobTx(otherKey, eth.MaxBlobDataSize+1), newBlobTx(batcherKey, eth.MaxBlobDataSize+10)},
+		}}
+		blobs := newFakeBlobs(l1)
+
+		require.NoError(t, checkBatchSizeUnder(context.Background(), l1, blobs, batcherAddr, eth.MaxBlobDataSize+10, 1))
+		require.ErrorContains(t, checkBatchSizeUnder(context.Background(), l1, blobs, batcherAddr, eth.MaxBlobDataSize+9, 1), "over the")
+	})
+}
@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/holiman/uint256"
+)
+
+// blobTxGas is the gas limit of the blob transactions built by PublishBlobTx, which only transfer their blobs.
+const blobTxGas = 21_000
+
+// NewBlobTxSidecar splits `data` into as many blobs as needed and returns them along with their KZG commitments and
+// proofs.
+func NewBlobTxSidecar(data []byte) (*types.BlobTxSidecar, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no blob data")
+	}
+
+	sidecar := &types.BlobTxSidecar{}
+	for start := 0; start < len(data); start += eth.MaxBlobDataSize {
+		var blob eth.Blob
+		if err := blob.FromData(data[start:min(start+eth.MaxBlobDataSize, len(data))]); err != nil {
+			return nil, fmt.Errorf("failed to encode blob %d: %w", len(sidecar.Blobs), err)
+		}
+		kzgBlob := blob.KZGBlob()
+
+		commitment, err := kzg4844.BlobToCommitment(kzgBlob)
+		if err != nil {
+			return nil, fmt.Errorf("failed to commit to blob %d: %w", len(sidecar.Blobs), err)
+		}
+		proof, err := kzg4844.ComputeBlobProof(kzgBlob, commitment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute the proof of blob %d: %w", len(sidecar.Blobs), err)
+		}
+
+		sidecar.Blobs = append(sidecar.Blobs, *kzgBlob)
+		sidecar.Commitments = append(sidecar.Commitments, commitment)
+		sidecar.Proofs = append(sidecar.Proofs, proof)
+	}
+	return sidecar, nil
+}
+
+// PublishBlobTx signs a blob transaction to `to` carrying `data` with `key` and sends it to the mempool of the EL, so
+// that the next block built includes it. The fee caps are twice the current base fees, so that the transaction stays
+// includable for a few blocks. The returned transaction carries its sidecar.
+func (s *TestBlockBuilder) PublishBlobTx(ctx context.Context, key *ecdsa.PrivateKey, to common.Address, data []byte) (*types.Transaction, error) {
+	sidecar, err := NewBlobTxSidecar(data)
+	if err != nil {
+		return nil, err
+	}
+
+	chainID, err := s.ethClient.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch chain ID: %w", err)
+	}
+	nonce, err := s.ethClient.PendingNonceAt(ctx, crypto.PubkeyToAddress(key.PublicKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch nonce: %w", err)
+	}
+	tip, err := s.ethClient.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch gas tip cap: %w", err)
+	}
+	head, err := s.ethClient.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch latest header: %w", err)
+	}
+	blobBaseFee, err := s.ethClient.BlobBaseFee(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch blob base fee: %w", err)
+	}
+
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(chainID), &types.BlobTx{
+		ChainID:    uint256.MustFromBig(chainID),
+		Nonce:      nonce,
+		GasTipCap:  uint256.MustFromBig(tip),
+		GasFeeCap:  uint256.MustFromBig(new(big.Int).Add(tip, new(big.Int).Mul(head.BaseFee, big.NewInt(2)))),
+		Gas:        blobTxGas,
+		To:         to,
+		BlobFeeCap: uint256.MustFromBig(new(big.Int).Mul(blobBaseFee, big.NewInt(2))),
+		BlobHashes: sidecar.BlobHashes(),
+		Sidecar:    sidecar,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign blob transaction: %w", err)
+	}
+
+	if err := s.ethClient.SendTransaction(ctx, tx); err != nil {
+		return nil, fmt.Errorf("failed to send blob transaction %s: %w", tx.Hash(), err)
+	}
+	return tx, nil
+}
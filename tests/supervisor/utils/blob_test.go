@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBlobTxSidecar(t *testing.T) {
+	t.Run("split into blobs", func(t *testing.T) {
+		data := bytes.Repeat([]byte{0xab}, eth.MaxBlobDataSize+1)
+		sidecar, err := NewBlobTxSidecar(data)
+		require.NoError(t, err)
+		require.Len(t, sidecar.Blobs, 2)
+		require.Len(t, sidecar.BlobHashes(), 2)
+
+		for i := range sidecar.Blobs {
+			require.NoError(t, kzg4844.VerifyBlobProof(&sidecar.Blobs[i], sidecar.Commitments[i], sidecar.Proofs[i]))
+		}
+
+		blob := eth.Blob(sidecar.Blobs[1])
+		decoded, err := blob.ToData()
+		require.NoError(t, err)
+		require.Equal(t, data[eth.MaxBlobDataSize:], []byte(decoded))
+	})
+
+	t.Run("no data", func(t *testing.T) {
+		_, err := NewBlobTxSidecar(nil)
+		require.EqualError(t, err, "no blob data")
+	})
+}
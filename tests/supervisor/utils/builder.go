@@ -15,6 +15,7 @@ import (
 	"math/rand"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
@@ -22,6 +23,7 @@ import (
 	"github.com/ethereum-optimism/optimism/op-service/testutils"
 	"github.com/ethereum/go-ethereum/beacon/engine"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
@@ -47,23 +49,44 @@ type rpcError struct {
 	Message string `json:"message"`
 }
 
+// defaultBlockTime is the timestamp delta between a block and its parent, in seconds, when none is configured.
+const defaultBlockTime = 6
+
 type TestBlockBuilderConfig struct {
 	safeBlockDistance      uint64
 	finalizedBlockDistance uint64
 
+	// BlockTime is the timestamp delta between a block and its parent, in seconds. It defaults to defaultBlockTime.
+	BlockTime uint64
+	// FeeRecipient is the fee recipient of the built blocks. It defaults to the fee recipient of the parent.
+	FeeRecipient *common.Address
+
 	GethRPC string
 
 	EngineRPC string
 	JWTSecret string
+
+	// NoWithdrawals disables the random withdrawals added to each block, so that the produced payloads only
+	// depend on the transactions in the mempool.
+	NoWithdrawals bool
 }
 
 type TestBlockBuilder struct {
 	t devtest.CommonT
 
+	// buildMu serializes block production, so that the blocks built by the POS loop do not interleave with the ones a
+	// test builds, for example to reorg the chain.
+	buildMu sync.Mutex
+
 	withdrawalsIndex uint64
 
 	cfg       TestBlockBuilderConfig
 	ethClient *ethclient.Client
+
+	// lastPayload is the last payload that was successfully inserted into the chain.
+	lastPayload *engine.ExecutionPayloadEnvelope
+	// lastBeaconRoot is the parent beacon block root that lastPayload was inserted with.
+	lastBeaconRoot *common.Hash
 }
 
 func NewTestBlockBuilder(t devtest.CommonT, cfg TestBlockBuilderConfig) *TestBlockBuilder {
@@ -73,7 +96,7 @@ func NewTestBlockBuilder(t devtest.CommonT, cfg TestBlockBuilderConfig) *TestBlo
 		return nil
 	}
 
-	return &TestBlockBuilder{t, 1001, cfg, ethClient}
+	return &TestBlockBuilder{t: t, withdrawalsIndex: 1001, cfg: cfg, ethClient: ethClient}
 }
 
 func createJWT(secret []byte) (string, error) {
@@ -98,8 +121,15 @@ func createJWT(secret []byte) (string, error) {
 }
 
 func (s *TestBlockBuilder) rpcCallWithJWT(url, method string, params interface{}) (*rpcResponse, error) {
+	return s.rpcCallWithJWTContext(context.Background(), url, method, params)
+}
+
+func (s *TestBlockBuilder) rpcCallWithJWTContext(ctx context.Context, url, method string, params interface{}) (*rpcResponse, error) {
 	reqBody, _ := json.Marshal(rpcRequest{Jsonrpc: "2.0", Method: method, Params: params, ID: 1})
-	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(reqBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
 	req.Header.Set("Content-Type", "application/json")
 
 	// Create JWT token
@@ -142,66 +172,241 @@ func (s *TestBlockBuilder) rewindTo(ctx context.Context, blockHash common.Hash)
 
 	block, err := s.ethClient.BlockByHash(ctx, blockHash)
 	if err != nil {
-		s.t.Errorf("failed to fetch block by hash %s: %v", blockHash.Hex(), err)
-		return nil, fmt.Errorf("failed to fetch block by hash: %v", err)
+		return nil, fmt.Errorf("failed to fetch block by hash %s: %w", blockHash.Hex(), err)
+	}
+
+	// Rewinding below the finalized block would revert finalized state, which is not a reorg that can happen.
+	finalized, err := s.ethClient.BlockByNumber(ctx, big.NewInt(rpc.FinalizedBlockNumber.Int64()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the finalized block before rewinding to %s: %w", blockHash.Hex(), err)
+	}
+	if err := checkRewindNotBelowFinalized(block.NumberU64(), finalized.NumberU64()); err != nil {
+		return nil, fmt.Errorf("refusing to rewind to block %s: %w", blockHash.Hex(), err)
 	}
 
 	// Attempt rewind using debug_setHead
 	_, err = s.rpcCall(s.cfg.GethRPC, "debug_setHead", []interface{}{fmt.Sprintf("0x%x", block.NumberU64())})
 	if err != nil {
-		s.t.Errorf("failed to rewind to block %s: %v", blockHash.Hex(), err)
-		return nil, fmt.Errorf("rewind failed: %v", err)
+		return nil, fmt.Errorf("failed to rewind to block %s: %w", blockHash.Hex(), err)
 	}
 
 	// Confirm head matches requested parent
 	head, err := s.ethClient.BlockByNumber(ctx, big.NewInt(int64(rpc.LatestBlockNumber)))
 	if err != nil {
-		s.t.Errorf("failed to fetch latest block: %v", err)
-		return nil, fmt.Errorf("failed to fetch latest block: %v", err)
+		return nil, fmt.Errorf("failed to fetch latest block: %w", err)
 	}
 
 	if head.Hash() != blockHash {
-		s.t.Errorf("head mismatch after rewind: expected %s, got %s", blockHash.Hex(), head.Hash().Hex())
-		return nil, fmt.Errorf("head mismatch after rewind")
+		return nil, fmt.Errorf("head mismatch after rewind: expected %s, got %s", blockHash.Hex(), head.Hash().Hex())
 	}
 
 	s.t.Logf("Successfully rewound to block %s", blockHash.Hex())
 	return block, nil
 }
 
-func (s *TestBlockBuilder) BuildBlock(ctx context.Context, parentHash *common.Hash) {
+// checkRewindNotBelowFinalized returns an error if the rewind target is below the finalized block. Unlike a target
+// at or below it, which the guard was first specified to reject, a target at the finalized block is allowed: rewindTo
+// makes the target the new head, so only the blocks above it are reverted and the finalized block stays canonical.
+// Rejecting it would forbid building a fork right on top of the finalized block, which is a reorg L1 can go through.
+func checkRewindNotBelowFinalized(target, finalized uint64) error {
+	if target < finalized {
+		return fmt.Errorf("rewind target %d is below the finalized block %d", target, finalized)
+	}
+	return nil
+}
+
+// BuildOption configures a single BuildBlock call. The settings it does not override are the ones of the builder.
+type BuildOption func(*buildOptions)
+
+type buildOptions struct {
+	transactions []hexutil.Bytes
+
+	blockTime         uint64
+	feeRecipient      *common.Address
+	safeDistance      uint64
+	finalizedDistance uint64
+	freezeFinality    bool
+	skippedSlots      uint64
+}
+
+// buildOptions returns the settings of the builder overridden by `opts`. It must be called with buildMu held.
+func (s *TestBlockBuilder) buildOptions(opts []BuildOption) (buildOptions, error) {
+	options := buildOptions{
+		blockTime:         s.cfg.BlockTime,
+		feeRecipient:      s.cfg.FeeRecipient,
+		safeDistance:      s.cfg.safeBlockDistance,
+		finalizedDistance: s.cfg.finalizedBlockDistance,
+	}
+	if options.blockTime == 0 {
+		options.blockTime = defaultBlockTime
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options, options.check()
+}
+
+// check checks that blocks can be built with the options.
+func (o buildOptions) check() error {
+	if o.blockTime == 0 {
+		return fmt.Errorf("block time must be positive")
+	}
+	if o.safeDistance > o.finalizedDistance {
+		return fmt.Errorf("safe distance %d is over the finalized distance %d", o.safeDistance, o.finalizedDistance)
+	}
+	return nil
+}
+
+// WithTransactions has the block include the signed `transactions`, e.g. batcher or deposit transactions, so that
+// tests control what L1 blocks carry. L1 payload attributes cannot carry transactions, so they are sent to the mempool
+// of the EL right before the block is built, and BuildBlock fails if the payload does not include all of them.
+func WithTransactions(transactions []hexutil.Bytes) BuildOption {
+	return func(o *buildOptions) {
+		o.transactions = append(o.transactions, transactions...)
+	}
+}
+
+// WithBlockTime sets the timestamp delta between the block and its parent, in seconds, to simulate slow L1 slots or
+// timestamp drift.
+func WithBlockTime(seconds uint64) BuildOption {
+	return func(o *buildOptions) {
+		o.blockTime = seconds
+	}
+}
+
+// WithFeeRecipient sets the fee recipient of the block.
+func WithFeeRecipient(recipient common.Address) BuildOption {
+	return func(o *buildOptions) {
+		o.feeRecipient = &recipient
+	}
+}
+
+// WithFinalityLag sets how many blocks the safe and finalized blocks trail the parent of the block by.
+func WithFinalityLag(safeDistance, finalizedDistance uint64) BuildOption {
+	return func(o *buildOptions) {
+		o.safeDistance = safeDistance
+		o.finalizedDistance = finalizedDistance
+	}
+}
+
+// WithFrozenFinality keeps the safe and finalized blocks of the EL where they are, to simulate an L1 finality stall.
+func WithFrozenFinality() BuildOption {
+	return func(o *buildOptions) {
+		o.freezeFinality = true
+	}
+}
+
+// withSkippedSlots leaves room for `slots` missed slots between the block and its parent, so that its timestamp is
+// the one it would have on an L1 that missed them.
+func withSkippedSlots(slots uint64) BuildOption {
+	return func(o *buildOptions) {
+		o.skippedSlots = slots
+	}
+}
+
+// SetBlockTime sets the timestamp delta between the next blocks and their parent, in seconds.
+func (s *TestBlockBuilder) SetBlockTime(seconds uint64) {
+	s.buildMu.Lock()
+	defer s.buildMu.Unlock()
+	s.cfg.BlockTime = seconds
+}
+
+// SetFeeRecipient sets the fee recipient of the next blocks.
+func (s *TestBlockBuilder) SetFeeRecipient(recipient common.Address) {
+	s.buildMu.Lock()
+	defer s.buildMu.Unlock()
+	s.cfg.FeeRecipient = &recipient
+}
+
+// SetFinalityLag sets how many blocks the safe and finalized blocks trail the head by, to simulate changing finality
+// lag. It takes effect with the next block built.
+func (s *TestBlockBuilder) SetFinalityLag(safeDistance, finalizedDistance uint64) {
+	s.buildMu.Lock()
+	defer s.buildMu.Unlock()
+	s.cfg.safeBlockDistance = safeDistance
+	s.cfg.finalizedBlockDistance = finalizedDistance
+}
+
+// BuildBlock builds a block on top of `parentHash`, rewinding the EL to it first, or on top of the latest block if
+// `parentHash` is nil. It inserts the block, makes it the head of the EL and returns its payload, or the error of the
+// first step that failed, in which case the head may have been rewound already.
+func (s *TestBlockBuilder) BuildBlock(ctx context.Context, parentHash *common.Hash, opts ...BuildOption) (*engine.ExecutionPayloadEnvelope, error) {
+	s.buildMu.Lock()
+	defer s.buildMu.Unlock()
+
+	options, err := s.buildOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	txHashes, err := transactionHashes(options.transactions)
+	if err != nil {
+		return nil, err
+	}
+
 	var head *types.Block
-	var err error
 	if parentHash != nil {
 		head, err = s.rewindTo(ctx, *parentHash)
 		if err != nil {
-			s.t.Errorf("failed to rewind to parent block: %v", err)
-			return
+			return nil, fmt.Errorf("failed to rewind to parent block: %w", err)
 		}
 	} else {
 		head, err = s.ethClient.BlockByNumber(ctx, big.NewInt(int64(rpc.LatestBlockNumber)))
 		if err != nil {
-			s.t.Errorf("failed to fetch latest block: %v", err)
-			return
+			return nil, fmt.Errorf("failed to fetch latest block: %w", err)
 		}
 	}
 
+	fcState, err := s.forkchoiceState(ctx, head, options)
+	if err != nil {
+		return nil, err
+	}
+	payloadAttrs := s.payloadAttributes(head, options)
+
+	// Send the transactions once the EL is at the parent, as rewinding it resets its mempool.
+	if err := s.sendTransactions(options.transactions); err != nil {
+		return nil, err
+	}
+
+	envelope, err := s.buildPayload(fcState, payloadAttrs)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTransactionsIncluded(envelope.ExecutionPayload.Transactions, txHashes); err != nil {
+		return nil, err
+	}
+	if err := s.insertPayload(envelope, payloadAttrs.BeaconRoot); err != nil {
+		return nil, err
+	}
+	if err := s.setHead(fcState, envelope.ExecutionPayload.BlockHash); err != nil {
+		return nil, err
+	}
+
+	s.advanceWithdrawalsIndex(envelope.ExecutionPayload.Withdrawals)
+	s.lastPayload = envelope
+	s.lastBeaconRoot = payloadAttrs.BeaconRoot
+
+	s.t.Logf("Successfully built block %s:%d at timestamp %d", envelope.ExecutionPayload.BlockHash.Hex(), envelope.ExecutionPayload.Number, payloadAttrs.Timestamp)
+	return envelope, nil
+}
+
+// forkchoiceState returns the forkchoice state with `head` as head, and the safe and finalized blocks trailing it by the
+// distances of the options, or the current ones of the EL if the options freeze finality.
+func (s *TestBlockBuilder) forkchoiceState(ctx context.Context, head *types.Block, options buildOptions) (engine.ForkchoiceStateV1, error) {
+	var err error
 	finalizedBlock, _ := s.ethClient.BlockByNumber(ctx, big.NewInt(rpc.FinalizedBlockNumber.Int64()))
 	if finalizedBlock == nil {
 		// set sb to genesis if safe block is not set
 		finalizedBlock, err = s.ethClient.BlockByNumber(ctx, big.NewInt(0))
 		if err != nil {
-			s.t.Errorf("failed to fetch genesis block: %v", err)
-			return
+			return engine.ForkchoiceStateV1{}, fmt.Errorf("failed to fetch genesis block: %w", err)
 		}
 	}
 
 	// progress finalised block
-	if head.NumberU64() > uint64(s.cfg.finalizedBlockDistance) {
-		finalizedBlock, err = s.ethClient.BlockByNumber(ctx, big.NewInt(int64(head.NumberU64()-s.cfg.finalizedBlockDistance)))
+	if !options.freezeFinality && head.NumberU64() > options.finalizedDistance {
+		finalizedBlock, err = s.ethClient.BlockByNumber(ctx, big.NewInt(int64(head.NumberU64()-options.finalizedDistance)))
 		if err != nil {
-			s.t.Errorf("failed to fetch safe block: %v", err)
-			return
+			return engine.ForkchoiceStateV1{}, fmt.Errorf("failed to fetch finalized block: %w", err)
 		}
 	}
 
@@ -211,112 +416,329 @@ func (s *TestBlockBuilder) BuildBlock(ctx context.Context, parentHash *common.Ha
 	}
 
 	// progress safe block
-	if head.NumberU64() > uint64(s.cfg.safeBlockDistance) {
-		safeBlock, err = s.ethClient.BlockByNumber(ctx, big.NewInt(int64(head.NumberU64()-s.cfg.safeBlockDistance)))
+	if !options.freezeFinality && head.NumberU64() > options.safeDistance {
+		safeBlock, err = s.ethClient.BlockByNumber(ctx, big.NewInt(int64(head.NumberU64()-options.safeDistance)))
 		if err != nil {
-			s.t.Errorf("failed to fetch safe block: %v", err)
-			return
+			return engine.ForkchoiceStateV1{}, fmt.Errorf("failed to fetch safe block: %w", err)
 		}
 	}
 
-	fcState := engine.ForkchoiceStateV1{
+	return engine.ForkchoiceStateV1{
 		HeadBlockHash:      head.Hash(),
 		SafeBlockHash:      safeBlock.Hash(),
 		FinalizedBlockHash: finalizedBlock.Hash(),
-	}
+	}, nil
+}
 
-	newBlockTimestamp := head.Time() + 6
+// payloadAttributes returns the attributes of a child of `head` with the block time, skipped slots and fee recipient of
+// the options, a random prevrandao and random withdrawals.
+func (s *TestBlockBuilder) payloadAttributes(head *types.Block, options buildOptions) engine.PayloadAttributes {
 	nonce := time.Now().UnixNano()
 	var nonceBytes [8]byte
 	binary.LittleEndian.PutUint64(nonceBytes[:], uint64(nonce))
-	randomHash := crypto.Keccak256Hash(nonceBytes[:])
-	payloadAttrs := engine.PayloadAttributes{
-		Timestamp:             uint64(newBlockTimestamp),
-		Random:                randomHash,
-		SuggestedFeeRecipient: head.Coinbase(),
-		Withdrawals:           randomWithdrawals(s.withdrawalsIndex),
-		BeaconRoot:            fakeBeaconBlockRoot(uint64(head.Time())),
-	}
-
-	// Start payload build
-	fcResp, err := s.rpcCallWithJWT(s.cfg.EngineRPC, "engine_forkchoiceUpdatedV3",
-		[]interface{}{fcState, payloadAttrs})
+	feeRecipient := head.Coinbase()
+	if options.feeRecipient != nil {
+		feeRecipient = *options.feeRecipient
+	}
+	return engine.PayloadAttributes{
+		Timestamp:             head.Time() + options.blockTime*(options.skippedSlots+1),
+		Random:                crypto.Keccak256Hash(nonceBytes[:]),
+		SuggestedFeeRecipient: feeRecipient,
+		Withdrawals:           s.randomWithdrawals(),
+		BeaconRoot:            fakeBeaconBlockRoot(head.Time()),
+	}
+}
+
+// buildPayload starts building a payload with `attrs` on top of the head of `fcState` and returns it once built.
+func (s *TestBlockBuilder) buildPayload(fcState engine.ForkchoiceStateV1, attrs engine.PayloadAttributes) (*engine.ExecutionPayloadEnvelope, error) {
+	fcResp, err := s.rpcCallWithJWT(s.cfg.EngineRPC, "engine_forkchoiceUpdatedV3", []interface{}{fcState, attrs})
 	if err != nil {
-		s.t.Errorf("forkchoiceUpdated failed: %v", err)
-		return
+		return nil, fmt.Errorf("forkchoiceUpdated failed: %w", err)
 	}
 
 	var fcResult engine.ForkChoiceResponse
-	json.Unmarshal(fcResp.Result, &fcResult)
+	if err := json.Unmarshal(fcResp.Result, &fcResult); err != nil {
+		return nil, fmt.Errorf("failed to decode forkchoiceUpdated result: %w", err)
+	}
 	if fcResult.PayloadStatus.Status != "VALID" && fcResult.PayloadStatus.Status != "SYNCING" {
-		s.t.Errorf("forkchoiceUpdated returned invalid status: %s", fcResult.PayloadStatus.Status)
-		return
+		return nil, fmt.Errorf("forkchoiceUpdated returned invalid status: %s", fcResult.PayloadStatus.Status)
 	}
 
 	if fcResult.PayloadID == nil {
-		s.t.Errorf("forkchoiceUpdated did not return a payload ID")
-		return
+		return nil, fmt.Errorf("forkchoiceUpdated did not return a payload ID")
 	}
 
 	time.Sleep(150 * time.Millisecond)
 
-	// Get payload
 	plResp, err := s.rpcCallWithJWT(s.cfg.EngineRPC, "engine_getPayloadV3", []interface{}{fcResult.PayloadID})
 	if err != nil {
-		s.t.Errorf("getPayload failed: %v", err)
-		return
+		return nil, fmt.Errorf("getPayload failed: %w", err)
 	}
 
 	var envelope engine.ExecutionPayloadEnvelope
-	json.Unmarshal(plResp.Result, &envelope)
+	if err := json.Unmarshal(plResp.Result, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to decode getPayload result: %w", err)
+	}
 	if envelope.ExecutionPayload == nil {
-		s.t.Errorf("getPayload returned empty execution payload")
-		return
+		return nil, fmt.Errorf("getPayload returned empty execution payload")
 	}
+	return &envelope, nil
+}
 
-	blobHashes := make([]common.Hash, 0)
-	if envelope.BlobsBundle != nil {
-		for _, commitment := range envelope.BlobsBundle.Commitments {
-			if len(commitment) != 48 {
-				break
-			}
-			blobHashes = append(blobHashes, opeth.KZGToVersionedHash(*(*[48]byte)(commitment)))
-		}
-		if len(blobHashes) != len(envelope.BlobsBundle.Commitments) {
-			s.t.Errorf("blob hashes length mismatch: expected %d, got %d", len(envelope.BlobsBundle.Commitments), len(blobHashes))
-			return
-		}
+// insertPayload inserts the payload built with `beaconRoot` into the EL through engine_newPayloadV3, without making it
+// the head.
+func (s *TestBlockBuilder) insertPayload(envelope *engine.ExecutionPayloadEnvelope, beaconRoot *common.Hash) error {
+	blobHashes, err := VersionedHashes(envelope.BlobsBundle)
+	if err != nil {
+		return fmt.Errorf("failed to compute blob hashes: %w", err)
 	}
 
-	// Insert
-	newPayloadResp, err := s.rpcCallWithJWT(s.cfg.EngineRPC, "engine_newPayloadV3", []interface{}{envelope.ExecutionPayload, blobHashes, payloadAttrs.BeaconRoot})
+	newPayloadResp, err := s.rpcCallWithJWT(s.cfg.EngineRPC, "engine_newPayloadV3", []interface{}{envelope.ExecutionPayload, blobHashes, beaconRoot})
 	if err != nil {
-		s.t.Errorf("newPayload failed: %v", err)
-		return
+		return fmt.Errorf("newPayload failed: %w", err)
 	}
 
 	var npRes engine.PayloadStatusV1
-	json.Unmarshal(newPayloadResp.Result, &npRes)
+	if err := json.Unmarshal(newPayloadResp.Result, &npRes); err != nil {
+		return fmt.Errorf("failed to decode newPayload result: %w", err)
+	}
 	if npRes.Status != "VALID" && npRes.Status != "ACCEPTED" {
-		s.t.Errorf("newPayload returned invalid status: %s", npRes.Status)
-		return
+		return fmt.Errorf("newPayload returned invalid status: %s", npRes.Status)
 	}
+	return nil
+}
 
-	// Update forkchoice
-	updateFc := engine.ForkchoiceStateV1{
-		HeadBlockHash:      envelope.ExecutionPayload.BlockHash,
-		SafeBlockHash:      safeBlock.Hash(),
-		FinalizedBlockHash: finalizedBlock.Hash(),
+// setHead makes `head` the head of the EL, keeping the safe and finalized blocks of `fcState`.
+func (s *TestBlockBuilder) setHead(fcState engine.ForkchoiceStateV1, head common.Hash) error {
+	fcState.HeadBlockHash = head
+	if _, err := s.rpcCallWithJWT(s.cfg.EngineRPC, "engine_forkchoiceUpdatedV3", []interface{}{fcState, nil}); err != nil {
+		return fmt.Errorf("forkchoiceUpdated failed after newPayload: %w", err)
+	}
+	return nil
+}
+
+// MustBuildBlock is BuildBlock failing the test on error. It must be called from the test goroutine.
+func (s *TestBlockBuilder) MustBuildBlock(ctx context.Context, parentHash *common.Hash, opts ...BuildOption) *engine.ExecutionPayloadEnvelope {
+	envelope, err := s.BuildBlock(ctx, parentHash, opts...)
+	s.t.Require().NoError(err, "failed to build block")
+	return envelope
+}
+
+// BuildCompetingBlocks builds `n` distinct children of `parent`, which differ in their prevrandao and fee recipient,
+// rewinding the EL to `parent` first. All of them are inserted into the EL, and the first one is made its head, so
+// that tests can build forks and switch between them with SetHead instead of rewinding and rebuilding. The options
+// cannot include transactions.
+func (s *TestBlockBuilder) BuildCompetingBlocks(ctx context.Context, parent common.Hash, n int, opts ...BuildOption) ([]*engine.ExecutionPayloadEnvelope, error) {
+	if n < 2 {
+		return nil, fmt.Errorf("need at least 2 competing blocks, got %d", n)
 	}
-	_, err = s.rpcCallWithJWT(s.cfg.EngineRPC, "engine_forkchoiceUpdatedV3", []interface{}{updateFc, nil})
+
+	s.buildMu.Lock()
+	defer s.buildMu.Unlock()
+
+	options, err := s.buildOptions(opts)
 	if err != nil {
-		s.t.Errorf("forkchoiceUpdated failed after newPayload: %v", err)
-		return
+		return nil, err
+	}
+	if len(options.transactions) > 0 {
+		return nil, fmt.Errorf("competing blocks cannot include transactions")
+	}
+
+	head, err := s.rewindTo(ctx, parent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rewind to parent block: %w", err)
+	}
+	fcState, err := s.forkchoiceState(ctx, head, options)
+	if err != nil {
+		return nil, err
+	}
+	attrs := s.payloadAttributes(head, options)
+
+	envelopes := make([]*engine.ExecutionPayloadEnvelope, 0, n)
+	for i, variant := range competingPayloadAttributes(attrs, n) {
+		envelope, err := s.buildPayload(fcState, variant)
+		if err != nil {
+			return nil, fmt.Errorf("competing block %d: %w", i, err)
+		}
+		if err := s.insertPayload(envelope, variant.BeaconRoot); err != nil {
+			return nil, fmt.Errorf("competing block %d: %w", i, err)
+		}
+		envelopes = append(envelopes, envelope)
+	}
+
+	if err := s.setHead(fcState, envelopes[0].ExecutionPayload.BlockHash); err != nil {
+		return nil, err
+	}
+
+	s.advanceWithdrawalsIndex(attrs.Withdrawals)
+	s.lastPayload = envelopes[0]
+	s.lastBeaconRoot = attrs.BeaconRoot
+
+	s.t.Logf("Successfully built %d competing blocks on top of %s", n, parent.Hex())
+	return envelopes, nil
+}
+
+// competingPayloadAttributes returns `n` copies of `attrs` with distinct prevrandao and fee recipients, so that the
+// payloads built from them are distinct blocks.
+func competingPayloadAttributes(attrs engine.PayloadAttributes, n int) []engine.PayloadAttributes {
+	variants := make([]engine.PayloadAttributes, n)
+	for i := range n {
+		var index [8]byte
+		binary.BigEndian.PutUint64(index[:], uint64(i))
+		variants[i] = attrs
+		variants[i].Random = crypto.Keccak256Hash(attrs.Random[:], index[:])
+		variants[i].SuggestedFeeRecipient = common.BytesToAddress(variants[i].Random[:common.AddressLength])
+	}
+	return variants
+}
+
+// SetHead makes the block `hash`, e.g. one of the competing blocks, the head of the EL without rebuilding it.
+func (s *TestBlockBuilder) SetHead(ctx context.Context, hash common.Hash) error {
+	s.buildMu.Lock()
+	defer s.buildMu.Unlock()
+
+	block, err := s.ethClient.BlockByHash(ctx, hash)
+	if err != nil {
+		return fmt.Errorf("failed to fetch block by hash %s: %w", hash.Hex(), err)
+	}
+	options, err := s.buildOptions(nil)
+	if err != nil {
+		return err
+	}
+	fcState, err := s.forkchoiceState(ctx, block, options)
+	if err != nil {
+		return err
+	}
+	return s.setHead(fcState, hash)
+}
+
+// sendTransactions sends the signed transactions to the mempool of the EL. A transaction the mempool already holds is
+// not an error.
+func (s *TestBlockBuilder) sendTransactions(transactions []hexutil.Bytes) error {
+	for i, tx := range transactions {
+		if _, err := s.rpcCall(s.cfg.GethRPC, "eth_sendRawTransaction", []interface{}{tx}); err != nil && !strings.Contains(err.Error(), "already known") {
+			return fmt.Errorf("failed to send transaction %d: %w", i, err)
+		}
 	}
+	return nil
+}
+
+// transactionHashes decodes the signed transactions and returns their hashes.
+func transactionHashes(transactions []hexutil.Bytes) ([]common.Hash, error) {
+	hashes := make([]common.Hash, len(transactions))
+	for i, raw := range transactions {
+		var tx types.Transaction
+		if err := tx.UnmarshalBinary(raw); err != nil {
+			return nil, fmt.Errorf("failed to decode transaction %d: %w", i, err)
+		}
+		hashes[i] = tx.Hash()
+	}
+	return hashes, nil
+}
+
+// checkTransactionsIncluded checks that the payload transactions include all the `expected` ones.
+func checkTransactionsIncluded(payloadTxs [][]byte, expected []common.Hash) error {
+	if len(expected) == 0 {
+		return nil
+	}
+
+	included := make(map[common.Hash]bool, len(payloadTxs))
+	for i, raw := range payloadTxs {
+		var tx types.Transaction
+		if err := tx.UnmarshalBinary(raw); err != nil {
+			return fmt.Errorf("failed to decode payload transaction %d: %w", i, err)
+		}
+		included[tx.Hash()] = true
+	}
+
+	for _, hash := range expected {
+		if !included[hash] {
+			return fmt.Errorf("transaction %s was not included in the payload", hash)
+		}
+	}
+	return nil
+}
+
+// LastPayload returns the last payload built and inserted by the builder, or nil if no block was built yet.
+func (s *TestBlockBuilder) LastPayload() *engine.ExecutionPayloadEnvelope {
+	s.buildMu.Lock()
+	defer s.buildMu.Unlock()
+
+	return s.lastPayload
+}
+
+// ResubmitLastPayload submits the last payload built by the builder to the EL again, through engine_newPayloadV3, and
+// returns the resulting payload status.
+func (s *TestBlockBuilder) ResubmitLastPayload(ctx context.Context) (engine.PayloadStatusV1, error) {
+	last, beaconRoot := s.lastInserted()
+	if last == nil {
+		return engine.PayloadStatusV1{}, fmt.Errorf("no payload was built yet")
+	}
+	return s.newPayload(ctx, last.ExecutionPayload, last.BlobsBundle, beaconRoot)
+}
+
+// ResubmitLastPayloadWithParent submits a copy of the last payload built by the builder, pointing at `parent` instead
+// of its actual parent, and returns the resulting payload status. The block hash is left untouched, so that the EL must
+// reject the payload.
+func (s *TestBlockBuilder) ResubmitLastPayloadWithParent(ctx context.Context, parent common.Hash) (engine.PayloadStatusV1, error) {
+	last, beaconRoot := s.lastInserted()
+	if last == nil {
+		return engine.PayloadStatusV1{}, fmt.Errorf("no payload was built yet")
+	}
+	payload := *last.ExecutionPayload
+	payload.ParentHash = parent
+	return s.newPayload(ctx, &payload, last.BlobsBundle, beaconRoot)
+}
 
-	s.withdrawalsIndex += uint64(len(envelope.ExecutionPayload.Withdrawals))
+// lastInserted returns a snapshot of the last inserted payload and of the beacon root it was inserted with, taken
+// under buildMu so that it does not race with the POS loop.
+func (s *TestBlockBuilder) lastInserted() (*engine.ExecutionPayloadEnvelope, *common.Hash) {
+	s.buildMu.Lock()
+	defer s.buildMu.Unlock()
 
-	s.t.Logf("Successfully built block %s:%d at timestamp %d", envelope.ExecutionPayload.BlockHash.Hex(), envelope.ExecutionPayload.Number, newBlockTimestamp)
+	return s.lastPayload, s.lastBeaconRoot
+}
+
+// newPayload submits the payload through engine_newPayloadV3, along with the given blobs and beacon root.
+func (s *TestBlockBuilder) newPayload(ctx context.Context, payload *engine.ExecutableData, blobs *engine.BlobsBundleV1, beaconRoot *common.Hash) (engine.PayloadStatusV1, error) {
+	blobHashes, err := VersionedHashes(blobs)
+	if err != nil {
+		return engine.PayloadStatusV1{}, fmt.Errorf("failed to compute blob hashes: %w", err)
+	}
+
+	resp, err := s.rpcCallWithJWTContext(ctx, s.cfg.EngineRPC, "engine_newPayloadV3", []interface{}{payload, blobHashes, beaconRoot})
+	if err != nil {
+		return engine.PayloadStatusV1{}, fmt.Errorf("newPayload failed: %w", err)
+	}
+	var status engine.PayloadStatusV1
+	if err := json.Unmarshal(resp.Result, &status); err != nil {
+		return engine.PayloadStatusV1{}, fmt.Errorf("failed to decode newPayload result: %w", err)
+	}
+	return status, nil
+}
+
+// LatestBlockHash returns the hash of the latest block of the EL.
+func (s *TestBlockBuilder) LatestBlockHash(ctx context.Context) (common.Hash, error) {
+	header, err := s.ethClient.HeaderByNumber(ctx, big.NewInt(int64(rpc.LatestBlockNumber)))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return header.Hash(), nil
+}
+
+// VersionedHashes computes the EIP-4844 versioned hashes of the commitments in a blobs bundle.
+func VersionedHashes(bundle *engine.BlobsBundleV1) ([]common.Hash, error) {
+	blobHashes := make([]common.Hash, 0)
+	if bundle == nil {
+		return blobHashes, nil
+	}
+	for _, commitment := range bundle.Commitments {
+		if len(commitment) != 48 {
+			return nil, fmt.Errorf("invalid blob commitment length: expected 48, got %d", len(commitment))
+		}
+		blobHashes = append(blobHashes, opeth.KZGToVersionedHash(*(*[48]byte)(commitment)))
+	}
+	return blobHashes, nil
 }
 
 func fakeBeaconBlockRoot(time uint64) *common.Hash {
@@ -326,7 +748,14 @@ func fakeBeaconBlockRoot(time uint64) *common.Hash {
 	return &hash
 }
 
-func randomWithdrawals(startIndex uint64) []*types.Withdrawal {
+// randomWithdrawals returns the withdrawals to include in the next block, starting at the current withdrawals index.
+// It returns an empty slice when withdrawals are disabled.
+func (s *TestBlockBuilder) randomWithdrawals() []*types.Withdrawal {
+	if s.cfg.NoWithdrawals {
+		return []*types.Withdrawal{}
+	}
+
+	startIndex := s.withdrawalsIndex
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
 	withdrawals := make([]*types.Withdrawal, r.Intn(4))
 	for i := 0; i < len(withdrawals); i++ {
@@ -339,3 +768,12 @@ func randomWithdrawals(startIndex uint64) []*types.Withdrawal {
 	}
 	return withdrawals
 }
+
+// advanceWithdrawalsIndex moves the withdrawals index past the withdrawals included in a block.
+// The index is left untouched when withdrawals are disabled.
+func (s *TestBlockBuilder) advanceWithdrawalsIndex(withdrawals []*types.Withdrawal) {
+	if s.cfg.NoWithdrawals {
+		return
+	}
+	s.withdrawalsIndex += uint64(len(withdrawals))
+}
@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNoWithdrawals(t *testing.T) {
+	withdrawals := []*types.Withdrawal{{Index: 1001}, {Index: 1002}}
+
+	t.Run("disabled", func(t *testing.T) {
+		builder := &TestBlockBuilder{withdrawalsIndex: 1001, cfg: TestBlockBuilderConfig{NoWithdrawals: true}}
+
+		for range 10 {
+			require.Empty(t, builder.randomWithdrawals())
+		}
+
+		builder.advanceWithdrawalsIndex(withdrawals)
+		require.Equal(t, uint64(1001), builder.withdrawalsIndex)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		builder := &TestBlockBuilder{withdrawalsIndex: 1001}
+
+		for _, withdrawal := range builder.randomWithdrawals() {
+			require.GreaterOrEqual(t, withdrawal.Index, uint64(1001))
+		}
+
+		builder.advanceWithdrawalsIndex(withdrawals)
+		require.Equal(t, uint64(1003), builder.withdrawalsIndex)
+	})
+}
+
+func TestRewindNotBelowFinalized(t *testing.T) {
+	t.Run("above finalized", func(t *testing.T) {
+		require.NoError(t, checkRewindNotBelowFinalized(11, 10))
+	})
+
+	// The target becomes the new head, so rewinding to the finalized block only reverts blocks above it, and the
+	// finalized block stays canonical.
+	t.Run("at finalized", func(t *testing.T) {
+		require.NoError(t, checkRewindNotBelowFinalized(10, 10))
+		require.NoError(t, checkRewindNotBelowFinalized(0, 0))
+	})
+
+	t.Run("just below finalized", func(t *testing.T) {
+		require.EqualError(t, checkRewindNotBelowFinalized(9, 10), "rewind target 9 is below the finalized block 10")
+	})
+
+	t.Run("below finalized", func(t *testing.T) {
+		require.Error(t, checkRewindNotBelowFinalized(0, 10))
+	})
+}
+
+func TestTransactionsIncluded(t *testing.T) {
+	tx := types.NewTx(&types.LegacyTx{Nonce: 1, Gas: 21000})
+	other := types.NewTx(&types.LegacyTx{Nonce: 2, Gas: 21000})
+	raw, err := tx.MarshalBinary()
+	require.NoError(t, err)
+	otherRaw, err := other.MarshalBinary()
+	require.NoError(t, err)
+
+	var options buildOptions
+	WithTransactions([]hexutil.Bytes{raw})(&options)
+	WithTransactions([]hexutil.Bytes{otherRaw})(&options)
+	hashes, err := transactionHashes(options.transactions)
+	require.NoError(t, err)
+	require.Equal(t, []common.Hash{tx.Hash(), other.Hash()}, hashes)
+
+	t.Run("included", func(t *testing.T) {
+		require.NoError(t, checkTransactionsIncluded([][]byte{otherRaw, raw}, hashes))
+		require.NoError(t, checkTransactionsIncluded(nil, nil))
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		err := checkTransactionsIncluded([][]byte{raw}, hashes)
+		require.EqualError(t, err, "transaction "+other.Hash().String()+" was not included in the payload")
+	})
+
+	t.Run("invalid encoding", func(t *testing.T) {
+		_, err := transactionHashes([]hexutil.Bytes{{0x01, 0x02}})
+		require.ErrorContains(t, err, "failed to decode transaction 0")
+	})
+}
+
+func TestCompetingPayloadAttributes(t *testing.T) {
+	attrs := engine.PayloadAttributes{Timestamp: 12, Random: common.Hash{0x01}, SuggestedFeeRecipient: common.Address{0x02}}
+	variants := competingPayloadAttributes(attrs, 3)
+	require.Len(t, variants, 3)
+
+	randoms := make(map[common.Hash]bool)
+	recipients := make(map[common.Address]bool)
+	for _, variant := range variants {
+		require.Equal(t, attrs.Timestamp, variant.Timestamp)
+		randoms[variant.Random] = true
+		recipients[variant.SuggestedFeeRecipient] = true
+	}
+	require.Len(t, randoms, 3, "the prevrandao of the competing blocks must differ")
+	require.Len(t, recipients, 3, "the fee recipients of the competing blocks must differ")
+	require.Equal(t, variants, competingPayloadAttributes(attrs, 3), "the variants only depend on the attributes")
+}
+
+func TestBuildOptions(t *testing.T) {
+	builder := &TestBlockBuilder{cfg: TestBlockBuilderConfig{safeBlockDistance: 10, finalizedBlockDistance: 20}}
+
+	t.Run("builder settings", func(t *testing.T) {
+		options, err := builder.buildOptions(nil)
+		require.NoError(t, err)
+		require.Equal(t, uint64(defaultBlockTime), options.blockTime)
+		require.Nil(t, options.feeRecipient)
+		require.Equal(t, uint64(10), options.safeDistance)
+		require.Equal(t, uint64(20), options.finalizedDistance)
+	})
+
+	t.Run("per call", func(t *testing.T) {
+		options, err := builder.buildOptions([]BuildOption{WithBlockTime(24), WithFeeRecipient(common.Address{0x01}), WithFinalityLag(2, 4)})
+		require.NoError(t, err)
+		require.Equal(t, uint64(24), options.blockTime)
+		require.Equal(t, &common.Address{0x01}, options.feeRecipient)
+		require.Equal(t, uint64(2), options.safeDistance)
+		require.Equal(t, uint64(4), options.finalizedDistance)
+	})
+
+	t.Run("at runtime", func(t *testing.T) {
+		builder := &TestBlockBuilder{}
+		builder.SetBlockTime(12)
+		builder.SetFeeRecipient(common.Address{0x02})
+		builder.SetFinalityLag(1, 3)
+
+		options, err := builder.buildOptions([]BuildOption{WithBlockTime(2)})
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), options.blockTime, "the call overrides the builder settings")
+		require.Equal(t, &common.Address{0x02}, options.feeRecipient)
+		require.Equal(t, uint64(3), options.finalizedDistance)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := builder.buildOptions([]BuildOption{WithBlockTime(0)})
+		require.EqualError(t, err, "block time must be positive")
+		_, err = builder.buildOptions([]BuildOption{WithFinalityLag(5, 4)})
+		require.EqualError(t, err, "safe distance 5 is over the finalized distance 4")
+	})
+}
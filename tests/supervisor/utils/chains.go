@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+)
+
+// l2HeadSource is satisfied by the *dsl.L2ELNode returned by (*dsl.L2Network).PublicRPC().
+type l2HeadSource interface {
+	BlockRefByLabel(label eth.BlockLabel) eth.L2BlockRef
+}
+
+// AssertChainsAligned makes `b` catch up to `a` and checks that the unsafe heads of the two chains end up within
+// `maxDelta` blocks of each other.
+func AssertChainsAligned(t devtest.T, a, b *dsl.L2Network, maxDelta uint64) {
+	b.CatchUpTo(a)
+
+	t.Require().NoError(checkChainsAligned(a.PublicRPC(), b.PublicRPC(), maxDelta), "chains %s and %s are not aligned", a, b)
+}
+
+func checkChainsAligned(a, b l2HeadSource, maxDelta uint64) error {
+	headA := a.BlockRefByLabel(eth.Unsafe)
+	headB := b.BlockRefByLabel(eth.Unsafe)
+
+	var delta uint64
+	if headA.Number > headB.Number {
+		delta = headA.Number - headB.Number
+	} else {
+		delta = headB.Number - headA.Number
+	}
+
+	if delta > maxDelta {
+		return fmt.Errorf("unsafe heads are %d blocks apart (%d vs %d), expected at most %d", delta, headA.Number, headB.Number, maxDelta)
+	}
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeL2Head always reports the same unsafe head.
+type fakeL2Head struct {
+	number uint64
+}
+
+func (f fakeL2Head) BlockRefByLabel(label eth.BlockLabel) eth.L2BlockRef {
+	return eth.L2BlockRef{Number: f.number}
+}
+
+func TestChainsAligned(t *testing.T) {
+	t.Run("aligned", func(t *testing.T) {
+		require.NoError(t, checkChainsAligned(fakeL2Head{number: 100}, fakeL2Head{number: 102}, 2))
+		require.NoError(t, checkChainsAligned(fakeL2Head{number: 102}, fakeL2Head{number: 100}, 2))
+	})
+
+	t.Run("far apart", func(t *testing.T) {
+		require.Error(t, checkChainsAligned(fakeL2Head{number: 100}, fakeL2Head{number: 150}, 2))
+		require.Error(t, checkChainsAligned(fakeL2Head{number: 150}, fakeL2Head{number: 100}, 2))
+	})
+}
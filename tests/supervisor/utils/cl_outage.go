@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/ethereum-optimism/optimism/op-service/apis"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+)
+
+const (
+	// clOutagePollInterval is how often the supervisor sync status is polled during and after a CL outage.
+	clOutagePollInterval = 2 * time.Second
+	// clOutageSlack is how many blocks the supervisor unsafe head may still advance once the CL is stopped, to
+	// account for an update that was already in flight.
+	clOutageSlack = 1
+	// clRecoveryTimeout bounds how long the supervisor has to resume the chain once the CL is restarted.
+	clRecoveryTimeout = 2 * time.Minute
+)
+
+// clControl is the subset of dsl.L2CLNode used to take the node offline and bring it back.
+type clControl interface {
+	Start()
+	Stop()
+}
+
+// AssertSupervisorHandlesCLOutage stops `cl` for `outage` and checks that the supervisor keeps serving its sync status
+// while the local-unsafe head of the chain stalls. It then restarts `cl` and checks that the supervisor reconnects to
+// it, which shows as the local-unsafe head advancing again.
+func AssertSupervisorHandlesCLOutage(t devtest.T, sup apis.SupervisorQueryAPI, cl dsl.L2CLNode, outage time.Duration) {
+	t.Require().NoError(checkSupervisorHandlesCLOutage(t.Ctx(), sup, cl, cl.ChainID(), outage, clRecoveryTimeout, clOutagePollInterval))
+}
+
+func checkSupervisorHandlesCLOutage(ctx context.Context, sup apis.SupervisorQueryAPI, cl clControl, chainID eth.ChainID, outage, recovery, interval time.Duration) error {
+	cl.Stop()
+
+	stalled, err := watchCLOutage(ctx, sup, chainID, outage, interval)
+	// Always bring the node back, the rest of the test should not run against a degraded system.
+	cl.Start()
+	if err != nil {
+		return err
+	}
+
+	recoveryCtx, cancel := context.WithTimeout(ctx, recovery)
+	defer cancel()
+
+	return waitForSupervisorResume(recoveryCtx, sup, chainID, stalled, interval)
+}
+
+// watchCLOutage polls the supervisor for `outage` and returns the local-unsafe head of `chainID` it stalled at. Every
+// poll must succeed, and the head must not move more than clOutageSlack blocks past the one seen at the first poll.
+func watchCLOutage(ctx context.Context, sup apis.SupervisorQueryAPI, chainID eth.ChainID, outage, interval time.Duration) (eth.BlockRef, error) {
+	deadline := time.NewTimer(outage)
+	defer deadline.Stop()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var baseline, head eth.BlockRef
+	first := true
+	for {
+		current, err := localUnsafe(ctx, sup, chainID)
+		if err != nil {
+			return eth.BlockRef{}, fmt.Errorf("supervisor failed while the CL of chain %s was offline: %w", chainID, err)
+		}
+		if first {
+			baseline = current
+			first = false
+		}
+		if current.Number > baseline.Number+clOutageSlack {
+			return eth.BlockRef{}, fmt.Errorf("chain %s local-unsafe head advanced from %d to %d while its CL was offline", chainID, baseline.Number, current.Number)
+		}
+		head = current
+
+		select {
+		case <-ctx.Done():
+			return eth.BlockRef{}, ctx.Err()
+		case <-deadline.C:
+			return head, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitForSupervisorResume waits for the local-unsafe head of `chainID` to advance past `stalled`.
+func waitForSupervisorResume(ctx context.Context, sup apis.SupervisorQueryAPI, chainID eth.ChainID, stalled eth.BlockRef, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastErr error
+	for {
+		current, err := localUnsafe(ctx, sup, chainID)
+		if err == nil && current.Number > stalled.Number {
+			return nil
+		}
+		lastErr = err
+
+		select {
+		case <-ctx.Done():
+			if lastErr == nil {
+				lastErr = fmt.Errorf("chain %s local-unsafe head did not advance past %d after its CL restarted", chainID, stalled.Number)
+			}
+			return errors.Join(lastErr, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func localUnsafe(ctx context.Context, sup apis.SupervisorQueryAPI, chainID eth.ChainID) (eth.BlockRef, error) {
+	status, err := sup.SyncStatus(ctx)
+	if err != nil {
+		return eth.BlockRef{}, fmt.Errorf("failed to fetch supervisor sync status: %w", err)
+	}
+
+	chain, ok := status.Chains[chainID]
+	if !ok {
+		return eth.BlockRef{}, fmt.Errorf("chain %s is missing from the supervisor sync status", chainID)
+	}
+
+	return chain.LocalUnsafe, nil
+}
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-service/apis"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeManagedCL is a CL whose chain advances by one block at each supervisor poll while it is running.
+type fakeManagedCL struct {
+	running bool
+	head    uint64
+	// advanceOffline makes the chain keep advancing while the node is stopped.
+	advanceOffline bool
+	// stuck makes the chain never advance again once the node is restarted.
+	stuck   bool
+	stopped bool
+}
+
+func (f *fakeManagedCL) Start() { f.running = true }
+func (f *fakeManagedCL) Stop()  { f.running, f.stopped = false, true }
+
+// fakeOutageSupervisor reports the head of the fake CL for its chain. It fails when `failOffline` is set and the CL is
+// down.
+type fakeOutageSupervisor struct {
+	apis.SupervisorQueryAPI
+
+	chainID     eth.ChainID
+	cl          *fakeManagedCL
+	failOffline bool
+}
+
+func (f *fakeOutageSupervisor) SyncStatus(ctx context.Context) (eth.SupervisorSyncStatus, error) {
+	if !f.cl.running && f.failOffline {
+		return eth.SupervisorSyncStatus{}, errors.New("connection refused")
+	}
+	if (f.cl.running && !(f.cl.stopped && f.cl.stuck)) || (!f.cl.running && f.cl.advanceOffline) {
+		f.cl.head++
+	}
+	return eth.SupervisorSyncStatus{
+		Chains: map[eth.ChainID]*eth.SupervisorChainSyncStatus{
+			f.chainID: {LocalUnsafe: eth.BlockRef{Number: f.cl.head}},
+		},
+	}, nil
+}
+
+func TestSupervisorHandlesCLOutage(t *testing.T) {
+	chainID := eth.ChainIDFromUInt64(901)
+
+	check := func(sup *fakeOutageSupervisor) error {
+		return checkSupervisorHandlesCLOutage(context.Background(), sup, sup.cl, chainID, 20*time.Millisecond, 20*time.Millisecond, time.Millisecond)
+	}
+
+	t.Run("stalls and resumes", func(t *testing.T) {
+		cl := &fakeManagedCL{running: true, head: 10}
+		require.NoError(t, check(&fakeOutageSupervisor{chainID: chainID, cl: cl}))
+		require.True(t, cl.running)
+	})
+
+	t.Run("head advances while offline", func(t *testing.T) {
+		cl := &fakeManagedCL{running: true, head: 10, advanceOffline: true}
+		require.ErrorContains(t, check(&fakeOutageSupervisor{chainID: chainID, cl: cl}), "advanced from")
+		require.True(t, cl.running, "the CL must be restarted even when the outage check fails")
+	})
+
+	t.Run("supervisor errors while offline", func(t *testing.T) {
+		cl := &fakeManagedCL{running: true, head: 10}
+		require.ErrorContains(t, check(&fakeOutageSupervisor{chainID: chainID, cl: cl, failOffline: true}), "supervisor failed while the CL")
+		require.True(t, cl.running)
+	})
+
+	t.Run("supervisor never resumes", func(t *testing.T) {
+		cl := &fakeManagedCL{running: true, head: 10, stuck: true}
+		require.ErrorContains(t, check(&fakeOutageSupervisor{chainID: chainID, cl: cl}), "did not advance past")
+	})
+}
@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum-optimism/optimism/op-node/rollup/derive"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// depositTransactionABI is the ABI of OptimismPortal.depositTransaction, the entrypoint of user deposits.
+const depositTransactionABI = `[{"type":"function","name":"depositTransaction","stateMutability":"payable","inputs":[
+	{"name":"_to","type":"address"},
+	{"name":"_value","type":"uint256"},
+	{"name":"_gasLimit","type":"uint64"},
+	{"name":"_isCreation","type":"bool"},
+	{"name":"_data","type":"bytes"}
+],"outputs":[]}]`
+
+var portalABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(depositTransactionABI))
+	if err != nil {
+		panic(fmt.Sprintf("invalid depositTransaction ABI: %v", err))
+	}
+	return parsed
+}()
+
+// DepositParams are the arguments of an OptimismPortal.depositTransaction call. Mint is the ETH sent along with the
+// call, which is minted on L2.
+type DepositParams struct {
+	To         common.Address
+	Mint       *big.Int
+	Value      *big.Int
+	GasLimit   uint64
+	IsCreation bool
+	Data       []byte
+}
+
+// packDepositTransaction returns the calldata of the OptimismPortal.depositTransaction call.
+func packDepositTransaction(params DepositParams) ([]byte, error) {
+	value := params.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	return portalABI.Pack("depositTransaction", params.To, value, params.GasLimit, params.IsCreation, params.Data)
+}
+
+// IncludeDeposit sends an OptimismPortal.depositTransaction call signed with `key` to the `portal` and builds the next
+// L1 block with it. It returns the deposit emitted by the portal, whose L2 transaction is `types.NewTx(deposit)`, and
+// the L1 block, so that L1-reorg tests can check whether the deposit is replayed or removed on L2.
+func (s *TestBlockBuilder) IncludeDeposit(ctx context.Context, key *ecdsa.PrivateKey, portal common.Address, params DepositParams) (*types.DepositTx, *engine.ExecutionPayloadEnvelope, error) {
+	calldata, err := packDepositTransaction(params)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to pack depositTransaction: %w", err)
+	}
+
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	mint := params.Mint
+	if mint == nil {
+		mint = new(big.Int)
+	}
+
+	chainID, err := s.ethClient.ChainID(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch chain ID: %w", err)
+	}
+	nonce, err := s.ethClient.PendingNonceAt(ctx, from)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch nonce: %w", err)
+	}
+	tip, err := s.ethClient.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch gas tip cap: %w", err)
+	}
+	head, err := s.ethClient.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch latest header: %w", err)
+	}
+	gas, err := s.ethClient.EstimateGas(ctx, ethereum.CallMsg{From: from, To: &portal, Value: mint, Data: calldata})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to estimate the gas of depositTransaction: %w", err)
+	}
+
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(chainID), &types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		GasTipCap: tip,
+		GasFeeCap: new(big.Int).Add(tip, new(big.Int).Mul(head.BaseFee, big.NewInt(2))),
+		Gas:       gas,
+		To:        &portal,
+		Value:     mint,
+		Data:      calldata,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to sign deposit transaction: %w", err)
+	}
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode deposit transaction: %w", err)
+	}
+
+	envelope, err := s.BuildBlock(ctx, nil, WithTransactions([]hexutil.Bytes{raw}))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to build a block with deposit transaction %s: %w", tx.Hash(), err)
+	}
+
+	receipt, err := s.ethClient.TransactionReceipt(ctx, tx.Hash())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch the receipt of deposit transaction %s: %w", tx.Hash(), err)
+	}
+	deposit, err := depositFromReceipt(receipt, portal)
+	if err != nil {
+		return nil, nil, err
+	}
+	return deposit, envelope, nil
+}
+
+// depositFromReceipt returns the deposit of the TransactionDeposited event the portal emitted in the receipt.
+func depositFromReceipt(receipt *types.Receipt, portal common.Address) (*types.DepositTx, error) {
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, fmt.Errorf("deposit transaction %s reverted", receipt.TxHash)
+	}
+
+	for _, log := range receipt.Logs {
+		if log.Address != portal || len(log.Topics) == 0 || log.Topics[0] != derive.DepositEventABIHash {
+			continue
+		}
+		deposit, err := derive.UnmarshalDepositLogEvent(log)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode the deposit event of transaction %s: %w", receipt.TxHash, err)
+		}
+		return deposit, nil
+	}
+	return nil, fmt.Errorf("transaction %s emitted no deposit event from portal %s", receipt.TxHash, portal)
+}
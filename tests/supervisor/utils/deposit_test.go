@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-node/rollup/derive"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackDepositTransaction(t *testing.T) {
+	params := DepositParams{To: common.Address{0xaa}, Value: big.NewInt(7), GasLimit: 100_000, Data: []byte{0x01}}
+	calldata, err := packDepositTransaction(params)
+	require.NoError(t, err)
+
+	method := portalABI.Methods["depositTransaction"]
+	require.Equal(t, method.ID, calldata[:4])
+	args, err := method.Inputs.Unpack(calldata[4:])
+	require.NoError(t, err)
+	require.Equal(t, []any{params.To, params.Value, params.GasLimit, false, params.Data}, args)
+}
+
+func TestDepositFromReceipt(t *testing.T) {
+	portal := common.Address{0x42}
+	deposit := &types.DepositTx{
+		From:  common.Address{0x01},
+		To:    &common.Address{0x02},
+		Mint:  big.NewInt(10),
+		Value: big.NewInt(3),
+		Gas:   100_000,
+		Data:  []byte{0xde, 0xad},
+	}
+	log, err := derive.MarshalDepositLogEvent(portal, deposit)
+	require.NoError(t, err)
+	log.BlockHash = common.Hash{0xbb}
+	log.Index = 3
+
+	t.Run("deposit event", func(t *testing.T) {
+		receipt := &types.Receipt{Status: types.ReceiptStatusSuccessful, Logs: []*types.Log{{Address: common.Address{0x99}}, log}}
+		got, err := depositFromReceipt(receipt, portal)
+		require.NoError(t, err)
+		require.Equal(t, deposit.From, got.From)
+		require.Equal(t, deposit.To, got.To)
+		require.Equal(t, deposit.Mint, got.Mint)
+		require.Equal(t, deposit.Data, got.Data)
+		require.NotEqual(t, common.Hash{}, got.SourceHash)
+	})
+
+	t.Run("other portal", func(t *testing.T) {
+		receipt := &types.Receipt{Status: types.ReceiptStatusSuccessful, Logs: []*types.Log{log}}
+		_, err := depositFromReceipt(receipt, common.Address{0x43})
+		require.ErrorContains(t, err, "emitted no deposit event")
+	})
+
+	t.Run("reverted", func(t *testing.T) {
+		_, err := depositFromReceipt(&types.Receipt{Status: types.ReceiptStatusFailed}, portal)
+		require.ErrorContains(t, err, "reverted")
+	})
+}
@@ -2,21 +2,39 @@ package utils
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"math/big"
+	"math/rand"
 	"sync"
 	"time"
 
 	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
+// posBlockTime is the interval at which the POS loop builds L1 blocks.
+const posBlockTime = 5 * time.Second
+
 type TestPOS struct {
 	t devtest.CommonT
 
 	ethClient    *ethclient.Client
 	blockBuilder *TestBlockBuilder
 
+	// slotMu guards the simulated L1 conditions, which tests change while the loop runs.
+	slotMu sync.Mutex
+	// missedSlotRate is the fraction of the slots in which no block is built.
+	missedSlotRate float64
+	rng            *rand.Rand
+	// missedSlots is the number of slots missed since the last block was built.
+	missedSlots uint64
+	// finalityStall is the number of blocks still to be built without advancing the safe and finalized blocks.
+	finalityStall int
+
 	// background management
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -30,7 +48,50 @@ func NewTestPOS(t devtest.CommonT, rpcURL string, blockBuilder *TestBlockBuilder
 		return nil
 	}
 
-	return &TestPOS{t: t, ethClient: ethClient, blockBuilder: blockBuilder}
+	return &TestPOS{t: t, ethClient: ethClient, blockBuilder: blockBuilder, rng: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+// SetMissedSlotRate makes the loop skip the given fraction of its slots, between 0 and 1, to simulate missed L1 slots.
+// The block following missed slots gets the timestamp it would have on L1, leaving a gap for each of them.
+func (p *TestPOS) SetMissedSlotRate(fraction float64) error {
+	if fraction < 0 || fraction > 1 {
+		return fmt.Errorf("missed slot rate %v is not between 0 and 1", fraction)
+	}
+
+	p.slotMu.Lock()
+	defer p.slotMu.Unlock()
+	p.missedSlotRate = fraction
+	return nil
+}
+
+// StallFinality keeps the safe and finalized blocks of L1 where they are for the next `blocks` blocks the loop builds,
+// to simulate an L1 finality stall without stopping the CL.
+func (p *TestPOS) StallFinality(blocks int) {
+	p.slotMu.Lock()
+	defer p.slotMu.Unlock()
+	p.finalityStall = blocks
+}
+
+// nextSlot decides whether a block is built in the current slot, and with which options.
+func (p *TestPOS) nextSlot() (bool, []BuildOption) {
+	p.slotMu.Lock()
+	defer p.slotMu.Unlock()
+
+	if p.missedSlotRate > 0 && p.rng.Float64() < p.missedSlotRate {
+		p.missedSlots++
+		return false, nil
+	}
+
+	var opts []BuildOption
+	if p.missedSlots > 0 {
+		opts = append(opts, withSkippedSlots(p.missedSlots))
+		p.missedSlots = 0
+	}
+	if p.finalityStall > 0 {
+		opts = append(opts, WithFrozenFinality())
+		p.finalityStall--
+	}
+	return true, opts
 }
 
 // Starts a background process to build blocks
@@ -44,23 +105,38 @@ func (p *TestPOS) Start() error {
 	p.ctx, p.cancel = context.WithCancel(context.Background())
 	p.wg.Add(1)
 
+	ctx := p.ctx
 	go func() {
 		defer p.wg.Done()
-		ticker := time.NewTicker(time.Second * 5)
+		ticker := time.NewTicker(posBlockTime)
 		defer ticker.Stop()
 
 		for {
 			select {
-			case <-p.ctx.Done():
+			case <-ctx.Done():
 				return
 			case <-ticker.C:
-				_, err := p.ethClient.BlockByNumber(p.ctx, big.NewInt(rpc.LatestBlockNumber.Int64()))
+				_, err := p.ethClient.BlockByNumber(ctx, big.NewInt(rpc.LatestBlockNumber.Int64()))
+				if posStopped(ctx, err) {
+					return
+				}
 				if err != nil {
 					p.t.Errorf("failed to fetch latest block: %v", err)
 				}
 
+				build, opts := p.nextSlot()
+				if !build {
+					continue
+				}
+
 				// Build a new block
-				p.blockBuilder.BuildBlock(p.ctx, nil)
+				_, err = p.blockBuilder.BuildBlock(ctx, nil, opts...)
+				if posStopped(ctx, err) {
+					return
+				}
+				if err != nil {
+					p.t.Errorf("failed to build block: %v", err)
+				}
 			}
 		}
 	}()
@@ -68,6 +144,12 @@ func (p *TestPOS) Start() error {
 	return nil
 }
 
+// posStopped reports whether `err` only results from Stop cancelling the loop context, in which case the loop exits
+// without reporting it.
+func posStopped(ctx context.Context, err error) bool {
+	return err != nil && ctx.Err() != nil && errors.Is(err, context.Canceled)
+}
+
 // Stops the background process
 func (p *TestPOS) Stop() {
 	// cancel the context to signal the goroutine to exit
@@ -80,3 +162,82 @@ func (p *TestPOS) Stop() {
 	// clear the context to mark stopped
 	p.ctx = nil
 }
+
+// posHeaderSource is the subset of *ethclient.Client used to follow the chain built by the POS loop.
+type posHeaderSource interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error)
+}
+
+// AssertPOSChainLinear runs the POS loop, starting it if needed, until it has built `samples` blocks, and checks that
+// they form a single chain: the head only moves forward, and every block that was the head at some point is still an
+// ancestor of the final head. Any accidental fork of the loop shows up as an observed block that was replaced.
+func AssertPOSChainLinear(t devtest.T, pos *TestPOS, samples int) {
+	if pos.ctx == nil {
+		t.Require().NoError(pos.Start())
+		defer pos.Stop()
+	}
+
+	ctx, cancel := context.WithTimeout(t.Ctx(), time.Duration(2*samples+2)*posBlockTime)
+	defer cancel()
+
+	t.Require().NoError(checkPOSChainLinear(ctx, pos.ethClient, samples, posBlockTime/5))
+}
+
+func checkPOSChainLinear(ctx context.Context, src posHeaderSource, samples int, interval time.Duration) error {
+	observed, err := observePOSHeads(ctx, src, samples, interval)
+	if err != nil {
+		return err
+	}
+
+	// Walk back from the last observed head, and check that each of the previously observed heads is on its ancestry.
+	cur := observed[len(observed)-1]
+	for i := len(observed) - 2; i >= 0; i-- {
+		want := observed[i]
+		for cur.Number.Cmp(want.Number) > 0 {
+			parent, err := src.HeaderByHash(ctx, cur.ParentHash)
+			if err != nil {
+				return fmt.Errorf("failed to get parent %s of block %d: %w", cur.ParentHash, cur.Number, err)
+			}
+			cur = parent
+		}
+		if cur.Hash() != want.Hash() {
+			return fmt.Errorf("chain forked: block %d is %s on the final chain but was %s when it was the head", want.Number, cur.Hash(), want.Hash())
+		}
+	}
+
+	return nil
+}
+
+// observePOSHeads polls the head of the chain until `samples` new heads were seen, and returns all the observed heads,
+// the initial one included. It fails if the head number ever goes back or stays the same with a different hash.
+func observePOSHeads(ctx context.Context, src posHeaderSource, samples int, interval time.Duration) ([]*types.Header, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var observed []*types.Header
+	for {
+		head, err := src.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the head of the chain: %w", err)
+		}
+
+		if len(observed) == 0 {
+			observed = append(observed, head)
+		} else if last := observed[len(observed)-1]; head.Hash() != last.Hash() {
+			if head.Number.Cmp(last.Number) <= 0 {
+				return nil, fmt.Errorf("head moved from block %d (%s) to block %d (%s), not strictly increasing", last.Number, last.Hash(), head.Number, head.Hash())
+			}
+			observed = append(observed, head)
+		}
+
+		if len(observed) > samples {
+			return observed, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("observed %d of %d new blocks: %w", len(observed)-1, samples, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePOSChain serves the heads in `heads` one after the other, then stays at the last one. Blocks are looked up by
+// hash among all the blocks it knows, including the ones that are not a head.
+type fakePOSChain struct {
+	heads  []*types.Header
+	byHash map[common.Hash]*types.Header
+}
+
+func newFakePOSChain(heads []*types.Header, blocks ...*types.Header) *fakePOSChain {
+	f := &fakePOSChain{heads: heads, byHash: make(map[common.Hash]*types.Header)}
+	for _, h := range append(blocks, heads...) {
+		f.byHash[h.Hash()] = h
+	}
+	return f
+}
+
+func (f *fakePOSChain) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	head := f.heads[0]
+	if len(f.heads) > 1 {
+		f.heads = f.heads[1:]
+	}
+	return head, nil
+}
+
+func (f *fakePOSChain) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	h, ok := f.byHash[hash]
+	if !ok {
+		return nil, fmt.Errorf("unknown block %s", hash)
+	}
+	return h, nil
+}
+
+func posBlock(parent *types.Header, tag byte) *types.Header {
+	if parent == nil {
+		return &types.Header{Number: big.NewInt(0), Extra: []byte{tag}}
+	}
+	return &types.Header{Number: new(big.Int).Add(parent.Number, common.Big1), ParentHash: parent.Hash(), Extra: []byte{tag}}
+}
+
+func TestPOSChainLinear(t *testing.T) {
+	check := func(src *fakePOSChain, samples int) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		return checkPOSChainLinear(ctx, src, samples, time.Millisecond)
+	}
+
+	genesis := posBlock(nil, 0)
+	b1 := posBlock(genesis, 1)
+	b2 := posBlock(b1, 2)
+	b3 := posBlock(b2, 3)
+
+	t.Run("linear chain", func(t *testing.T) {
+		require.NoError(t, check(newFakePOSChain([]*types.Header{genesis, genesis, b1, b2, b2, b3}), 3))
+	})
+
+	t.Run("head skips blocks", func(t *testing.T) {
+		require.NoError(t, check(newFakePOSChain([]*types.Header{genesis, b2, b3}, b1), 2))
+	})
+
+	t.Run("observed block replaced", func(t *testing.T) {
+		b2Fork := posBlock(b1, 0xf2)
+		b3Fork := posBlock(b2Fork, 0xf3)
+		err := check(newFakePOSChain([]*types.Header{genesis, b1, b2, b3Fork}, b2Fork), 3)
+		require.ErrorContains(t, err, "chain forked: block 2")
+	})
+
+	t.Run("head goes back", func(t *testing.T) {
+		b2Fork := posBlock(b1, 0xf2)
+		err := check(newFakePOSChain([]*types.Header{genesis, b1, b2, b2Fork}), 3)
+		require.ErrorContains(t, err, "not strictly increasing")
+	})
+
+	t.Run("loop stalls", func(t *testing.T) {
+		err := check(newFakePOSChain([]*types.Header{genesis, b1}), 3)
+		require.ErrorContains(t, err, "observed 1 of 3 new blocks")
+	})
+}
+
+func TestPOSSlots(t *testing.T) {
+	newPOS := func() *TestPOS {
+		return &TestPOS{rng: rand.New(rand.NewSource(1))}
+	}
+	apply := func(opts []BuildOption) buildOptions {
+		var options buildOptions
+		for _, opt := range opts {
+			opt(&options)
+		}
+		return options
+	}
+
+	t.Run("every slot", func(t *testing.T) {
+		pos := newPOS()
+		for range 10 {
+			build, opts := pos.nextSlot()
+			require.True(t, build)
+			require.Empty(t, opts)
+		}
+	})
+
+	t.Run("missed slots", func(t *testing.T) {
+		pos := newPOS()
+		require.NoError(t, pos.SetMissedSlotRate(1))
+		for range 3 {
+			build, _ := pos.nextSlot()
+			require.False(t, build)
+		}
+
+		require.NoError(t, pos.SetMissedSlotRate(0))
+		build, opts := pos.nextSlot()
+		require.True(t, build)
+		require.Equal(t, uint64(3), apply(opts).skippedSlots, "the block leaves room for the missed slots")
+
+		_, opts = pos.nextSlot()
+		require.Zero(t, apply(opts).skippedSlots)
+	})
+
+	t.Run("missed fraction", func(t *testing.T) {
+		pos := newPOS()
+		require.NoError(t, pos.SetMissedSlotRate(0.5))
+		built := 0
+		for range 1000 {
+			if build, _ := pos.nextSlot(); build {
+				built++
+			}
+		}
+		require.InDelta(t, 500, built, 100)
+	})
+
+	t.Run("finality stall", func(t *testing.T) {
+		pos := newPOS()
+		pos.StallFinality(2)
+		for i := range 3 {
+			_, opts := pos.nextSlot()
+			require.Equal(t, i < 2, apply(opts).freezeFinality, "block %d", i)
+		}
+	})
+
+	t.Run("invalid rate", func(t *testing.T) {
+		pos := newPOS()
+		require.ErrorContains(t, pos.SetMissedSlotRate(1.5), "not between 0 and 1")
+		require.ErrorContains(t, pos.SetMissedSlotRate(-0.1), "not between 0 and 1")
+		require.Zero(t, pos.missedSlotRate, "an invalid rate is not applied")
+	})
+}
+
+func TestPOSStopped(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	t.Run("running", func(t *testing.T) {
+		require.False(t, posStopped(ctx, nil))
+		require.False(t, posStopped(ctx, errors.New("connection refused")))
+		require.False(t, posStopped(ctx, fmt.Errorf("failed: %w", context.Canceled)), "a cancellation the loop did not ask for is reported")
+	})
+
+	cancel()
+
+	t.Run("stopped", func(t *testing.T) {
+		require.True(t, posStopped(ctx, context.Canceled))
+		require.True(t, posStopped(ctx, fmt.Errorf("failed to build block: %w", context.Canceled)))
+		require.False(t, posStopped(ctx, errors.New("connection refused")), "other errors are still reported")
+		require.False(t, posStopped(ctx, nil))
+	})
+}
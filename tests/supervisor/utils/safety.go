@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum-optimism/optimism/op-supervisor/supervisor/types"
+)
+
+// ForEachSafetyLevel runs fn for every safety level and returns the aggregated errors, each annotated with the level
+// it failed at. All the levels are run, even if some of them fail.
+func ForEachSafetyLevel(levels []types.SafetyLevel, fn func(types.SafetyLevel) error) error {
+	var errs []error
+	for _, level := range levels {
+		if err := fn(level); err != nil {
+			errs = append(errs, fmt.Errorf("safety level %s: %w", level, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-supervisor/supervisor/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForEachSafetyLevel(t *testing.T) {
+	levels := []types.SafetyLevel{types.LocalUnsafe, types.LocalSafe, types.CrossUnsafe, types.CrossSafe}
+	errHeadStalled := errors.New("head stalled")
+
+	var visited []types.SafetyLevel
+	err := ForEachSafetyLevel(levels, func(level types.SafetyLevel) error {
+		visited = append(visited, level)
+		if level == types.CrossUnsafe {
+			return errHeadStalled
+		}
+		return nil
+	})
+
+	require.Equal(t, levels, visited, "every level should be visited, even after a failure")
+	require.ErrorIs(t, err, errHeadStalled)
+	require.ErrorContains(t, err, string(types.CrossUnsafe))
+	require.NotContains(t, err.Error(), string(types.LocalSafe))
+
+	require.NoError(t, ForEachSafetyLevel(levels, func(types.SafetyLevel) error { return nil }))
+}
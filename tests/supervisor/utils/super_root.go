@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-service/apis"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// futureSuperRootOffset is how far in the future the super root is requested. It is large enough that no chain can
+// have produced a block at that timestamp.
+const futureSuperRootOffset = 24 * time.Hour
+
+// AssertSuperRootFutureRejected requests a super root at a timestamp well in the future and checks that the supervisor
+// errors, as it has no data for it yet, rather than returning stale data.
+func AssertSuperRootFutureRejected(t devtest.T, sup apis.SupervisorQueryAPI) {
+	t.Require().NoError(checkSuperRootFutureRejected(t.Ctx(), sup, time.Now()))
+}
+
+func checkSuperRootFutureRejected(ctx context.Context, sup apis.SupervisorQueryAPI, now time.Time) error {
+	timestamp := uint64(now.Add(futureSuperRootOffset).Unix())
+
+	root, err := sup.SuperRootAtTimestamp(ctx, hexutil.Uint64(timestamp))
+	if err == nil {
+		return fmt.Errorf("expected super root at future timestamp %d to be rejected, got super root %s at timestamp %d", timestamp, root.SuperRoot, root.Timestamp)
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-service/apis"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSuperRootSupervisor serves super roots up to `latest`. When `clamp` is set, it wrongly serves the latest known
+// super root for timestamps past `latest` instead of erroring.
+type fakeSuperRootSupervisor struct {
+	apis.SupervisorQueryAPI
+
+	latest uint64
+	clamp  bool
+}
+
+func (f *fakeSuperRootSupervisor) SuperRootAtTimestamp(ctx context.Context, timestamp hexutil.Uint64) (eth.SuperRootResponse, error) {
+	if uint64(timestamp) > f.latest {
+		if !f.clamp {
+			return eth.SuperRootResponse{}, fmt.Errorf("no data for timestamp %d", timestamp)
+		}
+		timestamp = hexutil.Uint64(f.latest)
+	}
+	return eth.SuperRootResponse{Timestamp: uint64(timestamp), SuperRoot: eth.Bytes32{0x01}}, nil
+}
+
+func TestSuperRootFutureRejected(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+
+	t.Run("future timestamp rejected", func(t *testing.T) {
+		sup := &fakeSuperRootSupervisor{latest: uint64(now.Unix())}
+		require.NoError(t, checkSuperRootFutureRejected(context.Background(), sup, now))
+	})
+
+	t.Run("stale data returned", func(t *testing.T) {
+		sup := &fakeSuperRootSupervisor{latest: uint64(now.Unix()), clamp: true}
+		require.Error(t, checkSuperRootFutureRejected(context.Background(), sup, now))
+	})
+}
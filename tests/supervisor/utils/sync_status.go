@@ -0,0 +1,286 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-devstack/devtest"
+	"github.com/ethereum-optimism/optimism/op-devstack/dsl"
+	"github.com/ethereum-optimism/optimism/op-service/apis"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/ethereum-optimism/optimism/op-supervisor/supervisor/types"
+)
+
+// minSyncedL1Timeout bounds the time AssertMinSyncedL1IsMinimum waits for a consistent view of the chains.
+const minSyncedL1Timeout = 30 * time.Second
+
+// minSyncedL1PollInterval is how often the CurrentL1 of the chains and the supervisor sync status are read again when
+// they disagree.
+const minSyncedL1PollInterval = time.Second
+
+// AssertMinSyncedL1IsMinimum checks that the supervisor's MinSyncedL1 is the minimum CurrentL1
+// across all the managed chains, which is the definition of MinSyncedL1. L1 keeps advancing between the reads of the
+// nodes and of the supervisor, so both are read again until they agree, for at most minSyncedL1Timeout.
+func AssertMinSyncedL1IsMinimum(t devtest.T, sup apis.SupervisorQueryAPI, clNodes map[eth.ChainID]dsl.L2CLNode) {
+	currentL1s := func() map[eth.ChainID]eth.L1BlockRef {
+		out := make(map[eth.ChainID]eth.L1BlockRef, len(clNodes))
+		for chainID, node := range clNodes {
+			out[chainID] = node.SyncStatus().CurrentL1
+		}
+		return out
+	}
+
+	ctx, cancel := context.WithTimeout(t.Ctx(), minSyncedL1Timeout)
+	defer cancel()
+
+	t.Require().NoError(checkMinSyncedL1IsMinimum(ctx, sup, currentL1s, minSyncedL1PollInterval))
+}
+
+func checkMinSyncedL1IsMinimum(ctx context.Context, sup apis.SupervisorQueryAPI, currentL1s func() map[eth.ChainID]eth.L1BlockRef, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := minSyncedL1IsMinimum(ctx, sup, currentL1s())
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-ticker.C:
+		}
+	}
+}
+
+// minSyncedL1IsMinimum compares a single snapshot of the CurrentL1 of the chains with the supervisor sync status.
+func minSyncedL1IsMinimum(ctx context.Context, sup apis.SupervisorQueryAPI, currentL1s map[eth.ChainID]eth.L1BlockRef) error {
+	if len(currentL1s) == 0 {
+		return fmt.Errorf("no managed chains to compare MinSyncedL1 against")
+	}
+
+	status, err := sup.SyncStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch supervisor sync status: %w", err)
+	}
+
+	var minChain eth.ChainID
+	var minL1 eth.L1BlockRef
+	first := true
+	for chainID, currentL1 := range currentL1s {
+		if first || currentL1.Number < minL1.Number {
+			minChain, minL1 = chainID, currentL1
+			first = false
+		}
+	}
+
+	if status.MinSyncedL1.Number != minL1.Number {
+		return fmt.Errorf("supervisor MinSyncedL1 %d does not match the minimum CurrentL1 %d (chain %s)", status.MinSyncedL1.Number, minL1.Number, minChain)
+	}
+
+	return nil
+}
+
+// crossUnsafePollInterval is how often the supervisor sync status is polled while waiting for the chains to align.
+const crossUnsafePollInterval = 2 * time.Second
+
+// AssertCrossUnsafeEqualsLocalWhenAligned waits, up to `timeout`, for the local-unsafe heads of `chainID` and of all
+// its dependencies to be at the same height, and checks that the cross-unsafe head of `chainID` then equals its
+// local-unsafe head: once every dependency is known, nothing should keep the chain from being cross-validated.
+func AssertCrossUnsafeEqualsLocalWhenAligned(t devtest.T, sup apis.SupervisorQueryAPI, chainID eth.ChainID, deps []eth.ChainID, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(t.Ctx(), timeout)
+	defer cancel()
+
+	t.Require().NoError(checkCrossUnsafeEqualsLocalWhenAligned(ctx, sup, chainID, deps, crossUnsafePollInterval))
+}
+
+func checkCrossUnsafeEqualsLocalWhenAligned(ctx context.Context, sup apis.SupervisorQueryAPI, chainID eth.ChainID, deps []eth.ChainID, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastErr error
+	for {
+		status, err := sup.SyncStatus(ctx)
+		if err != nil {
+			lastErr = fmt.Errorf("failed to fetch supervisor sync status: %w", err)
+		} else {
+			aligned, err := crossUnsafeEqualsLocalWhenAligned(status, chainID, deps)
+			if aligned && err == nil {
+				return nil
+			}
+			lastErr = err
+		}
+
+		select {
+		case <-ctx.Done():
+			if lastErr == nil {
+				lastErr = fmt.Errorf("chain %s and its dependencies never aligned", chainID)
+			}
+			return errors.Join(lastErr, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// crossUnsafeEqualsLocalWhenAligned reports whether `chainID` and its dependencies are at the same local-unsafe height
+// in the given status and, if they are, returns an error if the cross-unsafe head of `chainID` differs from its
+// local-unsafe head.
+func crossUnsafeEqualsLocalWhenAligned(status eth.SupervisorSyncStatus, chainID eth.ChainID, deps []eth.ChainID) (bool, error) {
+	chain, ok := status.Chains[chainID]
+	if !ok {
+		return false, fmt.Errorf("chain %s is missing from the supervisor sync status", chainID)
+	}
+
+	for _, dep := range deps {
+		depStatus, ok := status.Chains[dep]
+		if !ok {
+			return false, fmt.Errorf("dependency %s is missing from the supervisor sync status", dep)
+		}
+		if depStatus.LocalUnsafe.Number != chain.LocalUnsafe.Number {
+			return false, nil
+		}
+	}
+
+	if chain.CrossUnsafe != chain.LocalUnsafe.ID() {
+		return true, fmt.Errorf("chain %s cross-unsafe %s differs from local-unsafe %s while its dependencies are aligned", chainID, chain.CrossUnsafe, chain.LocalUnsafe.ID())
+	}
+
+	return true, nil
+}
+
+// AssertAllSafeConsistentWithCrossSafe checks that the blocks returned by AllSafeDerivedAt(l1) are consistent with the
+// cross-safe head of each chain: a block derived at `l1` can not be ahead of the cross-safe head, and it must be the
+// cross-safe head itself when the latter was derived from `l1`. This ties the two RPC surfaces into one invariant.
+func AssertAllSafeConsistentWithCrossSafe(t devtest.T, sup apis.SupervisorQueryAPI, l1 eth.BlockID) {
+	t.Require().NoError(checkAllSafeConsistentWithCrossSafe(t.Ctx(), sup, l1))
+}
+
+func checkAllSafeConsistentWithCrossSafe(ctx context.Context, sup apis.SupervisorQueryAPI, l1 eth.BlockID) error {
+	allSafe, err := sup.AllSafeDerivedAt(ctx, l1)
+	if err != nil {
+		return fmt.Errorf("failed to get all safe blocks derived at %s: %w", l1, err)
+	}
+	if len(allSafe) == 0 {
+		return fmt.Errorf("no safe blocks derived at %s", l1)
+	}
+
+	for chainID, derived := range allSafe {
+		crossSafe, err := sup.CrossSafe(ctx, chainID)
+		if err != nil {
+			return fmt.Errorf("failed to get cross-safe head of chain %s: %w", chainID, err)
+		}
+
+		if derived.Number > crossSafe.Derived.Number {
+			return fmt.Errorf("chain %s: block %s derived at %s is ahead of the cross-safe head %s", chainID, derived, l1, crossSafe.Derived)
+		}
+		if crossSafe.Source == l1 && derived != crossSafe.Derived {
+			return fmt.Errorf("chain %s: block %s derived at %s differs from the cross-safe head %s derived from the same L1 block", chainID, derived, l1, crossSafe.Derived)
+		}
+	}
+
+	return nil
+}
+
+// AssertFinalizedAgreement checks that the finalized head the supervisor reports for `chainID` agrees with the
+// finalized head of the CL node of that chain, within `tolerance` blocks. At the same height, both must hold the same
+// block. The two components update their finalized heads independently, hence the tolerance.
+func AssertFinalizedAgreement(t devtest.T, sup apis.SupervisorQueryAPI, cl dsl.L2CLNode, chainID eth.ChainID, tolerance uint64) {
+	clFinalized := cl.SyncStatus().FinalizedL2.ID()
+	t.Require().NoError(checkFinalizedAgreement(t.Ctx(), sup, chainID, clFinalized, tolerance))
+}
+
+func checkFinalizedAgreement(ctx context.Context, sup apis.SupervisorQueryAPI, chainID eth.ChainID, clFinalized eth.BlockID, tolerance uint64) error {
+	status, err := sup.SyncStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch supervisor sync status: %w", err)
+	}
+
+	chain, ok := status.Chains[chainID]
+	if !ok {
+		return fmt.Errorf("chain %s is missing from the supervisor sync status", chainID)
+	}
+	supFinalized := chain.Finalized
+
+	diff := max(supFinalized.Number, clFinalized.Number) - min(supFinalized.Number, clFinalized.Number)
+	if diff > tolerance {
+		return fmt.Errorf("chain %s: supervisor finalized %s and node finalized %s are %d blocks apart, more than %d", chainID, supFinalized, clFinalized, diff, tolerance)
+	}
+	if diff == 0 && supFinalized.Hash != clFinalized.Hash {
+		return fmt.Errorf("chain %s: supervisor finalized %s and node finalized %s differ at the same height", chainID, supFinalized, clFinalized)
+	}
+
+	return nil
+}
+
+// headAdvancePollInterval is how often the supervisor sync status is polled while waiting for a head to advance.
+const headAdvancePollInterval = time.Second
+
+// WaitForL2HeadToAdvance waits, up to `timeout`, until the head of `chainID` at `level` in the supervisor sync status is
+// at least `delta` blocks past the one it had when called. Unlike the dsl variant, it returns an error instead of
+// failing the test, so that the failures of several chains or safety levels can be aggregated.
+func WaitForL2HeadToAdvance(ctx context.Context, sup apis.SupervisorQueryAPI, chainID eth.ChainID, delta uint64, level types.SafetyLevel, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return waitForL2HeadToAdvance(ctx, sup, chainID, delta, level, headAdvancePollInterval)
+}
+
+func waitForL2HeadToAdvance(ctx context.Context, sup apis.SupervisorQueryAPI, chainID eth.ChainID, delta uint64, level types.SafetyLevel, interval time.Duration) error {
+	status, err := sup.SyncStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch supervisor sync status: %w", err)
+	}
+	start, err := l2HeadAt(status, chainID, level)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	head := start
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("chain %s: head advanced from %d to %d, less than %d blocks: %w", chainID, start, head, delta, ctx.Err())
+		case <-ticker.C:
+		}
+
+		status, err := sup.SyncStatus(ctx)
+		if err != nil {
+			continue
+		}
+		if head, err = l2HeadAt(status, chainID, level); err != nil {
+			return err
+		}
+		if head >= start+delta {
+			return nil
+		}
+	}
+}
+
+// l2HeadAt returns the number of the head of `chainID` at `level` in the supervisor sync status.
+func l2HeadAt(status eth.SupervisorSyncStatus, chainID eth.ChainID, level types.SafetyLevel) (uint64, error) {
+	chain, ok := status.Chains[chainID]
+	if !ok {
+		return 0, fmt.Errorf("chain %s is missing from the supervisor sync status", chainID)
+	}
+
+	switch level {
+	case types.LocalUnsafe:
+		return chain.LocalUnsafe.Number, nil
+	case types.LocalSafe:
+		return chain.LocalSafe.Number, nil
+	case types.CrossUnsafe:
+		return chain.CrossUnsafe.Number, nil
+	case types.CrossSafe:
+		return chain.CrossSafe.Number, nil
+	case types.Finalized:
+		return chain.Finalized.Number, nil
+	default:
+		return 0, fmt.Errorf("unsupported safety level %s", level)
+	}
+}
@@ -0,0 +1,205 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-service/apis"
+	"github.com/ethereum-optimism/optimism/op-service/eth"
+	"github.com/ethereum-optimism/optimism/op-supervisor/supervisor/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSupervisor serves canned responses for the supervisor query API. Calling a method that is not
+// overridden panics through the nil embedded interface.
+type fakeSupervisor struct {
+	apis.SupervisorQueryAPI
+
+	syncStatus eth.SupervisorSyncStatus
+	allSafe    map[eth.ChainID]eth.BlockID
+	crossSafe  map[eth.ChainID]types.DerivedIDPair
+}
+
+func (f *fakeSupervisor) SyncStatus(ctx context.Context) (eth.SupervisorSyncStatus, error) {
+	return f.syncStatus, nil
+}
+
+func (f *fakeSupervisor) AllSafeDerivedAt(ctx context.Context, derivedFrom eth.BlockID) (map[eth.ChainID]eth.BlockID, error) {
+	return f.allSafe, nil
+}
+
+func (f *fakeSupervisor) CrossSafe(ctx context.Context, chainID eth.ChainID) (types.DerivedIDPair, error) {
+	return f.crossSafe[chainID], nil
+}
+
+func TestMinSyncedL1IsMinimum(t *testing.T) {
+	chainA := eth.ChainIDFromUInt64(901)
+	chainB := eth.ChainIDFromUInt64(902)
+	// snapshots returns the CurrentL1s in `numbersB` one after the other for chain B, then stays at the last one.
+	snapshots := func(numbersB ...uint64) func() map[eth.ChainID]eth.L1BlockRef {
+		return func() map[eth.ChainID]eth.L1BlockRef {
+			numberB := numbersB[0]
+			if len(numbersB) > 1 {
+				numbersB = numbersB[1:]
+			}
+			return map[eth.ChainID]eth.L1BlockRef{chainA: {Number: 12}, chainB: {Number: numberB}}
+		}
+	}
+	check := func(minSynced uint64, currentL1s func() map[eth.ChainID]eth.L1BlockRef) error {
+		sup := &fakeSupervisor{syncStatus: eth.SupervisorSyncStatus{MinSyncedL1: eth.L1BlockRef{Number: minSynced}}}
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		return checkMinSyncedL1IsMinimum(ctx, sup, currentL1s, time.Millisecond)
+	}
+
+	t.Run("matches minimum", func(t *testing.T) {
+		require.NoError(t, check(10, snapshots(10)))
+	})
+
+	t.Run("matches once L1 progress is seen by both", func(t *testing.T) {
+		// The supervisor status is read after the nodes, and already accounts for an L1 block they had not reached.
+		require.NoError(t, check(11, snapshots(10, 11)))
+	})
+
+	t.Run("above minimum", func(t *testing.T) {
+		require.ErrorContains(t, check(11, snapshots(10)), "does not match the minimum CurrentL1 10")
+	})
+
+	t.Run("no chains", func(t *testing.T) {
+		require.ErrorContains(t, check(10, func() map[eth.ChainID]eth.L1BlockRef { return nil }), "no managed chains")
+	})
+}
+
+func TestCrossUnsafeEqualsLocalWhenAligned(t *testing.T) {
+	chainA := eth.ChainIDFromUInt64(901)
+	chainB := eth.ChainIDFromUInt64(902)
+
+	localUnsafeA := eth.BlockRef{Hash: common.Hash{0xa}, Number: 10}
+	localUnsafeB := eth.BlockRef{Hash: common.Hash{0xb}, Number: 10}
+
+	check := func(crossUnsafeA eth.BlockID) error {
+		sup := &fakeSupervisor{syncStatus: eth.SupervisorSyncStatus{Chains: map[eth.ChainID]*eth.SupervisorChainSyncStatus{
+			chainA: {LocalUnsafe: localUnsafeA, CrossUnsafe: crossUnsafeA},
+			chainB: {LocalUnsafe: localUnsafeB, CrossUnsafe: localUnsafeB.ID()},
+		}}}
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		return checkCrossUnsafeEqualsLocalWhenAligned(ctx, sup, chainA, []eth.ChainID{chainB}, 10*time.Millisecond)
+	}
+
+	t.Run("converges", func(t *testing.T) {
+		require.NoError(t, check(localUnsafeA.ID()))
+	})
+
+	t.Run("cross-unsafe stays behind", func(t *testing.T) {
+		err := check(eth.BlockID{Hash: common.Hash{0x9}, Number: 9})
+		require.ErrorContains(t, err, "differs from local-unsafe")
+	})
+}
+
+func TestAllSafeConsistentWithCrossSafe(t *testing.T) {
+	chainA := eth.ChainIDFromUInt64(900)
+	chainB := eth.ChainIDFromUInt64(901)
+	l1 := eth.BlockID{Number: 10, Hash: common.Hash{0x10}}
+	nextL1 := eth.BlockID{Number: 11, Hash: common.Hash{0x11}}
+
+	blockA := eth.BlockID{Number: 20, Hash: common.Hash{0xa0}}
+	blockB := eth.BlockID{Number: 30, Hash: common.Hash{0xb0}}
+
+	t.Run("consistent", func(t *testing.T) {
+		sup := &fakeSupervisor{
+			allSafe: map[eth.ChainID]eth.BlockID{chainA: blockA, chainB: blockB},
+			crossSafe: map[eth.ChainID]types.DerivedIDPair{
+				chainA: {Source: l1, Derived: blockA},
+				chainB: {Source: nextL1, Derived: eth.BlockID{Number: 32, Hash: common.Hash{0xb2}}},
+			},
+		}
+		require.NoError(t, checkAllSafeConsistentWithCrossSafe(context.Background(), sup, l1))
+	})
+
+	t.Run("derived block ahead of cross-safe", func(t *testing.T) {
+		sup := &fakeSupervisor{
+			allSafe: map[eth.ChainID]eth.BlockID{chainA: blockA},
+			crossSafe: map[eth.ChainID]types.DerivedIDPair{
+				chainA: {Source: l1, Derived: eth.BlockID{Number: 18, Hash: common.Hash{0xa8}}},
+			},
+		}
+		require.ErrorContains(t, checkAllSafeConsistentWithCrossSafe(context.Background(), sup, l1), "ahead of the cross-safe head")
+	})
+
+	t.Run("different block at the same L1", func(t *testing.T) {
+		sup := &fakeSupervisor{
+			allSafe: map[eth.ChainID]eth.BlockID{chainA: blockA},
+			crossSafe: map[eth.ChainID]types.DerivedIDPair{
+				chainA: {Source: l1, Derived: eth.BlockID{Number: 20, Hash: common.Hash{0xff}}},
+			},
+		}
+		require.ErrorContains(t, checkAllSafeConsistentWithCrossSafe(context.Background(), sup, l1), "differs from the cross-safe head")
+	})
+}
+
+func TestFinalizedAgreement(t *testing.T) {
+	chainID := eth.ChainIDFromUInt64(901)
+	sup := &fakeSupervisor{syncStatus: eth.SupervisorSyncStatus{Chains: map[eth.ChainID]*eth.SupervisorChainSyncStatus{
+		chainID: {Finalized: eth.BlockID{Hash: common.Hash{0x10}, Number: 10}},
+	}}}
+
+	t.Run("same block", func(t *testing.T) {
+		require.NoError(t, checkFinalizedAgreement(context.Background(), sup, chainID, eth.BlockID{Hash: common.Hash{0x10}, Number: 10}, 2))
+	})
+
+	t.Run("within tolerance", func(t *testing.T) {
+		require.NoError(t, checkFinalizedAgreement(context.Background(), sup, chainID, eth.BlockID{Hash: common.Hash{0x12}, Number: 12}, 2))
+	})
+
+	t.Run("out of tolerance", func(t *testing.T) {
+		require.ErrorContains(t, checkFinalizedAgreement(context.Background(), sup, chainID, eth.BlockID{Hash: common.Hash{0x13}, Number: 13}, 2), "3 blocks apart")
+	})
+
+	t.Run("different block at the same height", func(t *testing.T) {
+		require.ErrorContains(t, checkFinalizedAgreement(context.Background(), sup, chainID, eth.BlockID{Hash: common.Hash{0xff}, Number: 10}, 2), "differ at the same height")
+	})
+}
+
+// advancingSupervisor reports a cross-safe head that advances by `step` blocks on every sync status call.
+type advancingSupervisor struct {
+	apis.SupervisorQueryAPI
+
+	chainID eth.ChainID
+	head    uint64
+	step    uint64
+}
+
+func (f *advancingSupervisor) SyncStatus(ctx context.Context) (eth.SupervisorSyncStatus, error) {
+	status := eth.SupervisorSyncStatus{Chains: map[eth.ChainID]*eth.SupervisorChainSyncStatus{
+		f.chainID: {CrossSafe: eth.BlockID{Number: f.head}},
+	}}
+	f.head += f.step
+	return status, nil
+}
+
+func TestWaitForL2HeadToAdvance(t *testing.T) {
+	chainID := eth.ChainIDFromUInt64(901)
+	wait := func(sup apis.SupervisorQueryAPI, chainID eth.ChainID, level types.SafetyLevel) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		return waitForL2HeadToAdvance(ctx, sup, chainID, 3, level, time.Millisecond)
+	}
+
+	t.Run("head advances", func(t *testing.T) {
+		require.NoError(t, wait(&advancingSupervisor{chainID: chainID, head: 10, step: 1}, chainID, types.CrossSafe))
+	})
+
+	t.Run("head stalls", func(t *testing.T) {
+		err := wait(&advancingSupervisor{chainID: chainID, head: 10}, chainID, types.CrossSafe)
+		require.ErrorContains(t, err, "head advanced from 10 to 10, less than 3 blocks")
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("unknown chain", func(t *testing.T) {
+		err := wait(&advancingSupervisor{chainID: chainID, step: 1}, eth.ChainIDFromUInt64(902), types.CrossSafe)
+		require.ErrorContains(t, err, "is missing from the supervisor sync status")
+	})
+}